@@ -0,0 +1,117 @@
+package backend
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+var (
+	ibanPattern          = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]+$`)
+	accountDigitsPattern = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// ibanLengths lists the IBAN length of every country in the SWIFT IBAN
+// registry. Bank accounts of banks in these countries must be valid IBANs.
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22,
+	"BH": 22, "BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24, "DE": 22,
+	"DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24, "FI": 18, "FO": 18, "FR": 27,
+	"GB": 22, "GE": 22, "GI": 23, "GL": 18, "GR": 27, "GT": 28, "HR": 21, "HU": 28,
+	"IE": 22, "IL": 23, "IQ": 23, "IS": 26, "IT": 27, "JO": 30, "KW": 30, "KZ": 20,
+	"LB": 28, "LC": 32, "LI": 21, "LT": 20, "LU": 20, "LV": 21, "MC": 27, "MD": 24,
+	"ME": 22, "MK": 19, "MR": 27, "MT": 31, "MU": 30, "NL": 18, "NO": 15, "PK": 24,
+	"PL": 28, "PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "SA": 24, "SC": 31,
+	"SE": 24, "SI": 19, "SK": 24, "SM": 27, "ST": 25, "SV": 28, "TL": 23, "TN": 24,
+	"TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20,
+}
+
+// normalizeAccountNumber validates an account number against the scheme the
+// bank's country uses and returns it in compact form: IBANs for IBAN
+// countries, CBU in Argentina and CLABE in Mexico. Other countries have no
+// common check-digit scheme, so the trimmed value is accepted as is.
+func normalizeAccountNumber(country string, raw string) (string, error) {
+	value := strings.TrimSpace(raw)
+	compact := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(value))
+
+	if length, ok := ibanLengths[country]; ok {
+		return compact, validateIBAN(country, length, compact)
+	}
+
+	switch country {
+	case "AR":
+		return compact, validateCBU(compact)
+	case "MX":
+		return compact, validateCLABE(compact)
+	default:
+		return value, nil
+	}
+}
+
+func validateIBAN(country string, length int, iban string) error {
+	if !ibanPattern.MatchString(iban) || iban[:2] != country {
+		return fmt.Errorf("account_number must be an IBAN starting with %s", country)
+	}
+	if len(iban) != length {
+		return fmt.Errorf("account_number must be a %d character IBAN for %s", length, country)
+	}
+
+	// Move the country code and check digits to the end, turn letters into
+	// numbers (A=10 ... Z=35) and check that the result is 1 modulo 97.
+	var numeric strings.Builder
+	for _, character := range iban[4:] + iban[:4] {
+		if character >= 'A' && character <= 'Z' {
+			fmt.Fprintf(&numeric, "%d", character-'A'+10)
+			continue
+		}
+		numeric.WriteRune(character)
+	}
+
+	value, ok := new(big.Int).SetString(numeric.String(), 10)
+	if !ok || new(big.Int).Mod(value, big.NewInt(97)).Int64() != 1 {
+		return fmt.Errorf("account_number is not a valid IBAN (check digits do not match)")
+	}
+
+	return nil
+}
+
+// validateCBU checks an Argentine CBU: 22 digits in two blocks (bank and
+// branch, then account), each ending in a weighted check digit.
+func validateCBU(cbu string) error {
+	if len(cbu) != 22 || !accountDigitsPattern.MatchString(cbu) {
+		return fmt.Errorf("account_number must be a 22 digit CBU")
+	}
+	if weightedCheckDigit(cbu[:7], []int{7, 1, 3, 9}) != int(cbu[7]-'0') ||
+		weightedCheckDigit(cbu[8:21], []int{3, 9, 7, 1}) != int(cbu[21]-'0') {
+		return fmt.Errorf("account_number is not a valid CBU (check digits do not match)")
+	}
+
+	return nil
+}
+
+// validateCLABE checks a Mexican CLABE: 17 digits followed by a check digit
+// computed with weights 3, 7, 1.
+func validateCLABE(clabe string) error {
+	if len(clabe) != 18 || !accountDigitsPattern.MatchString(clabe) {
+		return fmt.Errorf("account_number must be an 18 digit CLABE")
+	}
+	if weightedCheckDigit(clabe[:17], []int{3, 7, 1}) != int(clabe[17]-'0') {
+		return fmt.Errorf("account_number is not a valid CLABE (check digit does not match)")
+	}
+
+	return nil
+}
+
+// weightedCheckDigit multiplies each digit by the repeating weights and returns
+// the digit that brings the sum to a multiple of ten. Only the last digit of
+// each product counts, which is the same for CBU (whose products are summed
+// whole) because only the sum modulo 10 matters.
+func weightedCheckDigit(digits string, weights []int) int {
+	sum := 0
+	for index, character := range digits {
+		sum += int(character-'0') * weights[index%len(weights)] % 10
+	}
+
+	return (10 - sum%10) % 10
+}
@@ -0,0 +1,45 @@
+package backend
+
+import "testing"
+
+func TestNormalizeAccountNumber(t *testing.T) {
+	valid := []struct {
+		country  string
+		raw      string
+		expected string
+	}{
+		{"DE", "de89 3704 0044 0532 0130 00", "DE89370400440532013000"},
+		{"GB", "GB82WEST12345698765432", "GB82WEST12345698765432"},
+		{"AR", "2850590940090418135201", "2850590940090418135201"},
+		{"MX", "032-180-000118359719", "032180000118359719"},
+		{"US", " 000123456789 ", "000123456789"},
+	}
+	for _, item := range valid {
+		normalized, err := normalizeAccountNumber(item.country, item.raw)
+		if err != nil {
+			t.Fatalf("expected %s %q to be valid: %v", item.country, item.raw, err)
+		}
+		if normalized != item.expected {
+			t.Fatalf("expected %s %q to normalize to %q, got %q", item.country, item.raw, item.expected, normalized)
+		}
+	}
+
+	invalid := []struct {
+		country string
+		raw     string
+	}{
+		{"DE", "DE88370400440532013000"},
+		{"DE", "GB82WEST12345698765432"},
+		{"DE", "DE8937040044053201300"},
+		{"AR", "2850590940090418135202"},
+		{"AR", "2850590840090418135201"},
+		{"AR", "285059094009041813520"},
+		{"MX", "032180000118359718"},
+		{"MX", "03218000011835971A"},
+	}
+	for _, item := range invalid {
+		if _, err := normalizeAccountNumber(item.country, item.raw); err == nil {
+			t.Fatalf("expected %s %q to be rejected", item.country, item.raw)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package backend
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 )
@@ -19,15 +21,56 @@ type errorBody struct {
 func (application app) routes() http.Handler {
 	mux := http.NewServeMux()
 	application.registerAPIRoutes(mux)
-	mux.Handle("/", http.FileServer(http.Dir("web")))
-	return recoverMiddleware(mux)
+	mux.HandleFunc(metricsPath, application.metricsHandler)
+	mux.Handle("/", http.FileServer(http.FS(application.web)))
+	return requestIDMiddleware(application.queryDeadlineMiddleware(application.observeMiddleware(application.recoverMiddleware(application.limitBodyMiddleware(mux)))))
 }
 
-func recoverMiddleware(next http.Handler) http.Handler {
+// queryDeadlineMiddleware gives every request a deadline of queryTimeout.
+// Queries run with the request context, so the database stops working on a
+// request once it has expired or the client has gone away. It wraps
+// observeMiddleware because the copy made by WithContext would hide the route
+// pattern the mux sets on the request.
+func (application app) queryDeadlineMiddleware(next http.Handler) http.Handler {
+	if application.queryTimeout <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ctx, cancel := context.WithTimeout(request.Context(), application.queryTimeout)
+		defer cancel()
+		next.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
+
+// limitBodyMiddleware rejects bodies announced larger than maxBodyBytes up
+// front and cuts off longer streamed bodies, which then fail to decode.
+func (application app) limitBodyMiddleware(next http.Handler) http.Handler {
+	if application.maxBodyBytes <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.ContentLength > application.maxBodyBytes {
+			writeError(writer, http.StatusRequestEntityTooLarge, "payload_too_large", fmt.Sprintf("request body must not exceed %d bytes", application.maxBodyBytes))
+			return
+		}
+
+		request.Body = http.MaxBytesReader(writer, request.Body, application.maxBodyBytes)
+		next.ServeHTTP(writer, request)
+	})
+}
+
+func (application app) recoverMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		defer func() {
 			if recovered := recover(); recovered != nil {
-				log.Printf("panic recovered for %s %s: %v", request.Method, request.URL.Path, recovered)
+				application.log().ErrorContext(request.Context(), "panic recovered",
+					slog.String("request_id", requestID(request.Context())),
+					slog.String("method", request.Method),
+					slog.String("path", request.URL.Path),
+					slog.Any("panic", recovered),
+				)
 				writeError(writer, http.StatusInternalServerError, "internal_error", "internal server error")
 			}
 		}()
@@ -38,6 +81,7 @@ func recoverMiddleware(next http.Handler) http.Handler {
 
 func (application app) registerAPIRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/health", healthHandler)
+	application.registerHealthRoutes(mux)
 	application.registerTransactionRoutes(mux)
 	application.registerTransactionCategoryRoutes(mux)
 	application.registerPeopleRoutes(mux)
@@ -52,6 +96,16 @@ func (application app) registerAPIRoutes(mux *http.ServeMux) {
 	application.registerCreditCardSubscriptionRoutes(mux)
 	application.registerExpenseRoutes(mux)
 	application.registerExpensePaymentRoutes(mux)
+	application.registerReconciliationRoutes(mux)
+	application.registerSharedExpenseRoutes(mux)
+	application.registerSettlementRoutes(mux)
+	application.registerLoanRoutes(mux)
+	application.registerLoanRepaymentRoutes(mux)
+	application.registerSavingsGoalRoutes(mux)
+	application.registerAuditRoutes(mux)
+	application.registerTrashRoutes(mux)
+	application.registerIntegrityRoutes(mux)
+	application.registerBackupRoutes(mux)
 }
 
 func healthHandler(writer http.ResponseWriter, _ *http.Request) {
@@ -1,8 +1,13 @@
 package backend
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestHealthHandler(t *testing.T) {
@@ -12,3 +17,86 @@ func TestHealthHandler(t *testing.T) {
 		t.Fatalf("expected 200, got %d", response.Code)
 	}
 }
+
+func TestRoutesServeEmbeddedWebFiles(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	index := performRequest(router, http.MethodGet, "/", nil)
+	if index.Code != http.StatusOK {
+		t.Fatalf("expected index to return 200, got %d", index.Code)
+	}
+	if !strings.Contains(index.Body.String(), "<html") {
+		t.Fatalf("expected index to serve the frontend HTML")
+	}
+
+	module := performRequest(router, http.MethodGet, "/app/index.js", nil)
+	if module.Code != http.StatusOK {
+		t.Fatalf("expected frontend module to return 200, got %d", module.Code)
+	}
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+	application := newTestApplication(t)
+	application.maxBodyBytes = 64
+	router := application.routes()
+
+	small := performRequest(router, http.MethodPost, "/api/banks", []byte(`{"name":"Bank One","country":"US"}`))
+	if small.Code != http.StatusCreated {
+		t.Fatalf("expected small body to be accepted, got %d", small.Code)
+	}
+
+	large := performRequest(router, http.MethodPost, "/api/banks", []byte(`{"name":"`+strings.Repeat("x", 100)+`","country":"US"}`))
+	if large.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for oversized body, got %d", large.Code)
+	}
+
+	var body apiError
+	if err := json.NewDecoder(large.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	if body.Error.Code != "payload_too_large" {
+		t.Fatalf("expected payload_too_large, got %q", body.Error.Code)
+	}
+}
+
+func TestExpiredQueryDeadline(t *testing.T) {
+	application := newTestApplication(t)
+	application.queryTimeout = time.Nanosecond
+	router := application.routes()
+
+	response := performRequest(router, http.MethodGet, "/api/people", nil)
+	if response.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504 once the query deadline passed, got %d", response.Code)
+	}
+
+	var body apiError
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	if body.Error.Code != "query_timeout" {
+		t.Fatalf("expected query_timeout, got %q", body.Error.Code)
+	}
+}
+
+func TestCanceledRequestStopsItsQueries(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request := httptest.NewRequest(http.MethodGet, "/api/people", nil).WithContext(ctx)
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	if response.Code != statusClientClosedRequest {
+		t.Fatalf("expected 499 for a canceled request, got %d", response.Code)
+	}
+
+	var body apiError
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	if body.Error.Code != "request_canceled" {
+		t.Fatalf("expected request_canceled, got %q", body.Error.Code)
+	}
+}
@@ -1,24 +1,124 @@
 package backend
 
 import (
+	"context"
 	"database/sql"
+	"io/fs"
+	"log/slog"
 	"net/http"
-	"strings"
+	"time"
 )
 
 type app struct {
-	db *sql.DB
+	// db is the writer pool; reader, when set, serves reads outside of
+	// transactions.
+	db      *sql.DB
+	reader  *sql.DB
+	web     fs.FS
+	backups BackupConfig
+	fields  *FieldCipher
+	logger  *slog.Logger
+	metrics *metrics
+	// maxBodyBytes caps request bodies; 0 means unlimited.
+	maxBodyBytes int64
+	// queryTimeout bounds the database work of one request; 0 means none.
+	queryTimeout time.Duration
+	// migrations, dataDir and minFreeDiskBytes feed the readiness checks.
+	migrations       fs.FS
+	dataDir          string
+	minFreeDiskBytes uint64
+	// batch is set while handlers run as part of a batch request. All reads and
+	// writes then go through this shared transaction.
+	batch *sql.Tx
 }
 
-func NewMux(db *sql.DB) http.Handler {
-	application := app{db: db}
+// queryer runs statements on a pool, a transaction or a batch savepoint.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// dbTx is the transaction handle used by mutation handlers. It is either a
+// regular *sql.Tx or a savepoint inside a batch transaction.
+type dbTx interface {
+	queryer
+	Commit() error
+	Rollback() error
+}
+
+// Options configures the optional parts of the HTTP handler.
+type Options struct {
+	// Reader is a read-only pool for queries outside of transactions, see
+	// DatabasePool; nil reads through the db passed to NewMux.
+	Reader *sql.DB
+	// Backups is where the admin backup endpoints write.
+	Backups BackupConfig
+	// Fields encrypts sensitive columns; nil when field encryption is disabled.
+	Fields *FieldCipher
+	// Logger receives one structured line per request; nil uses slog.Default().
+	Logger *slog.Logger
+	// MaxBodyBytes rejects larger request bodies with 413; 0 means unlimited.
+	MaxBodyBytes int64
+	// QueryTimeout cancels the queries of a request that runs longer, which
+	// then fails with 504 query_timeout; 0 means no deadline.
+	QueryTimeout time.Duration
+	// Migrations are the migration files the schema readiness check compares
+	// the database with.
+	Migrations fs.FS
+	// DataDir is the directory holding the database, whose free space the
+	// readiness check watches.
+	DataDir string
+	// MinFreeDiskBytes is the free space DataDir needs to be ready; 0 uses
+	// DefaultMinFreeDiskBytes.
+	MinFreeDiskBytes uint64
+}
+
+// NewMux builds the HTTP handler serving the API and the frontend files in web.
+func NewMux(db *sql.DB, web fs.FS, options Options) http.Handler {
+	application := app{
+		db:               db,
+		reader:           options.Reader,
+		web:              web,
+		backups:          options.Backups,
+		fields:           options.Fields,
+		logger:           options.Logger,
+		metrics:          newMetrics(),
+		maxBodyBytes:     options.MaxBodyBytes,
+		queryTimeout:     options.QueryTimeout,
+		migrations:       options.Migrations,
+		dataDir:          options.DataDir,
+		minFreeDiskBytes: options.MinFreeDiskBytes,
+	}
+	if application.minFreeDiskBytes == 0 {
+		application.minFreeDiskBytes = DefaultMinFreeDiskBytes
+	}
 	return application.routes()
 }
 
-func isUniqueConstraintError(err error) bool {
-	return strings.Contains(strings.ToLower(err.Error()), "unique constraint failed")
+type namedPool struct {
+	name string
+	db   *sql.DB
+}
+
+// pools lists the connection pools the application uses, for health checks
+// and metrics.
+func (application app) pools() []namedPool {
+	pools := []namedPool{{name: "writer", db: application.db}}
+	if application.reader != nil {
+		pools = append(pools, namedPool{name: "reader", db: application.reader})
+	}
+
+	return pools
 }
 
-func isForeignKeyConstraintError(err error) bool {
-	return strings.Contains(strings.ToLower(err.Error()), "foreign key constraint failed")
+// data returns the store the services of this request work on.
+func (application app) data() dataStore {
+	return dataStore{
+		db:      application.db,
+		reader:  application.reader,
+		batch:   application.batch,
+		fields:  application.fields,
+		metrics: application.metrics,
+	}
 }
@@ -0,0 +1,208 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	auditPath           = "/api/audit"
+	auditActorHeader    = "X-Actor"
+	defaultAuditActor   = "anonymous"
+	auditActionCreate   = "create"
+	auditActionUpdate   = "update"
+	auditActionDelete   = "delete"
+	auditActionRestore  = "restore"
+	auditActionPurge    = "purge"
+	auditActionMerge    = "merge"
+	auditDateTimeLayout = "2006-01-02 15:04:05"
+)
+
+const (
+	auditEntityBankAccounts            = "bank_accounts"
+	auditEntityBanks                   = "banks"
+	auditEntityCreditCardCycleBalances = "credit_card_cycle_balances"
+	auditEntityCreditCardCycles        = "credit_card_cycles"
+	auditEntityCreditCardInstallments  = "credit_card_installments"
+	auditEntityCreditCardSubscriptions = "credit_card_subscriptions"
+	auditEntityCreditCards             = "credit_cards"
+	auditEntityCurrencies              = "currencies"
+	auditEntityExpensePayments         = "expense_payments"
+	auditEntityExpenses                = "expenses"
+	auditEntityLoanRepayments          = "loan_repayments"
+	auditEntityLoans                   = "loans"
+	auditEntityPeople                  = "people"
+	auditEntityReconciliations         = "reconciliations"
+	auditEntitySavingsGoals            = "savings_goals"
+	auditEntitySettlements             = "settlements"
+	auditEntitySharedExpenses          = "shared_expenses"
+	auditEntityTransactionCategories   = "transaction_categories"
+	auditEntityTransactions            = "transactions"
+)
+
+type auditEvent struct {
+	ID         int64                  `json:"id"`
+	Entity     string                 `json:"entity"`
+	EntityID   int64                  `json:"entity_id"`
+	Action     string                 `json:"action"`
+	Actor      string                 `json:"actor"`
+	OccurredAt string                 `json:"occurred_at"`
+	Changes    map[string]auditChange `json:"changes"`
+}
+
+type auditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// auditRecord describes a single mutation to be appended to audit_events.
+// Before is nil for creates and After is nil for deletes.
+type auditRecord struct {
+	Entity   string
+	EntityID int64
+	Action   string
+	Actor    string
+	Before   any
+	After    any
+}
+
+func (application app) registerAuditRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(auditPath, application.auditHandler)
+}
+
+func (application app) auditHandler(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		application.listAuditEvents(writer, request)
+	default:
+		methodNotAllowed(writer, http.MethodGet)
+	}
+}
+
+func (application app) listAuditEvents(writer http.ResponseWriter, request *http.Request) {
+	var filter auditFilter
+
+	values := request.URL.Query()
+	filter.Entity = strings.TrimSpace(values.Get("entity"))
+	if rawID := strings.TrimSpace(values.Get("id")); rawID != "" {
+		id, err := strconv.ParseInt(rawID, 10, 64)
+		if err != nil || id <= 0 {
+			writeError(writer, http.StatusBadRequest, "invalid_query", "id must be a positive integer")
+			return
+		}
+		filter.EntityID = id
+	}
+	if from := strings.TrimSpace(values.Get("from")); from != "" {
+		if !isValidISODate(from) {
+			writeError(writer, http.StatusBadRequest, "invalid_query", "from must be a valid date in YYYY-MM-DD format")
+			return
+		}
+		filter.From = from
+	}
+	if to := strings.TrimSpace(values.Get("to")); to != "" {
+		if !isValidISODate(to) {
+			writeError(writer, http.StatusBadRequest, "invalid_query", "to must be a valid date in YYYY-MM-DD format")
+			return
+		}
+		filter.To = to
+	}
+
+	items, err := application.services().audit.list(request.Context(), filter)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load audit events")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, items)
+}
+
+func scanAuditEvent(source scanner) (auditEvent, error) {
+	var item auditEvent
+	var occurredAt any
+	var changes string
+
+	err := source.Scan(&item.ID, &item.Entity, &item.EntityID, &item.Action, &item.Actor, &occurredAt, &changes)
+	if err != nil {
+		return auditEvent{}, err
+	}
+
+	item.OccurredAt = formatAuditTimestamp(occurredAt)
+	item.Changes = make(map[string]auditChange)
+	if err = json.Unmarshal([]byte(changes), &item.Changes); err != nil {
+		return auditEvent{}, err
+	}
+
+	return item, nil
+}
+
+func diffAuditSnapshots(before any, after any) (map[string]auditChange, error) {
+	beforeFields, err := auditSnapshotFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditSnapshotFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]auditChange)
+	for field, beforeValue := range beforeFields {
+		afterValue := afterFields[field]
+		if !reflect.DeepEqual(beforeValue, afterValue) {
+			changes[field] = auditChange{Before: beforeValue, After: afterValue}
+		}
+	}
+	for field, afterValue := range afterFields {
+		if _, seen := beforeFields[field]; !seen && afterValue != nil {
+			changes[field] = auditChange{Before: nil, After: afterValue}
+		}
+	}
+
+	return changes, nil
+}
+
+func auditSnapshotFields(snapshot any) (map[string]any, error) {
+	fields := make(map[string]any)
+	if snapshot == nil {
+		return fields, nil
+	}
+
+	encoded, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+
+	delete(fields, "id")
+	return fields, nil
+}
+
+func requestActor(request *http.Request) string {
+	actor := strings.TrimSpace(request.Header.Get(auditActorHeader))
+	if actor == "" {
+		return defaultAuditActor
+	}
+
+	return actor
+}
+
+func formatAuditTimestamp(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case []byte:
+		return string(typed)
+	case time.Time:
+		return typed.UTC().Format(auditDateTimeLayout)
+	case interface{ Format(string) string }:
+		return typed.Format(auditDateTimeLayout)
+	default:
+		return ""
+	}
+}
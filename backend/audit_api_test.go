@@ -0,0 +1,123 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditEventsRecordedForMutations(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	createRequest := httptest.NewRequest(http.MethodPost, "/api/people", bytes.NewReader([]byte(`{"name":"John Doe"}`)))
+	createRequest.Header.Set(auditActorHeader, "alice")
+	createResponse := httptest.NewRecorder()
+	router.ServeHTTP(createResponse, createRequest)
+	if createResponse.Code != http.StatusCreated {
+		t.Fatalf("expected create to return 201, got %d", createResponse.Code)
+	}
+
+	updateResponse := performRequest(router, http.MethodPut, "/api/people/1", []byte(`{"name":"Jane Doe"}`))
+	if updateResponse.Code != http.StatusOK {
+		t.Fatalf("expected update to return 200, got %d", updateResponse.Code)
+	}
+
+	deleteResponse := performRequest(router, http.MethodDelete, "/api/people/1", nil)
+	if deleteResponse.Code != http.StatusNoContent {
+		t.Fatalf("expected delete to return 204, got %d", deleteResponse.Code)
+	}
+
+	listResponse := performRequest(router, http.MethodGet, "/api/audit?entity=people&id=1", nil)
+	if listResponse.Code != http.StatusOK {
+		t.Fatalf("expected audit list to return 200, got %d", listResponse.Code)
+	}
+
+	var events []auditEvent
+	if err := json.NewDecoder(listResponse.Body).Decode(&events); err != nil {
+		t.Fatalf("decode audit events: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 audit events, got %d", len(events))
+	}
+
+	if events[0].Action != auditActionCreate || events[0].Actor != "alice" {
+		t.Fatalf("unexpected create event: %+v", events[0])
+	}
+	if events[0].Changes["name"].Before != nil || events[0].Changes["name"].After != "John Doe" {
+		t.Fatalf("unexpected create changes: %+v", events[0].Changes)
+	}
+
+	if events[1].Action != auditActionUpdate || events[1].Actor != defaultAuditActor {
+		t.Fatalf("unexpected update event: %+v", events[1])
+	}
+	if events[1].Changes["name"].Before != "John Doe" || events[1].Changes["name"].After != "Jane Doe" {
+		t.Fatalf("unexpected update changes: %+v", events[1].Changes)
+	}
+
+	if events[2].Action != auditActionDelete || events[2].Changes["name"].After != nil {
+		t.Fatalf("unexpected delete event: %+v", events[2])
+	}
+}
+
+func TestAuditEventsNotRecordedForFailedMutations(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedCreditCardDependencies(t, router)
+
+	invalidCard := performRequest(router, http.MethodPost, "/api/credit-cards", []byte(`{"bank_id":999,"person_id":1,"number":"4111111111111111"}`))
+	if invalidCard.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid credit card to return 400, got %d", invalidCard.Code)
+	}
+
+	listResponse := performRequest(router, http.MethodGet, "/api/audit?entity=credit_cards", nil)
+	var events []auditEvent
+	if err := json.NewDecoder(listResponse.Body).Decode(&events); err != nil {
+		t.Fatalf("decode audit events: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected no audit events for failed mutation, got %d", len(events))
+	}
+}
+
+func TestAuditEventsQueryValidation(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	invalidID := performRequest(router, http.MethodGet, "/api/audit?id=abc", nil)
+	if invalidID.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid id to return 400, got %d", invalidID.Code)
+	}
+
+	invalidFrom := performRequest(router, http.MethodGet, "/api/audit?from=2026-13-01", nil)
+	if invalidFrom.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid from to return 400, got %d", invalidFrom.Code)
+	}
+
+	outOfRange := performRequest(router, http.MethodGet, "/api/audit?to=2000-01-01", nil)
+	if outOfRange.Code != http.StatusOK || strings.TrimSpace(outOfRange.Body.String()) != "[]" {
+		t.Fatalf("expected date filter to return no events, got %d %s", outOfRange.Code, outOfRange.Body.String())
+	}
+
+	performRequest(router, http.MethodPost, "/api/people", []byte(`{"name":"Alice"}`))
+	today := time.Now().UTC().Format("2006-01-02")
+	sameDay := performRequest(router, http.MethodGet, "/api/audit?from="+today+"&to="+today, nil)
+	if sameDay.Code != http.StatusOK || !strings.Contains(sameDay.Body.String(), `"entity":"people"`) {
+		t.Fatalf("expected from and to to include the whole day, got %d %s", sameDay.Code, sameDay.Body.String())
+	}
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+	later := performRequest(router, http.MethodGet, "/api/audit?from="+tomorrow, nil)
+	if strings.TrimSpace(later.Body.String()) != "[]" {
+		t.Fatalf("expected events before from to be left out, got %s", later.Body.String())
+	}
+
+	postResponse := performRequest(router, http.MethodPost, "/api/audit", []byte(`{}`))
+	if postResponse.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected POST to return 405, got %d", postResponse.Code)
+	}
+}
@@ -0,0 +1,94 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// auditFilter narrows the audit log; zero fields do not filter.
+type auditFilter struct {
+	Entity   string
+	EntityID int64
+	From     string
+	To       string
+}
+
+type auditRepository interface {
+	list(ctx context.Context, filter auditFilter) ([]auditEvent, error)
+	// record appends an event. Called inside a unit of work, the event is only
+	// persisted when the audited change itself commits.
+	record(ctx context.Context, record auditRecord) error
+}
+
+type sqlAuditRepository struct {
+	source queryer
+}
+
+func (repository sqlAuditRepository) list(ctx context.Context, filter auditFilter) ([]auditEvent, error) {
+	query := `SELECT id, entity, entity_id, action, actor, occurred_at, changes FROM audit_events WHERE 1 = 1`
+	args := make([]any, 0)
+	if filter.Entity != "" {
+		query += ` AND entity = ?`
+		args = append(args, filter.Entity)
+	}
+	if filter.EntityID > 0 {
+		query += ` AND entity_id = ?`
+		args = append(args, filter.EntityID)
+	}
+	// Days are compared as UTC timestamp ranges, which both engines read the
+	// same way, rather than with SQLite's date().
+	if filter.From != "" {
+		query += ` AND occurred_at >= ?`
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		to, err := time.Parse("2006-01-02", filter.To)
+		if err != nil {
+			return nil, err
+		}
+		query += ` AND occurred_at < ?`
+		args = append(args, to.AddDate(0, 0, 1).Format("2006-01-02"))
+	}
+	query += ` ORDER BY id`
+
+	rows, err := repository.source.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]auditEvent, 0)
+	for rows.Next() {
+		item, scanErr := scanAuditEvent(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlAuditRepository) record(ctx context.Context, record auditRecord) error {
+	changes, err := diffAuditSnapshots(record.Before, record.After)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.source.ExecContext(
+		ctx,
+		`INSERT INTO audit_events(entity, entity_id, action, actor, changes) VALUES (?, ?, ?, ?, ?)`,
+		record.Entity,
+		record.EntityID,
+		record.Action,
+		record.Actor,
+		string(encoded),
+	)
+	return err
+}
@@ -0,0 +1,11 @@
+package backend
+
+import "context"
+
+type auditService struct {
+	store dataStore
+}
+
+func (service auditService) list(ctx context.Context, filter auditFilter) ([]auditEvent, error) {
+	return service.store.reads().audit().list(ctx, filter)
+}
@@ -0,0 +1,340 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const backupTimeLayout = "20060102T150405Z"
+
+// ErrBackupExists is returned when the backup destination is already taken.
+var ErrBackupExists = errors.New("backup already exists")
+
+// ErrBackupUnsupported is returned for PostgreSQL databases, which are backed
+// up and restored with pg_dump and pg_restore instead.
+var ErrBackupUnsupported = errors.New("backups are only managed for SQLite databases, use pg_dump for PostgreSQL")
+
+var backupFilePattern = regexp.MustCompile(`^backup-(\d{8}T\d{6}Z)\.db$`)
+
+// BackupConfig says where managed backups are written and how many are kept.
+type BackupConfig struct {
+	Dir       string
+	Retention BackupRetention
+}
+
+// BackupRetention keeps the newest backup of each of the last Daily days,
+// Weekly ISO weeks and Monthly months. A backup kept by any rule survives.
+// When every count is zero, backups are never pruned.
+type BackupRetention struct {
+	Daily   int
+	Weekly  int
+	Monthly int
+}
+
+// BackupInfo describes a managed backup file in BackupConfig.Dir.
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	SizeBytes int64     `json:"size_bytes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BackupDatabase writes a consistent snapshot of db to destination with
+// VACUUM INTO. It is safe to run while the server keeps handling requests.
+func BackupDatabase(ctx context.Context, db *sql.DB, destination string) error {
+	if dialectOf(db).name != sqliteDialect.name {
+		return ErrBackupUnsupported
+	}
+	if _, err := os.Stat(destination); err == nil {
+		return fmt.Errorf("%w: %s", ErrBackupExists, destination)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
+		return fmt.Errorf("create backup directory: %w", err)
+	}
+
+	if _, err := db.ExecContext(ctx, `VACUUM INTO ?`, destination); err != nil {
+		return fmt.Errorf("write backup: %w", err)
+	}
+
+	return nil
+}
+
+// CreateBackup writes a timestamped backup into config.Dir and then prunes
+// older backups according to config.Retention.
+func CreateBackup(ctx context.Context, db *sql.DB, config BackupConfig, now time.Time) (BackupInfo, []string, error) {
+	if config.Dir == "" {
+		return BackupInfo{}, nil, fmt.Errorf("backup directory is not configured")
+	}
+
+	createdAt := now.UTC().Truncate(time.Second)
+	name := "backup-" + createdAt.Format(backupTimeLayout) + ".db"
+	destination := filepath.Join(config.Dir, name)
+	if err := BackupDatabase(ctx, db, destination); err != nil {
+		return BackupInfo{}, nil, err
+	}
+
+	stat, err := os.Stat(destination)
+	if err != nil {
+		return BackupInfo{}, nil, err
+	}
+
+	removed, err := PruneBackups(config)
+	if err != nil {
+		return BackupInfo{}, nil, fmt.Errorf("prune backups: %w", err)
+	}
+
+	return BackupInfo{Name: name, SizeBytes: stat.Size(), CreatedAt: createdAt}, removed, nil
+}
+
+// ListBackups returns the managed backups in dir, newest first. Files that do
+// not follow the backup-<timestamp>.db naming are ignored.
+func ListBackups(dir string) ([]BackupInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]BackupInfo, 0, len(entries))
+	for _, entry := range entries {
+		match := backupFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		createdAt, parseErr := time.Parse(backupTimeLayout, match[1])
+		if parseErr != nil {
+			continue
+		}
+
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			return nil, infoErr
+		}
+
+		backups = append(backups, BackupInfo{Name: entry.Name(), SizeBytes: info.Size(), CreatedAt: createdAt})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
+	return backups, nil
+}
+
+// PruneBackups deletes the managed backups in config.Dir that no retention
+// rule keeps and returns their names.
+func PruneBackups(config BackupConfig) ([]string, error) {
+	retention := config.Retention
+	if retention.Daily <= 0 && retention.Weekly <= 0 && retention.Monthly <= 0 {
+		return []string{}, nil
+	}
+
+	backups, err := ListBackups(config.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := make(map[string]bool, len(backups))
+	keepNewestPerPeriod(backups, retention.Daily, keep, func(at time.Time) string {
+		return at.Format("2006-01-02")
+	})
+	keepNewestPerPeriod(backups, retention.Weekly, keep, func(at time.Time) string {
+		year, week := at.ISOWeek()
+		return strconv.Itoa(year) + "-W" + strconv.Itoa(week)
+	})
+	keepNewestPerPeriod(backups, retention.Monthly, keep, func(at time.Time) string {
+		return at.Format("2006-01")
+	})
+
+	removed := make([]string, 0)
+	for _, backup := range backups {
+		if keep[backup.Name] {
+			continue
+		}
+		if err = os.Remove(filepath.Join(config.Dir, backup.Name)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, backup.Name)
+	}
+
+	return removed, nil
+}
+
+// keepNewestPerPeriod marks the newest backup of each of the newest count
+// periods. backups must be sorted newest first.
+func keepNewestPerPeriod(backups []BackupInfo, count int, keep map[string]bool, period func(time.Time) string) {
+	seen := make(map[string]bool, count)
+	for _, backup := range backups {
+		key := period(backup.CreatedAt)
+		if seen[key] {
+			continue
+		}
+		if len(seen) >= count {
+			return
+		}
+		seen[key] = true
+		keep[backup.Name] = true
+	}
+}
+
+// FindBackup returns the newest managed backup in dir taken at or before at,
+// for restoring the database as it was at a point in time.
+func FindBackup(dir string, at time.Time) (BackupInfo, error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return BackupInfo{}, err
+	}
+
+	for _, backup := range backups {
+		if !backup.CreatedAt.After(at) {
+			return backup, nil
+		}
+	}
+
+	return BackupInfo{}, fmt.Errorf("no backup in %s was taken at or before %s", dir, at.UTC().Format(time.RFC3339))
+}
+
+// RunBackupSchedule creates a backup every interval until ctx is cancelled.
+// Failures are logged and retried on the next tick. A backup that is running
+// when ctx is cancelled is not interrupted.
+func RunBackupSchedule(ctx context.Context, db *sql.DB, config BackupConfig, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			backup, removed, err := CreateBackup(context.WithoutCancel(ctx), db, config, now)
+			if err != nil {
+				slog.Error("scheduled backup failed", slog.Any("error", err))
+				continue
+			}
+			slog.Info("scheduled backup written", slog.String("backup", backup.Name), slog.Int("pruned", len(removed)))
+		}
+	}
+}
+
+// RestoreDatabase replaces the database file at databasePath with the backup
+// at backupPath. The backup must pass PRAGMA integrity_check and its applied
+// migrations must all be known to migrationFiles, so a snapshot from a newer
+// release or with edited migrations is refused; an older schema is upgraded
+// on the next start. The server must not be running against databasePath
+// while restoring.
+func RestoreDatabase(ctx context.Context, backupPath string, databasePath string, migrationFiles fs.FS) error {
+	if IsPostgresDSN(databasePath) {
+		return ErrBackupUnsupported
+	}
+	if err := verifyDatabaseFile(ctx, backupPath, migrationFiles); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(databasePath), 0o755); err != nil {
+		return fmt.Errorf("create db directory: %w", err)
+	}
+
+	staging := databasePath + ".restore"
+	if err := copyFile(backupPath, staging); err != nil {
+		os.Remove(staging)
+		return fmt.Errorf("stage backup: %w", err)
+	}
+
+	// Leftover journal files belong to the old database and would corrupt the
+	// restored one if SQLite replayed them.
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(databasePath + suffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			os.Remove(staging)
+			return fmt.Errorf("remove %s file: %w", suffix, err)
+		}
+	}
+
+	if err := os.Rename(staging, databasePath); err != nil {
+		os.Remove(staging)
+		return fmt.Errorf("replace database: %w", err)
+	}
+
+	return nil
+}
+
+func verifyDatabaseFile(ctx context.Context, path string, migrationFiles fs.FS) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("open backup: %w", err)
+	}
+
+	db, err := openReadOnlyDatabaseFile(path)
+	if err != nil {
+		return fmt.Errorf("open backup: %w", err)
+	}
+	defer db.Close()
+
+	var result string
+	if err = db.QueryRowContext(ctx, `PRAGMA integrity_check`).Scan(&result); err != nil {
+		return fmt.Errorf("check backup integrity: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("backup failed integrity check: %s", result)
+	}
+
+	statuses, err := MigrationStatuses(ctx, db, migrationFiles)
+	if err != nil {
+		return fmt.Errorf("check backup schema: %w", err)
+	}
+	if len(statuses) == 0 || !statuses[0].Applied {
+		return fmt.Errorf("check backup schema: backup has no applied migrations")
+	}
+
+	return nil
+}
+
+// openReadOnlyDatabaseFile opens a SQLite file that must not change, such as a
+// backup being verified. mode=ro only applies to file: URIs, and query_only
+// refuses writes as well.
+func openReadOnlyDatabaseFile(path string) (*sql.DB, error) {
+	query := url.Values{}
+	query.Set("mode", "ro")
+	query.Add("_pragma", "query_only(1)")
+
+	return sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+}
+
+func copyFile(source string, destination string) error {
+	input, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	output, err := os.OpenFile(destination, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(output, input); err != nil {
+		output.Close()
+		return err
+	}
+	if err = output.Sync(); err != nil {
+		output.Close()
+		return err
+	}
+
+	return output.Close()
+}
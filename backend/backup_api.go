@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+const adminBackupsPath = "/api/admin/backups"
+
+type backupCreatedResponse struct {
+	Backup  BackupInfo `json:"backup"`
+	Removed []string   `json:"removed"`
+}
+
+func (application app) registerBackupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(adminBackupsPath, application.backupsHandler)
+}
+
+func (application app) backupsHandler(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		application.listBackups(writer)
+	case http.MethodPost:
+		application.createBackup(writer, request)
+	default:
+		methodNotAllowed(writer, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (application app) listBackups(writer http.ResponseWriter) {
+	if application.backups.Dir == "" {
+		writeError(writer, http.StatusServiceUnavailable, "backups_disabled", "backup directory is not configured")
+		return
+	}
+
+	backups, err := ListBackups(application.backups.Dir)
+	if err != nil {
+		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to list backups")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, backups)
+}
+
+func (application app) createBackup(writer http.ResponseWriter, request *http.Request) {
+	if application.backups.Dir == "" {
+		writeError(writer, http.StatusServiceUnavailable, "backups_disabled", "backup directory is not configured")
+		return
+	}
+
+	backup, removed, err := CreateBackup(request.Context(), application.db, application.backups, time.Now())
+	if err != nil {
+		if errors.Is(err, ErrBackupExists) {
+			writeError(writer, http.StatusConflict, "backup_exists", "a backup was already taken this second")
+			return
+		}
+		if errors.Is(err, ErrBackupUnsupported) {
+			writeError(writer, http.StatusNotImplemented, "backups_unsupported", "backups of a PostgreSQL database are taken with pg_dump")
+			return
+		}
+		writeServiceError(writer, err, "failed to create backup")
+		return
+	}
+
+	writeJSON(writer, http.StatusCreated, backupCreatedResponse{Backup: backup, Removed: removed})
+}
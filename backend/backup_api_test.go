@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestAdminBackupEndpoints(t *testing.T) {
+	requireSQLite(t)
+	application := newTestApplication(t)
+	router := application.routes()
+
+	createResponse := performRequest(router, http.MethodPost, "/api/admin/backups", nil)
+	if createResponse.Code != http.StatusCreated {
+		t.Fatalf("expected backup to return 201, got %d", createResponse.Code)
+	}
+
+	var created backupCreatedResponse
+	if err := json.NewDecoder(createResponse.Body).Decode(&created); err != nil {
+		t.Fatalf("decode backup response: %v", err)
+	}
+	if created.Backup.Name == "" || created.Backup.SizeBytes == 0 {
+		t.Fatalf("unexpected backup: %+v", created.Backup)
+	}
+
+	listResponse := performRequest(router, http.MethodGet, "/api/admin/backups", nil)
+	if listResponse.Code != http.StatusOK {
+		t.Fatalf("expected list to return 200, got %d", listResponse.Code)
+	}
+
+	var backups []BackupInfo
+	if err := json.NewDecoder(listResponse.Body).Decode(&backups); err != nil {
+		t.Fatalf("decode backup list: %v", err)
+	}
+	if len(backups) != 1 || backups[0].Name != created.Backup.Name {
+		t.Fatalf("expected the new backup to be listed, got %+v", backups)
+	}
+
+	application.backups.Dir = ""
+	disabled := performRequest(application.routes(), http.MethodPost, "/api/admin/backups", nil)
+	if disabled.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected unconfigured backups to return 503, got %d", disabled.Code)
+	}
+}
@@ -0,0 +1,196 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"personal-finances/migrations"
+)
+
+func TestBackupAndRestoreRoundTrip(t *testing.T) {
+	requireSQLite(t)
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+
+	backupPath := filepath.Join(t.TempDir(), "backups", "snapshot.db")
+	if err := BackupDatabase(context.Background(), application.db, backupPath); err != nil {
+		t.Fatalf("backup database: %v", err)
+	}
+	if err := BackupDatabase(context.Background(), application.db, backupPath); err == nil {
+		t.Fatal("expected backup to refuse overwriting an existing file")
+	}
+
+	restoredPath := filepath.Join(t.TempDir(), "restored.db")
+	if err := RestoreDatabase(context.Background(), backupPath, restoredPath, migrations.Files); err != nil {
+		t.Fatalf("restore database: %v", err)
+	}
+
+	restored, err := SetupDatabase(restoredPath, migrations.Files)
+	if err != nil {
+		t.Fatalf("open restored database: %v", err)
+	}
+	defer restored.Close()
+
+	var accountNumber string
+	if err = restored.QueryRow(`SELECT account_number FROM bank_accounts WHERE id = 1`).Scan(&accountNumber); err != nil {
+		t.Fatalf("load restored bank account: %v", err)
+	}
+	if accountNumber != "ACC-001" {
+		t.Fatalf("expected restored bank account, got %q", accountNumber)
+	}
+
+	corruptPath := filepath.Join(t.TempDir(), "corrupt.db")
+	if err = os.WriteFile(corruptPath, []byte("not a database"), 0o644); err != nil {
+		t.Fatalf("write corrupt file: %v", err)
+	}
+	if err = RestoreDatabase(context.Background(), corruptPath, restoredPath, migrations.Files); err == nil {
+		t.Fatal("expected restore to reject a file that is not a valid database")
+	}
+}
+
+func TestVerifyLeavesTheBackupUnchanged(t *testing.T) {
+	requireSQLite(t)
+	application := newTestApplication(t)
+	seedTransactionDependencies(t, application.routes())
+
+	dir := t.TempDir()
+	backupPath := filepath.Join(dir, "snapshot.db")
+	if err := BackupDatabase(context.Background(), application.db, backupPath); err != nil {
+		t.Fatalf("backup database: %v", err)
+	}
+	before, err := os.ReadFile(backupPath)
+	if err != nil {
+		t.Fatalf("read backup: %v", err)
+	}
+
+	if err = verifyDatabaseFile(context.Background(), backupPath, migrations.Files); err != nil {
+		t.Fatalf("verify backup: %v", err)
+	}
+
+	readOnly, err := openReadOnlyDatabaseFile(backupPath)
+	if err != nil {
+		t.Fatalf("open backup: %v", err)
+	}
+	_, err = readOnly.Exec(`DELETE FROM bank_accounts`)
+	readOnly.Close()
+	if err == nil {
+		t.Fatal("expected the backup to be opened read-only")
+	}
+
+	after, err := os.ReadFile(backupPath)
+	if err != nil {
+		t.Fatalf("read verified backup: %v", err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Fatal("expected verification to leave the backup file unchanged")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected verification to leave no side files, got %v %v", entries, err)
+	}
+}
+
+func TestRestoreRejectsNewerSchema(t *testing.T) {
+	requireSQLite(t)
+	application := newTestApplication(t)
+
+	backupPath := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := BackupDatabase(context.Background(), application.db, backupPath); err != nil {
+		t.Fatalf("backup database: %v", err)
+	}
+
+	olderRelease := fstest.MapFS{}
+	names, err := fs.Glob(migrations.Files, "*.sql")
+	if err != nil {
+		t.Fatalf("read migrations: %v", err)
+	}
+	for _, name := range names[:len(names)-1] {
+		content, readErr := migrations.Files.ReadFile(name)
+		if readErr != nil {
+			t.Fatalf("read migration: %v", readErr)
+		}
+		olderRelease[name] = &fstest.MapFile{Data: content}
+	}
+
+	restoredPath := filepath.Join(t.TempDir(), "restored.db")
+	if err = RestoreDatabase(context.Background(), backupPath, restoredPath, olderRelease); err == nil {
+		t.Fatal("expected restore to refuse a backup with migrations unknown to this release")
+	}
+	if _, statErr := os.Stat(restoredPath); !os.IsNotExist(statErr) {
+		t.Fatal("expected the database file to stay untouched after a refused restore")
+	}
+
+	emptyPath := filepath.Join(t.TempDir(), "empty.db")
+	empty, err := OpenDatabase(emptyPath)
+	if err != nil {
+		t.Fatalf("create empty database: %v", err)
+	}
+	empty.Close()
+	if err = RestoreDatabase(context.Background(), emptyPath, restoredPath, migrations.Files); err == nil {
+		t.Fatal("expected restore to refuse a database without applied migrations")
+	}
+}
+
+func TestPruneBackupsKeepsDailyWeeklyMonthly(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, time.March, 31, 23, 0, 0, 0, time.UTC)
+	for day := 0; day < 90; day++ {
+		for _, hour := range []int{0, 12} {
+			name := "backup-" + start.AddDate(0, 0, -day).Add(-time.Duration(hour)*time.Hour).Format(backupTimeLayout) + ".db"
+			if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+				t.Fatalf("write backup: %v", err)
+			}
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644); err != nil {
+		t.Fatalf("write unrelated file: %v", err)
+	}
+
+	if _, err := PruneBackups(BackupConfig{Dir: dir, Retention: BackupRetention{Daily: 3, Weekly: 3, Monthly: 3}}); err != nil {
+		t.Fatalf("prune backups: %v", err)
+	}
+
+	kept, err := ListBackups(dir)
+	if err != nil {
+		t.Fatalf("list backups: %v", err)
+	}
+
+	// Daily keeps the 31st, 30th and 29th. Weekly keeps the newest of the
+	// ISO weeks ending on the 29th (already kept) and the 22nd. Monthly adds
+	// the newest of February and January.
+	expected := []string{
+		"backup-20260331T230000Z.db",
+		"backup-20260330T230000Z.db",
+		"backup-20260329T230000Z.db",
+		"backup-20260322T230000Z.db",
+		"backup-20260228T230000Z.db",
+		"backup-20260131T230000Z.db",
+	}
+	if len(kept) != len(expected) {
+		t.Fatalf("expected %d backups to survive, got %+v", len(expected), kept)
+	}
+	for index, name := range expected {
+		if kept[index].Name != name {
+			t.Fatalf("expected backup %d to be %s, got %s", index, name, kept[index].Name)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Fatal("expected files not named like backups to be left alone")
+	}
+
+	pointInTime, err := FindBackup(dir, time.Date(2026, time.March, 25, 0, 0, 0, 0, time.UTC))
+	if err != nil || pointInTime.Name != "backup-20260322T230000Z.db" {
+		t.Fatalf("expected point-in-time lookup to pick the 22nd, got %+v %v", pointInTime, err)
+	}
+	if _, err = FindBackup(dir, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Fatal("expected lookup before the oldest backup to fail")
+	}
+}
@@ -1,15 +1,12 @@
 package backend
 
 import (
-	"database/sql"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 )
 
 const (
+	bankAccountNumberField = "bank_accounts.account_number"
 	bankAccountsPath       = "/api/bank-accounts"
 	bankAccountsPathByID   = "/api/bank-accounts/"
 	bankAccountPathPattern = "/api/bank-accounts/%d"
@@ -33,12 +30,13 @@ type bankAccountPayload struct {
 func (application app) registerBankAccountRoutes(mux *http.ServeMux) {
 	mux.HandleFunc(bankAccountsPath, application.bankAccountsHandler)
 	mux.HandleFunc(bankAccountsPathByID, application.bankAccountByIDHandler)
+	mux.HandleFunc(bankAccountsPath+batchPathSuffix, application.batchHandler(bankAccountsPath, app.bankAccountsHandler, app.bankAccountByIDHandler))
 }
 
 func (application app) bankAccountsHandler(writer http.ResponseWriter, request *http.Request) {
 	switch request.Method {
 	case http.MethodGet:
-		application.listBankAccounts(writer)
+		application.listBankAccounts(writer, request)
 	case http.MethodPost:
 		application.createBankAccount(writer, request)
 	default:
@@ -55,245 +53,98 @@ func (application app) bankAccountByIDHandler(writer http.ResponseWriter, reques
 
 	switch request.Method {
 	case http.MethodGet:
-		application.getBankAccount(writer, id)
+		application.getBankAccount(writer, request, id)
 	case http.MethodPut:
 		application.updateBankAccount(writer, request, id)
+	case http.MethodPatch:
+		application.patchBankAccount(writer, request, id)
 	case http.MethodDelete:
-		application.deleteBankAccount(writer, id)
+		application.deleteBankAccount(writer, request, id)
 	default:
-		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodDelete)
+		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
 	}
 }
 
-func (application app) listBankAccounts(writer http.ResponseWriter) {
-	rows, err := application.db.Query(`SELECT id, bank_id, currency_id, account_number, balance FROM bank_accounts ORDER BY id`)
+func (application app) listBankAccounts(writer http.ResponseWriter, request *http.Request) {
+	items, err := application.services().bankAccounts.list(request.Context())
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load bank accounts")
+		writeServiceError(writer, err, "failed to load bank accounts")
 		return
 	}
-	defer rows.Close()
 
-	items := make([]bankAccount, 0)
-	for rows.Next() {
-		var item bankAccount
-		if scanErr := rows.Scan(&item.ID, &item.BankID, &item.CurrencyID, &item.AccountNumber, &item.Balance); scanErr != nil {
-			writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read bank accounts")
-			return
-		}
-		items = append(items, item)
+	for index, item := range items {
+		items[index] = maskBankAccount(item)
 	}
 
 	writeJSON(writer, http.StatusOK, items)
 }
 
-func (application app) getBankAccount(writer http.ResponseWriter, id int64) {
-	var item bankAccount
-	err := application.db.QueryRow(`SELECT id, bank_id, currency_id, account_number, balance FROM bank_accounts WHERE id = ?`, id).Scan(
-		&item.ID,
-		&item.BankID,
-		&item.CurrencyID,
-		&item.AccountNumber,
-		&item.Balance,
-	)
-	if errors.Is(err, sql.ErrNoRows) {
-		writeError(writer, http.StatusNotFound, "not_found", "bank account not found")
-		return
-	}
+func (application app) getBankAccount(writer http.ResponseWriter, request *http.Request, id int64) {
+	item, err := application.services().bankAccounts.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load bank account")
+		writeServiceError(writer, err, "failed to load bank account")
 		return
 	}
 
-	writeJSON(writer, http.StatusOK, item)
-}
-
-func (application app) createBankAccount(writer http.ResponseWriter, request *http.Request) {
-	payload, validationErr := decodeBankAccountPayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
+	if revealRequested(request.URL.Query()) {
+		writeJSONWithETag(writer, http.StatusOK, item)
 		return
 	}
 
-	if err := application.validateBankAccountReferences(payload.BankID, payload.CurrencyID); err != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", err.Error())
-		return
-	}
+	writeJSONWithETag(writer, http.StatusOK, maskBankAccount(item))
+}
 
-	result, err := application.db.Exec(
-		`INSERT INTO bank_accounts(bank_id, currency_id, account_number, balance) VALUES (?, ?, ?, ?)`,
-		payload.BankID,
-		payload.CurrencyID,
-		payload.AccountNumber,
-		payload.Balance,
-	)
-	if err != nil {
-		if isUniqueConstraintError(err) {
-			writeError(writer, http.StatusConflict, "duplicate_bank_account", "bank, currency and account number combination must be unique")
-			return
-		}
-		if isForeignKeyConstraintError(err) {
-			writeError(writer, http.StatusBadRequest, "invalid_payload", "bank and currency must exist")
-			return
-		}
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to create bank account")
+func (application app) createBankAccount(writer http.ResponseWriter, request *http.Request) {
+	var payload bankAccountPayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	id, err := result.LastInsertId()
+	created, err := application.services().bankAccounts.create(request.Context(), requestChange(request), payload)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read created bank account id")
+		writeServiceError(writer, err, "failed to create bank account")
 		return
 	}
 
-	created := bankAccount{
-		ID:            id,
-		BankID:        payload.BankID,
-		CurrencyID:    payload.CurrencyID,
-		AccountNumber: payload.AccountNumber,
-		Balance:       payload.Balance,
-	}
-	writer.Header().Set("Location", fmt.Sprintf(bankAccountPathPattern, id))
-	writeJSON(writer, http.StatusCreated, created)
+	writer.Header().Set("Location", fmt.Sprintf(bankAccountPathPattern, created.ID))
+	writeJSONWithETag(writer, http.StatusCreated, maskBankAccount(created))
 }
 
 func (application app) updateBankAccount(writer http.ResponseWriter, request *http.Request, id int64) {
-	payload, validationErr := decodeBankAccountPayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
-		return
-	}
-
-	if err := application.validateBankAccountReferences(payload.BankID, payload.CurrencyID); err != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", err.Error())
-		return
-	}
-
-	result, err := application.db.Exec(
-		`UPDATE bank_accounts SET bank_id = ?, currency_id = ?, account_number = ?, balance = ? WHERE id = ?`,
-		payload.BankID,
-		payload.CurrencyID,
-		payload.AccountNumber,
-		payload.Balance,
-		id,
-	)
-	if err != nil {
-		if isUniqueConstraintError(err) {
-			writeError(writer, http.StatusConflict, "duplicate_bank_account", "bank, currency and account number combination must be unique")
-			return
-		}
-		if isForeignKeyConstraintError(err) {
-			writeError(writer, http.StatusBadRequest, "invalid_payload", "bank and currency must exist")
-			return
-		}
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to update bank account")
+	var payload bankAccountPayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	updated, err := application.services().bankAccounts.update(request.Context(), requestChange(request), id, payload)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read update result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "bank account not found")
+		writeServiceError(writer, err, "failed to update bank account")
 		return
 	}
 
-	updated := bankAccount{
-		ID:            id,
-		BankID:        payload.BankID,
-		CurrencyID:    payload.CurrencyID,
-		AccountNumber: payload.AccountNumber,
-		Balance:       payload.Balance,
-	}
-	writeJSON(writer, http.StatusOK, updated)
+	writeJSONWithETag(writer, http.StatusOK, maskBankAccount(updated))
 }
 
-func (application app) deleteBankAccount(writer http.ResponseWriter, id int64) {
-	result, err := application.db.Exec(`DELETE FROM bank_accounts WHERE id = ?`, id)
+func (application app) patchBankAccount(writer http.ResponseWriter, request *http.Request, id int64) {
+	current, err := application.services().bankAccounts.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to delete bank account")
+		writeServiceError(writer, err, "failed to load bank account")
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read delete result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "bank account not found")
+	mergedRequest, ok := mergePatchRequest(writer, request, current)
+	if !ok {
 		return
 	}
 
-	writer.WriteHeader(http.StatusNoContent)
-}
-
-func decodeBankAccountPayload(request *http.Request) (bankAccountPayload, error) {
-	defer request.Body.Close()
-
-	var payload bankAccountPayload
-	if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
-		return bankAccountPayload{}, fmt.Errorf("request body must be valid JSON")
-	}
-
-	payload.AccountNumber = strings.TrimSpace(payload.AccountNumber)
-
-	if payload.BankID <= 0 {
-		return bankAccountPayload{}, fmt.Errorf("bank_id must be a positive integer")
-	}
-	if payload.CurrencyID <= 0 {
-		return bankAccountPayload{}, fmt.Errorf("currency_id must be a positive integer")
-	}
-	if payload.AccountNumber == "" {
-		return bankAccountPayload{}, fmt.Errorf("account_number is required")
-	}
-
-	return payload, nil
+	application.updateBankAccount(writer, mergedRequest, id)
 }
 
-func (application app) validateBankAccountReferences(bankID int64, currencyID int64) error {
-	bankExists, err := application.bankExists(bankID)
-	if err != nil {
-		return fmt.Errorf("failed to validate bank")
-	}
-	if !bankExists {
-		return fmt.Errorf("bank must exist")
-	}
-
-	currencyExists, err := application.currencyExists(currencyID)
-	if err != nil {
-		return fmt.Errorf("failed to validate currency")
-	}
-	if !currencyExists {
-		return fmt.Errorf("currency must exist")
-	}
-
-	return nil
-}
-
-func (application app) bankExists(id int64) (bool, error) {
-	var storedID int64
-	err := application.db.QueryRow(`SELECT id FROM banks WHERE id = ?`, id).Scan(&storedID)
-	if errors.Is(err, sql.ErrNoRows) {
-		return false, nil
-	}
-	if err != nil {
-		return false, err
-	}
-
-	return true, nil
-}
-
-func (application app) currencyExists(id int64) (bool, error) {
-	var storedID int64
-	err := application.db.QueryRow(`SELECT id FROM currencies WHERE id = ?`, id).Scan(&storedID)
-	if errors.Is(err, sql.ErrNoRows) {
-		return false, nil
-	}
-	if err != nil {
-		return false, err
+func (application app) deleteBankAccount(writer http.ResponseWriter, request *http.Request, id int64) {
+	if err := application.services().bankAccounts.delete(request.Context(), requestChange(request), id); err != nil {
+		writeServiceError(writer, err, "failed to delete bank account")
+		return
 	}
 
-	return true, nil
+	writer.WriteHeader(http.StatusNoContent)
 }
@@ -3,6 +3,7 @@ package backend
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -33,20 +34,40 @@ func TestBankAccountCRUDFlow(t *testing.T) {
 	if err := json.NewDecoder(createResponse.Body).Decode(&created); err != nil {
 		t.Fatalf("decode created response: %v", err)
 	}
-	if created.BankID != 1 || created.CurrencyID != 1 {
-		t.Fatalf("unexpected created references: %+v", created)
+	if created.BankID != 1 || created.CurrencyID != 1 || created.AccountNumber != "**** -001" {
+		t.Fatalf("unexpected created account: %+v", created)
 	}
 
 	listResponse := performRequest(router, http.MethodGet, "/api/bank-accounts", nil)
 	if listResponse.Code != http.StatusOK {
 		t.Fatalf("expected 200 for list, got %d", listResponse.Code)
 	}
+	if strings.Contains(listResponse.Body.String(), "ACC-001") {
+		t.Fatalf("expected list to mask account numbers, got %s", listResponse.Body.String())
+	}
+
+	revealResponse := performRequest(router, http.MethodGet, "/api/bank-accounts/1?reveal=true", nil)
+	if !strings.Contains(revealResponse.Body.String(), `"account_number":"ACC-001"`) {
+		t.Fatalf("expected reveal to return the full account number, got %s", revealResponse.Body.String())
+	}
+
+	echoMasked := performRequest(router, http.MethodPut, "/api/bank-accounts/1", []byte(`{"bank_id":1,"currency_id":1,"account_number":"**** -001","balance":200}`))
+	if echoMasked.Code != http.StatusOK {
+		t.Fatalf("expected update echoing the masked number to return 200, got %d: %s", echoMasked.Code, echoMasked.Body.String())
+	}
+	revealResponse = performRequest(router, http.MethodGet, "/api/bank-accounts/1?reveal=true", nil)
+	if !strings.Contains(revealResponse.Body.String(), `"account_number":"ACC-001"`) {
+		t.Fatalf("expected masked echo to keep the account number, got %s", revealResponse.Body.String())
+	}
 
 	updateBody := []byte(`{"bank_id":1,"currency_id":1,"account_number":"ACC-001-UPDATED","balance":555}`)
 	updateResponse := performRequest(router, http.MethodPut, "/api/bank-accounts/1", updateBody)
 	if updateResponse.Code != http.StatusOK {
 		t.Fatalf("expected 200 for update, got %d", updateResponse.Code)
 	}
+	if !strings.Contains(updateResponse.Body.String(), `"account_number":"**** ATED"`) {
+		t.Fatalf("expected update response to mask the account number, got %s", updateResponse.Body.String())
+	}
 
 	deleteResponse := performRequest(router, http.MethodDelete, "/api/bank-accounts/1", nil)
 	if deleteResponse.Code != http.StatusNoContent {
@@ -111,8 +132,55 @@ func TestBankAccountValidationErrors(t *testing.T) {
 		t.Fatalf("expected 400 for invalid account_number, got %d", invalidPayload.Code)
 	}
 
+	missingWithInvalidBank := performRequest(router, http.MethodPut, "/api/bank-accounts/42", []byte(`{"bank_id":999,"currency_id":1,"account_number":"ACC-001","balance":10}`))
+	if missingWithInvalidBank.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for updating a missing account with an invalid bank, got %d", missingWithInvalidBank.Code)
+	}
+
 	invalidID := performRequest(router, http.MethodGet, "/api/bank-accounts/not-a-number", nil)
 	if invalidID.Code != http.StatusBadRequest {
 		t.Fatalf("expected 400 for invalid id, got %d", invalidID.Code)
 	}
 }
+
+func TestBankAccountNumberFollowsBankCountry(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	performRequest(router, http.MethodPost, "/api/currencies", []byte(`{"name":"Euro","code":"EUR"}`))
+	performRequest(router, http.MethodPost, "/api/banks", []byte(`{"name":"Bank DE","country":"DE"}`))
+	performRequest(router, http.MethodPost, "/api/banks", []byte(`{"name":"Bank US","country":"US"}`))
+
+	invalidIBAN := performRequest(router, http.MethodPost, "/api/bank-accounts", []byte(`{"bank_id":1,"currency_id":1,"account_number":"DE88370400440532013000","balance":1}`))
+	if invalidIBAN.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid IBAN, got %d", invalidIBAN.Code)
+	}
+
+	created := performRequest(router, http.MethodPost, "/api/bank-accounts", []byte(`{"bank_id":1,"currency_id":1,"account_number":"de89 3704 0044 0532 0130 00","balance":1}`))
+	if created.Code != http.StatusCreated {
+		t.Fatalf("expected 201 for valid IBAN, got %d: %s", created.Code, created.Body.String())
+	}
+	revealed := performRequest(router, http.MethodGet, created.Header().Get("Location")+"?reveal=true", nil)
+	var account bankAccount
+	if err := json.NewDecoder(revealed.Body).Decode(&account); err != nil {
+		t.Fatalf("decode created account: %v", err)
+	}
+	if account.AccountNumber != "DE89370400440532013000" {
+		t.Fatalf("expected normalized IBAN, got %q", account.AccountNumber)
+	}
+
+	spaced := performRequest(router, http.MethodPost, "/api/bank-accounts", []byte(`{"bank_id":1,"currency_id":1,"account_number":"DE89 3704 0044 0532 0130 00","balance":1}`))
+	if spaced.Code != http.StatusConflict {
+		t.Fatalf("expected differently formatted IBAN to be a duplicate, got %d", spaced.Code)
+	}
+
+	legacy := performRequest(router, http.MethodPost, "/api/bank-accounts", []byte(`{"bank_id":2,"currency_id":1,"account_number":"ACC-001","balance":1}`))
+	if legacy.Code != http.StatusCreated {
+		t.Fatalf("expected free-form number for US bank, got %d", legacy.Code)
+	}
+
+	movedToDE := performRequest(router, http.MethodPut, "/api/bank-accounts/2", []byte(`{"bank_id":1,"currency_id":1,"account_number":"ACC-001","balance":1}`))
+	if movedToDE.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 when moving a non-IBAN account to a DE bank, got %d", movedToDE.Code)
+	}
+}
@@ -0,0 +1,106 @@
+package backend
+
+import "context"
+
+// bankAccountRepository stores account numbers sealed with the field cipher
+// and returns them opened.
+type bankAccountRepository interface {
+	list(ctx context.Context) ([]bankAccount, error)
+	get(ctx context.Context, id int64) (bankAccount, error)
+	create(ctx context.Context, payload bankAccountPayload) (int64, error)
+	update(ctx context.Context, id int64, payload bankAccountPayload) error
+	// delete soft deletes the bank account.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlBankAccountRepository struct {
+	source queryer
+	fields *FieldCipher
+}
+
+func (repository sqlBankAccountRepository) list(ctx context.Context) ([]bankAccount, error) {
+	rows, err := repository.source.QueryContext(ctx, `SELECT id, bank_id, currency_id, account_number, balance FROM bank_accounts WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]bankAccount, 0)
+	for rows.Next() {
+		item, scanErr := repository.scan(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlBankAccountRepository) get(ctx context.Context, id int64) (bankAccount, error) {
+	row := repository.source.QueryRowContext(ctx, `SELECT id, bank_id, currency_id, account_number, balance FROM bank_accounts WHERE id = ? AND deleted_at IS NULL`, id)
+
+	item, err := repository.scan(row)
+	if err != nil {
+		return bankAccount{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlBankAccountRepository) create(ctx context.Context, payload bankAccountPayload) (int64, error) {
+	storedNumber, numberLookup, err := repository.fields.seal(bankAccountNumberField, payload.AccountNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	return insertRow(
+		ctx,
+		repository.source,
+		`INSERT INTO bank_accounts(bank_id, currency_id, account_number, account_number_lookup, balance) VALUES (?, ?, ?, ?, ?)`,
+		payload.BankID,
+		payload.CurrencyID,
+		storedNumber,
+		numberLookup,
+		payload.Balance,
+	)
+}
+
+func (repository sqlBankAccountRepository) update(ctx context.Context, id int64, payload bankAccountPayload) error {
+	storedNumber, numberLookup, err := repository.fields.seal(bankAccountNumberField, payload.AccountNumber)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.source.ExecContext(
+		ctx,
+		`UPDATE bank_accounts SET bank_id = ?, currency_id = ?, account_number = ?, account_number_lookup = ?, balance = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`,
+		payload.BankID,
+		payload.CurrencyID,
+		storedNumber,
+		numberLookup,
+		payload.Balance,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlBankAccountRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE bank_accounts SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func (repository sqlBankAccountRepository) scan(source scanner) (bankAccount, error) {
+	var item bankAccount
+	if err := source.Scan(&item.ID, &item.BankID, &item.CurrencyID, &item.AccountNumber, &item.Balance); err != nil {
+		return bankAccount{}, err
+	}
+
+	accountNumber, err := repository.fields.open(bankAccountNumberField, item.AccountNumber)
+	if err != nil {
+		return bankAccount{}, err
+	}
+	item.AccountNumber = accountNumber
+
+	return item, nil
+}
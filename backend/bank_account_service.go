@@ -0,0 +1,238 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+type bankAccountService struct {
+	store dataStore
+}
+
+func (payload bankAccountPayload) normalize() (bankAccountPayload, error) {
+	payload.AccountNumber = strings.TrimSpace(payload.AccountNumber)
+
+	if payload.BankID <= 0 {
+		return bankAccountPayload{}, invalidPayload("bank_id must be a positive integer")
+	}
+	if payload.CurrencyID <= 0 {
+		return bankAccountPayload{}, invalidPayload("currency_id must be a positive integer")
+	}
+	if payload.AccountNumber == "" {
+		return bankAccountPayload{}, invalidPayload("account_number is required")
+	}
+
+	return payload, nil
+}
+
+// list and get return account numbers in clear text; callers mask them with
+// maskBankAccount before showing them.
+func (service bankAccountService) list(ctx context.Context) ([]bankAccount, error) {
+	return service.store.reads().bankAccounts().list(ctx)
+}
+
+func (service bankAccountService) get(ctx context.Context, id int64) (bankAccount, error) {
+	item, err := service.store.reads().bankAccounts().get(ctx, id)
+	if err != nil {
+		return bankAccount{}, orNotFound(err, "bank account not found")
+	}
+
+	return item, nil
+}
+
+func (service bankAccountService) create(ctx context.Context, by change, payload bankAccountPayload) (bankAccount, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return bankAccount{}, err
+	}
+
+	var created bankAccount
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkBankAccountReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		accountNumber, err := normalizeBankAccountNumber(ctx, tx, payload.BankID, payload.AccountNumber)
+		if err != nil {
+			return err
+		}
+		payload.AccountNumber = accountNumber
+
+		id, err := tx.bankAccounts().create(ctx, payload)
+		if err != nil {
+			return bankAccountWriteError(err)
+		}
+
+		created = bankAccount{
+			ID:            id,
+			BankID:        payload.BankID,
+			CurrencyID:    payload.CurrencyID,
+			AccountNumber: payload.AccountNumber,
+			Balance:       payload.Balance,
+		}
+		return tx.audit().record(ctx, by.record(auditEntityBankAccounts, id, auditActionCreate, nil, maskBankAccount(created)))
+	})
+	if err != nil {
+		return bankAccount{}, err
+	}
+
+	return created, nil
+}
+
+func (service bankAccountService) update(ctx context.Context, by change, id int64, payload bankAccountPayload) (bankAccount, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return bankAccount{}, err
+	}
+
+	var updated bankAccount
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.bankAccounts().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "bank account not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = checkBankAccountReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		// Clients echo the masked number they were shown when they do not change it.
+		if payload.AccountNumber == maskSensitive(existing.AccountNumber) {
+			payload.AccountNumber = existing.AccountNumber
+		}
+
+		// Numbers stored before validation existed stay editable as long as neither
+		// the number nor the bank (and with it the country's scheme) changes.
+		if payload.AccountNumber != existing.AccountNumber || payload.BankID != existing.BankID {
+			payload.AccountNumber, err = normalizeBankAccountNumber(ctx, tx, payload.BankID, payload.AccountNumber)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err = tx.bankAccounts().update(ctx, id, payload); err != nil {
+			return bankAccountWriteError(err)
+		}
+
+		updated = bankAccount{
+			ID:            id,
+			BankID:        payload.BankID,
+			CurrencyID:    payload.CurrencyID,
+			AccountNumber: payload.AccountNumber,
+			Balance:       payload.Balance,
+		}
+		return tx.audit().record(ctx, by.record(auditEntityBankAccounts, id, auditActionUpdate,
+			maskBankAccount(existing),
+			maskBankAccount(updated),
+		))
+	})
+	if err != nil {
+		return bankAccount{}, err
+	}
+
+	return updated, nil
+}
+
+// delete soft deletes a bank account nothing live refers to.
+func (service bankAccountService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.bankAccounts().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "bank account not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		inUse, err := tx.trash().hasDependents(ctx, auditEntityBankAccounts, id)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return conflict("bank_account_in_use", "bank account is in use")
+		}
+
+		if err = tx.bankAccounts().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityBankAccounts, id, auditActionDelete, maskBankAccount(existing), nil))
+	})
+}
+
+// maskBankAccount replaces the account number with its masked form. Full
+// numbers are only returned by GET /api/bank-accounts/{id}?reveal=true.
+func maskBankAccount(item bankAccount) bankAccount {
+	item.AccountNumber = maskSensitive(item.AccountNumber)
+	return item
+}
+
+func (item bankAccount) tagView() any {
+	return maskBankAccount(item)
+}
+
+// redactBankAccountRow masks the account number of a trashed row and drops
+// its lookup hash.
+func redactBankAccountRow(fields *FieldCipher, data map[string]any) error {
+	delete(data, "account_number_lookup")
+
+	stored, _ := data["account_number"].(string)
+	accountNumber, err := fields.open(bankAccountNumberField, stored)
+	if err != nil {
+		return err
+	}
+	data["account_number"] = maskSensitive(accountNumber)
+
+	return nil
+}
+
+func checkBankAccountReferences(ctx context.Context, tx repositories, payload bankAccountPayload) error {
+	bankExists, err := tx.trash().exists(ctx, auditEntityBanks, payload.BankID)
+	if err != nil {
+		return err
+	}
+	if !bankExists {
+		return invalidPayload("bank must exist")
+	}
+
+	currencyExists, err := tx.trash().exists(ctx, auditEntityCurrencies, payload.CurrencyID)
+	if err != nil {
+		return err
+	}
+	if !currencyExists {
+		return invalidPayload("currency must exist")
+	}
+
+	return nil
+}
+
+// normalizeBankAccountNumber validates the account number against the scheme
+// of the bank's country (IBAN, CBU or CLABE) and returns its normalized form.
+func normalizeBankAccountNumber(ctx context.Context, tx repositories, bankID int64, accountNumber string) (string, error) {
+	owner, err := tx.banks().get(ctx, bankID)
+	if err != nil {
+		return "", err
+	}
+
+	normalized, err := normalizeAccountNumber(owner.Country, accountNumber)
+	if err != nil {
+		return "", invalidPayload(err.Error())
+	}
+
+	return normalized, nil
+}
+
+func bankAccountWriteError(err error) error {
+	switch {
+	case errors.Is(err, errDuplicate):
+		return conflict("duplicate_bank_account", "bank, currency and account number combination must be unique")
+	case errors.Is(err, errMissingReference):
+		return invalidPayload("bank and currency must exist")
+	default:
+		return err
+	}
+}
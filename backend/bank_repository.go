@@ -0,0 +1,59 @@
+package backend
+
+import "context"
+
+type bankRepository interface {
+	list(ctx context.Context) ([]bank, error)
+	get(ctx context.Context, id int64) (bank, error)
+	create(ctx context.Context, payload bankPayload) (int64, error)
+	update(ctx context.Context, id int64, payload bankPayload) error
+	// delete soft deletes the bank.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlBankRepository struct {
+	source queryer
+}
+
+func (repository sqlBankRepository) list(ctx context.Context) ([]bank, error) {
+	rows, err := repository.source.QueryContext(ctx, `SELECT id, name, country FROM banks WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]bank, 0)
+	for rows.Next() {
+		var item bank
+		if scanErr := rows.Scan(&item.ID, &item.Name, &item.Country); scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlBankRepository) get(ctx context.Context, id int64) (bank, error) {
+	var item bank
+	err := repository.source.QueryRowContext(ctx, `SELECT id, name, country FROM banks WHERE id = ? AND deleted_at IS NULL`, id).Scan(&item.ID, &item.Name, &item.Country)
+	if err != nil {
+		return bank{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlBankRepository) create(ctx context.Context, payload bankPayload) (int64, error) {
+	return insertRow(ctx, repository.source, `INSERT INTO banks(name, country) VALUES (?, ?)`, payload.Name, payload.Country)
+}
+
+func (repository sqlBankRepository) update(ctx context.Context, id int64, payload bankPayload) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE banks SET name = ?, country = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, payload.Name, payload.Country, id)
+	return constraintError(err)
+}
+
+func (repository sqlBankRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE banks SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
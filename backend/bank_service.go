@@ -0,0 +1,149 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+type bankService struct {
+	store dataStore
+}
+
+func (payload bankPayload) normalize() (bankPayload, error) {
+	payload.Name = strings.TrimSpace(payload.Name)
+	payload.Country = strings.ToUpper(strings.TrimSpace(payload.Country))
+
+	if payload.Name == "" {
+		return bankPayload{}, invalidPayload("name is required")
+	}
+	if payload.Country == "" {
+		return bankPayload{}, invalidPayload("country is required")
+	}
+
+	return payload, nil
+}
+
+func (service bankService) list(ctx context.Context) ([]bank, error) {
+	return service.store.reads().banks().list(ctx)
+}
+
+func (service bankService) get(ctx context.Context, id int64) (bank, error) {
+	item, err := service.store.reads().banks().get(ctx, id)
+	if err != nil {
+		return bank{}, orNotFound(err, "bank not found")
+	}
+
+	return item, nil
+}
+
+func (service bankService) create(ctx context.Context, by change, payload bankPayload) (bank, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return bank{}, err
+	}
+
+	var created bank
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkBankCountry(ctx, tx, payload.Country); err != nil {
+			return err
+		}
+
+		id, err := tx.banks().create(ctx, payload)
+		if err != nil {
+			return bankWriteError(err)
+		}
+
+		created = bank{ID: id, Name: payload.Name, Country: payload.Country}
+		return tx.audit().record(ctx, by.record(auditEntityBanks, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return bank{}, err
+	}
+
+	return created, nil
+}
+
+func (service bankService) update(ctx context.Context, by change, id int64, payload bankPayload) (bank, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return bank{}, err
+	}
+
+	var updated bank
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkBankCountry(ctx, tx, payload.Country); err != nil {
+			return err
+		}
+
+		existing, err := tx.banks().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "bank not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = tx.banks().update(ctx, id, payload); err != nil {
+			return bankWriteError(err)
+		}
+
+		updated = bank{ID: id, Name: payload.Name, Country: payload.Country}
+		return tx.audit().record(ctx, by.record(auditEntityBanks, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return bank{}, err
+	}
+
+	return updated, nil
+}
+
+// delete soft deletes a bank nothing live refers to.
+func (service bankService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.banks().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "bank not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		inUse, err := tx.trash().hasDependents(ctx, auditEntityBanks, id)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return conflict("bank_in_use", "bank is in use")
+		}
+
+		if err = tx.banks().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityBanks, id, auditActionDelete, existing, nil))
+	})
+}
+
+func checkBankCountry(ctx context.Context, tx repositories, code string) error {
+	exists, err := tx.countries().exists(ctx, code)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return invalidPayload("country must exist")
+	}
+
+	return nil
+}
+
+func bankWriteError(err error) error {
+	switch {
+	case errors.Is(err, errDuplicate):
+		return conflict("duplicate_bank", "name and country combination must be unique")
+	case errors.Is(err, errMissingReference):
+		return invalidPayload("country must exist")
+	default:
+		return err
+	}
+}
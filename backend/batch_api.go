@@ -0,0 +1,283 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	batchPathSuffix      = "/batch"
+	maxBatchOperations   = 1000
+	batchSavepointName   = "batch_operation"
+	batchOperationCreate = "create"
+	batchOperationUpdate = "update"
+	batchOperationPatch  = "patch"
+	batchOperationDelete = "delete"
+)
+
+type batchRequest struct {
+	Operations []batchOperation `json:"operations"`
+}
+
+type batchOperation struct {
+	Op      string          `json:"op"`
+	ID      int64           `json:"id"`
+	IfMatch string          `json:"if_match"`
+	Body    json.RawMessage `json:"body"`
+}
+
+type batchResult struct {
+	Index  int             `json:"index"`
+	Status int             `json:"status"`
+	ETag   string          `json:"etag,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	Error  *errorBody      `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Results []batchResult `json:"results"`
+}
+
+type batchErrorResponse struct {
+	Error   errorBody     `json:"error"`
+	Results []batchResult `json:"results"`
+}
+
+// batchEntityHandler is the method expression of a collection or by-id
+// handler, so the batch can invoke it on a copy of app bound to its transaction.
+type batchEntityHandler func(app, http.ResponseWriter, *http.Request)
+
+// batchHandler serves POST <collectionPath>/batch. Every operation is replayed
+// through the regular collection and by-id handlers inside one SQL transaction,
+// each within its own savepoint, so all operations are validated and reported
+// before the batch is either committed or rolled back as a whole.
+func (application app) batchHandler(collectionPath string, collection batchEntityHandler, byID batchEntityHandler) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodPost {
+			methodNotAllowed(writer, http.MethodPost)
+			return
+		}
+
+		application.runBatch(writer, request, collectionPath, collection, byID)
+	}
+}
+
+func (application app) runBatch(writer http.ResponseWriter, request *http.Request, collectionPath string, collection batchEntityHandler, byID batchEntityHandler) {
+	defer request.Body.Close()
+
+	var payload batchRequest
+	if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_payload", "request body must be valid JSON")
+		return
+	}
+	if len(payload.Operations) == 0 {
+		writeError(writer, http.StatusBadRequest, "invalid_payload", "operations must not be empty")
+		return
+	}
+	if len(payload.Operations) > maxBatchOperations {
+		writeError(writer, http.StatusBadRequest, "invalid_payload", fmt.Sprintf("operations must not contain more than %d items", maxBatchOperations))
+		return
+	}
+
+	tx, err := application.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		writeServiceError(writer, err, "failed to start database transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	batchApplication := application
+	batchApplication.batch = tx
+
+	results := make([]batchResult, len(payload.Operations))
+	firstFailure := -1
+	for index, operation := range payload.Operations {
+		results[index] = batchApplication.runBatchOperation(request, collectionPath, collection, byID, index, operation)
+		if results[index].Error != nil && firstFailure < 0 {
+			firstFailure = index
+		}
+	}
+
+	if firstFailure >= 0 {
+		failed := results[firstFailure]
+		writeJSON(writer, failed.Status, batchErrorResponse{
+			Error: errorBody{
+				Code:    "batch_failed",
+				Message: fmt.Sprintf("operation %d failed: %s", firstFailure, failed.Error.Message),
+			},
+			Results: results,
+		})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		writeServiceError(writer, err, "failed to commit changes")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, batchResponse{Results: results})
+}
+
+func (application app) runBatchOperation(request *http.Request, collectionPath string, collection batchEntityHandler, byID batchEntityHandler, index int, operation batchOperation) batchResult {
+	method, path, handler, err := resolveBatchOperation(collectionPath, collection, byID, operation)
+	if err != nil {
+		return batchResult{
+			Index:  index,
+			Status: http.StatusBadRequest,
+			Error:  &errorBody{Code: "invalid_operation", Message: err.Error()},
+		}
+	}
+
+	operationRequest, err := http.NewRequestWithContext(request.Context(), method, path, bytes.NewReader(operation.Body))
+	if err != nil {
+		return batchResult{
+			Index:  index,
+			Status: http.StatusInternalServerError,
+			Error:  &errorBody{Code: "internal_error", Message: "failed to build batch operation"},
+		}
+	}
+	if actor := request.Header.Get(auditActorHeader); actor != "" {
+		operationRequest.Header.Set(auditActorHeader, actor)
+	}
+	if operation.Op == batchOperationPatch {
+		operationRequest.Header.Set("Content-Type", mergePatchContentType)
+	} else if len(operation.Body) > 0 {
+		operationRequest.Header.Set("Content-Type", "application/json")
+	}
+	if operation.IfMatch != "" {
+		operationRequest.Header.Set(ifMatchHeader, operation.IfMatch)
+	}
+
+	recorder := newBatchRecorder()
+	handler(application, recorder, operationRequest)
+
+	result := batchResult{Index: index, Status: recorder.status}
+	if recorder.status >= http.StatusBadRequest {
+		var failure apiError
+		if err = json.Unmarshal(recorder.body.Bytes(), &failure); err != nil || failure.Error.Code == "" {
+			failure.Error = errorBody{Code: "internal_error", Message: "batch operation failed"}
+		}
+		result.Error = &failure.Error
+		return result
+	}
+
+	result.ETag = recorder.Header().Get(etagHeader)
+	if body := bytes.TrimSpace(recorder.body.Bytes()); len(body) > 0 {
+		result.Data = json.RawMessage(body)
+	}
+
+	return result
+}
+
+func resolveBatchOperation(collectionPath string, collection batchEntityHandler, byID batchEntityHandler, operation batchOperation) (string, string, batchEntityHandler, error) {
+	itemPath := collectionPath + "/" + strconv.FormatInt(operation.ID, 10)
+
+	switch operation.Op {
+	case batchOperationCreate:
+		if operation.ID != 0 {
+			return "", "", nil, fmt.Errorf("id must not be set for create operations")
+		}
+		if len(operation.Body) == 0 {
+			return "", "", nil, fmt.Errorf("body is required for create operations")
+		}
+		return http.MethodPost, collectionPath, collection, nil
+	case batchOperationUpdate, batchOperationPatch:
+		if operation.ID <= 0 {
+			return "", "", nil, fmt.Errorf("id must be a positive integer")
+		}
+		if len(operation.Body) == 0 {
+			return "", "", nil, fmt.Errorf("body is required for %s operations", operation.Op)
+		}
+		if operation.Op == batchOperationPatch {
+			return http.MethodPatch, itemPath, byID, nil
+		}
+		return http.MethodPut, itemPath, byID, nil
+	case batchOperationDelete:
+		if operation.ID <= 0 {
+			return "", "", nil, fmt.Errorf("id must be a positive integer")
+		}
+		return http.MethodDelete, itemPath, byID, nil
+	default:
+		return "", "", nil, fmt.Errorf("op must be one of create, update, patch, delete")
+	}
+}
+
+// batchRecorder captures the response of a handler replayed by a batch.
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (recorder *batchRecorder) Header() http.Header {
+	return recorder.header
+}
+
+func (recorder *batchRecorder) WriteHeader(status int) {
+	recorder.status = status
+}
+
+func (recorder *batchRecorder) Write(data []byte) (int, error) {
+	return recorder.body.Write(data)
+}
+
+// savepointTx scopes one batch operation to a savepoint on the batch
+// transaction. Commit releases the savepoint and Rollback undoes only the
+// operation's own changes; calls after either one are no-ops.
+type savepointTx struct {
+	tx   *sql.Tx
+	done bool
+}
+
+func beginSavepoint(ctx context.Context, tx *sql.Tx) (dbTx, error) {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT `+batchSavepointName); err != nil {
+		return nil, err
+	}
+
+	return &savepointTx{tx: tx}, nil
+}
+
+func (savepoint *savepointTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return savepoint.tx.ExecContext(ctx, query, args...)
+}
+
+func (savepoint *savepointTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return savepoint.tx.QueryContext(ctx, query, args...)
+}
+
+func (savepoint *savepointTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return savepoint.tx.QueryRowContext(ctx, query, args...)
+}
+
+func (savepoint *savepointTx) Commit() error {
+	if savepoint.done {
+		return sql.ErrTxDone
+	}
+	savepoint.done = true
+
+	_, err := savepoint.tx.Exec(`RELEASE SAVEPOINT ` + batchSavepointName)
+	return err
+}
+
+func (savepoint *savepointTx) Rollback() error {
+	if savepoint.done {
+		return sql.ErrTxDone
+	}
+	savepoint.done = true
+
+	if _, err := savepoint.tx.Exec(`ROLLBACK TO SAVEPOINT ` + batchSavepointName); err != nil {
+		return err
+	}
+
+	_, err := savepoint.tx.Exec(`RELEASE SAVEPOINT ` + batchSavepointName)
+	return err
+}
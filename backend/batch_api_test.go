@@ -0,0 +1,132 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestTransactionBatchCommitsAllOperations(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+	performRequest(router, http.MethodPost, "/api/transaction-categories", []byte(`{"name":"Groceries"}`))
+
+	createBatch := performRequest(router, http.MethodPost, "/api/transactions/batch", []byte(`{"operations":[
+		{"op":"create","body":{"transaction_date":"2026-02-18","type":"expense","amount":10,"person_id":1,"bank_account_id":1,"category_id":1}},
+		{"op":"create","body":{"transaction_date":"2026-02-19","type":"expense","amount":20,"person_id":1,"bank_account_id":1,"category_id":1}}
+	]}`))
+	if createBatch.Code != http.StatusOK {
+		t.Fatalf("expected create batch to return 200, got %d", createBatch.Code)
+	}
+
+	var created batchResponse
+	if err := json.NewDecoder(createBatch.Body).Decode(&created); err != nil {
+		t.Fatalf("decode batch response: %v", err)
+	}
+	if len(created.Results) != 2 || created.Results[0].Status != http.StatusCreated || created.Results[1].Index != 1 || created.Results[1].ETag == "" {
+		t.Fatalf("unexpected create results: %+v", created.Results)
+	}
+
+	recategorize := performRequest(router, http.MethodPost, "/api/transactions/batch", []byte(`{"operations":[
+		{"op":"patch","id":1,"body":{"category_id":2}},
+		{"op":"update","id":2,"body":{"transaction_date":"2026-02-19","type":"expense","amount":25,"person_id":1,"bank_account_id":1,"category_id":2}}
+	]}`))
+	if recategorize.Code != http.StatusOK {
+		t.Fatalf("expected recategorize batch to return 200, got %d", recategorize.Code)
+	}
+
+	var items []transaction
+	listResponse := performRequest(router, http.MethodGet, "/api/transactions", nil)
+	if err := json.NewDecoder(listResponse.Body).Decode(&items); err != nil {
+		t.Fatalf("decode transactions: %v", err)
+	}
+	if len(items) != 2 || items[0].CategoryID != 2 || items[1].CategoryID != 2 || items[1].Amount != 25 {
+		t.Fatalf("expected both transactions recategorized, got %+v", items)
+	}
+
+	var auditCount int
+	if err := application.db.QueryRow(`SELECT COUNT(*) FROM audit_events WHERE entity = 'transactions'`).Scan(&auditCount); err != nil {
+		t.Fatalf("count audit events: %v", err)
+	}
+	if auditCount != 4 {
+		t.Fatalf("expected one audit event per operation, got %d", auditCount)
+	}
+}
+
+func TestTransactionBatchRollsBackOnFailure(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+	performRequest(
+		router,
+		http.MethodPost,
+		"/api/transactions",
+		[]byte(`{"transaction_date":"2026-02-18","type":"expense","amount":10,"person_id":1,"bank_account_id":1,"category_id":1}`),
+	)
+
+	batch := performRequest(router, http.MethodPost, "/api/transactions/batch", []byte(`{"operations":[
+		{"op":"delete","id":1},
+		{"op":"update","id":99,"body":{"transaction_date":"2026-02-19","type":"expense","amount":25,"person_id":1,"bank_account_id":1,"category_id":1}},
+		{"op":"create","body":{"transaction_date":"2026-02-19","type":"expense","amount":-1,"person_id":1,"bank_account_id":1,"category_id":1}},
+		{"op":"rename","id":1}
+	]}`))
+	if batch.Code != http.StatusNotFound {
+		t.Fatalf("expected batch to return the first failure status 404, got %d", batch.Code)
+	}
+
+	var failed batchErrorResponse
+	if err := json.NewDecoder(batch.Body).Decode(&failed); err != nil {
+		t.Fatalf("decode batch response: %v", err)
+	}
+	if failed.Error.Code != "batch_failed" || len(failed.Results) != 4 {
+		t.Fatalf("unexpected batch failure: %+v", failed)
+	}
+	expectedStatuses := []int{http.StatusNoContent, http.StatusNotFound, http.StatusBadRequest, http.StatusBadRequest}
+	for index, expected := range expectedStatuses {
+		if failed.Results[index].Index != index || failed.Results[index].Status != expected {
+			t.Fatalf("expected result %d to have status %d, got %+v", index, expected, failed.Results[index])
+		}
+	}
+	if failed.Results[0].Error != nil || failed.Results[3].Error == nil || failed.Results[3].Error.Code != "invalid_operation" {
+		t.Fatalf("expected errors aligned with failing operations, got %+v", failed.Results)
+	}
+
+	getResponse := performRequest(router, http.MethodGet, "/api/transactions/1", nil)
+	if getResponse.Code != http.StatusOK {
+		t.Fatalf("expected delete to be rolled back, got %d", getResponse.Code)
+	}
+
+	var auditCount int
+	if err := application.db.QueryRow(`SELECT COUNT(*) FROM audit_events WHERE entity = 'transactions' AND action = 'delete'`).Scan(&auditCount); err != nil {
+		t.Fatalf("count audit events: %v", err)
+	}
+	if auditCount != 0 {
+		t.Fatalf("expected rolled back batch to leave no audit events, got %d", auditCount)
+	}
+}
+
+func TestTransactionCategoryBatchSeesEarlierOperations(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	batch := performRequest(router, http.MethodPost, "/api/transaction-categories/batch", []byte(`{"operations":[
+		{"op":"create","body":{"name":"Housing"}},
+		{"op":"create","body":{"name":"Rent","parent_id":1}}
+	]}`))
+	if batch.Code != http.StatusOK {
+		t.Fatalf("expected batch to return 200, got %d", batch.Code)
+	}
+
+	emptyBatch := performRequest(router, http.MethodPost, "/api/transaction-categories/batch", []byte(`{"operations":[]}`))
+	if emptyBatch.Code != http.StatusBadRequest {
+		t.Fatalf("expected empty batch to return 400, got %d", emptyBatch.Code)
+	}
+
+	wrongMethod := performRequest(router, http.MethodGet, "/api/transaction-categories/batch", nil)
+	if wrongMethod.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected GET batch to return 405, got %d", wrongMethod.Code)
+	}
+}
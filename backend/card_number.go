@@ -0,0 +1,187 @@
+package backend
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const unknownCardNetwork = "unknown"
+
+var maskedCardNumberPattern = regexp.MustCompile(`^\*{4} [0-9]{4}$`)
+
+// cardNumber is a validated primary account number (PAN).
+type cardNumber struct {
+	Digits  string
+	Last4   string
+	Network string
+}
+
+// cardNetworkRule matches a network by IIN prefix range and allowed lengths.
+type cardNetworkRule struct {
+	Network string
+	Low     string
+	High    string
+	Lengths []int
+}
+
+// cardNetworkRules are checked in order; narrower ranges come before the
+// broad ones they overlap.
+var cardNetworkRules = []cardNetworkRule{
+	{Network: "amex", Low: "34", High: "34", Lengths: []int{15}},
+	{Network: "amex", Low: "37", High: "37", Lengths: []int{15}},
+	{Network: "diners", Low: "300", High: "305", Lengths: []int{14, 15, 16, 17, 18, 19}},
+	{Network: "diners", Low: "36", High: "36", Lengths: []int{14, 15, 16, 17, 18, 19}},
+	{Network: "diners", Low: "38", High: "39", Lengths: []int{14, 15, 16, 17, 18, 19}},
+	{Network: "jcb", Low: "3528", High: "3589", Lengths: []int{16, 17, 18, 19}},
+	{Network: "visa", Low: "4", High: "4", Lengths: []int{13, 16, 19}},
+	{Network: "mastercard", Low: "51", High: "55", Lengths: []int{16}},
+	{Network: "mastercard", Low: "2221", High: "2720", Lengths: []int{16}},
+	{Network: "discover", Low: "6011", High: "6011", Lengths: []int{16, 17, 18, 19}},
+	{Network: "discover", Low: "644", High: "649", Lengths: []int{16, 17, 18, 19}},
+	{Network: "discover", Low: "65", High: "65", Lengths: []int{16, 17, 18, 19}},
+	{Network: "unionpay", Low: "62", High: "62", Lengths: []int{16, 17, 18, 19}},
+	{Network: "maestro", Low: "50", High: "50", Lengths: []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{Network: "maestro", Low: "56", High: "69", Lengths: []int{12, 13, 14, 15, 16, 17, 18, 19}},
+}
+
+// parseCardNumber strips spaces and dashes from raw, checks the length and the
+// Luhn check digit, and detects the card network from the IIN prefix.
+func parseCardNumber(raw string) (cardNumber, error) {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(raw))
+	if digits == "" {
+		return cardNumber{}, fmt.Errorf("number is required")
+	}
+	for _, character := range digits {
+		if character < '0' || character > '9' {
+			return cardNumber{}, fmt.Errorf("number must contain only digits, spaces or dashes")
+		}
+	}
+	if len(digits) < 12 || len(digits) > 19 {
+		return cardNumber{}, fmt.Errorf("number must have between 12 and 19 digits")
+	}
+	if !luhnValid(digits) {
+		return cardNumber{}, fmt.Errorf("number is not a valid card number (Luhn check failed)")
+	}
+
+	return cardNumber{Digits: digits, Last4: digits[len(digits)-4:], Network: detectCardNetwork(digits)}, nil
+}
+
+func luhnValid(digits string) bool {
+	sum := 0
+	double := false
+	for index := len(digits) - 1; index >= 0; index-- {
+		digit := int(digits[index] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
+
+func detectCardNetwork(digits string) string {
+	for _, rule := range cardNetworkRules {
+		if len(digits) < len(rule.Low) {
+			continue
+		}
+		prefix := digits[:len(rule.Low)]
+		if prefix < rule.Low || prefix > rule.High {
+			continue
+		}
+		for _, length := range rule.Lengths {
+			if len(digits) == length {
+				return rule.Network
+			}
+		}
+	}
+
+	return unknownCardNetwork
+}
+
+func maskCardNumber(last4 string) string {
+	return maskedFieldPrefix + last4
+}
+
+// plainCardFingerprint identifies a card number for duplicate detection while
+// field encryption is disabled and the full number is not stored.
+func plainCardFingerprint(digits string) string {
+	sum := sha256.Sum256([]byte(creditCardNumberField + "\x00" + digits))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// storeCardNumber returns the number column and lookup hash for a card. The
+// full number is kept only when field encryption can encrypt it; otherwise
+// only the last four digits and a fingerprint are stored.
+func storeCardNumber(fields *FieldCipher, number cardNumber) (sql.NullString, string, error) {
+	if fields == nil {
+		return sql.NullString{}, plainCardFingerprint(number.Digits), nil
+	}
+
+	sealed, lookup, err := fields.seal(creditCardNumberField, number.Digits)
+	if err != nil {
+		return sql.NullString{}, "", err
+	}
+
+	return sql.NullString{String: sealed, Valid: true}, lookup.String, nil
+}
+
+// upgradeLegacyCardNumbers runs with migration 025. Cards saved with a
+// plaintext number keep only the last four digits, the detected network and a
+// fingerprint. Encrypted numbers are left for the read path, which derives the
+// last four digits after decrypting.
+func upgradeLegacyCardNumbers(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, number FROM credit_cards WHERE number IS NOT NULL AND number NOT LIKE 'enc:%'`)
+	if err != nil {
+		return err
+	}
+
+	type legacyCard struct {
+		id     int64
+		number string
+	}
+	cards := make([]legacyCard, 0)
+	for rows.Next() {
+		var card legacyCard
+		if err = rows.Scan(&card.id, &card.number); err != nil {
+			rows.Close()
+			return err
+		}
+		cards = append(cards, card)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, card := range cards {
+		digits := strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(card.number))
+		last4 := digits
+		if len(last4) > 4 {
+			last4 = last4[len(last4)-4:]
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE credit_cards SET number = NULL, number_lookup = ?, last4 = ?, network = ? WHERE id = ?`,
+			plainCardFingerprint(digits),
+			last4,
+			detectCardNetwork(digits),
+			card.id,
+		)
+		if err != nil {
+			return fmt.Errorf("credit card %d: %w", card.id, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package backend
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"personal-finances/migrations"
+)
+
+func TestParseCardNumberDetectsNetwork(t *testing.T) {
+	cases := []struct {
+		number  string
+		network string
+	}{
+		{"4111 1111 1111 1111", "visa"},
+		{"4222222222222", "visa"},
+		{"5555-5555-5555-4444", "mastercard"},
+		{"2223003122003222", "mastercard"},
+		{"378282246310005", "amex"},
+		{"6011111111111117", "discover"},
+		{"30569309025904", "diners"},
+		{"3530111333300000", "jcb"},
+		{"6200000000000005", "unionpay"},
+		{"6759649826438453", "maestro"},
+		{"9999999999999995", unknownCardNetwork},
+	}
+
+	for _, item := range cases {
+		number, err := parseCardNumber(item.number)
+		if err != nil {
+			t.Fatalf("parse %q: %v", item.number, err)
+		}
+		if number.Network != item.network {
+			t.Fatalf("expected %q to be %s, got %s", item.number, item.network, number.Network)
+		}
+		if number.Last4 != number.Digits[len(number.Digits)-4:] || strings.ContainsAny(number.Digits, " -") {
+			t.Fatalf("unexpected normalization of %q: %+v", item.number, number)
+		}
+	}
+
+	for _, invalid := range []string{"", "4111111111111112", "41111111111", "41111111111111111111", "4111x11111111111"} {
+		if _, err := parseCardNumber(invalid); err == nil {
+			t.Fatalf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestCardNumberMigrationDropsPlaintextNumbers(t *testing.T) {
+	beforeLast4 := fstest.MapFS{}
+	entries, err := migrations.Files.ReadDir(".")
+	if err != nil {
+		t.Fatalf("read migrations: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() >= "025_" {
+			continue
+		}
+		content, readErr := migrations.Files.ReadFile(entry.Name())
+		if readErr != nil {
+			t.Fatalf("read migration: %v", readErr)
+		}
+		beforeLast4[entry.Name()] = &fstest.MapFile{Data: content}
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "legacy.db")
+	db, err := SetupDatabase(dbPath, beforeLast4)
+	if err != nil {
+		t.Fatalf("setup legacy database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err = db.Exec(`
+		INSERT INTO banks (name, country) VALUES ('Legacy Bank', 'US');
+		INSERT INTO people (name) VALUES ('Legacy Person');
+		INSERT INTO credit_cards (bank_id, person_id, number) VALUES (1, 1, '5555 5555 5555 4444');
+	`); err != nil {
+		t.Fatalf("seed legacy card: %v", err)
+	}
+
+	if _, err = applyMigrations(db, migrations.Files); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+
+	var number *string
+	var lookup, last4, network string
+	if err = db.QueryRow(`SELECT number, number_lookup, last4, network FROM credit_cards WHERE id = 1`).Scan(&number, &lookup, &last4, &network); err != nil {
+		t.Fatalf("load upgraded card: %v", err)
+	}
+	if number != nil || last4 != "4444" || network != "mastercard" || lookup != plainCardFingerprint("5555555555554444") {
+		t.Fatalf("unexpected upgraded card: number=%v lookup=%s last4=%s network=%s", number, lookup, last4, network)
+	}
+}
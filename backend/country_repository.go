@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type countryRepository interface {
+	list(ctx context.Context) ([]country, error)
+	exists(ctx context.Context, code string) (bool, error)
+}
+
+type sqlCountryRepository struct {
+	source queryer
+}
+
+func (repository sqlCountryRepository) list(ctx context.Context) ([]country, error) {
+	rows, err := repository.source.QueryContext(ctx, `SELECT code, name FROM countries ORDER BY code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]country, 0)
+	for rows.Next() {
+		var item country
+		if scanErr := rows.Scan(&item.Code, &item.Name); scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlCountryRepository) exists(ctx context.Context, code string) (bool, error) {
+	var storedCode string
+	err := repository.source.QueryRowContext(ctx, `SELECT code FROM countries WHERE code = ?`, code).Scan(&storedCode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,11 @@
+package backend
+
+import "context"
+
+type countryService struct {
+	store dataStore
+}
+
+func (service countryService) list(ctx context.Context) ([]country, error) {
+	return service.store.reads().countries().list(ctx)
+}
@@ -1,25 +1,26 @@
 package backend
 
 import (
-	"database/sql"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 )
 
 const (
+	creditCardNumberField = "credit_cards.number"
 	creditCardsPath       = "/api/credit-cards"
 	creditCardsPathByID   = "/api/credit-cards/"
 	creditCardPathPattern = "/api/credit-cards/%d"
 )
 
+// creditCard holds the full number only while it is stored encrypted;
+// otherwise Number is the masked form built from Last4.
 type creditCard struct {
 	ID       int64   `json:"id"`
 	BankID   int64   `json:"bank_id"`
 	PersonID int64   `json:"person_id"`
 	Number   string  `json:"number"`
+	Last4    string  `json:"last4"`
+	Network  string  `json:"network"`
 	Name     *string `json:"name"`
 }
 
@@ -33,12 +34,13 @@ type creditCardPayload struct {
 func (application app) registerCreditCardRoutes(mux *http.ServeMux) {
 	mux.HandleFunc(creditCardsPath, application.creditCardsHandler)
 	mux.HandleFunc(creditCardsPathByID, application.creditCardByIDHandler)
+	mux.HandleFunc(creditCardsPath+batchPathSuffix, application.batchHandler(creditCardsPath, app.creditCardsHandler, app.creditCardByIDHandler))
 }
 
 func (application app) creditCardsHandler(writer http.ResponseWriter, request *http.Request) {
 	switch request.Method {
 	case http.MethodGet:
-		application.listCreditCards(writer)
+		application.listCreditCards(writer, request)
 	case http.MethodPost:
 		application.createCreditCard(writer, request)
 	default:
@@ -55,222 +57,98 @@ func (application app) creditCardByIDHandler(writer http.ResponseWriter, request
 
 	switch request.Method {
 	case http.MethodGet:
-		application.getCreditCard(writer, id)
+		application.getCreditCard(writer, request, id)
 	case http.MethodPut:
 		application.updateCreditCard(writer, request, id)
+	case http.MethodPatch:
+		application.patchCreditCard(writer, request, id)
 	case http.MethodDelete:
-		application.deleteCreditCard(writer, id)
+		application.deleteCreditCard(writer, request, id)
 	default:
-		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodDelete)
+		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
 	}
 }
 
-func (application app) listCreditCards(writer http.ResponseWriter) {
-	rows, err := application.db.Query(`SELECT id, bank_id, person_id, number, name FROM credit_cards ORDER BY id`)
+func (application app) listCreditCards(writer http.ResponseWriter, request *http.Request) {
+	items, err := application.services().creditCards.list(request.Context())
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load credit cards")
+		writeServiceError(writer, err, "failed to load credit cards")
 		return
 	}
-	defer rows.Close()
 
-	items := make([]creditCard, 0)
-	for rows.Next() {
-		item, scanErr := scanCreditCard(rows)
-		if scanErr != nil {
-			writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read credit cards")
-			return
-		}
-		items = append(items, item)
-	}
-
-	if err = rows.Err(); err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read credit cards")
-		return
+	for index, item := range items {
+		items[index] = maskCreditCard(item)
 	}
 
 	writeJSON(writer, http.StatusOK, items)
 }
 
-func (application app) getCreditCard(writer http.ResponseWriter, id int64) {
-	item, err := application.fetchCreditCard(id)
-	if errors.Is(err, sql.ErrNoRows) {
-		writeError(writer, http.StatusNotFound, "not_found", "credit card not found")
-		return
-	}
+func (application app) getCreditCard(writer http.ResponseWriter, request *http.Request, id int64) {
+	item, err := application.services().creditCards.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load credit card")
+		writeServiceError(writer, err, "failed to load credit card")
 		return
 	}
 
-	writeJSON(writer, http.StatusOK, item)
-}
-
-func (application app) createCreditCard(writer http.ResponseWriter, request *http.Request) {
-	payload, validationErr := decodeCreditCardPayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
+	if revealRequested(request.URL.Query()) {
+		writeJSONWithETag(writer, http.StatusOK, item)
 		return
 	}
 
-	result, err := application.db.Exec(
-		`INSERT INTO credit_cards(bank_id, person_id, number, name) VALUES (?, ?, ?, ?)`,
-		payload.BankID,
-		payload.PersonID,
-		payload.Number,
-		payload.Name,
-	)
-	if err != nil {
-		if isUniqueConstraintError(err) {
-			writeError(writer, http.StatusConflict, "duplicate_credit_card", "credit card number must be unique")
-			return
-		}
-		if isForeignKeyConstraintError(err) {
-			writeError(writer, http.StatusBadRequest, "invalid_payload", "bank and person must exist")
-			return
-		}
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to create credit card")
-		return
-	}
+	writeJSONWithETag(writer, http.StatusOK, maskCreditCard(item))
+}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read created credit card id")
+func (application app) createCreditCard(writer http.ResponseWriter, request *http.Request) {
+	var payload creditCardPayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	created, err := application.fetchCreditCard(id)
+	created, err := application.services().creditCards.create(request.Context(), requestChange(request), payload)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load created credit card")
+		writeServiceError(writer, err, "failed to create credit card")
 		return
 	}
 
-	writer.Header().Set("Location", fmt.Sprintf(creditCardPathPattern, id))
-	writeJSON(writer, http.StatusCreated, created)
+	writer.Header().Set("Location", fmt.Sprintf(creditCardPathPattern, created.ID))
+	writeJSONWithETag(writer, http.StatusCreated, maskCreditCard(created))
 }
 
 func (application app) updateCreditCard(writer http.ResponseWriter, request *http.Request, id int64) {
-	payload, validationErr := decodeCreditCardPayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
-		return
-	}
-
-	result, err := application.db.Exec(
-		`UPDATE credit_cards SET bank_id = ?, person_id = ?, number = ?, name = ? WHERE id = ?`,
-		payload.BankID,
-		payload.PersonID,
-		payload.Number,
-		payload.Name,
-		id,
-	)
-	if err != nil {
-		if isUniqueConstraintError(err) {
-			writeError(writer, http.StatusConflict, "duplicate_credit_card", "credit card number must be unique")
-			return
-		}
-		if isForeignKeyConstraintError(err) {
-			writeError(writer, http.StatusBadRequest, "invalid_payload", "bank and person must exist")
-			return
-		}
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to update credit card")
-		return
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read update result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "credit card not found")
+	var payload creditCardPayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	updated, err := application.fetchCreditCard(id)
+	updated, err := application.services().creditCards.update(request.Context(), requestChange(request), id, payload)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load updated credit card")
+		writeServiceError(writer, err, "failed to update credit card")
 		return
 	}
 
-	writeJSON(writer, http.StatusOK, updated)
+	writeJSONWithETag(writer, http.StatusOK, maskCreditCard(updated))
 }
 
-func (application app) deleteCreditCard(writer http.ResponseWriter, id int64) {
-	result, err := application.db.Exec(`DELETE FROM credit_cards WHERE id = ?`, id)
+func (application app) patchCreditCard(writer http.ResponseWriter, request *http.Request, id int64) {
+	current, err := application.services().creditCards.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to delete credit card")
+		writeServiceError(writer, err, "failed to load credit card")
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read delete result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "credit card not found")
+	mergedRequest, ok := mergePatchRequest(writer, request, current)
+	if !ok {
 		return
 	}
 
-	writer.WriteHeader(http.StatusNoContent)
+	application.updateCreditCard(writer, mergedRequest, id)
 }
 
-func decodeCreditCardPayload(request *http.Request) (creditCardPayload, error) {
-	defer request.Body.Close()
-
-	var payload creditCardPayload
-	if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
-		return creditCardPayload{}, fmt.Errorf("request body must be valid JSON")
-	}
-
-	payload.Number = strings.TrimSpace(payload.Number)
-	if payload.Number == "" {
-		return creditCardPayload{}, fmt.Errorf("number is required")
-	}
-
-	if payload.BankID <= 0 {
-		return creditCardPayload{}, fmt.Errorf("bank_id must be a positive integer")
-	}
-	if payload.PersonID <= 0 {
-		return creditCardPayload{}, fmt.Errorf("person_id must be a positive integer")
-	}
-
-	if payload.Name != nil {
-		trimmedName := strings.TrimSpace(*payload.Name)
-		if trimmedName == "" {
-			payload.Name = nil
-		} else {
-			payload.Name = &trimmedName
-		}
-	}
-
-	return payload, nil
-}
-
-func (application app) fetchCreditCard(id int64) (creditCard, error) {
-	row := application.db.QueryRow(`SELECT id, bank_id, person_id, number, name FROM credit_cards WHERE id = ?`, id)
-
-	item, err := scanCreditCard(row)
-	if err != nil {
-		return creditCard{}, err
-	}
-
-	return item, nil
-}
-
-func scanCreditCard(source scanner) (creditCard, error) {
-	var item creditCard
-	var name sql.NullString
-
-	err := source.Scan(&item.ID, &item.BankID, &item.PersonID, &item.Number, &name)
-	if err != nil {
-		return creditCard{}, err
-	}
-
-	if name.Valid {
-		value := name.String
-		item.Name = &value
+func (application app) deleteCreditCard(writer http.ResponseWriter, request *http.Request, id int64) {
+	if err := application.services().creditCards.delete(request.Context(), requestChange(request), id); err != nil {
+		writeServiceError(writer, err, "failed to delete credit card")
+		return
 	}
 
-	return item, nil
+	writer.WriteHeader(http.StatusNoContent)
 }
@@ -3,6 +3,7 @@ package backend
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -29,8 +30,16 @@ func TestCreditCardCRUDFlow(t *testing.T) {
 	if err := json.NewDecoder(createResponse.Body).Decode(&created); err != nil {
 		t.Fatalf("decode created response: %v", err)
 	}
-	if created.Number != "4111 1111 1111 1111" {
-		t.Fatalf("expected number to be trimmed, got %q", created.Number)
+	if created.Number != "**** 1111" || created.Last4 != "1111" || created.Network != "visa" {
+		t.Fatalf("expected masked visa number, got %+v", created)
+	}
+
+	var storedNumber *string
+	if err := application.db.QueryRow(`SELECT number FROM credit_cards WHERE id = 1`).Scan(&storedNumber); err != nil {
+		t.Fatalf("load stored number: %v", err)
+	}
+	if storedNumber != nil {
+		t.Fatalf("expected full number not to be stored without field encryption, got %q", *storedNumber)
 	}
 	if created.Name != nil {
 		t.Fatalf("expected name to default to null, got %+v", created.Name)
@@ -41,11 +50,21 @@ func TestCreditCardCRUDFlow(t *testing.T) {
 		t.Fatalf("expected list to return 200, got %d", listResponse.Code)
 	}
 
+	echoResponse := performRequest(
+		router,
+		http.MethodPut,
+		"/api/credit-cards/1",
+		[]byte(`{"bank_id":1,"person_id":1,"number":"**** 1111","name":"Kept"}`),
+	)
+	if echoResponse.Code != http.StatusOK || !strings.Contains(echoResponse.Body.String(), `"network":"visa"`) {
+		t.Fatalf("expected update echoing the masked number to keep the card, got %d %s", echoResponse.Code, echoResponse.Body.String())
+	}
+
 	updateResponse := performRequest(
 		router,
 		http.MethodPut,
 		"/api/credit-cards/1",
-		[]byte(`{"bank_id":1,"person_id":1,"number":"9999","name":"  Personal Visa  "}`),
+		[]byte(`{"bank_id":1,"person_id":1,"number":"5555-5555-5555-4444","name":"  Personal Visa  "}`),
 	)
 	if updateResponse.Code != http.StatusOK {
 		t.Fatalf("expected update to return 200, got %d", updateResponse.Code)
@@ -58,6 +77,9 @@ func TestCreditCardCRUDFlow(t *testing.T) {
 	if updated.Name == nil || *updated.Name != "Personal Visa" {
 		t.Fatalf("expected name to be saved, got %+v", updated.Name)
 	}
+	if updated.Number != "**** 4444" || updated.Network != "mastercard" {
+		t.Fatalf("expected new number to be detected as mastercard, got %+v", updated)
+	}
 
 	deleteResponse := performRequest(router, http.MethodDelete, "/api/credit-cards/1", nil)
 	if deleteResponse.Code != http.StatusNoContent {
@@ -76,12 +98,12 @@ func TestCreditCardUniqueNumberConstraint(t *testing.T) {
 
 	seedCreditCardDependencies(t, router)
 
-	first := performRequest(router, http.MethodPost, "/api/credit-cards", []byte(`{"bank_id":1,"person_id":1,"number":"1111"}`))
+	first := performRequest(router, http.MethodPost, "/api/credit-cards", []byte(`{"bank_id":1,"person_id":1,"number":"4111111111111111"}`))
 	if first.Code != http.StatusCreated {
 		t.Fatalf("expected first create to return 201, got %d", first.Code)
 	}
 
-	duplicate := performRequest(router, http.MethodPost, "/api/credit-cards", []byte(`{"bank_id":1,"person_id":1,"number":"1111"}`))
+	duplicate := performRequest(router, http.MethodPost, "/api/credit-cards", []byte(`{"bank_id":1,"person_id":1,"number":"4111 1111 1111 1111"}`))
 	if duplicate.Code != http.StatusConflict {
 		t.Fatalf("expected duplicate create to return 409, got %d", duplicate.Code)
 	}
@@ -98,12 +120,19 @@ func TestCreditCardValidationErrors(t *testing.T) {
 		t.Fatalf("expected invalid number to return 400, got %d", invalidNumber.Code)
 	}
 
-	invalidBank := performRequest(router, http.MethodPost, "/api/credit-cards", []byte(`{"bank_id":999,"person_id":1,"number":"123"}`))
+	for _, number := range []string{"4111111111111112", "4111 1111", "4111-ABCD-1111-1111", "**** 1111"} {
+		response := performRequest(router, http.MethodPost, "/api/credit-cards", []byte(`{"bank_id":1,"person_id":1,"number":"`+number+`"}`))
+		if response.Code != http.StatusBadRequest {
+			t.Fatalf("expected number %q to return 400, got %d", number, response.Code)
+		}
+	}
+
+	invalidBank := performRequest(router, http.MethodPost, "/api/credit-cards", []byte(`{"bank_id":999,"person_id":1,"number":"4111111111111111"}`))
 	if invalidBank.Code != http.StatusBadRequest {
 		t.Fatalf("expected invalid bank to return 400, got %d", invalidBank.Code)
 	}
 
-	invalidPerson := performRequest(router, http.MethodPost, "/api/credit-cards", []byte(`{"bank_id":1,"person_id":999,"number":"123"}`))
+	invalidPerson := performRequest(router, http.MethodPost, "/api/credit-cards", []byte(`{"bank_id":1,"person_id":999,"number":"4111111111111111"}`))
 	if invalidPerson.Code != http.StatusBadRequest {
 		t.Fatalf("expected invalid person to return 400, got %d", invalidPerson.Code)
 	}
@@ -1,13 +1,8 @@
 package backend
 
 import (
-	"database/sql"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
-	"strings"
-	"time"
 )
 
 const (
@@ -32,12 +27,13 @@ type creditCardCyclePayload struct {
 func (application app) registerCreditCardCycleRoutes(mux *http.ServeMux) {
 	mux.HandleFunc(creditCardCyclesPath, application.creditCardCyclesHandler)
 	mux.HandleFunc(creditCardCyclesPathByID, application.creditCardCycleByIDHandler)
+	mux.HandleFunc(creditCardCyclesPath+batchPathSuffix, application.batchHandler(creditCardCyclesPath, app.creditCardCyclesHandler, app.creditCardCycleByIDHandler))
 }
 
 func (application app) creditCardCyclesHandler(writer http.ResponseWriter, request *http.Request) {
 	switch request.Method {
 	case http.MethodGet:
-		application.listCreditCardCycles(writer)
+		application.listCreditCardCycles(writer, request)
 	case http.MethodPost:
 		application.createCreditCardCycle(writer, request)
 	default:
@@ -54,216 +50,89 @@ func (application app) creditCardCycleByIDHandler(writer http.ResponseWriter, re
 
 	switch request.Method {
 	case http.MethodGet:
-		application.getCreditCardCycle(writer, id)
+		application.getCreditCardCycle(writer, request, id)
 	case http.MethodPut:
 		application.updateCreditCardCycle(writer, request, id)
+	case http.MethodPatch:
+		application.patchCreditCardCycle(writer, request, id)
 	case http.MethodDelete:
-		application.deleteCreditCardCycle(writer, id)
+		application.deleteCreditCardCycle(writer, request, id)
 	default:
-		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodDelete)
+		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
 	}
 }
 
-func (application app) listCreditCardCycles(writer http.ResponseWriter) {
-	rows, err := application.db.Query(`SELECT id, credit_card_id, closing_date, due_date FROM credit_card_cycles ORDER BY id`)
+func (application app) listCreditCardCycles(writer http.ResponseWriter, request *http.Request) {
+	items, err := application.services().creditCardCycles.list(request.Context())
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load credit card cycles")
-		return
-	}
-	defer rows.Close()
-
-	items := make([]creditCardCycle, 0)
-	for rows.Next() {
-		item, scanErr := scanCreditCardCycle(rows)
-		if scanErr != nil {
-			writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read credit card cycles")
-			return
-		}
-		items = append(items, item)
-	}
-
-	if err = rows.Err(); err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read credit card cycles")
+		writeServiceError(writer, err, "failed to load credit card cycles")
 		return
 	}
 
 	writeJSON(writer, http.StatusOK, items)
 }
 
-func (application app) getCreditCardCycle(writer http.ResponseWriter, id int64) {
-	item, err := application.fetchCreditCardCycle(id)
-	if errors.Is(err, sql.ErrNoRows) {
-		writeError(writer, http.StatusNotFound, "not_found", "credit card cycle not found")
-		return
-	}
+func (application app) getCreditCardCycle(writer http.ResponseWriter, request *http.Request, id int64) {
+	item, err := application.services().creditCardCycles.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load credit card cycle")
+		writeServiceError(writer, err, "failed to load credit card cycle")
 		return
 	}
 
-	writeJSON(writer, http.StatusOK, item)
+	writeJSONWithETag(writer, http.StatusOK, item)
 }
 
 func (application app) createCreditCardCycle(writer http.ResponseWriter, request *http.Request) {
-	payload, validationErr := decodeCreditCardCyclePayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
-		return
-	}
-
-	result, err := application.db.Exec(
-		`INSERT INTO credit_card_cycles(credit_card_id, closing_date, due_date) VALUES (?, ?, ?)`,
-		payload.CreditCardID,
-		payload.ClosingDate,
-		payload.DueDate,
-	)
-	if err != nil {
-		if isUniqueConstraintError(err) {
-			writeError(writer, http.StatusConflict, "duplicate_credit_card_cycle", "credit card cycle already exists")
-			return
-		}
-		if isForeignKeyConstraintError(err) {
-			writeError(writer, http.StatusBadRequest, "invalid_payload", "credit card must exist")
-			return
-		}
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to create credit card cycle")
-		return
-	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read created credit card cycle id")
+	var payload creditCardCyclePayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	created, err := application.fetchCreditCardCycle(id)
+	created, err := application.services().creditCardCycles.create(request.Context(), requestChange(request), payload)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load created credit card cycle")
+		writeServiceError(writer, err, "failed to create credit card cycle")
 		return
 	}
 
-	writer.Header().Set("Location", fmt.Sprintf(creditCardCyclePathPattern, id))
-	writeJSON(writer, http.StatusCreated, created)
+	writer.Header().Set("Location", fmt.Sprintf(creditCardCyclePathPattern, created.ID))
+	writeJSONWithETag(writer, http.StatusCreated, created)
 }
 
 func (application app) updateCreditCardCycle(writer http.ResponseWriter, request *http.Request, id int64) {
-	payload, validationErr := decodeCreditCardCyclePayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
-		return
-	}
-
-	result, err := application.db.Exec(
-		`UPDATE credit_card_cycles SET credit_card_id = ?, closing_date = ?, due_date = ? WHERE id = ?`,
-		payload.CreditCardID,
-		payload.ClosingDate,
-		payload.DueDate,
-		id,
-	)
-	if err != nil {
-		if isUniqueConstraintError(err) {
-			writeError(writer, http.StatusConflict, "duplicate_credit_card_cycle", "credit card cycle already exists")
-			return
-		}
-		if isForeignKeyConstraintError(err) {
-			writeError(writer, http.StatusBadRequest, "invalid_payload", "credit card must exist")
-			return
-		}
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to update credit card cycle")
-		return
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read update result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "credit card cycle not found")
+	var payload creditCardCyclePayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	updated, err := application.fetchCreditCardCycle(id)
+	updated, err := application.services().creditCardCycles.update(request.Context(), requestChange(request), id, payload)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load updated credit card cycle")
+		writeServiceError(writer, err, "failed to update credit card cycle")
 		return
 	}
 
-	writeJSON(writer, http.StatusOK, updated)
+	writeJSONWithETag(writer, http.StatusOK, updated)
 }
 
-func (application app) deleteCreditCardCycle(writer http.ResponseWriter, id int64) {
-	result, err := application.db.Exec(`DELETE FROM credit_card_cycles WHERE id = ?`, id)
+func (application app) patchCreditCardCycle(writer http.ResponseWriter, request *http.Request, id int64) {
+	current, err := application.services().creditCardCycles.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to delete credit card cycle")
+		writeServiceError(writer, err, "failed to load credit card cycle")
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read delete result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "credit card cycle not found")
+	mergedRequest, ok := mergePatchRequest(writer, request, current)
+	if !ok {
 		return
 	}
 
-	writer.WriteHeader(http.StatusNoContent)
+	application.updateCreditCardCycle(writer, mergedRequest, id)
 }
 
-func decodeCreditCardCyclePayload(request *http.Request) (creditCardCyclePayload, error) {
-	defer request.Body.Close()
-
-	var payload creditCardCyclePayload
-	if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
-		return creditCardCyclePayload{}, fmt.Errorf("request body must be valid JSON")
-	}
-
-	payload.ClosingDate = strings.TrimSpace(payload.ClosingDate)
-	payload.DueDate = strings.TrimSpace(payload.DueDate)
-
-	if payload.CreditCardID <= 0 {
-		return creditCardCyclePayload{}, fmt.Errorf("credit_card_id must be a positive integer")
-	}
-	if !isValidISODate(payload.ClosingDate) {
-		return creditCardCyclePayload{}, fmt.Errorf("closing_date must be a valid date in YYYY-MM-DD format")
-	}
-	if !isValidISODate(payload.DueDate) {
-		return creditCardCyclePayload{}, fmt.Errorf("due_date must be a valid date in YYYY-MM-DD format")
-	}
-	if payload.DueDate < payload.ClosingDate {
-		return creditCardCyclePayload{}, fmt.Errorf("due_date must be on or after closing_date")
-	}
-
-	return payload, nil
-}
-
-func isValidISODate(value string) bool {
-	_, err := time.Parse("2006-01-02", value)
-	return err == nil
-}
-
-func (application app) fetchCreditCardCycle(id int64) (creditCardCycle, error) {
-	row := application.db.QueryRow(
-		`SELECT id, credit_card_id, closing_date, due_date FROM credit_card_cycles WHERE id = ?`,
-		id,
-	)
-
-	item, err := scanCreditCardCycle(row)
-	if err != nil {
-		return creditCardCycle{}, err
-	}
-
-	return item, nil
-}
-
-func scanCreditCardCycle(source scanner) (creditCardCycle, error) {
-	var item creditCardCycle
-	err := source.Scan(&item.ID, &item.CreditCardID, &item.ClosingDate, &item.DueDate)
-	if err != nil {
-		return creditCardCycle{}, err
+func (application app) deleteCreditCardCycle(writer http.ResponseWriter, request *http.Request, id int64) {
+	if err := application.services().creditCardCycles.delete(request.Context(), requestChange(request), id); err != nil {
+		writeServiceError(writer, err, "failed to delete credit card cycle")
+		return
 	}
 
-	return item, nil
+	writer.WriteHeader(http.StatusNoContent)
 }
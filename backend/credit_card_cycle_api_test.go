@@ -15,7 +15,7 @@ func TestCreditCardCycleCRUDFlow(t *testing.T) {
 		router,
 		http.MethodPost,
 		"/api/credit-cards",
-		[]byte(`{"bank_id":1,"person_id":1,"number":"4111 1111"}`),
+		[]byte(`{"bank_id":1,"person_id":1,"number":"4111 1111 1111 1111"}`),
 	)
 	if seedCreditCard.Code != http.StatusCreated {
 		t.Fatalf("expected credit card seed to return 201, got %d", seedCreditCard.Code)
@@ -85,7 +85,7 @@ func TestCreditCardCycleUniqueConstraint(t *testing.T) {
 		router,
 		http.MethodPost,
 		"/api/credit-cards",
-		[]byte(`{"bank_id":1,"person_id":1,"number":"7777"}`),
+		[]byte(`{"bank_id":1,"person_id":1,"number":"5555 5555 5555 4444"}`),
 	)
 	if seedCreditCard.Code != http.StatusCreated {
 		t.Fatalf("expected credit card seed to return 201, got %d", seedCreditCard.Code)
@@ -121,7 +121,7 @@ func TestCreditCardCycleValidationErrors(t *testing.T) {
 		router,
 		http.MethodPost,
 		"/api/credit-cards",
-		[]byte(`{"bank_id":1,"person_id":1,"number":"9999"}`),
+		[]byte(`{"bank_id":1,"person_id":1,"number":"3782 822463 10005"}`),
 	)
 	if seedCreditCard.Code != http.StatusCreated {
 		t.Fatalf("expected credit card seed to return 201, got %d", seedCreditCard.Code)
@@ -218,7 +218,7 @@ func seedCreditCardCycleBalanceDependencies(t *testing.T, router http.Handler) {
 		t.Fatalf("expected second currency seed to return 201, got %d", secondCurrency.Code)
 	}
 
-	creditCard := performRequest(router, http.MethodPost, "/api/credit-cards", []byte(`{"bank_id":1,"person_id":1,"number":"1111 2222"}`))
+	creditCard := performRequest(router, http.MethodPost, "/api/credit-cards", []byte(`{"bank_id":1,"person_id":1,"number":"4012 8888 8888 1881"}`))
 	if creditCard.Code != http.StatusCreated {
 		t.Fatalf("expected credit card seed to return 201, got %d", creditCard.Code)
 	}
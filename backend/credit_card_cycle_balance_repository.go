@@ -0,0 +1,95 @@
+package backend
+
+import "context"
+
+type creditCardCycleBalanceRepository interface {
+	list(ctx context.Context) ([]creditCardCycleBalance, error)
+	get(ctx context.Context, id int64) (creditCardCycleBalance, error)
+	create(ctx context.Context, payload creditCardCycleBalancePayload) (int64, error)
+	update(ctx context.Context, id int64, payload creditCardCycleBalancePayload) error
+	// delete soft deletes the credit card cycle balance.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlCreditCardCycleBalanceRepository struct {
+	source queryer
+}
+
+func (repository sqlCreditCardCycleBalanceRepository) list(ctx context.Context) ([]creditCardCycleBalance, error) {
+	rows, err := repository.source.QueryContext(ctx, `SELECT id, credit_card_cycle_id, currency_id, balance, paid FROM credit_card_cycle_balances WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]creditCardCycleBalance, 0)
+	for rows.Next() {
+		item, scanErr := scanCreditCardCycleBalance(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlCreditCardCycleBalanceRepository) get(ctx context.Context, id int64) (creditCardCycleBalance, error) {
+	row := repository.source.QueryRowContext(ctx, `SELECT id, credit_card_cycle_id, currency_id, balance, paid FROM credit_card_cycle_balances WHERE id = ? AND deleted_at IS NULL`, id)
+
+	item, err := scanCreditCardCycleBalance(row)
+	if err != nil {
+		return creditCardCycleBalance{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlCreditCardCycleBalanceRepository) create(ctx context.Context, payload creditCardCycleBalancePayload) (int64, error) {
+	return insertRow(
+		ctx,
+		repository.source,
+		`INSERT INTO credit_card_cycle_balances(credit_card_cycle_id, currency_id, balance, paid) VALUES (?, ?, ?, ?)`,
+		payload.CreditCardCycleID,
+		payload.CurrencyID,
+		payload.Balance,
+		payload.Paid,
+	)
+}
+
+func (repository sqlCreditCardCycleBalanceRepository) update(ctx context.Context, id int64, payload creditCardCycleBalancePayload) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE credit_card_cycle_balances SET credit_card_cycle_id = ?, currency_id = ?, balance = ?, paid = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`,
+		payload.CreditCardCycleID,
+		payload.CurrencyID,
+		payload.Balance,
+		payload.Paid,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlCreditCardCycleBalanceRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE credit_card_cycle_balances SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func scanCreditCardCycleBalance(source scanner) (creditCardCycleBalance, error) {
+	var item creditCardCycleBalance
+	var paidValue any
+	if err := source.Scan(&item.ID, &item.CreditCardCycleID, &item.CurrencyID, &item.Balance, &paidValue); err != nil {
+		return creditCardCycleBalance{}, err
+	}
+
+	switch value := paidValue.(type) {
+	case bool:
+		item.Paid = value
+	case int64:
+		item.Paid = value != 0
+	default:
+		item.Paid = false
+	}
+
+	return item, nil
+}
@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"context"
+	"errors"
+)
+
+type creditCardCycleBalanceService struct {
+	store dataStore
+}
+
+func (payload creditCardCycleBalancePayload) normalize() (creditCardCycleBalancePayload, error) {
+	if payload.CreditCardCycleID <= 0 {
+		return creditCardCycleBalancePayload{}, invalidPayload("credit_card_cycle_id must be a positive integer")
+	}
+	if payload.CurrencyID <= 0 {
+		return creditCardCycleBalancePayload{}, invalidPayload("currency_id must be a positive integer")
+	}
+
+	return payload, nil
+}
+
+func (service creditCardCycleBalanceService) list(ctx context.Context) ([]creditCardCycleBalance, error) {
+	return service.store.reads().creditCardCycleBalances().list(ctx)
+}
+
+func (service creditCardCycleBalanceService) get(ctx context.Context, id int64) (creditCardCycleBalance, error) {
+	item, err := service.store.reads().creditCardCycleBalances().get(ctx, id)
+	if err != nil {
+		return creditCardCycleBalance{}, orNotFound(err, "credit card cycle balance not found")
+	}
+
+	return item, nil
+}
+
+func (service creditCardCycleBalanceService) create(ctx context.Context, by change, payload creditCardCycleBalancePayload) (creditCardCycleBalance, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return creditCardCycleBalance{}, err
+	}
+
+	var created creditCardCycleBalance
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkCreditCardCycleBalanceReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		id, err := tx.creditCardCycleBalances().create(ctx, payload)
+		if err != nil {
+			return creditCardCycleBalanceWriteError(err)
+		}
+
+		if created, err = tx.creditCardCycleBalances().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCardCycleBalances, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return creditCardCycleBalance{}, err
+	}
+
+	return created, nil
+}
+
+func (service creditCardCycleBalanceService) update(ctx context.Context, by change, id int64, payload creditCardCycleBalancePayload) (creditCardCycleBalance, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return creditCardCycleBalance{}, err
+	}
+
+	var updated creditCardCycleBalance
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.creditCardCycleBalances().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "credit card cycle balance not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = checkCreditCardCycleBalanceReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		if err = tx.creditCardCycleBalances().update(ctx, id, payload); err != nil {
+			return creditCardCycleBalanceWriteError(err)
+		}
+
+		if updated, err = tx.creditCardCycleBalances().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCardCycleBalances, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return creditCardCycleBalance{}, err
+	}
+
+	return updated, nil
+}
+
+func (service creditCardCycleBalanceService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.creditCardCycleBalances().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "credit card cycle balance not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = tx.creditCardCycleBalances().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCardCycleBalances, id, auditActionDelete, existing, nil))
+	})
+}
+
+func checkCreditCardCycleBalanceReferences(ctx context.Context, tx repositories, payload creditCardCycleBalancePayload) error {
+	referencesExist, err := tx.trash().referencesExist(ctx, auditEntityCreditCardCycleBalances, map[string]int64{"credit_card_cycle_id": payload.CreditCardCycleID, "currency_id": payload.CurrencyID})
+	if err != nil {
+		return err
+	}
+	if !referencesExist {
+		return invalidPayload("credit card cycle and currency must exist")
+	}
+
+	return nil
+}
+
+func creditCardCycleBalanceWriteError(err error) error {
+	switch {
+	case errors.Is(err, errDuplicate):
+		return conflict("duplicate_credit_card_cycle_balance", "credit card cycle and currency combination must be unique")
+	case errors.Is(err, errMissingReference):
+		return invalidPayload("credit card cycle and currency must exist")
+	default:
+		return err
+	}
+}
@@ -0,0 +1,84 @@
+package backend
+
+import "context"
+
+type creditCardCycleRepository interface {
+	list(ctx context.Context) ([]creditCardCycle, error)
+	get(ctx context.Context, id int64) (creditCardCycle, error)
+	create(ctx context.Context, payload creditCardCyclePayload) (int64, error)
+	update(ctx context.Context, id int64, payload creditCardCyclePayload) error
+	// delete soft deletes the credit card cycle.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlCreditCardCycleRepository struct {
+	source queryer
+}
+
+func (repository sqlCreditCardCycleRepository) list(ctx context.Context) ([]creditCardCycle, error) {
+	rows, err := repository.source.QueryContext(ctx, `SELECT id, credit_card_id, closing_date, due_date FROM credit_card_cycles WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]creditCardCycle, 0)
+	for rows.Next() {
+		item, scanErr := scanCreditCardCycle(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlCreditCardCycleRepository) get(ctx context.Context, id int64) (creditCardCycle, error) {
+	row := repository.source.QueryRowContext(ctx, `SELECT id, credit_card_id, closing_date, due_date FROM credit_card_cycles WHERE id = ? AND deleted_at IS NULL`, id)
+
+	item, err := scanCreditCardCycle(row)
+	if err != nil {
+		return creditCardCycle{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlCreditCardCycleRepository) create(ctx context.Context, payload creditCardCyclePayload) (int64, error) {
+	return insertRow(
+		ctx,
+		repository.source,
+		`INSERT INTO credit_card_cycles(credit_card_id, closing_date, due_date) VALUES (?, ?, ?)`,
+		payload.CreditCardID,
+		payload.ClosingDate,
+		payload.DueDate,
+	)
+}
+
+func (repository sqlCreditCardCycleRepository) update(ctx context.Context, id int64, payload creditCardCyclePayload) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE credit_card_cycles SET credit_card_id = ?, closing_date = ?, due_date = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`,
+		payload.CreditCardID,
+		payload.ClosingDate,
+		payload.DueDate,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlCreditCardCycleRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE credit_card_cycles SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func scanCreditCardCycle(source scanner) (creditCardCycle, error) {
+	var item creditCardCycle
+	err := source.Scan(&item.ID, &item.CreditCardID, &item.ClosingDate, &item.DueDate)
+	if err != nil {
+		return creditCardCycle{}, err
+	}
+
+	return item, nil
+}
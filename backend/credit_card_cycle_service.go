@@ -0,0 +1,167 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+type creditCardCycleService struct {
+	store dataStore
+}
+
+func (payload creditCardCyclePayload) normalize() (creditCardCyclePayload, error) {
+	payload.ClosingDate = strings.TrimSpace(payload.ClosingDate)
+	payload.DueDate = strings.TrimSpace(payload.DueDate)
+
+	if payload.CreditCardID <= 0 {
+		return creditCardCyclePayload{}, invalidPayload("credit_card_id must be a positive integer")
+	}
+	if !isValidISODate(payload.ClosingDate) {
+		return creditCardCyclePayload{}, invalidPayload("closing_date must be a valid date in YYYY-MM-DD format")
+	}
+	if !isValidISODate(payload.DueDate) {
+		return creditCardCyclePayload{}, invalidPayload("due_date must be a valid date in YYYY-MM-DD format")
+	}
+	if payload.DueDate < payload.ClosingDate {
+		return creditCardCyclePayload{}, invalidPayload("due_date must be on or after closing_date")
+	}
+
+	return payload, nil
+}
+
+func (service creditCardCycleService) list(ctx context.Context) ([]creditCardCycle, error) {
+	return service.store.reads().creditCardCycles().list(ctx)
+}
+
+func (service creditCardCycleService) get(ctx context.Context, id int64) (creditCardCycle, error) {
+	item, err := service.store.reads().creditCardCycles().get(ctx, id)
+	if err != nil {
+		return creditCardCycle{}, orNotFound(err, "credit card cycle not found")
+	}
+
+	return item, nil
+}
+
+func (service creditCardCycleService) create(ctx context.Context, by change, payload creditCardCyclePayload) (creditCardCycle, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return creditCardCycle{}, err
+	}
+
+	var created creditCardCycle
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkCreditCardCycleReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		id, err := tx.creditCardCycles().create(ctx, payload)
+		if err != nil {
+			return creditCardCycleWriteError(err)
+		}
+
+		if created, err = tx.creditCardCycles().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCardCycles, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return creditCardCycle{}, err
+	}
+
+	return created, nil
+}
+
+func (service creditCardCycleService) update(ctx context.Context, by change, id int64, payload creditCardCyclePayload) (creditCardCycle, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return creditCardCycle{}, err
+	}
+
+	var updated creditCardCycle
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.creditCardCycles().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "credit card cycle not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = checkCreditCardCycleReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		if err = tx.creditCardCycles().update(ctx, id, payload); err != nil {
+			return creditCardCycleWriteError(err)
+		}
+
+		if updated, err = tx.creditCardCycles().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCardCycles, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return creditCardCycle{}, err
+	}
+
+	return updated, nil
+}
+
+// delete soft deletes a credit card cycle nothing live refers to.
+func (service creditCardCycleService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.creditCardCycles().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "credit card cycle not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		inUse, err := tx.trash().hasDependents(ctx, auditEntityCreditCardCycles, id)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return conflict("credit_card_cycle_in_use", "credit card cycle is in use")
+		}
+
+		if err = tx.creditCardCycles().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCardCycles, id, auditActionDelete, existing, nil))
+	})
+}
+
+func checkCreditCardCycleReferences(ctx context.Context, tx repositories, payload creditCardCyclePayload) error {
+	referencesExist, err := tx.trash().referencesExist(ctx, auditEntityCreditCardCycles, map[string]int64{"credit_card_id": payload.CreditCardID})
+	if err != nil {
+		return err
+	}
+	if !referencesExist {
+		return invalidPayload("credit card must exist")
+	}
+
+	return nil
+}
+
+func creditCardCycleWriteError(err error) error {
+	switch {
+	case errors.Is(err, errDuplicate):
+		return conflict("duplicate_credit_card_cycle", "credit card cycle already exists")
+	case errors.Is(err, errMissingReference):
+		return invalidPayload("credit card must exist")
+	default:
+		return err
+	}
+}
+
+func isValidISODate(value string) bool {
+	_, err := time.Parse("2006-01-02", value)
+	return err == nil
+}
@@ -1,12 +1,8 @@
 package backend
 
 import (
-	"database/sql"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 )
 
 const (
@@ -37,12 +33,13 @@ type creditCardInstallmentPayload struct {
 func (application app) registerCreditCardInstallmentRoutes(mux *http.ServeMux) {
 	mux.HandleFunc(creditCardInstallmentsPath, application.creditCardInstallmentsHandler)
 	mux.HandleFunc(creditCardInstallmentsPathByID, application.creditCardInstallmentByIDHandler)
+	mux.HandleFunc(creditCardInstallmentsPath+batchPathSuffix, application.batchHandler(creditCardInstallmentsPath, app.creditCardInstallmentsHandler, app.creditCardInstallmentByIDHandler))
 }
 
 func (application app) creditCardInstallmentsHandler(writer http.ResponseWriter, request *http.Request) {
 	switch request.Method {
 	case http.MethodGet:
-		application.listCreditCardInstallments(writer)
+		application.listCreditCardInstallments(writer, request)
 	case http.MethodPost:
 		application.createCreditCardInstallment(writer, request)
 	default:
@@ -59,224 +56,89 @@ func (application app) creditCardInstallmentByIDHandler(writer http.ResponseWrit
 
 	switch request.Method {
 	case http.MethodGet:
-		application.getCreditCardInstallment(writer, id)
+		application.getCreditCardInstallment(writer, request, id)
 	case http.MethodPut:
 		application.updateCreditCardInstallment(writer, request, id)
+	case http.MethodPatch:
+		application.patchCreditCardInstallment(writer, request, id)
 	case http.MethodDelete:
-		application.deleteCreditCardInstallment(writer, id)
+		application.deleteCreditCardInstallment(writer, request, id)
 	default:
-		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodDelete)
+		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
 	}
 }
 
-func (application app) listCreditCardInstallments(writer http.ResponseWriter) {
-	rows, err := application.db.Query(
-		`SELECT id, credit_card_id, currency_id, concept, amount, start_date, count FROM credit_card_installments ORDER BY id`,
-	)
+func (application app) listCreditCardInstallments(writer http.ResponseWriter, request *http.Request) {
+	items, err := application.services().creditCardInstallments.list(request.Context())
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load credit card installments")
-		return
-	}
-	defer rows.Close()
-
-	items := make([]creditCardInstallment, 0)
-	for rows.Next() {
-		item, scanErr := scanCreditCardInstallment(rows)
-		if scanErr != nil {
-			writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read credit card installments")
-			return
-		}
-		items = append(items, item)
-	}
-
-	if err = rows.Err(); err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read credit card installments")
+		writeServiceError(writer, err, "failed to load credit card installments")
 		return
 	}
 
 	writeJSON(writer, http.StatusOK, items)
 }
 
-func (application app) getCreditCardInstallment(writer http.ResponseWriter, id int64) {
-	item, err := application.fetchCreditCardInstallment(id)
-	if errors.Is(err, sql.ErrNoRows) {
-		writeError(writer, http.StatusNotFound, "not_found", "credit card installment not found")
-		return
-	}
+func (application app) getCreditCardInstallment(writer http.ResponseWriter, request *http.Request, id int64) {
+	item, err := application.services().creditCardInstallments.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load credit card installment")
+		writeServiceError(writer, err, "failed to load credit card installment")
 		return
 	}
 
-	writeJSON(writer, http.StatusOK, item)
+	writeJSONWithETag(writer, http.StatusOK, item)
 }
 
 func (application app) createCreditCardInstallment(writer http.ResponseWriter, request *http.Request) {
-	payload, validationErr := decodeCreditCardInstallmentPayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
-		return
-	}
-
-	result, err := application.db.Exec(
-		`INSERT INTO credit_card_installments(credit_card_id, currency_id, concept, amount, start_date, count) VALUES (?, ?, ?, ?, ?, ?)`,
-		payload.CreditCardID,
-		payload.CurrencyID,
-		payload.Concept,
-		payload.Amount,
-		payload.StartDate,
-		payload.Count,
-	)
-	if err != nil {
-		if isUniqueConstraintError(err) {
-			writeError(writer, http.StatusConflict, "duplicate_credit_card_installment", "credit card, currency and concept combination must be unique")
-			return
-		}
-		if isForeignKeyConstraintError(err) {
-			writeError(writer, http.StatusBadRequest, "invalid_payload", "credit card and currency must exist")
-			return
-		}
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to create credit card installment")
-		return
-	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read created credit card installment id")
+	var payload creditCardInstallmentPayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	created, err := application.fetchCreditCardInstallment(id)
+	created, err := application.services().creditCardInstallments.create(request.Context(), requestChange(request), payload)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load created credit card installment")
+		writeServiceError(writer, err, "failed to create credit card installment")
 		return
 	}
 
-	writer.Header().Set("Location", fmt.Sprintf(creditCardInstallmentPathPattern, id))
-	writeJSON(writer, http.StatusCreated, created)
+	writer.Header().Set("Location", fmt.Sprintf(creditCardInstallmentPathPattern, created.ID))
+	writeJSONWithETag(writer, http.StatusCreated, created)
 }
 
 func (application app) updateCreditCardInstallment(writer http.ResponseWriter, request *http.Request, id int64) {
-	payload, validationErr := decodeCreditCardInstallmentPayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
-		return
-	}
-
-	result, err := application.db.Exec(
-		`UPDATE credit_card_installments SET credit_card_id = ?, currency_id = ?, concept = ?, amount = ?, start_date = ?, count = ? WHERE id = ?`,
-		payload.CreditCardID,
-		payload.CurrencyID,
-		payload.Concept,
-		payload.Amount,
-		payload.StartDate,
-		payload.Count,
-		id,
-	)
-	if err != nil {
-		if isUniqueConstraintError(err) {
-			writeError(writer, http.StatusConflict, "duplicate_credit_card_installment", "credit card, currency and concept combination must be unique")
-			return
-		}
-		if isForeignKeyConstraintError(err) {
-			writeError(writer, http.StatusBadRequest, "invalid_payload", "credit card and currency must exist")
-			return
-		}
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to update credit card installment")
-		return
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read update result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "credit card installment not found")
+	var payload creditCardInstallmentPayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	updated, err := application.fetchCreditCardInstallment(id)
+	updated, err := application.services().creditCardInstallments.update(request.Context(), requestChange(request), id, payload)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load updated credit card installment")
+		writeServiceError(writer, err, "failed to update credit card installment")
 		return
 	}
 
-	writeJSON(writer, http.StatusOK, updated)
+	writeJSONWithETag(writer, http.StatusOK, updated)
 }
 
-func (application app) deleteCreditCardInstallment(writer http.ResponseWriter, id int64) {
-	result, err := application.db.Exec(`DELETE FROM credit_card_installments WHERE id = ?`, id)
+func (application app) patchCreditCardInstallment(writer http.ResponseWriter, request *http.Request, id int64) {
+	current, err := application.services().creditCardInstallments.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to delete credit card installment")
+		writeServiceError(writer, err, "failed to load credit card installment")
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read delete result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "credit card installment not found")
+	mergedRequest, ok := mergePatchRequest(writer, request, current)
+	if !ok {
 		return
 	}
 
-	writer.WriteHeader(http.StatusNoContent)
-}
-
-func decodeCreditCardInstallmentPayload(request *http.Request) (creditCardInstallmentPayload, error) {
-	defer request.Body.Close()
-
-	var payload creditCardInstallmentPayload
-	if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
-		return creditCardInstallmentPayload{}, fmt.Errorf("request body must be valid JSON")
-	}
-
-	payload.Concept = strings.TrimSpace(payload.Concept)
-	payload.StartDate = strings.TrimSpace(payload.StartDate)
-
-	if payload.CreditCardID <= 0 {
-		return creditCardInstallmentPayload{}, fmt.Errorf("credit_card_id must be a positive integer")
-	}
-	if payload.CurrencyID <= 0 {
-		return creditCardInstallmentPayload{}, fmt.Errorf("currency_id must be a positive integer")
-	}
-	if payload.Concept == "" {
-		return creditCardInstallmentPayload{}, fmt.Errorf("concept is required")
-	}
-	if payload.Amount <= 0 {
-		return creditCardInstallmentPayload{}, fmt.Errorf("amount must be greater than zero")
-	}
-	if !isValidISODate(payload.StartDate) {
-		return creditCardInstallmentPayload{}, fmt.Errorf("start_date must be a valid date in YYYY-MM-DD format")
-	}
-	if payload.Count <= 0 {
-		return creditCardInstallmentPayload{}, fmt.Errorf("count must be greater than zero")
-	}
-
-	return payload, nil
+	application.updateCreditCardInstallment(writer, mergedRequest, id)
 }
 
-func (application app) fetchCreditCardInstallment(id int64) (creditCardInstallment, error) {
-	row := application.db.QueryRow(
-		`SELECT id, credit_card_id, currency_id, concept, amount, start_date, count FROM credit_card_installments WHERE id = ?`,
-		id,
-	)
-
-	item, err := scanCreditCardInstallment(row)
-	if err != nil {
-		return creditCardInstallment{}, err
-	}
-
-	return item, nil
-}
-
-func scanCreditCardInstallment(source scanner) (creditCardInstallment, error) {
-	var item creditCardInstallment
-	if err := source.Scan(&item.ID, &item.CreditCardID, &item.CurrencyID, &item.Concept, &item.Amount, &item.StartDate, &item.Count); err != nil {
-		return creditCardInstallment{}, err
+func (application app) deleteCreditCardInstallment(writer http.ResponseWriter, request *http.Request, id int64) {
+	if err := application.services().creditCardInstallments.delete(request.Context(), requestChange(request), id); err != nil {
+		writeServiceError(writer, err, "failed to delete credit card installment")
+		return
 	}
 
-	return item, nil
+	writer.WriteHeader(http.StatusNoContent)
 }
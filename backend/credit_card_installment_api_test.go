@@ -207,7 +207,7 @@ func seedCreditCardInstallmentDependencies(t *testing.T, router http.Handler) {
 		router,
 		http.MethodPost,
 		"/api/credit-cards",
-		[]byte(`{"bank_id":1,"person_id":1,"number":"1234 5678 9012 3456"}`),
+		[]byte(`{"bank_id":1,"person_id":1,"number":"5105 1051 0510 5100"}`),
 	)
 	if creditCard.Code != http.StatusCreated {
 		t.Fatalf("expected credit card seed to return 201, got %d", creditCard.Code)
@@ -0,0 +1,89 @@
+package backend
+
+import "context"
+
+type creditCardInstallmentRepository interface {
+	list(ctx context.Context) ([]creditCardInstallment, error)
+	get(ctx context.Context, id int64) (creditCardInstallment, error)
+	create(ctx context.Context, payload creditCardInstallmentPayload) (int64, error)
+	update(ctx context.Context, id int64, payload creditCardInstallmentPayload) error
+	// delete soft deletes the credit card installment.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlCreditCardInstallmentRepository struct {
+	source queryer
+}
+
+func (repository sqlCreditCardInstallmentRepository) list(ctx context.Context) ([]creditCardInstallment, error) {
+	rows, err := repository.source.QueryContext(ctx, `SELECT id, credit_card_id, currency_id, concept, amount, start_date, count FROM credit_card_installments WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]creditCardInstallment, 0)
+	for rows.Next() {
+		item, scanErr := scanCreditCardInstallment(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlCreditCardInstallmentRepository) get(ctx context.Context, id int64) (creditCardInstallment, error) {
+	row := repository.source.QueryRowContext(ctx, `SELECT id, credit_card_id, currency_id, concept, amount, start_date, count FROM credit_card_installments WHERE id = ? AND deleted_at IS NULL`, id)
+
+	item, err := scanCreditCardInstallment(row)
+	if err != nil {
+		return creditCardInstallment{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlCreditCardInstallmentRepository) create(ctx context.Context, payload creditCardInstallmentPayload) (int64, error) {
+	return insertRow(
+		ctx,
+		repository.source,
+		`INSERT INTO credit_card_installments(credit_card_id, currency_id, concept, amount, start_date, count) VALUES (?, ?, ?, ?, ?, ?)`,
+		payload.CreditCardID,
+		payload.CurrencyID,
+		payload.Concept,
+		payload.Amount,
+		payload.StartDate,
+		payload.Count,
+	)
+}
+
+func (repository sqlCreditCardInstallmentRepository) update(ctx context.Context, id int64, payload creditCardInstallmentPayload) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE credit_card_installments SET credit_card_id = ?, currency_id = ?, concept = ?, amount = ?, start_date = ?, count = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`,
+		payload.CreditCardID,
+		payload.CurrencyID,
+		payload.Concept,
+		payload.Amount,
+		payload.StartDate,
+		payload.Count,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlCreditCardInstallmentRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE credit_card_installments SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func scanCreditCardInstallment(source scanner) (creditCardInstallment, error) {
+	var item creditCardInstallment
+	if err := source.Scan(&item.ID, &item.CreditCardID, &item.CurrencyID, &item.Concept, &item.Amount, &item.StartDate, &item.Count); err != nil {
+		return creditCardInstallment{}, err
+	}
+
+	return item, nil
+}
@@ -0,0 +1,158 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+type creditCardInstallmentService struct {
+	store dataStore
+}
+
+func (payload creditCardInstallmentPayload) normalize() (creditCardInstallmentPayload, error) {
+	payload.Concept = strings.TrimSpace(payload.Concept)
+	payload.StartDate = strings.TrimSpace(payload.StartDate)
+
+	if payload.CreditCardID <= 0 {
+		return creditCardInstallmentPayload{}, invalidPayload("credit_card_id must be a positive integer")
+	}
+	if payload.CurrencyID <= 0 {
+		return creditCardInstallmentPayload{}, invalidPayload("currency_id must be a positive integer")
+	}
+	if payload.Concept == "" {
+		return creditCardInstallmentPayload{}, invalidPayload("concept is required")
+	}
+	if payload.Amount <= 0 {
+		return creditCardInstallmentPayload{}, invalidPayload("amount must be greater than zero")
+	}
+	if !isValidISODate(payload.StartDate) {
+		return creditCardInstallmentPayload{}, invalidPayload("start_date must be a valid date in YYYY-MM-DD format")
+	}
+	if payload.Count <= 0 {
+		return creditCardInstallmentPayload{}, invalidPayload("count must be greater than zero")
+	}
+
+	return payload, nil
+}
+
+func (service creditCardInstallmentService) list(ctx context.Context) ([]creditCardInstallment, error) {
+	return service.store.reads().creditCardInstallments().list(ctx)
+}
+
+func (service creditCardInstallmentService) get(ctx context.Context, id int64) (creditCardInstallment, error) {
+	item, err := service.store.reads().creditCardInstallments().get(ctx, id)
+	if err != nil {
+		return creditCardInstallment{}, orNotFound(err, "credit card installment not found")
+	}
+
+	return item, nil
+}
+
+func (service creditCardInstallmentService) create(ctx context.Context, by change, payload creditCardInstallmentPayload) (creditCardInstallment, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return creditCardInstallment{}, err
+	}
+
+	var created creditCardInstallment
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkCreditCardInstallmentReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		id, err := tx.creditCardInstallments().create(ctx, payload)
+		if err != nil {
+			return creditCardInstallmentWriteError(err)
+		}
+
+		if created, err = tx.creditCardInstallments().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCardInstallments, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return creditCardInstallment{}, err
+	}
+
+	return created, nil
+}
+
+func (service creditCardInstallmentService) update(ctx context.Context, by change, id int64, payload creditCardInstallmentPayload) (creditCardInstallment, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return creditCardInstallment{}, err
+	}
+
+	var updated creditCardInstallment
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.creditCardInstallments().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "credit card installment not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = checkCreditCardInstallmentReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		if err = tx.creditCardInstallments().update(ctx, id, payload); err != nil {
+			return creditCardInstallmentWriteError(err)
+		}
+
+		if updated, err = tx.creditCardInstallments().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCardInstallments, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return creditCardInstallment{}, err
+	}
+
+	return updated, nil
+}
+
+func (service creditCardInstallmentService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.creditCardInstallments().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "credit card installment not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = tx.creditCardInstallments().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCardInstallments, id, auditActionDelete, existing, nil))
+	})
+}
+
+func checkCreditCardInstallmentReferences(ctx context.Context, tx repositories, payload creditCardInstallmentPayload) error {
+	referencesExist, err := tx.trash().referencesExist(ctx, auditEntityCreditCardInstallments, map[string]int64{"credit_card_id": payload.CreditCardID, "currency_id": payload.CurrencyID})
+	if err != nil {
+		return err
+	}
+	if !referencesExist {
+		return invalidPayload("credit card and currency must exist")
+	}
+
+	return nil
+}
+
+func creditCardInstallmentWriteError(err error) error {
+	switch {
+	case errors.Is(err, errDuplicate):
+		return conflict("duplicate_credit_card_installment", "credit card, currency and concept combination must be unique")
+	case errors.Is(err, errMissingReference):
+		return invalidPayload("credit card and currency must exist")
+	default:
+		return err
+	}
+}
@@ -0,0 +1,166 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+)
+
+// creditCardRepository stores card numbers through storeCardNumber and
+// returns them opened; see creditCard for what Number holds.
+type creditCardRepository interface {
+	list(ctx context.Context) ([]creditCard, error)
+	get(ctx context.Context, id int64) (creditCard, error)
+	create(ctx context.Context, payload creditCardPayload, number cardNumber) (int64, error)
+	// update keeps the stored number when number is nil.
+	update(ctx context.Context, id int64, payload creditCardPayload, number *cardNumber) error
+	// delete soft deletes the credit card.
+	delete(ctx context.Context, id int64) error
+	// numberInUse reports whether an active card other than exceptID has the
+	// same number. Cards saved before encryption was enabled keep their plain
+	// fingerprint, so both forms are checked.
+	numberInUse(ctx context.Context, number cardNumber, exceptID int64) (bool, error)
+}
+
+type sqlCreditCardRepository struct {
+	source queryer
+	fields *FieldCipher
+}
+
+func (repository sqlCreditCardRepository) list(ctx context.Context) ([]creditCard, error) {
+	rows, err := repository.source.QueryContext(ctx, `SELECT id, bank_id, person_id, number, last4, network, name FROM credit_cards WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]creditCard, 0)
+	for rows.Next() {
+		item, scanErr := scanCreditCard(rows, repository.fields)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlCreditCardRepository) get(ctx context.Context, id int64) (creditCard, error) {
+	row := repository.source.QueryRowContext(ctx, `SELECT id, bank_id, person_id, number, last4, network, name FROM credit_cards WHERE id = ? AND deleted_at IS NULL`, id)
+
+	item, err := scanCreditCard(row, repository.fields)
+	if err != nil {
+		return creditCard{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlCreditCardRepository) create(ctx context.Context, payload creditCardPayload, number cardNumber) (int64, error) {
+	storedNumber, numberLookup, err := storeCardNumber(repository.fields, number)
+	if err != nil {
+		return 0, err
+	}
+
+	return insertRow(
+		ctx,
+		repository.source,
+		`INSERT INTO credit_cards(bank_id, person_id, number, number_lookup, last4, network, name) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		payload.BankID,
+		payload.PersonID,
+		storedNumber,
+		numberLookup,
+		number.Last4,
+		number.Network,
+		payload.Name,
+	)
+}
+
+func (repository sqlCreditCardRepository) update(ctx context.Context, id int64, payload creditCardPayload, number *cardNumber) error {
+	if number == nil {
+		_, err := repository.source.ExecContext(
+			ctx,
+			`UPDATE credit_cards SET bank_id = ?, person_id = ?, name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`,
+			payload.BankID,
+			payload.PersonID,
+			payload.Name,
+			id,
+		)
+		return constraintError(err)
+	}
+
+	storedNumber, numberLookup, err := storeCardNumber(repository.fields, *number)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.source.ExecContext(
+		ctx,
+		`UPDATE credit_cards SET bank_id = ?, person_id = ?, number = ?, number_lookup = ?, last4 = ?, network = ?, name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`,
+		payload.BankID,
+		payload.PersonID,
+		storedNumber,
+		numberLookup,
+		number.Last4,
+		number.Network,
+		payload.Name,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlCreditCardRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE credit_cards SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func (repository sqlCreditCardRepository) numberInUse(ctx context.Context, number cardNumber, exceptID int64) (bool, error) {
+	_, lookup, err := storeCardNumber(repository.fields, number)
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	err = repository.source.QueryRowContext(
+		ctx,
+		`SELECT COUNT(1) FROM credit_cards WHERE deleted_at IS NULL AND id <> ? AND number_lookup IN (?, ?)`,
+		exceptID,
+		lookup,
+		plainCardFingerprint(number.Digits),
+	).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func scanCreditCard(source scanner, fields *FieldCipher) (creditCard, error) {
+	var item creditCard
+	var number, name sql.NullString
+
+	err := source.Scan(&item.ID, &item.BankID, &item.PersonID, &number, &item.Last4, &item.Network, &name)
+	if err != nil {
+		return creditCard{}, err
+	}
+
+	if number.Valid {
+		item.Number, err = fields.open(creditCardNumberField, number.String)
+		if err != nil {
+			return creditCard{}, err
+		}
+		// Cards encrypted before last4 existed get it from the decrypted number.
+		if item.Last4 == "" && len(item.Number) >= 4 {
+			item.Last4 = item.Number[len(item.Number)-4:]
+		}
+	} else {
+		item.Number = maskCardNumber(item.Last4)
+	}
+
+	if name.Valid {
+		value := name.String
+		item.Name = &value
+	}
+
+	return item, nil
+}
@@ -0,0 +1,239 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+type creditCardService struct {
+	store dataStore
+}
+
+func (payload creditCardPayload) normalize() (creditCardPayload, error) {
+	payload.Number = strings.TrimSpace(payload.Number)
+	if payload.Number == "" {
+		return creditCardPayload{}, invalidPayload("number is required")
+	}
+
+	if payload.BankID <= 0 {
+		return creditCardPayload{}, invalidPayload("bank_id must be a positive integer")
+	}
+	if payload.PersonID <= 0 {
+		return creditCardPayload{}, invalidPayload("person_id must be a positive integer")
+	}
+
+	if payload.Name != nil {
+		trimmedName := strings.TrimSpace(*payload.Name)
+		if trimmedName == "" {
+			payload.Name = nil
+		} else {
+			payload.Name = &trimmedName
+		}
+	}
+
+	return payload, nil
+}
+
+// list and get may return full card numbers; callers mask them with
+// maskCreditCard before showing them.
+func (service creditCardService) list(ctx context.Context) ([]creditCard, error) {
+	return service.store.reads().creditCards().list(ctx)
+}
+
+func (service creditCardService) get(ctx context.Context, id int64) (creditCard, error) {
+	item, err := service.store.reads().creditCards().get(ctx, id)
+	if err != nil {
+		return creditCard{}, orNotFound(err, "credit card not found")
+	}
+
+	return item, nil
+}
+
+func (service creditCardService) create(ctx context.Context, by change, payload creditCardPayload) (creditCard, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return creditCard{}, err
+	}
+
+	if maskedCardNumberPattern.MatchString(payload.Number) {
+		return creditCard{}, invalidPayload("number must be the full card number")
+	}
+
+	number, err := parseCardNumber(payload.Number)
+	if err != nil {
+		return creditCard{}, invalidPayload(err.Error())
+	}
+
+	var created creditCard
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkCreditCardReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+		if err := checkCardNumberAvailable(ctx, tx, number, 0); err != nil {
+			return err
+		}
+
+		id, err := tx.creditCards().create(ctx, payload, number)
+		if err != nil {
+			return creditCardWriteError(err)
+		}
+
+		if created, err = tx.creditCards().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCards, id, auditActionCreate, nil, maskCreditCard(created)))
+	})
+	if err != nil {
+		return creditCard{}, err
+	}
+
+	return created, nil
+}
+
+func (service creditCardService) update(ctx context.Context, by change, id int64, payload creditCardPayload) (creditCard, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return creditCard{}, err
+	}
+
+	var updated creditCard
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.creditCards().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "credit card not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = checkCreditCardReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		// Clients echo the masked number they were shown when they do not change
+		// it; the stored number is then left untouched.
+		var number *cardNumber
+		if payload.Number != maskCardNumber(existing.Last4) && payload.Number != existing.Number {
+			parsed, parseErr := parseCardNumber(payload.Number)
+			if parseErr != nil {
+				return invalidPayload(parseErr.Error())
+			}
+			if err = checkCardNumberAvailable(ctx, tx, parsed, id); err != nil {
+				return err
+			}
+			number = &parsed
+		}
+
+		if err = tx.creditCards().update(ctx, id, payload, number); err != nil {
+			return creditCardWriteError(err)
+		}
+
+		if updated, err = tx.creditCards().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCards, id, auditActionUpdate, maskCreditCard(existing), maskCreditCard(updated)))
+	})
+	if err != nil {
+		return creditCard{}, err
+	}
+
+	return updated, nil
+}
+
+// delete soft deletes a credit card nothing live refers to.
+func (service creditCardService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.creditCards().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "credit card not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		inUse, err := tx.trash().hasDependents(ctx, auditEntityCreditCards, id)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return conflict("credit_card_in_use", "credit card is in use")
+		}
+
+		if err = tx.creditCards().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCards, id, auditActionDelete, maskCreditCard(existing), nil))
+	})
+}
+
+// maskCreditCard replaces the card number with its masked form. Full numbers
+// are only returned by GET /api/credit-cards/{id}?reveal=true.
+func maskCreditCard(item creditCard) creditCard {
+	item.Number = maskCardNumber(item.Last4)
+	return item
+}
+
+// redactCreditCardRow masks the number of a trashed card and drops its
+// lookup hash or fingerprint.
+func redactCreditCardRow(fields *FieldCipher, data map[string]any) error {
+	delete(data, "number_lookup")
+
+	last4, _ := data["last4"].(string)
+	// Cards encrypted before last4 existed get it from the decrypted number.
+	if stored, ok := data["number"].(string); ok && last4 == "" {
+		number, err := fields.open(creditCardNumberField, stored)
+		if err != nil {
+			return err
+		}
+		if len(number) >= 4 {
+			last4 = number[len(number)-4:]
+		}
+	}
+	data["number"] = maskCardNumber(last4)
+
+	return nil
+}
+
+// tagView keeps the full card number out of the ETag.
+func (item creditCard) tagView() any {
+	return maskCreditCard(item)
+}
+
+func checkCreditCardReferences(ctx context.Context, tx repositories, payload creditCardPayload) error {
+	referencesExist, err := tx.trash().referencesExist(ctx, auditEntityCreditCards, map[string]int64{"bank_id": payload.BankID, "person_id": payload.PersonID})
+	if err != nil {
+		return err
+	}
+	if !referencesExist {
+		return invalidPayload("bank and person must exist")
+	}
+
+	return nil
+}
+
+func checkCardNumberAvailable(ctx context.Context, tx repositories, number cardNumber, exceptID int64) error {
+	inUse, err := tx.creditCards().numberInUse(ctx, number, exceptID)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return conflict("duplicate_credit_card", "credit card number must be unique")
+	}
+
+	return nil
+}
+
+func creditCardWriteError(err error) error {
+	switch {
+	case errors.Is(err, errDuplicate):
+		return conflict("duplicate_credit_card", "credit card number must be unique")
+	case errors.Is(err, errMissingReference):
+		return invalidPayload("bank and person must exist")
+	default:
+		return err
+	}
+}
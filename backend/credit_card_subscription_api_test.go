@@ -210,7 +210,7 @@ func seedCreditCardSubscriptionDependencies(t *testing.T, router http.Handler) {
 		router,
 		http.MethodPost,
 		"/api/credit-cards",
-		[]byte(`{"bank_id":1,"person_id":1,"number":"4444 4444 4444 4444"}`),
+		[]byte(`{"bank_id":1,"person_id":1,"number":"4242 4242 4242 4242"}`),
 	)
 	if creditCard.Code != http.StatusCreated {
 		t.Fatalf("expected credit card seed to return 201, got %d", creditCard.Code)
@@ -0,0 +1,85 @@
+package backend
+
+import "context"
+
+type creditCardSubscriptionRepository interface {
+	list(ctx context.Context) ([]creditCardSubscription, error)
+	get(ctx context.Context, id int64) (creditCardSubscription, error)
+	create(ctx context.Context, payload creditCardSubscriptionPayload) (int64, error)
+	update(ctx context.Context, id int64, payload creditCardSubscriptionPayload) error
+	// delete soft deletes the credit card subscription.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlCreditCardSubscriptionRepository struct {
+	source queryer
+}
+
+func (repository sqlCreditCardSubscriptionRepository) list(ctx context.Context) ([]creditCardSubscription, error) {
+	rows, err := repository.source.QueryContext(ctx, `SELECT id, credit_card_id, currency_id, concept, amount FROM credit_card_subscriptions WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]creditCardSubscription, 0)
+	for rows.Next() {
+		item, scanErr := scanCreditCardSubscription(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlCreditCardSubscriptionRepository) get(ctx context.Context, id int64) (creditCardSubscription, error) {
+	row := repository.source.QueryRowContext(ctx, `SELECT id, credit_card_id, currency_id, concept, amount FROM credit_card_subscriptions WHERE id = ? AND deleted_at IS NULL`, id)
+
+	item, err := scanCreditCardSubscription(row)
+	if err != nil {
+		return creditCardSubscription{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlCreditCardSubscriptionRepository) create(ctx context.Context, payload creditCardSubscriptionPayload) (int64, error) {
+	return insertRow(
+		ctx,
+		repository.source,
+		`INSERT INTO credit_card_subscriptions(credit_card_id, currency_id, concept, amount) VALUES (?, ?, ?, ?)`,
+		payload.CreditCardID,
+		payload.CurrencyID,
+		payload.Concept,
+		payload.Amount,
+	)
+}
+
+func (repository sqlCreditCardSubscriptionRepository) update(ctx context.Context, id int64, payload creditCardSubscriptionPayload) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE credit_card_subscriptions SET credit_card_id = ?, currency_id = ?, concept = ?, amount = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`,
+		payload.CreditCardID,
+		payload.CurrencyID,
+		payload.Concept,
+		payload.Amount,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlCreditCardSubscriptionRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE credit_card_subscriptions SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func scanCreditCardSubscription(source scanner) (creditCardSubscription, error) {
+	var item creditCardSubscription
+	if err := source.Scan(&item.ID, &item.CreditCardID, &item.CurrencyID, &item.Concept, &item.Amount); err != nil {
+		return creditCardSubscription{}, err
+	}
+
+	return item, nil
+}
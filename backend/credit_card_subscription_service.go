@@ -0,0 +1,151 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+type creditCardSubscriptionService struct {
+	store dataStore
+}
+
+func (payload creditCardSubscriptionPayload) normalize() (creditCardSubscriptionPayload, error) {
+	payload.Concept = strings.TrimSpace(payload.Concept)
+
+	if payload.CreditCardID <= 0 {
+		return creditCardSubscriptionPayload{}, invalidPayload("credit_card_id must be a positive integer")
+	}
+	if payload.CurrencyID <= 0 {
+		return creditCardSubscriptionPayload{}, invalidPayload("currency_id must be a positive integer")
+	}
+	if payload.Concept == "" {
+		return creditCardSubscriptionPayload{}, invalidPayload("concept is required")
+	}
+	if payload.Amount <= 0 {
+		return creditCardSubscriptionPayload{}, invalidPayload("amount must be greater than zero")
+	}
+
+	return payload, nil
+}
+
+func (service creditCardSubscriptionService) list(ctx context.Context) ([]creditCardSubscription, error) {
+	return service.store.reads().creditCardSubscriptions().list(ctx)
+}
+
+func (service creditCardSubscriptionService) get(ctx context.Context, id int64) (creditCardSubscription, error) {
+	item, err := service.store.reads().creditCardSubscriptions().get(ctx, id)
+	if err != nil {
+		return creditCardSubscription{}, orNotFound(err, "credit card subscription not found")
+	}
+
+	return item, nil
+}
+
+func (service creditCardSubscriptionService) create(ctx context.Context, by change, payload creditCardSubscriptionPayload) (creditCardSubscription, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return creditCardSubscription{}, err
+	}
+
+	var created creditCardSubscription
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkCreditCardSubscriptionReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		id, err := tx.creditCardSubscriptions().create(ctx, payload)
+		if err != nil {
+			return creditCardSubscriptionWriteError(err)
+		}
+
+		if created, err = tx.creditCardSubscriptions().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCardSubscriptions, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return creditCardSubscription{}, err
+	}
+
+	return created, nil
+}
+
+func (service creditCardSubscriptionService) update(ctx context.Context, by change, id int64, payload creditCardSubscriptionPayload) (creditCardSubscription, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return creditCardSubscription{}, err
+	}
+
+	var updated creditCardSubscription
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.creditCardSubscriptions().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "credit card subscription not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = checkCreditCardSubscriptionReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		if err = tx.creditCardSubscriptions().update(ctx, id, payload); err != nil {
+			return creditCardSubscriptionWriteError(err)
+		}
+
+		if updated, err = tx.creditCardSubscriptions().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCardSubscriptions, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return creditCardSubscription{}, err
+	}
+
+	return updated, nil
+}
+
+func (service creditCardSubscriptionService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.creditCardSubscriptions().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "credit card subscription not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = tx.creditCardSubscriptions().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCreditCardSubscriptions, id, auditActionDelete, existing, nil))
+	})
+}
+
+func checkCreditCardSubscriptionReferences(ctx context.Context, tx repositories, payload creditCardSubscriptionPayload) error {
+	referencesExist, err := tx.trash().referencesExist(ctx, auditEntityCreditCardSubscriptions, map[string]int64{"credit_card_id": payload.CreditCardID, "currency_id": payload.CurrencyID})
+	if err != nil {
+		return err
+	}
+	if !referencesExist {
+		return invalidPayload("credit card and currency must exist")
+	}
+
+	return nil
+}
+
+func creditCardSubscriptionWriteError(err error) error {
+	switch {
+	case errors.Is(err, errDuplicate):
+		return conflict("duplicate_credit_card_subscription", "credit card, currency and concept combination must be unique")
+	case errors.Is(err, errMissingReference):
+		return invalidPayload("credit card and currency must exist")
+	default:
+		return err
+	}
+}
@@ -1,8 +1,6 @@
 package backend
 
 import (
-	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -30,12 +28,13 @@ type currencyPayload struct {
 func (application app) registerCurrencyRoutes(mux *http.ServeMux) {
 	mux.HandleFunc(currenciesPath, application.currenciesHandler)
 	mux.HandleFunc(currenciesPathByID, application.currencyByIDHandler)
+	mux.HandleFunc(currenciesPath+batchPathSuffix, application.batchHandler(currenciesPath, app.currenciesHandler, app.currencyByIDHandler))
 }
 
 func (application app) currenciesHandler(writer http.ResponseWriter, request *http.Request) {
 	switch request.Method {
 	case http.MethodGet:
-		application.listCurrencies(writer)
+		application.listCurrencies(writer, request)
 	case http.MethodPost:
 		application.createCurrency(writer, request)
 	default:
@@ -52,150 +51,91 @@ func (application app) currencyByIDHandler(writer http.ResponseWriter, request *
 
 	switch request.Method {
 	case http.MethodGet:
-		application.getCurrency(writer, id)
+		application.getCurrency(writer, request, id)
 	case http.MethodPut:
 		application.updateCurrency(writer, request, id)
+	case http.MethodPatch:
+		application.patchCurrency(writer, request, id)
 	case http.MethodDelete:
-		application.deleteCurrency(writer, id)
+		application.deleteCurrency(writer, request, id)
 	default:
-		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodDelete)
+		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
 	}
 }
 
-func (application app) listCurrencies(writer http.ResponseWriter) {
-	rows, err := application.db.Query(`SELECT id, name, code FROM currencies ORDER BY id`)
+func (application app) listCurrencies(writer http.ResponseWriter, request *http.Request) {
+	items, err := application.services().currencies.list(request.Context())
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load currencies")
+		writeServiceError(writer, err, "failed to load currencies")
 		return
 	}
-	defer rows.Close()
-
-	items := make([]currency, 0)
-	for rows.Next() {
-		var item currency
-		if scanErr := rows.Scan(&item.ID, &item.Name, &item.Code); scanErr != nil {
-			writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read currencies")
-			return
-		}
-		items = append(items, item)
-	}
 
 	writeJSON(writer, http.StatusOK, items)
 }
 
-func (application app) getCurrency(writer http.ResponseWriter, id int64) {
-	var item currency
-	err := application.db.QueryRow(`SELECT id, name, code FROM currencies WHERE id = ?`, id).Scan(&item.ID, &item.Name, &item.Code)
-	if errors.Is(err, sql.ErrNoRows) {
-		writeError(writer, http.StatusNotFound, "not_found", "currency not found")
-		return
-	}
+func (application app) getCurrency(writer http.ResponseWriter, request *http.Request, id int64) {
+	item, err := application.services().currencies.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load currency")
+		writeServiceError(writer, err, "failed to load currency")
 		return
 	}
 
-	writeJSON(writer, http.StatusOK, item)
+	writeJSONWithETag(writer, http.StatusOK, item)
 }
 
 func (application app) createCurrency(writer http.ResponseWriter, request *http.Request) {
-	payload, validationErr := decodeCurrencyPayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
-		return
-	}
-
-	result, err := application.db.Exec(`INSERT INTO currencies(name, code) VALUES (?, ?)`, payload.Name, payload.Code)
-	if err != nil {
-		if isUniqueConstraintError(err) {
-			writeError(writer, http.StatusConflict, "duplicate_currency", "name and code must be unique")
-			return
-		}
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to create currency")
+	var payload currencyPayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	id, err := result.LastInsertId()
+	created, err := application.services().currencies.create(request.Context(), requestChange(request), payload)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read created currency id")
+		writeServiceError(writer, err, "failed to create currency")
 		return
 	}
 
-	created := currency{ID: id, Name: payload.Name, Code: payload.Code}
-	writer.Header().Set("Location", fmt.Sprintf(currencyPathPattern, id))
-	writeJSON(writer, http.StatusCreated, created)
+	writer.Header().Set("Location", fmt.Sprintf(currencyPathPattern, created.ID))
+	writeJSONWithETag(writer, http.StatusCreated, created)
 }
 
 func (application app) updateCurrency(writer http.ResponseWriter, request *http.Request, id int64) {
-	payload, validationErr := decodeCurrencyPayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
+	var payload currencyPayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	result, err := application.db.Exec(`UPDATE currencies SET name = ?, code = ? WHERE id = ?`, payload.Name, payload.Code, id)
+	updated, err := application.services().currencies.update(request.Context(), requestChange(request), id, payload)
 	if err != nil {
-		if isUniqueConstraintError(err) {
-			writeError(writer, http.StatusConflict, "duplicate_currency", "name and code must be unique")
-			return
-		}
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to update currency")
+		writeServiceError(writer, err, "failed to update currency")
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read update result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "currency not found")
-		return
-	}
-
-	updated := currency{ID: id, Name: payload.Name, Code: payload.Code}
-	writeJSON(writer, http.StatusOK, updated)
+	writeJSONWithETag(writer, http.StatusOK, updated)
 }
 
-func (application app) deleteCurrency(writer http.ResponseWriter, id int64) {
-	result, err := application.db.Exec(`DELETE FROM currencies WHERE id = ?`, id)
+func (application app) patchCurrency(writer http.ResponseWriter, request *http.Request, id int64) {
+	current, err := application.services().currencies.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to delete currency")
+		writeServiceError(writer, err, "failed to load currency")
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read delete result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "currency not found")
+	mergedRequest, ok := mergePatchRequest(writer, request, current)
+	if !ok {
 		return
 	}
 
-	writer.WriteHeader(http.StatusNoContent)
+	application.updateCurrency(writer, mergedRequest, id)
 }
 
-func decodeCurrencyPayload(request *http.Request) (currencyPayload, error) {
-	defer request.Body.Close()
-
-	var payload currencyPayload
-	if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
-		return currencyPayload{}, fmt.Errorf("request body must be valid JSON")
-	}
-
-	payload.Name = strings.TrimSpace(payload.Name)
-	payload.Code = strings.ToUpper(strings.TrimSpace(payload.Code))
-
-	if payload.Name == "" {
-		return currencyPayload{}, fmt.Errorf("name is required")
-	}
-	if payload.Code == "" {
-		return currencyPayload{}, fmt.Errorf("code is required")
+func (application app) deleteCurrency(writer http.ResponseWriter, request *http.Request, id int64) {
+	if err := application.services().currencies.delete(request.Context(), requestChange(request), id); err != nil {
+		writeServiceError(writer, err, "failed to delete currency")
+		return
 	}
 
-	return payload, nil
+	writer.WriteHeader(http.StatusNoContent)
 }
 
 func parseIDFromPath(path string, prefix string) (int64, error) {
@@ -0,0 +1,59 @@
+package backend
+
+import "context"
+
+type currencyRepository interface {
+	list(ctx context.Context) ([]currency, error)
+	get(ctx context.Context, id int64) (currency, error)
+	create(ctx context.Context, payload currencyPayload) (int64, error)
+	update(ctx context.Context, id int64, payload currencyPayload) error
+	// delete soft deletes the currency.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlCurrencyRepository struct {
+	source queryer
+}
+
+func (repository sqlCurrencyRepository) list(ctx context.Context) ([]currency, error) {
+	rows, err := repository.source.QueryContext(ctx, `SELECT id, name, code FROM currencies WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]currency, 0)
+	for rows.Next() {
+		var item currency
+		if scanErr := rows.Scan(&item.ID, &item.Name, &item.Code); scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlCurrencyRepository) get(ctx context.Context, id int64) (currency, error) {
+	var item currency
+	err := repository.source.QueryRowContext(ctx, `SELECT id, name, code FROM currencies WHERE id = ? AND deleted_at IS NULL`, id).Scan(&item.ID, &item.Name, &item.Code)
+	if err != nil {
+		return currency{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlCurrencyRepository) create(ctx context.Context, payload currencyPayload) (int64, error) {
+	return insertRow(ctx, repository.source, `INSERT INTO currencies(name, code) VALUES (?, ?)`, payload.Name, payload.Code)
+}
+
+func (repository sqlCurrencyRepository) update(ctx context.Context, id int64, payload currencyPayload) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE currencies SET name = ?, code = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, payload.Name, payload.Code, id)
+	return constraintError(err)
+}
+
+func (repository sqlCurrencyRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE currencies SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
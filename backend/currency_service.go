@@ -0,0 +1,126 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+type currencyService struct {
+	store dataStore
+}
+
+func (payload currencyPayload) normalize() (currencyPayload, error) {
+	payload.Name = strings.TrimSpace(payload.Name)
+	payload.Code = strings.ToUpper(strings.TrimSpace(payload.Code))
+
+	if payload.Name == "" {
+		return currencyPayload{}, invalidPayload("name is required")
+	}
+	if payload.Code == "" {
+		return currencyPayload{}, invalidPayload("code is required")
+	}
+
+	return payload, nil
+}
+
+func (service currencyService) list(ctx context.Context) ([]currency, error) {
+	return service.store.reads().currencies().list(ctx)
+}
+
+func (service currencyService) get(ctx context.Context, id int64) (currency, error) {
+	item, err := service.store.reads().currencies().get(ctx, id)
+	if err != nil {
+		return currency{}, orNotFound(err, "currency not found")
+	}
+
+	return item, nil
+}
+
+func (service currencyService) create(ctx context.Context, by change, payload currencyPayload) (currency, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return currency{}, err
+	}
+
+	var created currency
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		id, err := tx.currencies().create(ctx, payload)
+		if err != nil {
+			return duplicateCurrency(err)
+		}
+
+		created = currency{ID: id, Name: payload.Name, Code: payload.Code}
+		return tx.audit().record(ctx, by.record(auditEntityCurrencies, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return currency{}, err
+	}
+
+	return created, nil
+}
+
+func (service currencyService) update(ctx context.Context, by change, id int64, payload currencyPayload) (currency, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return currency{}, err
+	}
+
+	var updated currency
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.currencies().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "currency not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = tx.currencies().update(ctx, id, payload); err != nil {
+			return duplicateCurrency(err)
+		}
+
+		updated = currency{ID: id, Name: payload.Name, Code: payload.Code}
+		return tx.audit().record(ctx, by.record(auditEntityCurrencies, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return currency{}, err
+	}
+
+	return updated, nil
+}
+
+// delete soft deletes a currency nothing live refers to.
+func (service currencyService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.currencies().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "currency not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		inUse, err := tx.trash().hasDependents(ctx, auditEntityCurrencies, id)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return conflict("currency_in_use", "currency is in use")
+		}
+
+		if err = tx.currencies().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityCurrencies, id, auditActionDelete, existing, nil))
+	})
+}
+
+func duplicateCurrency(err error) error {
+	if errors.Is(err, errDuplicate) {
+		return conflict("duplicate_currency", "name and code must be unique")
+	}
+
+	return err
+}
@@ -2,116 +2,134 @@ package backend
 
 import (
 	"database/sql"
-	"errors"
 	"fmt"
 	"io/fs"
+	"net/url"
 	"os"
 	"path/filepath"
-	"sort"
-	"strings"
+	"runtime"
 
 	_ "modernc.org/sqlite"
 )
 
-func SetupDatabase(path string) (*sql.DB, error) {
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return nil, fmt.Errorf("create db directory: %w", err)
-	}
-
-	db, err := sql.Open("sqlite", path)
-	if err != nil {
-		return nil, fmt.Errorf("open db: %w", err)
-	}
+// connectionPragmas run on every new pooled connection, because SQLite keeps
+// these settings per connection (journal_mode is stored in the file, but
+// setting it on each connection is harmless). busy_timeout makes a connection
+// wait for a concurrent writer instead of failing with "database is locked".
+var connectionPragmas = []string{
+	"foreign_keys(1)",
+	"busy_timeout(5000)",
+	"journal_mode(WAL)",
+	"synchronous(NORMAL)",
+}
 
-	if err = db.Ping(); err != nil {
-		return nil, fmt.Errorf("ping db: %w", err)
-	}
+// DatabasePool separates writes from reads. SQLite allows a single writer at a
+// time, so Writer holds one connection and queues writers in Go instead of
+// letting them contend for the file lock. Reader holds several query-only
+// connections which, in WAL mode, read concurrently with the writer. On
+// PostgreSQL both pools keep the driver's defaults and Reader only refuses
+// writes.
+type DatabasePool struct {
+	Writer *sql.DB
+	Reader *sql.DB
+}
 
-	if _, err = db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		return nil, fmt.Errorf("enable foreign keys: %w", err)
+// SetupDatabase opens the database at path, a SQLite file or a postgres://
+// DSN, and applies the migrations for its engine found in migrationFiles.
+func SetupDatabase(path string, migrationFiles fs.FS) (*sql.DB, error) {
+	db, err := OpenDatabase(path)
+	if err != nil {
+		return nil, err
 	}
 
-	migrationsDir := resolveMigrationsDir()
-	if err = applyMigrations(db, migrationsDir); err != nil {
+	if _, err = applyMigrations(db, migrationFiles); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("apply migrations: %w", err)
 	}
 
 	return db, nil
 }
 
-func resolveMigrationsDir() string {
-	if info, err := os.Stat("migrations"); err == nil && info.IsDir() {
-		return "migrations"
+// SetupDatabasePool migrates the database at path like SetupDatabase and opens
+// the writer and reader pools the server uses.
+func SetupDatabasePool(path string, migrationFiles fs.FS) (DatabasePool, error) {
+	writer, err := SetupDatabase(path, migrationFiles)
+	if err != nil {
+		return DatabasePool{}, err
 	}
+	if IsPostgresDSN(path) {
+		reader, err := openPostgres(path, true)
+		if err != nil {
+			writer.Close()
+			return DatabasePool{}, err
+		}
 
-	fallback := filepath.Join("..", "migrations")
-	if info, err := os.Stat(fallback); err == nil && info.IsDir() {
-		return fallback
+		return DatabasePool{Writer: writer, Reader: reader}, nil
 	}
+	writer.SetMaxOpenConns(1)
+	writer.SetMaxIdleConns(1)
 
-	return "migrations"
+	reader, err := openConnections(path, true)
+	if err != nil {
+		writer.Close()
+		return DatabasePool{}, err
+	}
+	readers := max(4, runtime.NumCPU())
+	reader.SetMaxOpenConns(readers)
+	reader.SetMaxIdleConns(readers)
+
+	return DatabasePool{Writer: writer, Reader: reader}, nil
 }
 
-func applyMigrations(db *sql.DB, migrationsDir string) error {
-	if _, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS schema_migrations (
-			version TEXT PRIMARY KEY,
-			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
-		)
-	`); err != nil {
+// Close closes both pools.
+func (pool DatabasePool) Close() error {
+	readerErr := pool.Reader.Close()
+	if err := pool.Writer.Close(); err != nil {
 		return err
 	}
 
-	entries, err := os.ReadDir(migrationsDir)
-	if err != nil {
-		if errors.Is(err, fs.ErrNotExist) {
-			return nil
-		}
-		return err
-	}
+	return readerErr
+}
 
-	files := make([]string, 0, len(entries))
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
-			continue
-		}
-		files = append(files, entry.Name())
+// OpenDatabase opens the database at path without touching its schema. Paths
+// starting with postgres:// or postgresql:// open a PostgreSQL server, anything
+// else a SQLite file.
+func OpenDatabase(path string) (*sql.DB, error) {
+	if IsPostgresDSN(path) {
+		return openPostgres(path, false)
 	}
-	sort.Strings(files)
-
-	for _, file := range files {
-		var count int
-		if err = db.QueryRow("SELECT COUNT(1) FROM schema_migrations WHERE version = ?", file).Scan(&count); err != nil {
-			return err
-		}
-		if count > 0 {
-			continue
-		}
 
-		sqlBytes, readErr := os.ReadFile(filepath.Join(migrationsDir, file))
-		if readErr != nil {
-			return readErr
-		}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create db directory: %w", err)
+	}
 
-		tx, beginErr := db.Begin()
-		if beginErr != nil {
-			return beginErr
-		}
+	return openConnections(path, false)
+}
 
-		if _, execErr := tx.Exec(string(sqlBytes)); execErr != nil {
-			tx.Rollback()
-			return execErr
-		}
+// openConnections opens a pool whose connections all run connectionPragmas.
+// Writable pools begin transactions IMMEDIATE, so a write transaction takes the
+// write lock up front rather than failing when it upgrades from a read.
+// Read-only pools refuse writes with PRAGMA query_only.
+func openConnections(path string, readOnly bool) (*sql.DB, error) {
+	query := url.Values{}
+	for _, pragma := range connectionPragmas {
+		query.Add("_pragma", pragma)
+	}
+	if readOnly {
+		query.Add("_pragma", "query_only(1)")
+	} else {
+		query.Set("_txlock", "immediate")
+	}
 
-		if _, insertErr := tx.Exec("INSERT INTO schema_migrations(version) VALUES (?)", file); insertErr != nil {
-			tx.Rollback()
-			return insertErr
-		}
+	db, err := sql.Open("sqlite", path+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("open db: %w", err)
+	}
 
-		if commitErr := tx.Commit(); commitErr != nil {
-			return commitErr
-		}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ping db: %w", err)
 	}
 
-	return nil
+	return db, nil
 }
@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"personal-finances/migrations"
+)
+
+// holdConnections checks out count distinct connections from db at once, so
+// every one of them is a separate pooled connection.
+func holdConnections(t *testing.T, db *sql.DB, count int) []*sql.Conn {
+	t.Helper()
+	conns := make([]*sql.Conn, 0, count)
+	for range count {
+		conn, err := db.Conn(context.Background())
+		if err != nil {
+			t.Fatalf("check out connection: %v", err)
+		}
+		conns = append(conns, conn)
+	}
+	t.Cleanup(func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+
+	return conns
+}
+
+func pragmaValue(t *testing.T, conn *sql.Conn, name string) string {
+	t.Helper()
+	var value string
+	if err := conn.QueryRowContext(context.Background(), "PRAGMA "+name).Scan(&value); err != nil {
+		t.Fatalf("read PRAGMA %s: %v", name, err)
+	}
+	return value
+}
+
+func TestEveryPooledConnectionEnforcesForeignKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.db")
+	pool, err := SetupDatabasePool(path, migrations.Files)
+	if err != nil {
+		t.Fatalf("setup pool: %v", err)
+	}
+	defer pool.Close()
+
+	unlimited, err := OpenDatabase(path)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer unlimited.Close()
+
+	if stats := pool.Writer.Stats(); stats.MaxOpenConnections != 1 {
+		t.Fatalf("expected a single writer connection, got %d", stats.MaxOpenConnections)
+	}
+	readers := pool.Reader.Stats().MaxOpenConnections
+	if readers < 2 {
+		t.Fatalf("expected several reader connections, got %d", readers)
+	}
+
+	groups := []struct {
+		name     string
+		conns    []*sql.Conn
+		readOnly bool
+	}{
+		{"writer", holdConnections(t, pool.Writer, 1), false},
+		{"reader", holdConnections(t, pool.Reader, readers), true},
+		{"unlimited", holdConnections(t, unlimited, 8), false},
+	}
+
+	for _, group := range groups {
+		for index, conn := range group.conns {
+			label := fmt.Sprintf("%s connection %d", group.name, index)
+			expected := map[string]string{"foreign_keys": "1", "busy_timeout": "5000", "journal_mode": "wal", "synchronous": "1"}
+			for name, want := range expected {
+				if got := pragmaValue(t, conn, name); got != want {
+					t.Fatalf("%s: expected PRAGMA %s = %s, got %s", label, name, want, got)
+				}
+			}
+
+			_, err := conn.ExecContext(context.Background(), `INSERT INTO banks(name, country) VALUES (?, 'XX')`, label)
+			if err == nil {
+				t.Fatalf("%s: expected insert with an unknown country to fail", label)
+			}
+			if group.readOnly {
+				if !strings.Contains(err.Error(), "readonly") {
+					t.Fatalf("%s: expected read-only error, got %v", label, err)
+				}
+				if got := pragmaValue(t, conn, "query_only"); got != "1" {
+					t.Fatalf("%s: expected query_only, got %s", label, got)
+				}
+				continue
+			}
+			if !isForeignKeyConstraintError(err) {
+				t.Fatalf("%s: expected foreign key error, got %v", label, err)
+			}
+		}
+	}
+}
+
+func TestConcurrentWritesWaitForTheWriter(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	const writers = 24
+	var wait sync.WaitGroup
+	codes := make([]int, writers)
+	for index := range writers {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			body := fmt.Sprintf(`{"name":"Concurrent Person %d"}`, index)
+			codes[index] = performRequest(router, http.MethodPost, "/api/people", []byte(body)).Code
+			performRequest(router, http.MethodGet, "/api/people", nil)
+		}()
+	}
+	wait.Wait()
+
+	for index, code := range codes {
+		if code != http.StatusCreated {
+			t.Fatalf("expected concurrent create %d to succeed, got %d", index, code)
+		}
+	}
+
+	var count int
+	if err := application.db.QueryRow(`SELECT COUNT(*) FROM people WHERE name LIKE 'Concurrent Person %'`).Scan(&count); err != nil {
+		t.Fatalf("count people: %v", err)
+	}
+	if count != writers {
+		t.Fatalf("expected %d people, got %d", writers, count)
+	}
+}
@@ -0,0 +1,145 @@
+package backend
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// dialect describes what differs between the database engines the app runs
+// on. Queries are written once in the SQL both engines understand, with ?
+// placeholders that the PostgreSQL connections rewrite to $1, $2 and so on.
+// The schema differs more, so every engine has its own migration files.
+type dialect struct {
+	name string
+	// migrationsDir holds the engine's migrations inside the migration file
+	// system, "." for the top level.
+	migrationsDir string
+	// migrationTable creates schema_migrations.
+	migrationTable string
+	// tableExists and columnExists take the table (and column) name as
+	// arguments and return a count.
+	tableExists  string
+	columnExists string
+	// migrationSetup and migrationTeardown run on the migration connection
+	// before and after migrating.
+	migrationSetup    string
+	migrationTeardown string
+	// checksForeignKeys reports whether runMigrationStep has to look for
+	// foreign key violations itself before committing.
+	checksForeignKeys     bool
+	isUniqueViolation     func(err error) bool
+	isForeignKeyViolation func(err error) bool
+}
+
+// sqliteDialect follows SQLite's procedure for rebuilding tables: foreign
+// keys are off while migrating and checked with PRAGMA foreign_key_check
+// before each migration commits.
+var sqliteDialect = dialect{
+	name:          "sqlite",
+	migrationsDir: ".",
+	migrationTable: `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			checksum TEXT
+		)
+	`,
+	tableExists:       `SELECT COUNT(1) FROM sqlite_master WHERE type = 'table' AND name = ?`,
+	columnExists:      `SELECT COUNT(1) FROM pragma_table_info(?) WHERE name = ?`,
+	migrationSetup:    `PRAGMA foreign_keys = OFF`,
+	migrationTeardown: `PRAGMA foreign_keys = ON`,
+	checksForeignKeys: true,
+	isUniqueViolation: func(err error) bool {
+		return strings.Contains(strings.ToLower(err.Error()), "unique constraint failed")
+	},
+	isForeignKeyViolation: func(err error) bool {
+		return strings.Contains(strings.ToLower(err.Error()), "foreign key constraint failed")
+	},
+}
+
+// postgresDialect migrates with foreign keys enforced: PostgreSQL alters
+// tables in place, so nothing needs to be rebuilt.
+var postgresDialect = dialect{
+	name:          "postgres",
+	migrationsDir: "postgres",
+	migrationTable: `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			checksum TEXT
+		)
+	`,
+	tableExists:  `SELECT COUNT(1) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?`,
+	columnExists: `SELECT COUNT(1) FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?`,
+	isUniqueViolation: func(err error) bool {
+		return postgresErrorCode(err) == "23505"
+	},
+	isForeignKeyViolation: func(err error) bool {
+		return postgresErrorCode(err) == "23503"
+	},
+}
+
+var dialects = []dialect{sqliteDialect, postgresDialect}
+
+// dialectOf returns the dialect of the engine behind db.
+func dialectOf(db *sql.DB) dialect {
+	if _, ok := db.Driver().(postgresDriver); ok {
+		return postgresDialect
+	}
+
+	return sqliteDialect
+}
+
+// migrationFiles returns the dialect's part of the migration file system.
+func (engine dialect) migrationFiles(files fs.FS) (fs.FS, error) {
+	if engine.migrationsDir == "." {
+		return files, nil
+	}
+
+	sub, err := fs.Sub(files, engine.migrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("%s migrations: %w", engine.name, err)
+	}
+
+	return sub, nil
+}
+
+// IsPostgresDSN reports whether the database setting names a PostgreSQL
+// server rather than a SQLite file.
+func IsPostgresDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// postgresErrorCode returns the SQLSTATE of a PostgreSQL error, which the
+// common drivers expose through a SQLState method.
+func postgresErrorCode(err error) string {
+	var coded interface{ SQLState() string }
+	if errors.As(err, &coded) {
+		return coded.SQLState()
+	}
+
+	return ""
+}
+
+func isUniqueConstraintError(err error) bool {
+	for _, engine := range dialects {
+		if engine.isUniqueViolation(err) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isForeignKeyConstraintError(err error) bool {
+	for _, engine := range dialects {
+		if engine.isForeignKeyViolation(err) {
+			return true
+		}
+	}
+
+	return false
+}
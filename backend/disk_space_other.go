@@ -0,0 +1,7 @@
+//go:build !unix
+
+package backend
+
+func freeDiskBytes(_ string) (uint64, error) {
+	return 0, errDiskSpaceUnsupported
+}
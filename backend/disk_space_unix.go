@@ -0,0 +1,16 @@
+//go:build unix
+
+package backend
+
+import "syscall"
+
+// freeDiskBytes returns the space available to unprivileged users on the
+// filesystem holding path.
+func freeDiskBytes(path string) (uint64, error) {
+	var stats syscall.Statfs_t
+	if err := syscall.Statfs(path, &stats); err != nil {
+		return 0, err
+	}
+
+	return stats.Bavail * uint64(stats.Bsize), nil
+}
@@ -0,0 +1,435 @@
+package backend
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	encryptedFieldPrefix = "enc:v1:"
+	fieldKeyVerifier     = "personal-finances field encryption"
+	fieldKeySaltBytes    = 16
+	maskedFieldPrefix    = "**** "
+)
+
+// fieldKeyCost is the argon2id cost of new keys, the second recommended option
+// of RFC 9106. Existing keys keep the cost they were created with.
+var fieldKeyCost = fieldKeyDerivation{Iterations: 3, MemoryKiB: 64 * 1024, Parallelism: 4}
+
+// fieldKeyDerivation is the argon2id cost a key was stretched from its
+// passphrase with, as stored in encryption_keys.
+type fieldKeyDerivation struct {
+	Iterations  int
+	MemoryKiB   int
+	Parallelism int
+}
+
+// ErrEncryptionPassphraseRequired is returned when the database holds
+// encrypted fields but no passphrase was supplied.
+var ErrEncryptionPassphraseRequired = errors.New("database has encrypted fields: set ENCRYPTION_PASSPHRASE")
+
+// encryptedColumn is a sensitive column stored encrypted while field
+// encryption is enabled. store rewrites one row's value under a new cipher
+// (nil for plaintext), including the keyed lookup hash that lets unique
+// indexes work on ciphertext.
+type encryptedColumn struct {
+	Table  string
+	Column string
+	store  func(ctx context.Context, tx *sql.Tx, to *FieldCipher, id int64, plaintext string) error
+}
+
+var encryptedColumns = []encryptedColumn{
+	{Table: auditEntityBankAccounts, Column: "account_number", store: storeBankAccountNumber},
+	{Table: auditEntityCreditCards, Column: "number", store: storeCreditCardNumber},
+}
+
+// FieldCipher encrypts sensitive columns with AES-256-GCM under a key derived
+// from a passphrase. A nil *FieldCipher means field encryption is disabled:
+// values are stored and returned as plaintext.
+type FieldCipher struct {
+	keyID     int64
+	aead      cipher.AEAD
+	lookupKey []byte
+}
+
+// LoadFieldCipher derives the active field key from passphrase. It returns
+// nil when field encryption is not enabled for db.
+func LoadFieldCipher(ctx context.Context, db *sql.DB, passphrase string) (*FieldCipher, error) {
+	fields, err := loadActiveFieldCipher(ctx, db, passphrase)
+	if errors.Is(err, sql.ErrNoRows) {
+		if passphrase != "" {
+			return nil, fmt.Errorf("field encryption is not enabled for this database")
+		}
+		return nil, nil
+	}
+
+	return fields, err
+}
+
+// FieldEncryptionEnabled reports whether db has an active field key.
+func FieldEncryptionEnabled(ctx context.Context, db *sql.DB) (bool, error) {
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(1) FROM encryption_keys WHERE retired_at IS NULL`).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// EnableFieldEncryption creates a field key from passphrase and encrypts every
+// sensitive column, including soft-deleted rows.
+func EnableFieldEncryption(ctx context.Context, db *sql.DB, passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("passphrase must not be empty")
+	}
+
+	return reencryptFields(ctx, db, func(tx *sql.Tx) (*FieldCipher, *FieldCipher, error) {
+		if _, err := activeFieldKeyID(ctx, tx); !errors.Is(err, sql.ErrNoRows) {
+			if err == nil {
+				return nil, nil, fmt.Errorf("field encryption is already enabled")
+			}
+			return nil, nil, err
+		}
+
+		next, err := createFieldKey(ctx, tx, passphrase)
+		return nil, next, err
+	})
+}
+
+// RotateFieldEncryption re-encrypts every sensitive column under a new key
+// derived from newPassphrase with a fresh salt, and retires the old key.
+// Passing the current passphrase again rotates the key without changing it.
+func RotateFieldEncryption(ctx context.Context, db *sql.DB, passphrase string, newPassphrase string) error {
+	if newPassphrase == "" {
+		return fmt.Errorf("new passphrase must not be empty")
+	}
+
+	return reencryptFields(ctx, db, func(tx *sql.Tx) (*FieldCipher, *FieldCipher, error) {
+		current, err := openActiveFieldKey(ctx, tx, passphrase)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err = tx.ExecContext(ctx, `UPDATE encryption_keys SET retired_at = CURRENT_TIMESTAMP WHERE id = ?`, current.keyID); err != nil {
+			return nil, nil, err
+		}
+
+		next, err := createFieldKey(ctx, tx, newPassphrase)
+		return current, next, err
+	})
+}
+
+// DisableFieldEncryption decrypts every sensitive column back to plaintext and
+// retires the active key.
+func DisableFieldEncryption(ctx context.Context, db *sql.DB, passphrase string) error {
+	return reencryptFields(ctx, db, func(tx *sql.Tx) (*FieldCipher, *FieldCipher, error) {
+		current, err := openActiveFieldKey(ctx, tx, passphrase)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err = tx.ExecContext(ctx, `UPDATE encryption_keys SET retired_at = CURRENT_TIMESTAMP WHERE id = ?`, current.keyID); err != nil {
+			return nil, nil, err
+		}
+
+		return current, nil, nil
+	})
+}
+
+// reencryptFields rewrites every sensitive value from the cipher returned as
+// from to the one returned as to, in a single transaction. Either may be nil
+// for plaintext.
+func reencryptFields(ctx context.Context, db *sql.DB, keys func(tx *sql.Tx) (*FieldCipher, *FieldCipher, error)) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	from, to, err := keys(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, column := range encryptedColumns {
+		if err = reencryptColumn(ctx, tx, column, from, to); err != nil {
+			return fmt.Errorf("%s.%s: %w", column.Table, column.Column, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func reencryptColumn(ctx context.Context, tx *sql.Tx, column encryptedColumn, from *FieldCipher, to *FieldCipher) error {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT id, %s FROM %s WHERE %s IS NOT NULL`, column.Column, column.Table, column.Column))
+	if err != nil {
+		return err
+	}
+
+	type storedValue struct {
+		id    int64
+		value string
+	}
+	values := make([]storedValue, 0)
+	for rows.Next() {
+		var item storedValue
+		if err = rows.Scan(&item.id, &item.value); err != nil {
+			rows.Close()
+			return err
+		}
+		values = append(values, item)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, item := range values {
+		plaintext, openErr := from.open(column.field(), item.value)
+		if openErr != nil {
+			return openErr
+		}
+
+		if err = column.store(ctx, tx, to, item.id, plaintext); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func storeBankAccountNumber(ctx context.Context, tx *sql.Tx, to *FieldCipher, id int64, plaintext string) error {
+	sealed, lookup, err := to.seal(bankAccountNumberField, plaintext)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE bank_accounts SET account_number = ?, account_number_lookup = ? WHERE id = ?`, sealed, lookup, id)
+	return err
+}
+
+// storeCreditCardNumber drops the full number when encryption is turned off,
+// since card numbers are only kept in encrypted form.
+func storeCreditCardNumber(ctx context.Context, tx *sql.Tx, to *FieldCipher, id int64, plaintext string) error {
+	number := cardNumber{Digits: plaintext, Last4: plaintext, Network: detectCardNetwork(plaintext)}
+	if len(plaintext) > 4 {
+		number.Last4 = plaintext[len(plaintext)-4:]
+	}
+
+	stored, lookup, err := storeCardNumber(to, number)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE credit_cards SET number = ?, number_lookup = ?, last4 = ?, network = ? WHERE id = ?`, stored, lookup, number.Last4, number.Network, id)
+	return err
+}
+
+func (column encryptedColumn) field() string {
+	return column.Table + "." + column.Column
+}
+
+func loadActiveFieldCipher(ctx context.Context, db *sql.DB, passphrase string) (*FieldCipher, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err = activeFieldKeyID(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	return openActiveFieldKey(ctx, tx, passphrase)
+}
+
+func activeFieldKeyID(ctx context.Context, source queryer) (int64, error) {
+	var id int64
+	err := source.QueryRowContext(ctx, `SELECT id FROM encryption_keys WHERE retired_at IS NULL`).Scan(&id)
+	return id, err
+}
+
+// openActiveFieldKey derives the active key from passphrase and checks it
+// against the stored verifier, so a wrong passphrase is reported up front
+// instead of as failures on individual fields.
+func openActiveFieldKey(ctx context.Context, source queryer, passphrase string) (*FieldCipher, error) {
+	var id int64
+	var encodedSalt, verifier string
+	var derivation fieldKeyDerivation
+	err := source.QueryRowContext(
+		ctx,
+		`SELECT id, salt, iterations, memory_kib, parallelism, verifier FROM encryption_keys WHERE retired_at IS NULL`,
+	).Scan(&id, &encodedSalt, &derivation.Iterations, &derivation.MemoryKiB, &derivation.Parallelism, &verifier)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("field encryption is not enabled")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == "" {
+		return nil, ErrEncryptionPassphraseRequired
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(encodedSalt)
+	if err != nil {
+		return nil, fmt.Errorf("decode key salt: %w", err)
+	}
+
+	fields, err := deriveFieldCipher(id, passphrase, salt, derivation)
+	if err != nil {
+		return nil, err
+	}
+
+	if value, openErr := fields.open("encryption_keys.verifier", verifier); openErr != nil || value != fieldKeyVerifier {
+		return nil, fmt.Errorf("wrong encryption passphrase")
+	}
+
+	return fields, nil
+}
+
+func createFieldKey(ctx context.Context, tx *sql.Tx, passphrase string) (*FieldCipher, error) {
+	salt := make([]byte, fieldKeySaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	// The verifier is sealed with the new key, whose id is only known after the
+	// insert, so the row is written first with a placeholder.
+	id, err := insertRow(
+		ctx,
+		tx,
+		`INSERT INTO encryption_keys(salt, iterations, memory_kib, parallelism, verifier) VALUES (?, ?, ?, ?, '')`,
+		base64.StdEncoding.EncodeToString(salt),
+		fieldKeyCost.Iterations,
+		fieldKeyCost.MemoryKiB,
+		fieldKeyCost.Parallelism,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := deriveFieldCipher(id, passphrase, salt, fieldKeyCost)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, _, err := fields.seal("encryption_keys.verifier", fieldKeyVerifier)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE encryption_keys SET verifier = ? WHERE id = ?`, verifier, id); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+// deriveFieldCipher stretches passphrase into an AES-256 key for the values
+// and a separate HMAC key for the lookup hashes.
+func deriveFieldCipher(id int64, passphrase string, salt []byte, derivation fieldKeyDerivation) (*FieldCipher, error) {
+	material, err := derivation.key(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(material[:32])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FieldCipher{keyID: id, aead: aead, lookupKey: material[32:]}, nil
+}
+
+func (derivation fieldKeyDerivation) key(passphrase string, salt []byte) ([]byte, error) {
+	if derivation.Iterations < 1 || derivation.MemoryKiB < 1 || derivation.Parallelism < 1 || derivation.Parallelism > 255 {
+		return nil, fmt.Errorf("invalid argon2id key cost %+v", derivation)
+	}
+
+	return argon2.IDKey([]byte(passphrase), salt, uint32(derivation.Iterations), uint32(derivation.MemoryKiB), uint8(derivation.Parallelism), 64), nil
+}
+
+// seal encrypts value for field and returns the stored form with its lookup
+// hash. The field name is bound as additional data, so a value copied into
+// another column does not decrypt. A nil cipher returns value unchanged and a
+// NULL lookup.
+func (fields *FieldCipher) seal(field string, value string) (string, sql.NullString, error) {
+	if fields == nil {
+		return value, sql.NullString{}, nil
+	}
+
+	nonce := make([]byte, fields.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", sql.NullString{}, err
+	}
+
+	sealed := fields.aead.Seal(nonce, nonce, []byte(value), []byte(field))
+	stored := encryptedFieldPrefix + strconv.FormatInt(fields.keyID, 10) + ":" + base64.StdEncoding.EncodeToString(sealed)
+
+	mac := hmac.New(sha256.New, fields.lookupKey)
+	mac.Write([]byte(field + "\x00" + value))
+	lookup := sql.NullString{String: hex.EncodeToString(mac.Sum(nil)), Valid: true}
+
+	return stored, lookup, nil
+}
+
+// open returns the plaintext of a stored value. Plaintext values pass through,
+// so rows written before encryption was enabled stay readable.
+func (fields *FieldCipher) open(field string, stored string) (string, error) {
+	if !strings.HasPrefix(stored, encryptedFieldPrefix) {
+		return stored, nil
+	}
+	if fields == nil {
+		return "", ErrEncryptionPassphraseRequired
+	}
+
+	keyPart, payload, ok := strings.Cut(strings.TrimPrefix(stored, encryptedFieldPrefix), ":")
+	if !ok || keyPart != strconv.FormatInt(fields.keyID, 10) {
+		return "", fmt.Errorf("%s was encrypted with another key", field)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < fields.aead.NonceSize() {
+		return "", fmt.Errorf("%s is not a valid encrypted value", field)
+	}
+
+	nonceSize := fields.aead.NonceSize()
+	plaintext, err := fields.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(field))
+	if err != nil {
+		return "", fmt.Errorf("%s failed to decrypt", field)
+	}
+
+	return string(plaintext), nil
+}
+
+// maskSensitive hides all but the last four characters of value.
+func maskSensitive(value string) string {
+	runes := []rune(value)
+	if len(runes) <= 4 {
+		return maskedFieldPrefix[:4]
+	}
+
+	return maskedFieldPrefix + string(runes[len(runes)-4:])
+}
+
+// revealRequested reports whether a GET asked for unmasked values with
+// ?reveal=true.
+func revealRequested(query map[string][]string) bool {
+	values := query["reveal"]
+	return len(values) > 0 && values[len(values)-1] == "true"
+}
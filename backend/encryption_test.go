@@ -0,0 +1,210 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func useFastFieldKeys(t *testing.T) {
+	t.Helper()
+	original := fieldKeyCost
+	fieldKeyCost = fieldKeyDerivation{Iterations: 1, MemoryKiB: 64, Parallelism: 1}
+	t.Cleanup(func() {
+		fieldKeyCost = original
+	})
+}
+
+func TestFieldEncryptionMasksAndRoundTrips(t *testing.T) {
+	useFastFieldKeys(t)
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+
+	if err := EnableFieldEncryption(context.Background(), application.db, "first secret"); err != nil {
+		t.Fatalf("enable encryption: %v", err)
+	}
+
+	var stored string
+	var lookup *string
+	if err := application.db.QueryRow(`SELECT account_number, account_number_lookup FROM bank_accounts WHERE id = 1`).Scan(&stored, &lookup); err != nil {
+		t.Fatalf("load stored account number: %v", err)
+	}
+	if !strings.HasPrefix(stored, encryptedFieldPrefix) || strings.Contains(stored, "ACC-001") || lookup == nil {
+		t.Fatalf("expected encrypted account number with lookup hash, got %q", stored)
+	}
+
+	if _, err := LoadFieldCipher(context.Background(), application.db, ""); !errors.Is(err, ErrEncryptionPassphraseRequired) {
+		t.Fatalf("expected missing passphrase to be rejected, got %v", err)
+	}
+	if _, err := LoadFieldCipher(context.Background(), application.db, "wrong"); err == nil {
+		t.Fatal("expected wrong passphrase to be rejected")
+	}
+
+	fields, err := LoadFieldCipher(context.Background(), application.db, "first secret")
+	if err != nil {
+		t.Fatalf("load field cipher: %v", err)
+	}
+	application.fields = fields
+	router = application.routes()
+
+	listResponse := performRequest(router, http.MethodGet, "/api/bank-accounts", nil)
+	var accounts []bankAccount
+	if err = json.NewDecoder(listResponse.Body).Decode(&accounts); err != nil {
+		t.Fatalf("decode bank accounts: %v", err)
+	}
+	if len(accounts) != 1 || accounts[0].AccountNumber != "**** -001" {
+		t.Fatalf("expected masked account number, got %+v", accounts)
+	}
+
+	revealResponse := performRequest(router, http.MethodGet, "/api/bank-accounts/1?reveal=true", nil)
+	var revealed bankAccount
+	if err = json.NewDecoder(revealResponse.Body).Decode(&revealed); err != nil {
+		t.Fatalf("decode revealed bank account: %v", err)
+	}
+	if revealed.AccountNumber != "ACC-001" {
+		t.Fatalf("expected reveal to return the full account number, got %q", revealed.AccountNumber)
+	}
+
+	maskedResponse := performRequest(router, http.MethodGet, "/api/bank-accounts/1", nil)
+	accountTag, err := entityTag(bankAccount{ID: 1, BankID: 1, CurrencyID: 1, AccountNumber: "**** -001", Balance: revealed.Balance})
+	if err != nil || revealResponse.Header().Get("ETag") != accountTag {
+		t.Fatalf("expected the bank account ETag to be computed without the account number, got %q", revealResponse.Header().Get("ETag"))
+	}
+
+	echoMasked := performRequestWithHeaders(
+		router,
+		http.MethodPut,
+		"/api/bank-accounts/1",
+		[]byte(`{"bank_id":1,"currency_id":1,"account_number":"**** -001","balance":250}`),
+		map[string]string{"If-Match": maskedResponse.Header().Get("ETag")},
+	)
+	if echoMasked.Code != http.StatusOK {
+		t.Fatalf("expected update echoing the masked number to return 200, got %d", echoMasked.Code)
+	}
+	current, err := sqlBankAccountRepository{source: application.db, fields: fields}.get(context.Background(), 1)
+	if err != nil || current.AccountNumber != "ACC-001" || current.Balance != 250 {
+		t.Fatalf("expected masked echo to keep the account number, got %+v %v", current, err)
+	}
+
+	duplicate := performRequest(router, http.MethodPost, "/api/bank-accounts", []byte(`{"bank_id":1,"currency_id":1,"account_number":"ACC-001","balance":0}`))
+	if duplicate.Code != http.StatusConflict {
+		t.Fatalf("expected duplicate encrypted account number to return 409, got %d", duplicate.Code)
+	}
+
+	card := performRequest(router, http.MethodPost, "/api/credit-cards", []byte(`{"bank_id":1,"person_id":1,"number":"4111111111111111"}`))
+	if card.Code != http.StatusCreated || !strings.Contains(card.Body.String(), `"**** 1111"`) {
+		t.Fatalf("expected masked card in create response, got %d %s", card.Code, card.Body.String())
+	}
+	revealedCard := performRequest(router, http.MethodGet, "/api/credit-cards/1?reveal=true", nil)
+	if !strings.Contains(revealedCard.Body.String(), `"number":"4111111111111111"`) {
+		t.Fatalf("expected reveal to return the encrypted card number, got %s", revealedCard.Body.String())
+	}
+	maskedCardTag, err := entityTag(creditCard{ID: 1, BankID: 1, PersonID: 1, Number: "**** 1111", Last4: "1111", Network: "visa"})
+	if err != nil || revealedCard.Header().Get("ETag") != maskedCardTag {
+		t.Fatalf("expected the card ETag to be computed without the full number, got %q", revealedCard.Header().Get("ETag"))
+	}
+
+	var leaked int
+	if err = application.db.QueryRow(`SELECT COUNT(1) FROM audit_events WHERE changes LIKE '%4111111111111111%' OR changes LIKE '%ACC-001%'`).Scan(&leaked); err != nil {
+		t.Fatalf("search audit events: %v", err)
+	}
+	if leaked != 0 {
+		t.Fatalf("expected every audit event to hold masked numbers, found %d", leaked)
+	}
+
+	if err = RotateFieldEncryption(context.Background(), application.db, "first secret", "second secret"); err != nil {
+		t.Fatalf("rotate key: %v", err)
+	}
+	if _, err = LoadFieldCipher(context.Background(), application.db, "first secret"); err == nil {
+		t.Fatal("expected the old passphrase to stop working after rotation")
+	}
+	rotated, err := LoadFieldCipher(context.Background(), application.db, "second secret")
+	if err != nil {
+		t.Fatalf("load rotated cipher: %v", err)
+	}
+	if current, err = (sqlBankAccountRepository{source: application.db, fields: rotated}).get(context.Background(), 1); err != nil || current.AccountNumber != "ACC-001" {
+		t.Fatalf("expected rotated key to decrypt, got %+v %v", current, err)
+	}
+
+	if err = DisableFieldEncryption(context.Background(), application.db, "second secret"); err != nil {
+		t.Fatalf("disable encryption: %v", err)
+	}
+	var cardNumberAfter *string
+	var cardLast4 string
+	if err = application.db.QueryRow(`SELECT number, last4 FROM credit_cards WHERE id = 1`).Scan(&cardNumberAfter, &cardLast4); err != nil {
+		t.Fatalf("load card after disabling: %v", err)
+	}
+	if cardNumberAfter != nil || cardLast4 != "1111" {
+		t.Fatalf("expected disabling to keep only the last four card digits, got %v %q", cardNumberAfter, cardLast4)
+	}
+	if fields, err = LoadFieldCipher(context.Background(), application.db, ""); err != nil || fields != nil {
+		t.Fatalf("expected no cipher once disabled, got %v %v", fields, err)
+	}
+}
+
+func TestFieldCipherBindsValuesToTheirColumn(t *testing.T) {
+	useFastFieldKeys(t)
+	application := newTestApplication(t)
+
+	if err := EnableFieldEncryption(context.Background(), application.db, "secret"); err != nil {
+		t.Fatalf("enable encryption: %v", err)
+	}
+	if err := EnableFieldEncryption(context.Background(), application.db, "secret"); err == nil {
+		t.Fatal("expected enabling twice to fail")
+	}
+
+	fields, err := LoadFieldCipher(context.Background(), application.db, "secret")
+	if err != nil {
+		t.Fatalf("load field cipher: %v", err)
+	}
+
+	sealed, _, err := fields.seal(creditCardNumberField, "4111111111111111")
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if _, err = fields.open(bankAccountNumberField, sealed); err == nil {
+		t.Fatal("expected a value sealed for one column to fail in another")
+	}
+
+	var nilCipher *FieldCipher
+	if _, err = nilCipher.open(creditCardNumberField, sealed); !errors.Is(err, ErrEncryptionPassphraseRequired) {
+		t.Fatalf("expected encrypted value without a cipher to need the passphrase, got %v", err)
+	}
+
+	if maskSensitive("12") != "****" || maskSensitive("4111111111111111") != "**** 1111" {
+		t.Fatal("unexpected masking")
+	}
+}
+
+func TestFieldKeysKeepTheirCost(t *testing.T) {
+	useFastFieldKeys(t)
+	application := newTestApplication(t)
+	ctx := context.Background()
+
+	if err := EnableFieldEncryption(ctx, application.db, "secret"); err != nil {
+		t.Fatalf("enable encryption: %v", err)
+	}
+
+	fieldKeyCost = fieldKeyDerivation{Iterations: 2, MemoryKiB: 128, Parallelism: 2}
+	if _, err := LoadFieldCipher(ctx, application.db, "secret"); err != nil {
+		t.Fatalf("expected a key to open after the cost of new keys changed, got %v", err)
+	}
+
+	if err := RotateFieldEncryption(ctx, application.db, "secret", "secret"); err != nil {
+		t.Fatalf("rotate key: %v", err)
+	}
+	var stored fieldKeyDerivation
+	if err := application.db.QueryRow(
+		`SELECT iterations, memory_kib, parallelism FROM encryption_keys WHERE retired_at IS NULL`,
+	).Scan(&stored.Iterations, &stored.MemoryKiB, &stored.Parallelism); err != nil || stored != fieldKeyCost {
+		t.Fatalf("expected rotation to record the current key cost, got %+v %v", stored, err)
+	}
+	if _, err := LoadFieldCipher(ctx, application.db, "secret"); err != nil {
+		t.Fatalf("load rotated key: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const (
+	etagHeader    = "ETag"
+	ifMatchHeader = "If-Match"
+)
+
+// taggedView is implemented by resources holding secrets. Their tag is derived
+// from the returned view so it cannot be used to recover the secret.
+type taggedView interface {
+	tagView() any
+}
+
+// entityTag derives a strong ETag from the JSON representation of a resource,
+// so any change made by another client produces a different tag.
+func entityTag(item any) (string, error) {
+	if view, ok := item.(taggedView); ok {
+		item = view.tagView()
+	}
+
+	encoded, err := json.Marshal(item)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(encoded)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+func writeJSONWithETag(writer http.ResponseWriter, status int, item any) {
+	if tag, err := entityTag(item); err == nil {
+		writer.Header().Set(etagHeader, tag)
+	}
+
+	writeJSON(writer, status, item)
+}
+
+// checkIfMatch compares the If-Match request header against the current
+// representation of a resource. Requests without the header are allowed.
+// On mismatch it writes a 412 response and returns false.
+func checkIfMatch(writer http.ResponseWriter, request *http.Request, current any) bool {
+	if err := requestChange(request).checkVersion(current); err != nil {
+		writeServiceError(writer, err, "failed to compute entity tag")
+		return false
+	}
+
+	return true
+}
+
+// ifMatchSatisfied applies the strong comparison required for If-Match: weak
+// tags never match and "*" matches any existing resource.
+func ifMatchSatisfied(header string, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+
+	return false
+}
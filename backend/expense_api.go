@@ -1,13 +1,8 @@
 package backend
 
 import (
-	"database/sql"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
-	"slices"
-	"strings"
 )
 
 const (
@@ -33,12 +28,13 @@ type expensePayload struct {
 func (application app) registerExpenseRoutes(mux *http.ServeMux) {
 	mux.HandleFunc(expensesPath, application.expensesHandler)
 	mux.HandleFunc(expensesPathByID, application.expenseByIDHandler)
+	mux.HandleFunc(expensesPath+batchPathSuffix, application.batchHandler(expensesPath, app.expensesHandler, app.expenseByIDHandler))
 }
 
 func (application app) expensesHandler(writer http.ResponseWriter, request *http.Request) {
 	switch request.Method {
 	case http.MethodGet:
-		application.listExpenses(writer)
+		application.listExpenses(writer, request)
 	case http.MethodPost:
 		application.createExpense(writer, request)
 	default:
@@ -55,156 +51,89 @@ func (application app) expenseByIDHandler(writer http.ResponseWriter, request *h
 
 	switch request.Method {
 	case http.MethodGet:
-		application.getExpense(writer, id)
+		application.getExpense(writer, request, id)
 	case http.MethodPut:
 		application.updateExpense(writer, request, id)
+	case http.MethodPatch:
+		application.patchExpense(writer, request, id)
 	case http.MethodDelete:
-		application.deleteExpense(writer, id)
+		application.deleteExpense(writer, request, id)
 	default:
-		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodDelete)
+		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
 	}
 }
 
-func (application app) listExpenses(writer http.ResponseWriter) {
-	rows, err := application.db.Query(`SELECT id, name, frequency FROM expenses ORDER BY id`)
+func (application app) listExpenses(writer http.ResponseWriter, request *http.Request) {
+	items, err := application.services().expenses.list(request.Context())
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load expenses")
-		return
-	}
-	defer rows.Close()
-
-	items := make([]expense, 0)
-	for rows.Next() {
-		var item expense
-		if scanErr := rows.Scan(&item.ID, &item.Name, &item.Frequency); scanErr != nil {
-			writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read expenses")
-			return
-		}
-		items = append(items, item)
-	}
-
-	if err = rows.Err(); err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read expenses")
+		writeServiceError(writer, err, "failed to load expenses")
 		return
 	}
 
 	writeJSON(writer, http.StatusOK, items)
 }
 
-func (application app) getExpense(writer http.ResponseWriter, id int64) {
-	var item expense
-	err := application.db.QueryRow(`SELECT id, name, frequency FROM expenses WHERE id = ?`, id).Scan(&item.ID, &item.Name, &item.Frequency)
-	if errors.Is(err, sql.ErrNoRows) {
-		writeError(writer, http.StatusNotFound, "not_found", "expense not found")
-		return
-	}
+func (application app) getExpense(writer http.ResponseWriter, request *http.Request, id int64) {
+	item, err := application.services().expenses.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load expense")
+		writeServiceError(writer, err, "failed to load expense")
 		return
 	}
 
-	writeJSON(writer, http.StatusOK, item)
+	writeJSONWithETag(writer, http.StatusOK, item)
 }
 
 func (application app) createExpense(writer http.ResponseWriter, request *http.Request) {
-	payload, validationErr := decodeExpensePayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
-		return
-	}
-
-	result, err := application.db.Exec(`INSERT INTO expenses(name, frequency) VALUES (?, ?)`, payload.Name, payload.Frequency)
-	if err != nil {
-		if isUniqueConstraintError(err) {
-			writeError(writer, http.StatusConflict, duplicateExpenseCode, "expense name must be unique")
-			return
-		}
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to create expense")
+	var payload expensePayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	id, err := result.LastInsertId()
+	created, err := application.services().expenses.create(request.Context(), requestChange(request), payload)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read created expense id")
+		writeServiceError(writer, err, "failed to create expense")
 		return
 	}
 
-	created := expense{ID: id, Name: payload.Name, Frequency: payload.Frequency}
-	writer.Header().Set("Location", fmt.Sprintf(expensePathPattern, id))
-	writeJSON(writer, http.StatusCreated, created)
+	writer.Header().Set("Location", fmt.Sprintf(expensePathPattern, created.ID))
+	writeJSONWithETag(writer, http.StatusCreated, created)
 }
 
 func (application app) updateExpense(writer http.ResponseWriter, request *http.Request, id int64) {
-	payload, validationErr := decodeExpensePayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
+	var payload expensePayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	result, err := application.db.Exec(`UPDATE expenses SET name = ?, frequency = ? WHERE id = ?`, payload.Name, payload.Frequency, id)
+	updated, err := application.services().expenses.update(request.Context(), requestChange(request), id, payload)
 	if err != nil {
-		if isUniqueConstraintError(err) {
-			writeError(writer, http.StatusConflict, duplicateExpenseCode, "expense name must be unique")
-			return
-		}
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to update expense")
+		writeServiceError(writer, err, "failed to update expense")
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read update result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "expense not found")
-		return
-	}
-
-	updated := expense{ID: id, Name: payload.Name, Frequency: payload.Frequency}
-	writeJSON(writer, http.StatusOK, updated)
+	writeJSONWithETag(writer, http.StatusOK, updated)
 }
 
-func (application app) deleteExpense(writer http.ResponseWriter, id int64) {
-	result, err := application.db.Exec(`DELETE FROM expenses WHERE id = ?`, id)
+func (application app) patchExpense(writer http.ResponseWriter, request *http.Request, id int64) {
+	current, err := application.services().expenses.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to delete expense")
+		writeServiceError(writer, err, "failed to load expense")
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read delete result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "expense not found")
+	mergedRequest, ok := mergePatchRequest(writer, request, current)
+	if !ok {
 		return
 	}
 
-	writer.WriteHeader(http.StatusNoContent)
+	application.updateExpense(writer, mergedRequest, id)
 }
 
-func decodeExpensePayload(request *http.Request) (expensePayload, error) {
-	defer request.Body.Close()
-
-	var payload expensePayload
-	if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
-		return expensePayload{}, fmt.Errorf("request body must be valid JSON")
-	}
-
-	payload.Name = strings.TrimSpace(payload.Name)
-	payload.Frequency = strings.ToLower(strings.TrimSpace(payload.Frequency))
-
-	if payload.Name == "" {
-		return expensePayload{}, fmt.Errorf("name is required")
-	}
-	if payload.Frequency == "" {
-		return expensePayload{}, fmt.Errorf("frequency is required")
-	}
-	if !slices.Contains(validExpenseFrequencies, payload.Frequency) {
-		return expensePayload{}, fmt.Errorf("frequency must be one of: daily, weekly, monthly, annually")
+func (application app) deleteExpense(writer http.ResponseWriter, request *http.Request, id int64) {
+	if err := application.services().expenses.delete(request.Context(), requestChange(request), id); err != nil {
+		writeServiceError(writer, err, "failed to delete expense")
+		return
 	}
 
-	return payload, nil
+	writer.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,115 @@
+package backend
+
+import (
+	"context"
+	"time"
+)
+
+type expensePaymentRepository interface {
+	list(ctx context.Context) ([]expensePayment, error)
+	get(ctx context.Context, id int64) (expensePayment, error)
+	create(ctx context.Context, payload expensePaymentPayload) (int64, error)
+	update(ctx context.Context, id int64, payload expensePaymentPayload) error
+	// delete soft deletes the expense payment.
+	delete(ctx context.Context, id int64) error
+	// dates returns the dates of the active payments of an expense, leaving
+	// out exceptID.
+	dates(ctx context.Context, expenseID int64, exceptID int64) ([]time.Time, error)
+}
+
+type sqlExpensePaymentRepository struct {
+	source queryer
+}
+
+func (repository sqlExpensePaymentRepository) list(ctx context.Context) ([]expensePayment, error) {
+	rows, err := repository.source.QueryContext(ctx, `SELECT id, expense_id, amount, currency_id, payment_date FROM expense_payments WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]expensePayment, 0)
+	for rows.Next() {
+		item, scanErr := scanExpensePayment(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlExpensePaymentRepository) get(ctx context.Context, id int64) (expensePayment, error) {
+	row := repository.source.QueryRowContext(ctx, `SELECT id, expense_id, amount, currency_id, payment_date FROM expense_payments WHERE id = ? AND deleted_at IS NULL`, id)
+
+	item, err := scanExpensePayment(row)
+	if err != nil {
+		return expensePayment{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlExpensePaymentRepository) create(ctx context.Context, payload expensePaymentPayload) (int64, error) {
+	return insertRow(
+		ctx,
+		repository.source,
+		`INSERT INTO expense_payments(expense_id, amount, currency_id, payment_date) VALUES (?, ?, ?, ?)`,
+		payload.ExpenseID,
+		payload.Amount,
+		payload.CurrencyID,
+		payload.Date,
+	)
+}
+
+func (repository sqlExpensePaymentRepository) update(ctx context.Context, id int64, payload expensePaymentPayload) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE expense_payments SET expense_id = ?, amount = ?, currency_id = ?, payment_date = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`,
+		payload.ExpenseID,
+		payload.Amount,
+		payload.CurrencyID,
+		payload.Date,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlExpensePaymentRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE expense_payments SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func (repository sqlExpensePaymentRepository) dates(ctx context.Context, expenseID int64, exceptID int64) ([]time.Time, error) {
+	rows, err := repository.source.QueryContext(ctx, `SELECT payment_date FROM expense_payments WHERE expense_id = ? AND id != ? AND deleted_at IS NULL`, expenseID, exceptID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dates := make([]time.Time, 0)
+	for rows.Next() {
+		var raw string
+		if scanErr := rows.Scan(&raw); scanErr != nil {
+			return nil, scanErr
+		}
+
+		date, parseErr := time.Parse("2006-01-02", raw)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		dates = append(dates, date)
+	}
+
+	return dates, rows.Err()
+}
+
+func scanExpensePayment(source scanner) (expensePayment, error) {
+	var item expensePayment
+	if err := source.Scan(&item.ID, &item.ExpenseID, &item.Amount, &item.CurrencyID, &item.Date); err != nil {
+		return expensePayment{}, err
+	}
+
+	return item, nil
+}
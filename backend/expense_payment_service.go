@@ -0,0 +1,193 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type expensePaymentService struct {
+	store dataStore
+}
+
+func (payload expensePaymentPayload) normalize() (expensePaymentPayload, error) {
+	payload.Date = strings.TrimSpace(payload.Date)
+
+	if payload.ExpenseID <= 0 {
+		return expensePaymentPayload{}, invalidPayload("expense_id must be a positive integer")
+	}
+	if payload.Amount <= 0 {
+		return expensePaymentPayload{}, invalidPayload("amount must be greater than zero")
+	}
+	if payload.CurrencyID <= 0 {
+		return expensePaymentPayload{}, invalidPayload("currency_id must be a positive integer")
+	}
+	if !isValidISODate(payload.Date) {
+		return expensePaymentPayload{}, invalidPayload("date must be a valid date in YYYY-MM-DD format")
+	}
+
+	return payload, nil
+}
+
+func (service expensePaymentService) list(ctx context.Context) ([]expensePayment, error) {
+	return service.store.reads().expensePayments().list(ctx)
+}
+
+func (service expensePaymentService) get(ctx context.Context, id int64) (expensePayment, error) {
+	item, err := service.store.reads().expensePayments().get(ctx, id)
+	if err != nil {
+		return expensePayment{}, orNotFound(err, "expense payment not found")
+	}
+
+	return item, nil
+}
+
+func (service expensePaymentService) create(ctx context.Context, by change, payload expensePaymentPayload) (expensePayment, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return expensePayment{}, err
+	}
+
+	var created expensePayment
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkExpensePayment(ctx, tx, payload, 0); err != nil {
+			return err
+		}
+
+		id, err := tx.expensePayments().create(ctx, payload)
+		if err != nil {
+			return expensePaymentWriteError(err)
+		}
+
+		if created, err = tx.expensePayments().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityExpensePayments, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return expensePayment{}, err
+	}
+
+	return created, nil
+}
+
+func (service expensePaymentService) update(ctx context.Context, by change, id int64, payload expensePaymentPayload) (expensePayment, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return expensePayment{}, err
+	}
+
+	var updated expensePayment
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.expensePayments().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "expense payment not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = checkExpensePayment(ctx, tx, payload, id); err != nil {
+			return err
+		}
+
+		if err = tx.expensePayments().update(ctx, id, payload); err != nil {
+			return expensePaymentWriteError(err)
+		}
+
+		if updated, err = tx.expensePayments().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityExpensePayments, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return expensePayment{}, err
+	}
+
+	return updated, nil
+}
+
+func (service expensePaymentService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.expensePayments().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "expense payment not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = tx.expensePayments().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityExpensePayments, id, auditActionDelete, existing, nil))
+	})
+}
+
+// checkExpensePayment checks that the expense and currency exist and that the
+// expense has no other payment, besides paymentID, in the period of the new
+// date.
+func checkExpensePayment(ctx context.Context, tx repositories, payload expensePaymentPayload, paymentID int64) error {
+	paid, err := tx.expenses().get(ctx, payload.ExpenseID)
+	if errors.Is(err, errNotFound) {
+		return invalidPayload("expense and currency must exist")
+	}
+	if err != nil {
+		return err
+	}
+
+	date, err := time.Parse("2006-01-02", payload.Date)
+	if err != nil {
+		return err
+	}
+
+	dates, err := tx.expensePayments().dates(ctx, payload.ExpenseID, paymentID)
+	if err != nil {
+		return err
+	}
+	for _, existing := range dates {
+		if isSameExpenseFrequencyPeriod(date, existing, paid.Frequency) {
+			return conflict("duplicate_expense_payment_period", fmt.Sprintf("an expense payment already exists in the same %s period", paid.Frequency))
+		}
+	}
+
+	referencesExist, err := tx.trash().referencesExist(ctx, auditEntityExpensePayments, map[string]int64{"expense_id": payload.ExpenseID, "currency_id": payload.CurrencyID})
+	if err != nil {
+		return err
+	}
+	if !referencesExist {
+		return invalidPayload("expense and currency must exist")
+	}
+
+	return nil
+}
+
+func isSameExpenseFrequencyPeriod(left time.Time, right time.Time, frequency string) bool {
+	switch frequency {
+	case "daily":
+		return left.Year() == right.Year() && left.Month() == right.Month() && left.Day() == right.Day()
+	case "weekly":
+		leftYear, leftWeek := left.ISOWeek()
+		rightYear, rightWeek := right.ISOWeek()
+		return leftYear == rightYear && leftWeek == rightWeek
+	case "monthly":
+		return left.Year() == right.Year() && left.Month() == right.Month()
+	case "annually":
+		return left.Year() == right.Year()
+	default:
+		return false
+	}
+}
+
+func expensePaymentWriteError(err error) error {
+	if errors.Is(err, errMissingReference) {
+		return invalidPayload("expense and currency must exist")
+	}
+
+	return err
+}
@@ -0,0 +1,59 @@
+package backend
+
+import "context"
+
+type expenseRepository interface {
+	list(ctx context.Context) ([]expense, error)
+	get(ctx context.Context, id int64) (expense, error)
+	create(ctx context.Context, payload expensePayload) (int64, error)
+	update(ctx context.Context, id int64, payload expensePayload) error
+	// delete soft deletes the expense.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlExpenseRepository struct {
+	source queryer
+}
+
+func (repository sqlExpenseRepository) list(ctx context.Context) ([]expense, error) {
+	rows, err := repository.source.QueryContext(ctx, `SELECT id, name, frequency FROM expenses WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]expense, 0)
+	for rows.Next() {
+		var item expense
+		if scanErr := rows.Scan(&item.ID, &item.Name, &item.Frequency); scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlExpenseRepository) get(ctx context.Context, id int64) (expense, error) {
+	var item expense
+	err := repository.source.QueryRowContext(ctx, `SELECT id, name, frequency FROM expenses WHERE id = ? AND deleted_at IS NULL`, id).Scan(&item.ID, &item.Name, &item.Frequency)
+	if err != nil {
+		return expense{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlExpenseRepository) create(ctx context.Context, payload expensePayload) (int64, error) {
+	return insertRow(ctx, repository.source, `INSERT INTO expenses(name, frequency) VALUES (?, ?)`, payload.Name, payload.Frequency)
+}
+
+func (repository sqlExpenseRepository) update(ctx context.Context, id int64, payload expensePayload) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE expenses SET name = ?, frequency = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, payload.Name, payload.Frequency, id)
+	return constraintError(err)
+}
+
+func (repository sqlExpenseRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE expenses SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
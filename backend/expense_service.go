@@ -0,0 +1,130 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+)
+
+type expenseService struct {
+	store dataStore
+}
+
+func (payload expensePayload) normalize() (expensePayload, error) {
+	payload.Name = strings.TrimSpace(payload.Name)
+	payload.Frequency = strings.ToLower(strings.TrimSpace(payload.Frequency))
+
+	if payload.Name == "" {
+		return expensePayload{}, invalidPayload("name is required")
+	}
+	if payload.Frequency == "" {
+		return expensePayload{}, invalidPayload("frequency is required")
+	}
+	if !slices.Contains(validExpenseFrequencies, payload.Frequency) {
+		return expensePayload{}, invalidPayload("frequency must be one of: daily, weekly, monthly, annually")
+	}
+
+	return payload, nil
+}
+
+func (service expenseService) list(ctx context.Context) ([]expense, error) {
+	return service.store.reads().expenses().list(ctx)
+}
+
+func (service expenseService) get(ctx context.Context, id int64) (expense, error) {
+	item, err := service.store.reads().expenses().get(ctx, id)
+	if err != nil {
+		return expense{}, orNotFound(err, "expense not found")
+	}
+
+	return item, nil
+}
+
+func (service expenseService) create(ctx context.Context, by change, payload expensePayload) (expense, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return expense{}, err
+	}
+
+	var created expense
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		id, err := tx.expenses().create(ctx, payload)
+		if err != nil {
+			return expenseWriteError(err)
+		}
+
+		created = expense{ID: id, Name: payload.Name, Frequency: payload.Frequency}
+		return tx.audit().record(ctx, by.record(auditEntityExpenses, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return expense{}, err
+	}
+
+	return created, nil
+}
+
+func (service expenseService) update(ctx context.Context, by change, id int64, payload expensePayload) (expense, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return expense{}, err
+	}
+
+	var updated expense
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.expenses().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "expense not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = tx.expenses().update(ctx, id, payload); err != nil {
+			return expenseWriteError(err)
+		}
+
+		updated = expense{ID: id, Name: payload.Name, Frequency: payload.Frequency}
+		return tx.audit().record(ctx, by.record(auditEntityExpenses, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return expense{}, err
+	}
+
+	return updated, nil
+}
+
+// delete soft deletes an expense nothing live refers to.
+func (service expenseService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.expenses().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "expense not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		inUse, err := tx.trash().hasDependents(ctx, auditEntityExpenses, id)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return conflict("expense_in_use", "expense is in use")
+		}
+
+		if err = tx.expenses().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityExpenses, id, auditActionDelete, existing, nil))
+	})
+}
+
+func expenseWriteError(err error) error {
+	if errors.Is(err, errDuplicate) {
+		return conflict(duplicateExpenseCode, "expense name must be unique")
+	}
+
+	return err
+}
@@ -0,0 +1,126 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ExportTable holds the active rows of one table in column order.
+type ExportTable struct {
+	Entity  string           `json:"entity"`
+	Columns []string         `json:"columns"`
+	Rows    []map[string]any `json:"rows"`
+}
+
+// ExportEntities lists the tables that can be exported, parents first. Child
+// tables follow the table owning their rows.
+func ExportEntities() []string {
+	entities := make([]string, 0, len(softDeleteTables))
+	for _, table := range softDeleteTables {
+		entities = append(entities, table.Table)
+		for _, child := range table.Children {
+			entities = append(entities, child.Table)
+		}
+	}
+
+	return entities
+}
+
+// ExportData reads the active (not soft-deleted) rows of the given tables, and
+// the child rows of active parents. An empty entities list exports every table.
+func ExportData(ctx context.Context, db *sql.DB, entities []string) ([]ExportTable, error) {
+	if len(entities) == 0 {
+		entities = ExportEntities()
+	}
+
+	tables := make([]ExportTable, 0, len(entities))
+	for _, entity := range entities {
+		query, ok := exportQuery(entity)
+		if !ok {
+			return nil, fmt.Errorf("unknown entity %q", entity)
+		}
+
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		columns, records, err := scanRowMaps(rows)
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		exported := ExportTable{Entity: entity, Columns: make([]string, 0, len(columns)), Rows: records}
+		for _, column := range columns {
+			if column == "deleted_at" {
+				continue
+			}
+			exported.Columns = append(exported.Columns, column)
+		}
+		for _, record := range records {
+			delete(record, "deleted_at")
+		}
+		tables = append(tables, exported)
+	}
+
+	return tables, nil
+}
+
+func exportQuery(entity string) (string, bool) {
+	if table, ok := findSoftDeleteTable(entity); ok {
+		return fmt.Sprintf(`SELECT * FROM %s WHERE deleted_at IS NULL ORDER BY id`, table.Table), true
+	}
+	if child, parent, ok := findSoftDeleteChild(entity); ok {
+		return fmt.Sprintf(
+			`SELECT c.* FROM %s c JOIN %s p ON p.id = c.%s WHERE p.deleted_at IS NULL ORDER BY c.id`,
+			child.Table, parent.Table, child.ParentColumn,
+		), true
+	}
+
+	return "", false
+}
+
+// scanRowMaps reads rows of an arbitrary query into maps keyed by column name.
+// Text comes back as string and timestamps in the audit layout.
+func scanRowMaps(rows *sql.Rows) ([]string, []map[string]any, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records := make([]map[string]any, 0)
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for index := range values {
+			pointers[index] = &values[index]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return nil, nil, err
+		}
+
+		record := make(map[string]any, len(columns))
+		for index, column := range columns {
+			value := values[index]
+			if raw, ok := value.([]byte); ok {
+				value = string(raw)
+			}
+			switch column {
+			case "created_at", "updated_at", "deleted_at":
+				if value != nil {
+					value = formatAuditTimestamp(value)
+				}
+			}
+			record[column] = value
+		}
+		records = append(records, record)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return columns, records, nil
+}
@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExportDataSkipsSoftDeletedRows(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+
+	if _, err := application.db.Exec(`INSERT INTO people (name, deleted_at) VALUES ('Gone', CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatalf("insert deleted person: %v", err)
+	}
+
+	tables, err := ExportData(context.Background(), application.db, []string{"people"})
+	if err != nil {
+		t.Fatalf("export people: %v", err)
+	}
+	if len(tables) != 1 || tables[0].Entity != "people" {
+		t.Fatalf("expected only the people table, got %+v", tables)
+	}
+	if len(tables[0].Rows) != 1 || tables[0].Rows[0]["name"] != "Jane Doe" {
+		t.Fatalf("expected only the active person, got %+v", tables[0].Rows)
+	}
+	for _, column := range tables[0].Columns {
+		if column == "deleted_at" {
+			t.Fatal("expected deleted_at to be left out of exports")
+		}
+	}
+
+	all, err := ExportData(context.Background(), application.db, nil)
+	if err != nil {
+		t.Fatalf("export all: %v", err)
+	}
+	if len(all) != len(ExportEntities()) {
+		t.Fatalf("expected every entity to be exported, got %d tables", len(all))
+	}
+
+	if _, err = ExportData(context.Background(), application.db, []string{"sqlite_master"}); err == nil {
+		t.Fatal("expected unknown entity to fail")
+	}
+}
@@ -0,0 +1,200 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	healthLivePath  = "/api/health/live"
+	healthReadyPath = "/api/health/ready"
+
+	// DefaultMinFreeDiskBytes is the free space below which the data
+	// directory fails the readiness check.
+	DefaultMinFreeDiskBytes = 100 << 20
+
+	healthCheckTimeout = 2 * time.Second
+	healthStatusOK     = "ok"
+	healthStatusFail   = "fail"
+	healthStatusSkip   = "skipped"
+)
+
+// errDiskSpaceUnsupported is returned by freeDiskBytes on platforms where the
+// free space of a directory cannot be read.
+var errDiskSpaceUnsupported = errors.New("free disk space is not available on this platform")
+
+// errNoDataDirectory skips the disk check when the database is not a local
+// file, as with PostgreSQL, whose server disk is not ours to watch.
+var errNoDataDirectory = errors.New("the database has no local data directory")
+
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]healthCheck `json:"checks,omitempty"`
+}
+
+type healthCheck struct {
+	Status    string         `json:"status"`
+	LatencyMS float64        `json:"latency_ms"`
+	Message   string         `json:"message,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// healthProbe runs one readiness check. It returns details for the response
+// and an error when the check failed.
+type healthProbe struct {
+	name string
+	run  func(ctx context.Context) (map[string]any, error)
+}
+
+func (application app) registerHealthRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(healthLivePath, application.liveHandler)
+	mux.HandleFunc(healthReadyPath, application.readyHandler)
+}
+
+// liveHandler answers as long as the process can serve requests. It touches
+// nothing else, so a slow database never gets the process restarted.
+func (application app) liveHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		methodNotAllowed(writer, http.MethodGet)
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, healthResponse{Status: healthStatusOK})
+}
+
+// readyHandler runs every readiness check and answers 503 when any of them
+// fails.
+func (application app) readyHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		methodNotAllowed(writer, http.MethodGet)
+		return
+	}
+
+	response := healthResponse{Status: healthStatusOK, Checks: map[string]healthCheck{}}
+	for _, probe := range application.healthProbes() {
+		check := runHealthProbe(request.Context(), probe)
+		if check.Status == healthStatusFail {
+			response.Status = healthStatusFail
+		}
+		response.Checks[probe.name] = check
+	}
+
+	status := http.StatusOK
+	if response.Status != healthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	writer.Header().Set("Cache-Control", "no-store")
+	writeJSON(writer, status, response)
+}
+
+func (application app) healthProbes() []healthProbe {
+	return []healthProbe{
+		{name: "database", run: application.checkDatabase},
+		{name: "schema", run: application.checkSchema},
+		{name: "disk", run: application.checkDiskSpace},
+	}
+}
+
+func runHealthProbe(ctx context.Context, probe healthProbe) healthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	started := time.Now()
+	details, err := probe.run(ctx)
+	check := healthCheck{
+		Status:    healthStatusOK,
+		LatencyMS: float64(time.Since(started).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if errors.Is(err, errDiskSpaceUnsupported) || errors.Is(err, errNoDataDirectory) {
+		check.Status = healthStatusSkip
+		check.Message = err.Error()
+	} else if err != nil {
+		check.Status = healthStatusFail
+		check.Message = err.Error()
+	}
+
+	return check
+}
+
+func (application app) checkDatabase(ctx context.Context) (map[string]any, error) {
+	for _, pool := range application.pools() {
+		if err := pool.db.PingContext(ctx); err != nil {
+			return nil, fmt.Errorf("%s ping failed: %w", pool.name, err)
+		}
+
+		var result string
+		if err := pool.db.QueryRowContext(ctx, `SELECT 'ok'`).Scan(&result); err != nil {
+			return nil, fmt.Errorf("%s query failed: %w", pool.name, err)
+		}
+	}
+
+	return nil, nil
+}
+
+// checkSchema verifies that every embedded migration is applied unchanged and
+// that the database has none this build does not know about.
+func (application app) checkSchema(ctx context.Context) (map[string]any, error) {
+	if application.migrations == nil {
+		return nil, fmt.Errorf("migration files are not configured")
+	}
+
+	// The writer pool has a single connection, so a long write would otherwise
+	// hold the probe until it times out.
+	source := application.db
+	if application.reader != nil {
+		source = application.reader
+	}
+
+	latest, err := LatestMigrationVersion(source, application.migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := MigrationStatuses(ctx, source, application.migrations)
+	if err != nil {
+		return map[string]any{"latest_version": latest}, err
+	}
+
+	version := 0
+	pending := 0
+	for _, status := range statuses {
+		if status.Applied {
+			version = status.Version
+		} else {
+			pending++
+		}
+	}
+
+	details := map[string]any{"version": version, "latest_version": latest}
+	if pending > 0 {
+		return details, fmt.Errorf("pending migrations: %d", pending)
+	}
+
+	return details, nil
+}
+
+func (application app) checkDiskSpace(_ context.Context) (map[string]any, error) {
+	if application.dataDir == "" {
+		return nil, errNoDataDirectory
+	}
+
+	free, err := freeDiskBytes(application.dataDir)
+	if err != nil {
+		return map[string]any{"path": application.dataDir}, err
+	}
+
+	details := map[string]any{
+		"path":           application.dataDir,
+		"free_bytes":     free,
+		"min_free_bytes": application.minFreeDiskBytes,
+	}
+	if free < application.minFreeDiskBytes {
+		return details, fmt.Errorf("only %d bytes free, need %d", free, application.minFreeDiskBytes)
+	}
+
+	return details, nil
+}
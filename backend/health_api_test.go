@@ -0,0 +1,136 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"personal-finances/migrations"
+)
+
+func decodeHealthResponse(t *testing.T, body []byte) healthResponse {
+	t.Helper()
+	var response healthResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("decode health response: %v", err)
+	}
+	return response
+}
+
+func TestHealthLiveAndReady(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	live := performRequest(router, http.MethodGet, healthLivePath, nil)
+	if live.Code != http.StatusOK || decodeHealthResponse(t, live.Body.Bytes()).Status != healthStatusOK {
+		t.Fatalf("expected live to be ok, got %d %s", live.Code, live.Body.String())
+	}
+
+	ready := performRequest(router, http.MethodGet, healthReadyPath, nil)
+	if ready.Code != http.StatusOK {
+		t.Fatalf("expected ready to return 200, got %d %s", ready.Code, ready.Body.String())
+	}
+	response := decodeHealthResponse(t, ready.Body.Bytes())
+	for _, name := range []string{"database", "schema", "disk"} {
+		check, ok := response.Checks[name]
+		if !ok || (check.Status != healthStatusOK && check.Status != healthStatusSkip) || check.LatencyMS < 0 {
+			t.Fatalf("expected %s check to pass, got %+v", name, response.Checks)
+		}
+	}
+
+	latest, err := LatestMigrationVersion(application.db, migrations.Files)
+	if err != nil {
+		t.Fatalf("latest migration version: %v", err)
+	}
+	schema := response.Checks["schema"].Details
+	if schema["version"] != float64(latest) || schema["latest_version"] != float64(latest) {
+		t.Fatalf("expected schema version %d, got %+v", latest, schema)
+	}
+
+	notAllowed := performRequest(router, http.MethodPost, healthReadyPath, nil)
+	if notAllowed.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for POST ready, got %d", notAllowed.Code)
+	}
+
+	// PostgreSQL databases have no local data directory to watch.
+	application.dataDir = ""
+	withoutDataDir := performRequest(application.routes(), http.MethodGet, healthReadyPath, nil)
+	if withoutDataDir.Code != http.StatusOK || decodeHealthResponse(t, withoutDataDir.Body.Bytes()).Checks["disk"].Status != healthStatusSkip {
+		t.Fatalf("expected the disk check to be skipped without a data directory, got %d %s", withoutDataDir.Code, withoutDataDir.Body.String())
+	}
+}
+
+func TestHealthReadyReportsFailedChecks(t *testing.T) {
+	requireSQLite(t)
+	application := newTestApplication(t)
+
+	latest, err := LatestMigrationVersion(application.db, migrations.Files)
+	if err != nil {
+		t.Fatalf("latest migration version: %v", err)
+	}
+	newer := fstest.MapFS{
+		fmt.Sprintf("%03d_future_change.sql", latest+1): {Data: []byte("CREATE TABLE future_change (id INTEGER);")},
+	}
+	entries, err := migrations.Files.ReadDir(".")
+	if err != nil {
+		t.Fatalf("read migrations: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		content, readErr := migrations.Files.ReadFile(entry.Name())
+		if readErr != nil {
+			t.Fatalf("read %s: %v", entry.Name(), readErr)
+		}
+		newer[entry.Name()] = &fstest.MapFile{Data: content}
+	}
+	application.migrations = newer
+	application.minFreeDiskBytes = 1 << 62
+
+	ready := performRequest(application.routes(), http.MethodGet, healthReadyPath, nil)
+	if ready.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d %s", ready.Code, ready.Body.String())
+	}
+	response := decodeHealthResponse(t, ready.Body.Bytes())
+	if response.Status != healthStatusFail || response.Checks["database"].Status != healthStatusOK {
+		t.Fatalf("expected only schema and disk to fail, got %+v", response)
+	}
+	if check := response.Checks["schema"]; check.Status != healthStatusFail || check.Message != "pending migrations: 1" {
+		t.Fatalf("expected pending migration failure, got %+v", check)
+	}
+	if check := response.Checks["disk"]; check.Status != healthStatusFail && check.Status != healthStatusSkip {
+		t.Fatalf("expected disk space failure, got %+v", check)
+	}
+
+	application.db.Close()
+	closed := decodeHealthResponse(t, performRequest(application.routes(), http.MethodGet, healthReadyPath, nil).Body.Bytes())
+	if closed.Checks["database"].Status != healthStatusFail {
+		t.Fatalf("expected database check to fail on a closed pool, got %+v", closed.Checks["database"])
+	}
+
+	live := performRequest(application.routes(), http.MethodGet, healthLivePath, nil)
+	if live.Code != http.StatusOK {
+		t.Fatalf("expected live to stay ok, got %d", live.Code)
+	}
+}
+
+func TestHealthSchemaCheckDoesNotWaitForTheWriter(t *testing.T) {
+	application := newTestApplication(t)
+
+	busy, err := application.db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("hold writer connection: %v", err)
+	}
+	defer busy.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err = application.checkSchema(ctx); err != nil {
+		t.Fatalf("expected the schema check to run while the writer is busy, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const defaultImportActor = "import"
+
+// ImportOptions selects where imported statement lines are booked.
+type ImportOptions struct {
+	BankAccountID int64
+	PersonID      int64
+	CategoryID    int64
+	Actor         string
+}
+
+// ImportResult summarizes a completed import.
+type ImportResult struct {
+	Imported int
+}
+
+// importColumns maps accepted CSV header names to the statement field they fill.
+var importColumns = map[string]string{
+	"date":             "date",
+	"transaction_date": "date",
+	"amount":           "amount",
+	"description":      "notes",
+	"notes":            "notes",
+	"type":             "type",
+}
+
+// ImportTransactionsCSV books every line of a CSV bank statement as a
+// transaction on options.BankAccountID. The first line is a header naming the
+// columns: date and amount are required, description and type are optional.
+// Without a type column, negative amounts are expenses and positive amounts
+// are income. Lines go through the same validation as the transactions API
+// and are imported all-or-nothing.
+func ImportTransactionsCSV(ctx context.Context, db *sql.DB, source io.Reader, options ImportOptions) (ImportResult, error) {
+	reader := csv.NewReader(source)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return ImportResult{}, fmt.Errorf("statement is empty")
+	}
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("read header: %w", err)
+	}
+
+	positions := make(map[string]int)
+	for index, name := range header {
+		field, ok := importColumns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))]
+		if !ok {
+			continue
+		}
+		if _, duplicated := positions[field]; duplicated {
+			return ImportResult{}, fmt.Errorf("header has more than one %s column", field)
+		}
+		positions[field] = index
+	}
+	if _, ok := positions["date"]; !ok {
+		return ImportResult{}, fmt.Errorf("header must include a date column")
+	}
+	if _, ok := positions["amount"]; !ok {
+		return ImportResult{}, fmt.Errorf("header must include an amount column")
+	}
+
+	actor := strings.TrimSpace(options.Actor)
+	if actor == "" {
+		actor = defaultImportActor
+	}
+
+	by := change{actor: actor}
+	result := ImportResult{}
+	err = dataStore{db: db}.withTx(ctx, func(tx repositories) error {
+		referencesExist, err := tx.trash().referencesExist(ctx, auditEntityTransactions, map[string]int64{
+			"person_id":       options.PersonID,
+			"bank_account_id": options.BankAccountID,
+			"category_id":     options.CategoryID,
+		})
+		if err != nil {
+			return err
+		}
+		if !referencesExist {
+			return fmt.Errorf("person, bank account and transaction category must exist")
+		}
+
+		for {
+			record, readErr := reader.Read()
+			if errors.Is(readErr, io.EOF) {
+				return nil
+			}
+			if readErr != nil {
+				return readErr
+			}
+
+			line, _ := reader.FieldPos(0)
+			payload, parseErr := parseImportRecord(record, positions, options)
+			if parseErr != nil {
+				return fmt.Errorf("line %d: %w", line, parseErr)
+			}
+
+			if _, bookErr := bookTransaction(ctx, tx, by, payload); bookErr != nil {
+				return fmt.Errorf("line %d: %w", line, bookErr)
+			}
+			result.Imported++
+		}
+	})
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	return result, nil
+}
+
+func parseImportRecord(record []string, positions map[string]int, options ImportOptions) (transactionPayload, error) {
+	field := func(name string) string {
+		index, ok := positions[name]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	amount, err := strconv.ParseFloat(field("amount"), 64)
+	if err != nil {
+		return transactionPayload{}, fmt.Errorf("amount must be a number")
+	}
+
+	transactionType := strings.ToLower(field("type"))
+	if transactionType == "" {
+		transactionType = "income"
+		if amount < 0 {
+			transactionType = "expense"
+		}
+	}
+	if amount < 0 {
+		amount = -amount
+	}
+
+	var notes *string
+	if description := field("notes"); description != "" {
+		notes = &description
+	}
+
+	return transactionPayload{
+		TransactionDate: field("date"),
+		Type:            transactionType,
+		Amount:          amount,
+		Notes:           notes,
+		PersonID:        options.PersonID,
+		BankAccountID:   options.BankAccountID,
+		CategoryID:      options.CategoryID,
+	}.normalize()
+}
@@ -0,0 +1,58 @@
+package backend
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestImportTransactionsCSV(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+
+	statement := "\ufeffDate,Description,Amount\n2026-03-01,Salary,2500.00\n2026-03-02,Groceries,-84.20\n"
+	result, err := ImportTransactionsCSV(context.Background(), application.db, strings.NewReader(statement), ImportOptions{BankAccountID: 1, PersonID: 1, CategoryID: 1})
+	if err != nil {
+		t.Fatalf("import statement: %v", err)
+	}
+	if result.Imported != 2 {
+		t.Fatalf("expected 2 imported transactions, got %d", result.Imported)
+	}
+
+	var expenseType string
+	var expenseAmount float64
+	if err = application.db.QueryRow(`SELECT type, amount FROM transactions WHERE notes = 'Groceries'`).Scan(&expenseType, &expenseAmount); err != nil {
+		t.Fatalf("load imported expense: %v", err)
+	}
+	if expenseType != "expense" || expenseAmount != 84.20 {
+		t.Fatalf("expected negative amount to import as expense 84.20, got %s %v", expenseType, expenseAmount)
+	}
+
+	var auditActor string
+	if err = application.db.QueryRow(`SELECT actor FROM audit_events WHERE entity = 'transactions' LIMIT 1`).Scan(&auditActor); err != nil {
+		t.Fatalf("load audit event: %v", err)
+	}
+	if auditActor != defaultImportActor {
+		t.Fatalf("expected audit actor %q, got %q", defaultImportActor, auditActor)
+	}
+
+	_, err = ImportTransactionsCSV(context.Background(), application.db, strings.NewReader("date,amount\n2026-03-05,10\n2026-02-30,5\n"), ImportOptions{BankAccountID: 1, PersonID: 1, CategoryID: 1})
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Fatalf("expected invalid date on line 3 to fail the import, got %v", err)
+	}
+
+	var count int
+	if err = application.db.QueryRow(`SELECT COUNT(1) FROM transactions`).Scan(&count); err != nil {
+		t.Fatalf("count transactions: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected failed import to roll back, got %d transactions", count)
+	}
+
+	_, err = ImportTransactionsCSV(context.Background(), application.db, strings.NewReader("date,amount\n2026-03-05,10\n"), ImportOptions{BankAccountID: 99, PersonID: 1, CategoryID: 1})
+	if err == nil {
+		t.Fatal("expected unknown bank account to fail the import")
+	}
+}
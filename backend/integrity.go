@@ -0,0 +1,578 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// IntegritySeverity ranks a finding: errors are broken data, warnings are
+// data that is almost certainly wrong and notices are worth a second look.
+type IntegritySeverity string
+
+const (
+	IntegrityError   IntegritySeverity = "error"
+	IntegrityWarning IntegritySeverity = "warning"
+	IntegrityNotice  IntegritySeverity = "notice"
+)
+
+// IntegrityEntity points a finding at one row and at the API resource that
+// edits it.
+type IntegrityEntity struct {
+	Entity string `json:"entity"`
+	ID     int64  `json:"id"`
+	Link   string `json:"link,omitempty"`
+}
+
+// IntegrityFinding is one problem reported by a check, with the repair the
+// user is expected to make by hand.
+type IntegrityFinding struct {
+	Check    string            `json:"check"`
+	Severity IntegritySeverity `json:"severity"`
+	Message  string            `json:"message"`
+	Repair   string            `json:"repair"`
+	Entities []IntegrityEntity `json:"entities"`
+}
+
+// IntegrityCheckResult says how one check went: ok, findings, skipped when the
+// database engine does not support it, or failed with the error.
+type IntegrityCheckResult struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	Findings    int    `json:"findings"`
+	Error       string `json:"error,omitempty"`
+}
+
+// IntegrityReport is the result of RunIntegrityChecks. Status is "ok", or the
+// most severe finding, or "failed" when a check could not run.
+type IntegrityReport struct {
+	Status   string                 `json:"status"`
+	Checks   []IntegrityCheckResult `json:"checks"`
+	Findings []IntegrityFinding     `json:"findings"`
+}
+
+// Count returns how many findings have the given severity.
+func (report IntegrityReport) Count(severity IntegritySeverity) int {
+	count := 0
+	for _, finding := range report.Findings {
+		if finding.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// integrityCheck is one read-only check. dialects limits it to some database
+// engines; empty means every engine.
+type integrityCheck struct {
+	name        string
+	description string
+	dialects    []string
+	run         func(ctx context.Context, source queryer) ([]IntegrityFinding, error)
+}
+
+// integrityChecks lists the checks RunIntegrityChecks runs, in order. A new
+// check is a function returning findings plus an entry here.
+var integrityChecks = []integrityCheck{
+	{
+		name:        "sqlite_integrity",
+		description: "SQLite PRAGMA integrity_check",
+		dialects:    []string{sqliteDialect.name},
+		run:         integritySQLite,
+	},
+	{
+		name:        "foreign_keys",
+		description: "rows whose foreign keys point at missing rows",
+		dialects:    []string{sqliteDialect.name},
+		run:         integrityForeignKeys,
+	},
+	{
+		name:        "cycle_balance_currency",
+		description: "cycle balances in a currency the card has no installment or subscription in",
+		run:         integrityCycleBalanceCurrencies,
+	},
+	{
+		name:        "installment_before_card",
+		description: "installments starting before their card was added",
+		run:         integrityInstallmentsBeforeCard,
+	},
+	{
+		name:        "expense_payment_currency",
+		description: "expense payments in a different currency than the expense's other payments",
+		run:         integrityExpensePaymentCurrencies,
+	},
+	{
+		name:        "split_totals",
+		description: "split transactions whose lines do not add up to the amount",
+		run:         integritySplitTotals,
+	},
+	{
+		name:        "shared_expense_totals",
+		description: "exact shared expenses whose shares do not add up to the transaction amount",
+		run:         integritySharedExpenseTotals,
+	},
+	{
+		name:        "duplicate_transactions",
+		description: "transactions with the same date, type, amount and account",
+		run:         integrityDuplicateTransactions,
+	},
+}
+
+// IntegrityCheckNames returns the names RunIntegrityChecks accepts.
+func IntegrityCheckNames() []string {
+	names := make([]string, 0, len(integrityChecks))
+	for _, check := range integrityChecks {
+		names = append(names, check.name)
+	}
+	return names
+}
+
+// RunIntegrityChecks runs the named checks, or all of them when names is
+// empty, and reports what they found. It never changes data. A check that
+// fails is reported as failed and the others still run; only an unknown name
+// is returned as an error.
+func RunIntegrityChecks(ctx context.Context, db *sql.DB, names []string) (IntegrityReport, error) {
+	known := IntegrityCheckNames()
+	for _, name := range names {
+		if !slices.Contains(known, name) {
+			return IntegrityReport{}, fmt.Errorf("unknown check %q (expected one of %s)", name, strings.Join(known, ", "))
+		}
+	}
+
+	engine := dialectOf(db).name
+	report := IntegrityReport{
+		Status:   "ok",
+		Checks:   make([]IntegrityCheckResult, 0, len(integrityChecks)),
+		Findings: make([]IntegrityFinding, 0),
+	}
+	for _, check := range integrityChecks {
+		if len(names) > 0 && !slices.Contains(names, check.name) {
+			continue
+		}
+
+		result := IntegrityCheckResult{Name: check.name, Description: check.description, Status: "ok"}
+		if len(check.dialects) > 0 && !slices.Contains(check.dialects, engine) {
+			result.Status = "skipped"
+			report.Checks = append(report.Checks, result)
+			continue
+		}
+
+		findings, err := check.run(ctx, db)
+		if err != nil {
+			if ctx.Err() != nil {
+				return IntegrityReport{}, ctx.Err()
+			}
+			result.Status = "failed"
+			result.Error = err.Error()
+			report.Status = "failed"
+		} else if len(findings) > 0 {
+			result.Status = "findings"
+			result.Findings = len(findings)
+			for index := range findings {
+				findings[index].Check = check.name
+			}
+			report.Findings = append(report.Findings, findings...)
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	if report.Status == "ok" {
+		for _, severity := range []IntegritySeverity{IntegrityError, IntegrityWarning, IntegrityNotice} {
+			if report.Count(severity) > 0 {
+				report.Status = string(severity)
+				break
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// integrityEntityPaths maps tables to the API collection that serves them.
+var integrityEntityPaths = map[string]string{
+	auditEntityCurrencies:              currenciesPath,
+	auditEntityBanks:                   banksPath,
+	auditEntityBankAccounts:            bankAccountsPath,
+	auditEntityPeople:                  peoplePath,
+	auditEntityTransactionCategories:   transactionCategoriesPath,
+	auditEntityTransactions:            transactionsPath,
+	auditEntityCreditCards:             creditCardsPath,
+	auditEntityCreditCardCycles:        creditCardCyclesPath,
+	auditEntityCreditCardCycleBalances: creditCardCycleBalancesPath,
+	auditEntityCreditCardInstallments:  creditCardInstallmentsPath,
+	auditEntityCreditCardSubscriptions: creditCardSubscriptionsPath,
+	auditEntityExpenses:                expensesPath,
+	auditEntityExpensePayments:         expensePaymentsPath,
+	auditEntityReconciliations:         reconciliationsPath,
+	auditEntitySharedExpenses:          sharedExpensesPath,
+	auditEntitySettlements:             settlementsPath,
+	auditEntityLoans:                   loansPath,
+	auditEntityLoanRepayments:          loanRepaymentsPath,
+	auditEntitySavingsGoals:            savingsGoalsPath,
+}
+
+func integrityEntity(table string, id int64) IntegrityEntity {
+	entity := IntegrityEntity{Entity: table, ID: id}
+	if path, ok := integrityEntityPaths[table]; ok {
+		entity.Link = fmt.Sprintf("%s/%d", path, id)
+	}
+	return entity
+}
+
+func integritySQLite(ctx context.Context, source queryer) ([]IntegrityFinding, error) {
+	rows, err := source.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	findings := make([]IntegrityFinding, 0)
+	for rows.Next() {
+		var message string
+		if err = rows.Scan(&message); err != nil {
+			return nil, err
+		}
+		if message == "ok" {
+			continue
+		}
+		findings = append(findings, IntegrityFinding{
+			Severity: IntegrityError,
+			Message:  message,
+			Repair:   "restore the latest backup that passes this check",
+			Entities: []IntegrityEntity{},
+		})
+	}
+
+	return findings, rows.Err()
+}
+
+func integrityForeignKeys(ctx context.Context, source queryer) ([]IntegrityFinding, error) {
+	rows, err := source.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	findings := make([]IntegrityFinding, 0)
+	for rows.Next() {
+		var (
+			table  string
+			rowID  sql.NullInt64
+			parent string
+			key    int64
+		)
+		if err = rows.Scan(&table, &rowID, &parent, &key); err != nil {
+			return nil, err
+		}
+		finding := IntegrityFinding{
+			Severity: IntegrityError,
+			Message:  fmt.Sprintf("%s row %d references a missing %s row", table, rowID.Int64, parent),
+			Repair:   fmt.Sprintf("point the row at an existing %s row or delete it", parent),
+			Entities: []IntegrityEntity{},
+		}
+		if rowID.Valid {
+			finding.Entities = append(finding.Entities, integrityEntity(table, rowID.Int64))
+		}
+		findings = append(findings, finding)
+	}
+
+	return findings, rows.Err()
+}
+
+func integrityCycleBalanceCurrencies(ctx context.Context, source queryer) ([]IntegrityFinding, error) {
+	rows, err := source.QueryContext(ctx, `
+		SELECT b.id, c.credit_card_id, cur.code
+		FROM credit_card_cycle_balances b
+		JOIN credit_card_cycles c ON c.id = b.credit_card_cycle_id
+		JOIN currencies cur ON cur.id = b.currency_id
+		WHERE b.deleted_at IS NULL AND c.deleted_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM credit_card_installments i
+				WHERE i.credit_card_id = c.credit_card_id AND i.currency_id = b.currency_id AND i.deleted_at IS NULL
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM credit_card_subscriptions s
+				WHERE s.credit_card_id = c.credit_card_id AND s.currency_id = b.currency_id AND s.deleted_at IS NULL
+			)
+		ORDER BY b.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	findings := make([]IntegrityFinding, 0)
+	for rows.Next() {
+		var (
+			balanceID, cardID int64
+			currency          string
+		)
+		if err = rows.Scan(&balanceID, &cardID, &currency); err != nil {
+			return nil, err
+		}
+		findings = append(findings, IntegrityFinding{
+			Severity: IntegrityWarning,
+			Message:  fmt.Sprintf("cycle balance %d is in %s, which credit card %d has no installments or subscriptions in", balanceID, currency, cardID),
+			Repair:   "delete the balance or fix its currency",
+			Entities: []IntegrityEntity{
+				integrityEntity(auditEntityCreditCardCycleBalances, balanceID),
+				integrityEntity(auditEntityCreditCards, cardID),
+			},
+		})
+	}
+
+	return findings, rows.Err()
+}
+
+func integrityInstallmentsBeforeCard(ctx context.Context, source queryer) ([]IntegrityFinding, error) {
+	rows, err := source.QueryContext(ctx, `
+		SELECT i.id, i.credit_card_id, i.start_date, c.created_at
+		FROM credit_card_installments i
+		JOIN credit_cards c ON c.id = i.credit_card_id
+		WHERE i.deleted_at IS NULL AND c.deleted_at IS NULL
+		ORDER BY i.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	findings := make([]IntegrityFinding, 0)
+	for rows.Next() {
+		var (
+			installmentID, cardID int64
+			startDate             string
+			cardCreatedAt         any
+		)
+		if err = rows.Scan(&installmentID, &cardID, &startDate, &cardCreatedAt); err != nil {
+			return nil, err
+		}
+		// Timestamps come back as text from SQLite and as time.Time from
+		// PostgreSQL, so the day is compared here rather than in SQL.
+		cardDay := formatAuditTimestamp(cardCreatedAt)
+		if len(cardDay) < len("2006-01-02") || startDate >= cardDay[:len("2006-01-02")] {
+			continue
+		}
+		findings = append(findings, IntegrityFinding{
+			Severity: IntegrityWarning,
+			Message:  fmt.Sprintf("installment %d starts on %s, before credit card %d was added on %s", installmentID, startDate, cardID, cardDay[:len("2006-01-02")]),
+			Repair:   "fix the start date or move the installment to the right card",
+			Entities: []IntegrityEntity{
+				integrityEntity(auditEntityCreditCardInstallments, installmentID),
+				integrityEntity(auditEntityCreditCards, cardID),
+			},
+		})
+	}
+
+	return findings, rows.Err()
+}
+
+// integritySplitTotals flags split transactions whose amount was changed
+// without going through the API, which keeps the lines in step.
+func integritySplitTotals(ctx context.Context, source queryer) ([]IntegrityFinding, error) {
+	rows, err := source.QueryContext(ctx, `
+		SELECT t.id, t.amount, SUM(s.amount)
+		FROM transactions t
+		JOIN transaction_splits s ON s.transaction_id = t.id
+		WHERE t.deleted_at IS NULL
+		GROUP BY t.id, t.amount
+		ORDER BY t.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	findings := make([]IntegrityFinding, 0)
+	for rows.Next() {
+		var (
+			transactionID int64
+			amount, total float64
+		)
+		if err = rows.Scan(&transactionID, &amount, &total); err != nil {
+			return nil, err
+		}
+		if math.Abs(total-amount) < splitAmountTolerance {
+			continue
+		}
+		findings = append(findings, IntegrityFinding{
+			Severity: IntegrityError,
+			Message:  fmt.Sprintf("transaction %d has an amount of %.2f but its split lines add up to %.2f", transactionID, amount, total),
+			Repair:   "update the transaction with split lines that add up to its amount",
+			Entities: []IntegrityEntity{integrityEntity(auditEntityTransactions, transactionID)},
+		})
+	}
+
+	return findings, rows.Err()
+}
+
+// integritySharedExpenseTotals flags exact shared expenses whose transaction
+// amount changed after the shares were recorded. Equal and percentage shares
+// follow the amount by themselves.
+func integritySharedExpenseTotals(ctx context.Context, source queryer) ([]IntegrityFinding, error) {
+	rows, err := source.QueryContext(ctx, `
+		SELECT e.id, e.transaction_id, t.amount, SUM(s.amount)
+		FROM shared_expenses e
+		JOIN transactions t ON t.id = e.transaction_id
+		JOIN shared_expense_shares s ON s.shared_expense_id = e.id
+		WHERE e.deleted_at IS NULL AND e.split_method = 'exact'
+		GROUP BY e.id, e.transaction_id, t.amount
+		ORDER BY e.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	findings := make([]IntegrityFinding, 0)
+	for rows.Next() {
+		var (
+			expenseID, transactionID int64
+			amount, total            float64
+		)
+		if err = rows.Scan(&expenseID, &transactionID, &amount, &total); err != nil {
+			return nil, err
+		}
+		if math.Abs(total-amount) < splitAmountTolerance {
+			continue
+		}
+		findings = append(findings, IntegrityFinding{
+			Severity: IntegrityWarning,
+			Message:  fmt.Sprintf("shared expense %d splits %.2f but transaction %d amounts to %.2f", expenseID, total, transactionID, amount),
+			Repair:   "update the shares of the shared expense, or switch it to an equal or percentage split",
+			Entities: []IntegrityEntity{
+				integrityEntity(auditEntitySharedExpenses, expenseID),
+				integrityEntity(auditEntityTransactions, transactionID),
+			},
+		})
+	}
+
+	return findings, rows.Err()
+}
+
+// integrityExpensePaymentCurrencies flags the payments of an expense that are not
+// in its usual currency: the one most of its payments use, ties going to the
+// currency of the earliest payment. Expenses with a single currency or a
+// single payment are never flagged.
+func integrityExpensePaymentCurrencies(ctx context.Context, source queryer) ([]IntegrityFinding, error) {
+	rows, err := source.QueryContext(ctx, `
+		SELECT p.id, p.expense_id, cur.code
+		FROM expense_payments p
+		JOIN expenses e ON e.id = p.expense_id
+		JOIN currencies cur ON cur.id = p.currency_id
+		WHERE p.deleted_at IS NULL AND e.deleted_at IS NULL
+		ORDER BY p.expense_id, p.payment_date, p.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type payment struct {
+		id       int64
+		currency string
+	}
+	expenseIDs := make([]int64, 0)
+	payments := make(map[int64][]payment)
+	for rows.Next() {
+		var (
+			expenseID int64
+			current   payment
+		)
+		if err = rows.Scan(&current.id, &expenseID, &current.currency); err != nil {
+			return nil, err
+		}
+		if _, seen := payments[expenseID]; !seen {
+			expenseIDs = append(expenseIDs, expenseID)
+		}
+		payments[expenseID] = append(payments[expenseID], current)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	findings := make([]IntegrityFinding, 0)
+	for _, expenseID := range expenseIDs {
+		counts := make(map[string]int)
+		usual := ""
+		for _, current := range payments[expenseID] {
+			counts[current.currency]++
+			if usual == "" || counts[current.currency] > counts[usual] {
+				usual = current.currency
+			}
+		}
+		if len(counts) < 2 {
+			continue
+		}
+		for _, current := range payments[expenseID] {
+			if current.currency == usual {
+				continue
+			}
+			findings = append(findings, IntegrityFinding{
+				Severity: IntegrityNotice,
+				Message:  fmt.Sprintf("expense payment %d is in %s, expense %d is usually paid in %s", current.id, current.currency, expenseID, usual),
+				Repair:   "fix the payment currency if it was entered by mistake",
+				Entities: []IntegrityEntity{
+					integrityEntity(auditEntityExpensePayments, current.id),
+					integrityEntity(auditEntityExpenses, expenseID),
+				},
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// integrityDuplicateTransactions reports each group of live transactions sharing
+// date, type, amount and bank account as one finding.
+func integrityDuplicateTransactions(ctx context.Context, source queryer) ([]IntegrityFinding, error) {
+	rows, err := source.QueryContext(ctx, `
+		SELECT t.id, t.transaction_date, t.type, t.amount, t.bank_account_id
+		FROM transactions t
+		WHERE t.deleted_at IS NULL AND EXISTS (
+			SELECT 1 FROM transactions o
+			WHERE o.id <> t.id AND o.deleted_at IS NULL
+				AND o.transaction_date = t.transaction_date AND o.type = t.type
+				AND o.amount = t.amount AND o.bank_account_id = t.bank_account_id
+		)
+		ORDER BY t.bank_account_id, t.transaction_date, t.type, t.amount, t.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type duplicateKey struct {
+		date, kind string
+		amount     float64
+		accountID  int64
+	}
+	findings := make([]IntegrityFinding, 0)
+	var previous duplicateKey
+	for rows.Next() {
+		var (
+			id  int64
+			key duplicateKey
+		)
+		if err = rows.Scan(&id, &key.date, &key.kind, &key.amount, &key.accountID); err != nil {
+			return nil, err
+		}
+		if len(findings) == 0 || key != previous {
+			findings = append(findings, IntegrityFinding{
+				Severity: IntegrityWarning,
+				Message:  fmt.Sprintf("%s transactions of %.2f on %s in bank account %d look like duplicates", key.kind, key.amount, key.date, key.accountID),
+				Repair:   "merge the extra transactions into one with POST /api/transactions/{id}/merge",
+				Entities: []IntegrityEntity{},
+			})
+			previous = key
+		}
+		last := &findings[len(findings)-1]
+		last.Entities = append(last.Entities, integrityEntity(auditEntityTransactions, id))
+	}
+
+	return findings, rows.Err()
+}
@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const adminIntegrityPath = "/api/admin/integrity"
+
+func (application app) registerIntegrityRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(adminIntegrityPath, application.integrityHandler)
+}
+
+func (application app) integrityHandler(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		application.checkIntegrity(writer, request)
+	default:
+		methodNotAllowed(writer, http.MethodGet)
+	}
+}
+
+// checkIntegrity runs the checks named by the check query parameter, which may
+// repeat or hold a comma-separated list, or every check when it is absent.
+func (application app) checkIntegrity(writer http.ResponseWriter, request *http.Request) {
+	names := make([]string, 0)
+	for _, value := range request.URL.Query()["check"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	known := IntegrityCheckNames()
+	for _, name := range names {
+		if !slices.Contains(known, name) {
+			writeError(writer, http.StatusBadRequest, "invalid_query", "check must be one of "+strings.Join(known, ", "))
+			return
+		}
+	}
+
+	db := application.db
+	if application.reader != nil {
+		db = application.reader
+	}
+	report, err := RunIntegrityChecks(request.Context(), db, names)
+	if err != nil {
+		writeServiceError(writer, err, "failed to check data integrity")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, report)
+}
@@ -0,0 +1,136 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestIntegrityReportsSuspiciousData(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedCreditCardCycleBalanceDependencies(t, router)
+	for _, seed := range []struct {
+		path string
+		body string
+	}{
+		{"/api/credit-card-installments", `{"credit_card_id":1,"currency_id":1,"concept":"Laptop","amount":1200,"start_date":"2000-01-01","count":12}`},
+		{"/api/credit-card-cycle-balances", `{"credit_card_cycle_id":1,"currency_id":1,"balance":100,"paid":false}`},
+		{"/api/credit-card-cycle-balances", `{"credit_card_cycle_id":1,"currency_id":2,"balance":50,"paid":false}`},
+		{"/api/expenses", `{"name":"Rent","frequency":"monthly"}`},
+		{"/api/expense-payments", `{"expense_id":1,"amount":500,"currency_id":1,"date":"2026-01-01"}`},
+		{"/api/expense-payments", `{"expense_id":1,"amount":500,"currency_id":1,"date":"2026-02-01"}`},
+		{"/api/expense-payments", `{"expense_id":1,"amount":460,"currency_id":2,"date":"2026-03-01"}`},
+		{"/api/transaction-categories", `{"name":"Groceries"}`},
+		{"/api/bank-accounts", `{"bank_id":1,"currency_id":1,"account_number":"ACC-001","balance":100}`},
+		{"/api/transactions", `{"transaction_date":"2026-01-05","type":"expense","amount":42.5,"person_id":1,"bank_account_id":1,"category_id":1}`},
+		{"/api/transactions", `{"transaction_date":"2026-01-05","type":"expense","amount":42.5,"person_id":1,"bank_account_id":1,"category_id":1,"notes":"again"}`},
+		{"/api/transactions", `{"transaction_date":"2026-01-05","type":"expense","amount":12,"person_id":1,"bank_account_id":1,"category_id":1}`},
+	} {
+		response := performRequest(router, http.MethodPost, seed.path, []byte(seed.body))
+		if response.Code != http.StatusCreated {
+			t.Fatalf("expected seed of %s to return 201, got %d: %s", seed.path, response.Code, response.Body.String())
+		}
+	}
+
+	response := performRequest(router, http.MethodGet, "/api/admin/integrity", nil)
+	if response.Code != http.StatusOK {
+		t.Fatalf("expected integrity check to return 200, got %d", response.Code)
+	}
+	var report IntegrityReport
+	if err := json.NewDecoder(response.Body).Decode(&report); err != nil {
+		t.Fatalf("decode integrity report: %v", err)
+	}
+	if report.Status != string(IntegrityWarning) || len(report.Checks) != len(integrityChecks) {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	found := make(map[string]IntegrityFinding)
+	for _, finding := range report.Findings {
+		if _, seen := found[finding.Check]; seen {
+			t.Fatalf("expected one finding per check, got %+v", report.Findings)
+		}
+		found[finding.Check] = finding
+	}
+	expected := map[string][]string{
+		"cycle_balance_currency":   {"/api/credit-card-cycle-balances/2", "/api/credit-cards/1"},
+		"installment_before_card":  {"/api/credit-card-installments/1", "/api/credit-cards/1"},
+		"expense_payment_currency": {"/api/expense-payments/3", "/api/expenses/1"},
+		"duplicate_transactions":   {"/api/transactions/1", "/api/transactions/2"},
+	}
+	if len(found) != len(expected) {
+		t.Fatalf("expected findings for %d checks, got %+v", len(expected), report.Findings)
+	}
+	for check, links := range expected {
+		finding, ok := found[check]
+		if !ok || len(finding.Entities) != len(links) || finding.Repair == "" {
+			t.Fatalf("unexpected %s finding: %+v", check, finding)
+		}
+		for index, link := range links {
+			if finding.Entities[index].Link != link {
+				t.Fatalf("expected %s finding to link %s, got %+v", check, link, finding.Entities)
+			}
+		}
+	}
+
+	filtered := performRequest(router, http.MethodGet, "/api/admin/integrity?check=duplicate_transactions", nil)
+	if filtered.Code != http.StatusOK {
+		t.Fatalf("expected filtered check to return 200, got %d", filtered.Code)
+	}
+	report = IntegrityReport{}
+	if err := json.NewDecoder(filtered.Body).Decode(&report); err != nil {
+		t.Fatalf("decode filtered report: %v", err)
+	}
+	if len(report.Checks) != 1 || len(report.Findings) != 1 || report.Findings[0].Check != "duplicate_transactions" {
+		t.Fatalf("expected only the duplicate check to run, got %+v", report)
+	}
+
+	unknown := performRequest(router, http.MethodGet, "/api/admin/integrity?check=nope", nil)
+	if unknown.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown check to return 400, got %d", unknown.Code)
+	}
+
+	method := performRequest(router, http.MethodPost, "/api/admin/integrity", nil)
+	if method.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected POST to return 405, got %d", method.Code)
+	}
+}
+
+func TestIntegrityReportsForeignKeyViolations(t *testing.T) {
+	requireSQLite(t)
+	application := newTestApplication(t)
+	ctx := context.Background()
+
+	conn, err := application.db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("get connection: %v", err)
+	}
+	for _, statement := range []string{
+		"PRAGMA foreign_keys = OFF",
+		"INSERT INTO expense_payments (expense_id, amount, currency_id, payment_date) VALUES (42, 10, 42, '2026-01-01')",
+		"PRAGMA foreign_keys = ON",
+	} {
+		if _, err = conn.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	conn.Close()
+
+	report, err := RunIntegrityChecks(ctx, application.db, []string{"foreign_keys", "sqlite_integrity"})
+	if err != nil {
+		t.Fatalf("run integrity checks: %v", err)
+	}
+	if report.Status != string(IntegrityError) || report.Count(IntegrityError) != 2 {
+		t.Fatalf("expected two foreign key errors, got %+v", report)
+	}
+	for _, finding := range report.Findings {
+		if finding.Check != "foreign_keys" || len(finding.Entities) != 1 || finding.Entities[0].Link != "/api/expense-payments/1" {
+			t.Fatalf("unexpected finding: %+v", finding)
+		}
+	}
+	if report.Checks[1].Name != "foreign_keys" || report.Checks[0].Status != "ok" {
+		t.Fatalf("expected the integrity check to pass, got %+v", report.Checks)
+	}
+}
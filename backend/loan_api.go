@@ -0,0 +1,213 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	loansPath       = "/api/loans"
+	loansPathByID   = "/api/loans/"
+	loanPathPattern = "/api/loans/%d"
+)
+
+// Directions of a loan: money lent to the counterparty or borrowed from it.
+const (
+	loanLent     = "lent"
+	loanBorrowed = "borrowed"
+)
+
+// Amortization methods of a loan: a level payment, a constant principal
+// repayment, or interest only with the principal due at maturity.
+const (
+	amortizationFrench       = "french"
+	amortizationGerman       = "german"
+	amortizationInterestOnly = "interest_only"
+)
+
+// Statuses of a loan.
+const (
+	loanActive  = "active"
+	loanPaidOff = "paid_off"
+)
+
+const maxLoanTermMonths = 1200
+
+// loan is money lent to or borrowed from a person or a bank. The position
+// fields are computed from the recorded repayments.
+type loan struct {
+	ID           int64   `json:"id"`
+	Name         string  `json:"name"`
+	Direction    string  `json:"direction"`
+	PersonID     *int64  `json:"person_id"`
+	BankID       *int64  `json:"bank_id"`
+	Principal    float64 `json:"principal"`
+	CurrencyID   int64   `json:"currency_id"`
+	AnnualRate   float64 `json:"annual_rate"`
+	TermMonths   int     `json:"term_months"`
+	StartDate    string  `json:"start_date"`
+	Amortization string  `json:"amortization"`
+	Notes        *string `json:"notes"`
+
+	OutstandingPrincipal float64 `json:"outstanding_principal"`
+	PrincipalPaid        float64 `json:"principal_paid"`
+	InterestPaid         float64 `json:"interest_paid"`
+	// UnpaidInterest is interest accrued up to the last repayment that it
+	// did not cover.
+	UnpaidInterest float64 `json:"unpaid_interest"`
+	PayoffDate     string  `json:"payoff_date"`
+	Status         string  `json:"status"`
+}
+
+type loanPayload struct {
+	Name         string  `json:"name"`
+	Direction    string  `json:"direction"`
+	PersonID     *int64  `json:"person_id"`
+	BankID       *int64  `json:"bank_id"`
+	Principal    float64 `json:"principal"`
+	CurrencyID   int64   `json:"currency_id"`
+	AnnualRate   float64 `json:"annual_rate"`
+	TermMonths   int     `json:"term_months"`
+	StartDate    string  `json:"start_date"`
+	Amortization string  `json:"amortization"`
+	Notes        *string `json:"notes"`
+}
+
+func (application app) registerLoanRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(loansPath, application.loansHandler)
+	mux.HandleFunc(loansPathByID, application.loanByIDHandler)
+	mux.HandleFunc(loansPath+batchPathSuffix, application.batchHandler(loansPath, app.loansHandler, app.loanByIDHandler))
+}
+
+func (application app) loansHandler(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		application.listLoans(writer, request)
+	case http.MethodPost:
+		application.createLoan(writer, request)
+	default:
+		methodNotAllowed(writer, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (application app) loanByIDHandler(writer http.ResponseWriter, request *http.Request) {
+	if path, ok := strings.CutSuffix(request.URL.Path, "/schedule"); ok {
+		application.loanScheduleHandler(writer, request, path)
+		return
+	}
+
+	id, err := parseIDFromPath(request.URL.Path, loansPathByID)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_id", "loan id must be a positive integer")
+		return
+	}
+
+	switch request.Method {
+	case http.MethodGet:
+		application.getLoan(writer, request, id)
+	case http.MethodPut:
+		application.updateLoan(writer, request, id)
+	case http.MethodPatch:
+		application.patchLoan(writer, request, id)
+	case http.MethodDelete:
+		application.deleteLoan(writer, request, id)
+	default:
+		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
+	}
+}
+
+func (application app) loanScheduleHandler(writer http.ResponseWriter, request *http.Request, path string) {
+	id, err := parseIDFromPath(path, loansPathByID)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_id", "loan id must be a positive integer")
+		return
+	}
+	if request.Method != http.MethodGet {
+		methodNotAllowed(writer, http.MethodGet)
+		return
+	}
+
+	rows, err := application.services().loans.schedule(request.Context(), id)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load loan schedule")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, rows)
+}
+
+func (application app) listLoans(writer http.ResponseWriter, request *http.Request) {
+	items, err := application.services().loans.list(request.Context())
+	if err != nil {
+		writeServiceError(writer, err, "failed to load loans")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, items)
+}
+
+func (application app) getLoan(writer http.ResponseWriter, request *http.Request, id int64) {
+	item, err := application.services().loans.get(request.Context(), id)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load loan")
+		return
+	}
+
+	writeJSONWithETag(writer, http.StatusOK, item)
+}
+
+func (application app) createLoan(writer http.ResponseWriter, request *http.Request) {
+	var payload loanPayload
+	if !decodeJSON(writer, request, &payload) {
+		return
+	}
+
+	created, err := application.services().loans.create(request.Context(), requestChange(request), payload)
+	if err != nil {
+		writeServiceError(writer, err, "failed to create loan")
+		return
+	}
+
+	writer.Header().Set("Location", fmt.Sprintf(loanPathPattern, created.ID))
+	writeJSONWithETag(writer, http.StatusCreated, created)
+}
+
+func (application app) updateLoan(writer http.ResponseWriter, request *http.Request, id int64) {
+	var payload loanPayload
+	if !decodeJSON(writer, request, &payload) {
+		return
+	}
+
+	updated, err := application.services().loans.update(request.Context(), requestChange(request), id, payload)
+	if err != nil {
+		writeServiceError(writer, err, "failed to update loan")
+		return
+	}
+
+	writeJSONWithETag(writer, http.StatusOK, updated)
+}
+
+func (application app) patchLoan(writer http.ResponseWriter, request *http.Request, id int64) {
+	current, err := application.services().loans.get(request.Context(), id)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load loan")
+		return
+	}
+
+	mergedRequest, ok := mergePatchRequest(writer, request, current)
+	if !ok {
+		return
+	}
+
+	application.updateLoan(writer, mergedRequest, id)
+}
+
+func (application app) deleteLoan(writer http.ResponseWriter, request *http.Request, id int64) {
+	if err := application.services().loans.delete(request.Context(), requestChange(request), id); err != nil {
+		writeServiceError(writer, err, "failed to delete loan")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
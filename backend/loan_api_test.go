@@ -0,0 +1,90 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestLoanCRUDFlow(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+
+	createResponse := performRequest(router, http.MethodPost, "/api/loans", []byte(`{"name":"Car loan","direction":"borrowed","bank_id":1,"principal":1200,"currency_id":1,"annual_rate":12,"term_months":12,"start_date":"2026-01-31","amortization":"german"}`))
+	if createResponse.Code != http.StatusCreated {
+		t.Fatalf("expected create to return 201, got %d: %s", createResponse.Code, createResponse.Body.String())
+	}
+	if location := createResponse.Header().Get("Location"); location != "/api/loans/1" {
+		t.Fatalf("unexpected Location header %q", location)
+	}
+
+	var created loan
+	if err := json.NewDecoder(createResponse.Body).Decode(&created); err != nil {
+		t.Fatalf("decode loan: %v", err)
+	}
+	if created.OutstandingPrincipal != 1200 || created.Status != loanActive || created.PayoffDate != "2027-01-31" || created.PersonID != nil {
+		t.Fatalf("unexpected loan: %+v", created)
+	}
+
+	for _, invalid := range []string{
+		`{"direction":"borrowed","bank_id":1,"principal":1200,"currency_id":1,"term_months":12,"start_date":"2026-01-31","amortization":"german"}`,
+		`{"name":"Loan","direction":"owed","bank_id":1,"principal":1200,"currency_id":1,"term_months":12,"start_date":"2026-01-31","amortization":"german"}`,
+		`{"name":"Loan","direction":"lent","person_id":1,"bank_id":1,"principal":1200,"currency_id":1,"term_months":12,"start_date":"2026-01-31","amortization":"german"}`,
+		`{"name":"Loan","direction":"lent","principal":1200,"currency_id":1,"term_months":12,"start_date":"2026-01-31","amortization":"german"}`,
+		`{"name":"Loan","direction":"lent","person_id":1,"principal":0,"currency_id":1,"term_months":12,"start_date":"2026-01-31","amortization":"german"}`,
+		`{"name":"Loan","direction":"lent","person_id":1,"principal":1200,"currency_id":1,"annual_rate":-1,"term_months":12,"start_date":"2026-01-31","amortization":"german"}`,
+		`{"name":"Loan","direction":"lent","person_id":1,"principal":1200,"currency_id":1,"term_months":0,"start_date":"2026-01-31","amortization":"german"}`,
+		`{"name":"Loan","direction":"lent","person_id":1,"principal":1200,"currency_id":1,"term_months":12,"start_date":"2026-01-31","amortization":"balloon"}`,
+		`{"name":"Loan","direction":"lent","person_id":99,"principal":1200,"currency_id":1,"term_months":12,"start_date":"2026-01-31","amortization":"german"}`,
+	} {
+		response := performRequest(router, http.MethodPost, "/api/loans", []byte(invalid))
+		if response.Code != http.StatusBadRequest {
+			t.Fatalf("expected %s to return 400, got %d", invalid, response.Code)
+		}
+	}
+
+	scheduleResponse := performRequest(router, http.MethodGet, "/api/loans/1/schedule", nil)
+	if scheduleResponse.Code != http.StatusOK {
+		t.Fatalf("expected schedule to return 200, got %d: %s", scheduleResponse.Code, scheduleResponse.Body.String())
+	}
+	var rows []loanInstallment
+	if err := json.NewDecoder(scheduleResponse.Body).Decode(&rows); err != nil {
+		t.Fatalf("decode schedule: %v", err)
+	}
+	if len(rows) != 12 || rows[0].Payment != 112 || rows[11].DueDate != "2027-01-31" || rows[11].Balance != 0 {
+		t.Fatalf("unexpected schedule: %+v", rows)
+	}
+
+	patchResponse := performRequestWithHeaders(router, http.MethodPatch, "/api/loans/1", []byte(`{"bank_id":null,"person_id":1,"direction":"lent","term_months":6}`), map[string]string{"Content-Type": "application/merge-patch+json"})
+	if patchResponse.Code != http.StatusOK {
+		t.Fatalf("expected patch to return 200, got %d: %s", patchResponse.Code, patchResponse.Body.String())
+	}
+
+	listResponse := performRequest(router, http.MethodGet, "/api/loans", nil)
+	var items []loan
+	if err := json.NewDecoder(listResponse.Body).Decode(&items); err != nil {
+		t.Fatalf("decode loans: %v", err)
+	}
+	if len(items) != 1 || items[0].PersonID == nil || *items[0].PersonID != 1 || items[0].BankID != nil || items[0].PayoffDate != "2026-07-31" {
+		t.Fatalf("unexpected loans: %+v", items)
+	}
+
+	if response := performRequest(router, http.MethodPost, "/api/loans/1/schedule", nil); response.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected posting a schedule to return 405, got %d", response.Code)
+	}
+	if response := performRequest(router, http.MethodDelete, "/api/people/1", nil); response.Code != http.StatusConflict {
+		t.Fatalf("expected deleting a person with loans to return 409, got %d", response.Code)
+	}
+
+	if response := performRequest(router, http.MethodDelete, "/api/loans/1", nil); response.Code != http.StatusNoContent {
+		t.Fatalf("expected delete to return 204, got %d", response.Code)
+	}
+	if response := performRequest(router, http.MethodGet, "/api/loans/1/schedule", nil); response.Code != http.StatusNotFound {
+		t.Fatalf("expected the schedule of a deleted loan to return 404, got %d", response.Code)
+	}
+	if response := performRequest(router, http.MethodGet, "/api/loans/abc", nil); response.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid id to return 400, got %d", response.Code)
+	}
+}
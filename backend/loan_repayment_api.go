@@ -0,0 +1,159 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	loanRepaymentsPath       = "/api/loan-repayments"
+	loanRepaymentsPathByID   = "/api/loan-repayments/"
+	loanRepaymentPathPattern = "/api/loan-repayments/%d"
+)
+
+// loanRepayment is a payment actually made on a loan through a bank account.
+// Principal and Interest are its split, computed from the loan's terms and
+// the repayments before it.
+type loanRepayment struct {
+	ID            int64   `json:"id"`
+	LoanID        int64   `json:"loan_id"`
+	BankAccountID int64   `json:"bank_account_id"`
+	PaymentDate   string  `json:"payment_date"`
+	Amount        float64 `json:"amount"`
+	Principal     float64 `json:"principal"`
+	Interest      float64 `json:"interest"`
+	Notes         *string `json:"notes"`
+}
+
+type loanRepaymentPayload struct {
+	LoanID        int64   `json:"loan_id"`
+	BankAccountID int64   `json:"bank_account_id"`
+	PaymentDate   string  `json:"payment_date"`
+	Amount        float64 `json:"amount"`
+	Notes         *string `json:"notes"`
+}
+
+func (application app) registerLoanRepaymentRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(loanRepaymentsPath, application.loanRepaymentsHandler)
+	mux.HandleFunc(loanRepaymentsPathByID, application.loanRepaymentByIDHandler)
+	mux.HandleFunc(loanRepaymentsPath+batchPathSuffix, application.batchHandler(loanRepaymentsPath, app.loanRepaymentsHandler, app.loanRepaymentByIDHandler))
+}
+
+func (application app) loanRepaymentsHandler(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		application.listLoanRepayments(writer, request)
+	case http.MethodPost:
+		application.createLoanRepayment(writer, request)
+	default:
+		methodNotAllowed(writer, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (application app) loanRepaymentByIDHandler(writer http.ResponseWriter, request *http.Request) {
+	id, err := parseIDFromPath(request.URL.Path, loanRepaymentsPathByID)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_id", "loan repayment id must be a positive integer")
+		return
+	}
+
+	switch request.Method {
+	case http.MethodGet:
+		application.getLoanRepayment(writer, request, id)
+	case http.MethodPut:
+		application.updateLoanRepayment(writer, request, id)
+	case http.MethodPatch:
+		application.patchLoanRepayment(writer, request, id)
+	case http.MethodDelete:
+		application.deleteLoanRepayment(writer, request, id)
+	default:
+		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
+	}
+}
+
+func (application app) listLoanRepayments(writer http.ResponseWriter, request *http.Request) {
+	var loanID int64
+	if raw := strings.TrimSpace(request.URL.Query().Get("loan_id")); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 {
+			writeError(writer, http.StatusBadRequest, "invalid_query", "loan_id must be a positive integer")
+			return
+		}
+		loanID = parsed
+	}
+
+	items, err := application.services().loanRepayments.list(request.Context(), loanID)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load loan repayments")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, items)
+}
+
+func (application app) getLoanRepayment(writer http.ResponseWriter, request *http.Request, id int64) {
+	item, err := application.services().loanRepayments.get(request.Context(), id)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load loan repayment")
+		return
+	}
+
+	writeJSONWithETag(writer, http.StatusOK, item)
+}
+
+func (application app) createLoanRepayment(writer http.ResponseWriter, request *http.Request) {
+	var payload loanRepaymentPayload
+	if !decodeJSON(writer, request, &payload) {
+		return
+	}
+
+	created, err := application.services().loanRepayments.create(request.Context(), requestChange(request), payload)
+	if err != nil {
+		writeServiceError(writer, err, "failed to create loan repayment")
+		return
+	}
+
+	writer.Header().Set("Location", fmt.Sprintf(loanRepaymentPathPattern, created.ID))
+	writeJSONWithETag(writer, http.StatusCreated, created)
+}
+
+func (application app) updateLoanRepayment(writer http.ResponseWriter, request *http.Request, id int64) {
+	var payload loanRepaymentPayload
+	if !decodeJSON(writer, request, &payload) {
+		return
+	}
+
+	updated, err := application.services().loanRepayments.update(request.Context(), requestChange(request), id, payload)
+	if err != nil {
+		writeServiceError(writer, err, "failed to update loan repayment")
+		return
+	}
+
+	writeJSONWithETag(writer, http.StatusOK, updated)
+}
+
+func (application app) patchLoanRepayment(writer http.ResponseWriter, request *http.Request, id int64) {
+	current, err := application.services().loanRepayments.get(request.Context(), id)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load loan repayment")
+		return
+	}
+
+	mergedRequest, ok := mergePatchRequest(writer, request, current)
+	if !ok {
+		return
+	}
+
+	application.updateLoanRepayment(writer, mergedRequest, id)
+}
+
+func (application app) deleteLoanRepayment(writer http.ResponseWriter, request *http.Request, id int64) {
+	if err := application.services().loanRepayments.delete(request.Context(), requestChange(request), id); err != nil {
+		writeServiceError(writer, err, "failed to delete loan repayment")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
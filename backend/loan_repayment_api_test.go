@@ -0,0 +1,74 @@
+package backend
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestLoanRepaymentFlow(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+
+	expectResponse(t, router, http.MethodPost, "/api/currencies", `{"name":"Euro","code":"EUR"}`, http.StatusCreated)
+	expectResponse(t, router, http.MethodPost, "/api/bank-accounts", `{"bank_id":1,"currency_id":2,"account_number":"ACC-EUR","balance":0}`, http.StatusCreated)
+	expectResponse(t, router, http.MethodPost, "/api/loans", `{"name":"Mortgage","direction":"borrowed","bank_id":1,"principal":1200,"currency_id":1,"annual_rate":12,"term_months":12,"start_date":"2026-01-31","amortization":"french"}`, http.StatusCreated)
+
+	var first loanRepayment
+	decodeResponse(t, expectResponse(t, router, http.MethodPost, "/api/loan-repayments", `{"loan_id":1,"bank_account_id":1,"payment_date":"2026-02-28","amount":106.62}`, http.StatusCreated), &first)
+	if first.Interest != 12 || first.Principal != 94.62 {
+		t.Fatalf("expected the repayment to pay 12 interest and 94.62 principal, got %+v", first)
+	}
+
+	for _, invalid := range []string{
+		`{"loan_id":1,"bank_account_id":2,"payment_date":"2026-03-31","amount":100}`,
+		`{"loan_id":1,"bank_account_id":1,"payment_date":"2026-01-01","amount":100}`,
+		`{"loan_id":1,"bank_account_id":1,"payment_date":"2026-03-31","amount":2000}`,
+		`{"loan_id":1,"bank_account_id":1,"payment_date":"2026-03-31","amount":0}`,
+		`{"loan_id":9,"bank_account_id":1,"payment_date":"2026-03-31","amount":100}`,
+	} {
+		expectResponse(t, router, http.MethodPost, "/api/loan-repayments", invalid, http.StatusBadRequest)
+	}
+
+	var second loanRepayment
+	decodeResponse(t, expectResponse(t, router, http.MethodPost, "/api/loan-repayments", `{"loan_id":1,"bank_account_id":1,"payment_date":"2026-04-30","amount":400}`, http.StatusCreated), &second)
+	if second.Interest != 22.1 || second.Principal != 377.9 {
+		t.Fatalf("expected the repayment to pay 22.10 interest and 377.90 principal, got %+v", second)
+	}
+
+	var item loan
+	decodeResponse(t, expectResponse(t, router, http.MethodGet, "/api/loans/1", "", http.StatusOK), &item)
+	if item.OutstandingPrincipal != 727.48 || item.InterestPaid != 34.1 || item.PrincipalPaid != 472.52 || item.PayoffDate != "2026-12-31" || item.Status != loanActive {
+		t.Fatalf("unexpected loan position: %+v", item)
+	}
+
+	// Moving the first repayment after the second one changes both splits.
+	expectResponse(t, router, http.MethodPatch, "/api/loan-repayments/1", `{"payment_date":"2026-05-31"}`, http.StatusOK)
+	var repayments []loanRepayment
+	decodeResponse(t, expectResponse(t, router, http.MethodGet, "/api/loan-repayments?loan_id=1", "", http.StatusOK), &repayments)
+	if len(repayments) != 2 || repayments[0].ID != 2 || repayments[0].Interest != 36 || repayments[1].Interest != 8.36 {
+		t.Fatalf("unexpected repayments: %+v", repayments)
+	}
+
+	expectResponse(t, router, http.MethodPatch, "/api/loans/1", `{"principal":100}`, http.StatusBadRequest)
+	expectResponse(t, router, http.MethodPatch, "/api/loans/1", `{"currency_id":2}`, http.StatusBadRequest)
+	expectResponse(t, router, http.MethodPatch, "/api/loans/1", `{"start_date":"2026-05-01"}`, http.StatusBadRequest)
+	expectResponse(t, router, http.MethodDelete, "/api/loans/1", "", http.StatusConflict)
+	expectResponse(t, router, http.MethodDelete, "/api/bank-accounts/1", "", http.StatusConflict)
+
+	decodeResponse(t, expectResponse(t, router, http.MethodGet, "/api/loans/1", "", http.StatusOK), &item)
+	owed := item.OutstandingPrincipal + item.UnpaidInterest
+	expectResponse(t, router, http.MethodPost, "/api/loan-repayments", `{"loan_id":1,"bank_account_id":1,"payment_date":"2026-05-31","amount":`+strconv.FormatFloat(owed, 'f', 2, 64)+`}`, http.StatusCreated)
+	decodeResponse(t, expectResponse(t, router, http.MethodGet, "/api/loans/1", "", http.StatusOK), &item)
+	if item.Status != loanPaidOff || item.OutstandingPrincipal != 0 || item.PayoffDate != "2026-05-31" {
+		t.Fatalf("expected the loan to be paid off on 2026-05-31, got %+v", item)
+	}
+
+	for _, id := range []string{"3", "2", "1"} {
+		expectResponse(t, router, http.MethodDelete, "/api/loan-repayments/"+id, "", http.StatusNoContent)
+	}
+	expectResponse(t, router, http.MethodGet, "/api/loan-repayments/1", "", http.StatusNotFound)
+	expectResponse(t, router, http.MethodDelete, "/api/loans/1", "", http.StatusNoContent)
+}
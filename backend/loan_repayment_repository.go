@@ -0,0 +1,113 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+)
+
+type loanRepaymentRepository interface {
+	// list returns the repayments of one loan, or of every loan when loanID
+	// is 0.
+	list(ctx context.Context, loanID int64) ([]loanRepayment, error)
+	get(ctx context.Context, id int64) (loanRepayment, error)
+	create(ctx context.Context, payload loanRepaymentPayload) (int64, error)
+	update(ctx context.Context, id int64, payload loanRepaymentPayload) error
+	// delete soft deletes the repayment.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlLoanRepaymentRepository struct {
+	source queryer
+}
+
+const loanRepaymentSelect = `
+	SELECT id, loan_id, bank_account_id, payment_date, amount, notes
+	FROM loan_repayments
+	WHERE deleted_at IS NULL`
+
+func (repository sqlLoanRepaymentRepository) list(ctx context.Context, loanID int64) ([]loanRepayment, error) {
+	query := loanRepaymentSelect
+	args := make([]any, 0)
+	if loanID > 0 {
+		query += ` AND loan_id = ?`
+		args = append(args, loanID)
+	}
+	query += ` ORDER BY payment_date, id`
+
+	rows, err := repository.source.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]loanRepayment, 0)
+	for rows.Next() {
+		item, scanErr := scanLoanRepayment(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlLoanRepaymentRepository) get(ctx context.Context, id int64) (loanRepayment, error) {
+	row := repository.source.QueryRowContext(ctx, loanRepaymentSelect+` AND id = ?`, id)
+
+	item, err := scanLoanRepayment(row)
+	if err != nil {
+		return loanRepayment{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlLoanRepaymentRepository) create(ctx context.Context, payload loanRepaymentPayload) (int64, error) {
+	return insertRow(
+		ctx,
+		repository.source,
+		`INSERT INTO loan_repayments(loan_id, bank_account_id, payment_date, amount, notes) VALUES (?, ?, ?, ?, ?)`,
+		payload.LoanID,
+		payload.BankAccountID,
+		payload.PaymentDate,
+		payload.Amount,
+		payload.Notes,
+	)
+}
+
+func (repository sqlLoanRepaymentRepository) update(ctx context.Context, id int64, payload loanRepaymentPayload) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE loan_repayments
+		 SET loan_id = ?, bank_account_id = ?, payment_date = ?, amount = ?, notes = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND deleted_at IS NULL`,
+		payload.LoanID,
+		payload.BankAccountID,
+		payload.PaymentDate,
+		payload.Amount,
+		payload.Notes,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlLoanRepaymentRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE loan_repayments SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func scanLoanRepayment(source scanner) (loanRepayment, error) {
+	var item loanRepayment
+	var notes sql.NullString
+	if err := source.Scan(&item.ID, &item.LoanID, &item.BankAccountID, &item.PaymentDate, &item.Amount, &notes); err != nil {
+		return loanRepayment{}, err
+	}
+
+	if notes.Valid {
+		value := notes.String
+		item.Notes = &value
+	}
+
+	return item, nil
+}
@@ -0,0 +1,243 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+type loanRepaymentService struct {
+	store dataStore
+}
+
+func (payload loanRepaymentPayload) normalize() (loanRepaymentPayload, error) {
+	payload.PaymentDate = strings.TrimSpace(payload.PaymentDate)
+	payload.Notes = normalizeTransactionNotes(payload.Notes)
+
+	if payload.LoanID <= 0 {
+		return loanRepaymentPayload{}, invalidPayload("loan_id must be a positive integer")
+	}
+	if payload.BankAccountID <= 0 {
+		return loanRepaymentPayload{}, invalidPayload("bank_account_id must be a positive integer")
+	}
+	if !isValidISODate(payload.PaymentDate) {
+		return loanRepaymentPayload{}, invalidPayload("payment_date must be a valid date in YYYY-MM-DD format")
+	}
+	if math.IsNaN(payload.Amount) || math.IsInf(payload.Amount, 0) || payload.Amount <= 0 {
+		return loanRepaymentPayload{}, invalidPayload("amount must be greater than zero")
+	}
+
+	return payload, nil
+}
+
+// list returns the repayments of one loan, or of every loan when loanID is 0,
+// in date order.
+func (service loanRepaymentService) list(ctx context.Context, loanID int64) ([]loanRepayment, error) {
+	repos := service.store.reads()
+	items, err := repos.loanRepayments().list(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	byLoan := make(map[int64][]loanRepayment)
+	for _, item := range items {
+		byLoan[item.LoanID] = append(byLoan[item.LoanID], item)
+	}
+
+	applied := make(map[int64]loanRepayment, len(items))
+	for id, repayments := range byLoan {
+		item, err := repos.loans().get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		item.applyRepayments(repayments)
+		for _, repayment := range repayments {
+			applied[repayment.ID] = repayment
+		}
+	}
+
+	for index, item := range items {
+		items[index] = applied[item.ID]
+	}
+
+	return items, nil
+}
+
+func (service loanRepaymentService) get(ctx context.Context, id int64) (loanRepayment, error) {
+	return loadLoanRepayment(ctx, service.store.reads(), id)
+}
+
+func (service loanRepaymentService) create(ctx context.Context, by change, payload loanRepaymentPayload) (loanRepayment, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return loanRepayment{}, err
+	}
+
+	var created loanRepayment
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkLoanRepayment(ctx, tx, 0, payload); err != nil {
+			return err
+		}
+
+		id, err := tx.loanRepayments().create(ctx, payload)
+		if err != nil {
+			return loanRepaymentWriteError(err)
+		}
+
+		if created, err = loadLoanRepayment(ctx, tx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityLoanRepayments, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return loanRepayment{}, err
+	}
+
+	return created, nil
+}
+
+func (service loanRepaymentService) update(ctx context.Context, by change, id int64, payload loanRepaymentPayload) (loanRepayment, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return loanRepayment{}, err
+	}
+
+	var updated loanRepayment
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := loadLoanRepayment(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = checkLoanRepayment(ctx, tx, id, payload); err != nil {
+			return err
+		}
+
+		if err = tx.loanRepayments().update(ctx, id, payload); err != nil {
+			return loanRepaymentWriteError(err)
+		}
+
+		if updated, err = loadLoanRepayment(ctx, tx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityLoanRepayments, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return loanRepayment{}, err
+	}
+
+	return updated, nil
+}
+
+func (service loanRepaymentService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := loadLoanRepayment(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = tx.loanRepayments().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityLoanRepayments, id, auditActionDelete, existing, nil))
+	})
+}
+
+// loadLoanRepayment returns the repayment with its split between principal
+// and interest.
+func loadLoanRepayment(ctx context.Context, repos repositories, id int64) (loanRepayment, error) {
+	item, err := repos.loanRepayments().get(ctx, id)
+	if err != nil {
+		return loanRepayment{}, orNotFound(err, "loan repayment not found")
+	}
+
+	_, repayments, err := loadLoan(ctx, repos, item.LoanID)
+	if err != nil {
+		return loanRepayment{}, err
+	}
+	for _, repayment := range repayments {
+		if repayment.ID == id {
+			return repayment, nil
+		}
+	}
+
+	return item, nil
+}
+
+// checkLoanRepayment checks that the repayment, replacing repayment id when
+// it is not 0, fits its loan: it is paid from a bank account in the loan
+// currency, not before the loan starts, and not for more than is owed.
+// Moving a repayment off a loan only raises what that loan owes, so the loan
+// it leaves needs no check.
+func checkLoanRepayment(ctx context.Context, tx repositories, id int64, payload loanRepaymentPayload) error {
+	item, err := tx.loans().get(ctx, payload.LoanID)
+	if errors.Is(err, errNotFound) {
+		return invalidPayload("loan must exist")
+	}
+	if err != nil {
+		return err
+	}
+
+	account, err := tx.bankAccounts().get(ctx, payload.BankAccountID)
+	if errors.Is(err, errNotFound) {
+		return invalidPayload("bank account must exist")
+	}
+	if err != nil {
+		return err
+	}
+	if account.CurrencyID != item.CurrencyID {
+		return invalidPayload("bank account currency must match the loan currency")
+	}
+
+	if payload.PaymentDate < item.StartDate {
+		return invalidPayload(fmt.Sprintf("payment_date must not be before %s, the start of the loan", item.StartDate))
+	}
+
+	repayments, err := tx.loanRepayments().list(ctx, payload.LoanID)
+	if err != nil {
+		return err
+	}
+	candidate := loanRepayment{
+		ID:            id,
+		LoanID:        payload.LoanID,
+		BankAccountID: payload.BankAccountID,
+		PaymentDate:   payload.PaymentDate,
+		Amount:        payload.Amount,
+	}
+	if id == 0 {
+		// A new repayment gets the highest id, so it follows the others of
+		// its day.
+		candidate.ID = math.MaxInt64
+	}
+
+	others := make([]loanRepayment, 0, len(repayments)+1)
+	for _, repayment := range repayments {
+		if repayment.ID != id {
+			others = append(others, repayment)
+		}
+	}
+	if _, err = item.applyRepayments(append(others, candidate)); err != nil {
+		return invalidPayload(err.Error())
+	}
+
+	return nil
+}
+
+func loanRepaymentWriteError(err error) error {
+	if errors.Is(err, errMissingReference) {
+		return invalidPayload("loan and bank account must exist")
+	}
+
+	return err
+}
@@ -0,0 +1,141 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+)
+
+type loanRepository interface {
+	list(ctx context.Context) ([]loan, error)
+	get(ctx context.Context, id int64) (loan, error)
+	create(ctx context.Context, payload loanPayload) (int64, error)
+	update(ctx context.Context, id int64, payload loanPayload) error
+	// delete soft deletes the loan.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlLoanRepository struct {
+	source queryer
+}
+
+const loanSelect = `
+	SELECT id, name, direction, person_id, bank_id, principal, currency_id, annual_rate, term_months, start_date, amortization, notes
+	FROM loans
+	WHERE deleted_at IS NULL`
+
+func (repository sqlLoanRepository) list(ctx context.Context) ([]loan, error) {
+	rows, err := repository.source.QueryContext(ctx, loanSelect+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]loan, 0)
+	for rows.Next() {
+		item, scanErr := scanLoan(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlLoanRepository) get(ctx context.Context, id int64) (loan, error) {
+	row := repository.source.QueryRowContext(ctx, loanSelect+` AND id = ?`, id)
+
+	item, err := scanLoan(row)
+	if err != nil {
+		return loan{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlLoanRepository) create(ctx context.Context, payload loanPayload) (int64, error) {
+	return insertRow(
+		ctx,
+		repository.source,
+		`INSERT INTO loans(name, direction, person_id, bank_id, principal, currency_id, annual_rate, term_months, start_date, amortization, notes)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		payload.Name,
+		payload.Direction,
+		payload.PersonID,
+		payload.BankID,
+		payload.Principal,
+		payload.CurrencyID,
+		payload.AnnualRate,
+		payload.TermMonths,
+		payload.StartDate,
+		payload.Amortization,
+		payload.Notes,
+	)
+}
+
+func (repository sqlLoanRepository) update(ctx context.Context, id int64, payload loanPayload) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE loans
+		 SET name = ?, direction = ?, person_id = ?, bank_id = ?, principal = ?, currency_id = ?, annual_rate = ?,
+		     term_months = ?, start_date = ?, amortization = ?, notes = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND deleted_at IS NULL`,
+		payload.Name,
+		payload.Direction,
+		payload.PersonID,
+		payload.BankID,
+		payload.Principal,
+		payload.CurrencyID,
+		payload.AnnualRate,
+		payload.TermMonths,
+		payload.StartDate,
+		payload.Amortization,
+		payload.Notes,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlLoanRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE loans SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func scanLoan(source scanner) (loan, error) {
+	var item loan
+	var personID, bankID sql.NullInt64
+	var notes sql.NullString
+
+	err := source.Scan(
+		&item.ID,
+		&item.Name,
+		&item.Direction,
+		&personID,
+		&bankID,
+		&item.Principal,
+		&item.CurrencyID,
+		&item.AnnualRate,
+		&item.TermMonths,
+		&item.StartDate,
+		&item.Amortization,
+		&notes,
+	)
+	if err != nil {
+		return loan{}, err
+	}
+
+	if personID.Valid {
+		value := personID.Int64
+		item.PersonID = &value
+	}
+	if bankID.Valid {
+		value := bankID.Int64
+		item.BankID = &value
+	}
+	if notes.Valid {
+		value := notes.String
+		item.Notes = &value
+	}
+
+	return item, nil
+}
@@ -0,0 +1,191 @@
+package backend
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// loanInstallment is one monthly row of an amortization schedule. Balance is
+// the principal still owed after the installment.
+type loanInstallment struct {
+	Number    int     `json:"number"`
+	DueDate   string  `json:"due_date"`
+	Payment   float64 `json:"payment"`
+	Principal float64 `json:"principal"`
+	Interest  float64 `json:"interest"`
+	Balance   float64 `json:"balance"`
+}
+
+// loanPosition is where a loan stands after its recorded repayments. Month is
+// the number of installment dates passed at the last repayment.
+type loanPosition struct {
+	Outstanding     float64
+	PrincipalPaid   float64
+	InterestPaid    float64
+	UnpaidInterest  float64
+	Month           int
+	LastPaymentDate string
+}
+
+func (item loan) monthlyRate() float64 {
+	return item.AnnualRate / 100 / 12
+}
+
+// levelPayment is the constant installment of the French method.
+func (item loan) levelPayment() float64 {
+	months := float64(item.TermMonths)
+	rate := item.monthlyRate()
+	if rate == 0 {
+		return roundCents(item.Principal / months)
+	}
+
+	return roundCents(item.Principal * rate / (1 - math.Pow(1+rate, -months)))
+}
+
+// installment splits installment number, due on balance, into principal and
+// interest. The installment at maturity, and any after it, repays the whole
+// balance, which absorbs the rounding of the earlier ones.
+func (item loan) installment(number int, balance float64) (float64, float64) {
+	interest := roundCents(balance * item.monthlyRate())
+
+	var principal float64
+	switch item.Amortization {
+	case amortizationFrench:
+		principal = roundCents(item.levelPayment() - interest)
+	case amortizationGerman:
+		principal = roundCents(item.Principal / float64(item.TermMonths))
+	}
+	if number >= item.TermMonths || principal > balance {
+		principal = balance
+	}
+
+	return math.Max(principal, 0), interest
+}
+
+func (item loan) schedule() []loanInstallment {
+	rows := make([]loanInstallment, 0, item.TermMonths)
+	balance := item.Principal
+	for number := 1; number <= item.TermMonths; number++ {
+		principal, interest := item.installment(number, balance)
+		balance = roundCents(balance - principal)
+		rows = append(rows, loanInstallment{
+			Number:    number,
+			DueDate:   item.dueDate(number),
+			Payment:   roundCents(principal + interest),
+			Principal: principal,
+			Interest:  interest,
+			Balance:   balance,
+		})
+	}
+
+	return rows
+}
+
+// applyRepayments allocates repayments in date order, first to the interest
+// accrued since the previous one and then to principal, and fills in their
+// split. Interest accrues on the outstanding principal once per installment
+// date passed. A repayment of more than is owed is an error for writes;
+// rows restored from the trash can still overpay, so the excess is left out
+// of the split and the position is returned with the error.
+func (item loan) applyRepayments(repayments []loanRepayment) (loanPosition, error) {
+	sort.SliceStable(repayments, func(left, right int) bool {
+		if repayments[left].PaymentDate != repayments[right].PaymentDate {
+			return repayments[left].PaymentDate < repayments[right].PaymentDate
+		}
+		return repayments[left].ID < repayments[right].ID
+	})
+
+	position := loanPosition{Outstanding: item.Principal}
+	var overpaid error
+	for index := range repayments {
+		repayment := &repayments[index]
+
+		month := item.monthsElapsed(repayment.PaymentDate)
+		if month > position.Month {
+			accrued := roundCents(position.Outstanding*item.monthlyRate()) * float64(month-position.Month)
+			position.UnpaidInterest = roundCents(position.UnpaidInterest + accrued)
+			position.Month = month
+		}
+
+		owed := roundCents(position.Outstanding + position.UnpaidInterest)
+		if repayment.Amount > owed+0.005 && overpaid == nil {
+			overpaid = fmt.Errorf("the repayment of %.2f on %s is more than the %.2f owed", repayment.Amount, repayment.PaymentDate, owed)
+		}
+
+		repayment.Interest = roundCents(math.Min(repayment.Amount, position.UnpaidInterest))
+		repayment.Principal = roundCents(math.Min(repayment.Amount-repayment.Interest, position.Outstanding))
+
+		position.UnpaidInterest = roundCents(position.UnpaidInterest - repayment.Interest)
+		position.Outstanding = roundCents(position.Outstanding - repayment.Principal)
+		position.InterestPaid = roundCents(position.InterestPaid + repayment.Interest)
+		position.PrincipalPaid = roundCents(position.PrincipalPaid + repayment.Principal)
+		position.LastPaymentDate = repayment.PaymentDate
+	}
+
+	return position, overpaid
+}
+
+// withPosition fills in the computed fields of the loan. A loan that is not
+// paid off is projected to be paid off by installments paid as scheduled
+// from the first installment date after its last repayment.
+func (item loan) withPosition(position loanPosition) loan {
+	item.OutstandingPrincipal = position.Outstanding
+	item.PrincipalPaid = position.PrincipalPaid
+	item.InterestPaid = position.InterestPaid
+	item.UnpaidInterest = position.UnpaidInterest
+
+	if position.Outstanding <= 0 {
+		item.Status = loanPaidOff
+		item.PayoffDate = position.LastPaymentDate
+		return item
+	}
+
+	item.Status = loanActive
+	balance := position.Outstanding
+	for number := position.Month + 1; ; number++ {
+		principal, _ := item.installment(number, balance)
+		if balance = roundCents(balance - principal); balance <= 0 {
+			item.PayoffDate = item.dueDate(number)
+			return item
+		}
+	}
+}
+
+// dueDate is the date of installment number: the start date moved by that
+// many months, on the last day of the month when it is shorter.
+func (item loan) dueDate(number int) string {
+	start, err := time.Parse("2006-01-02", item.StartDate)
+	if err != nil {
+		return ""
+	}
+
+	return addMonths(start, number).Format("2006-01-02")
+}
+
+// monthsElapsed counts the installment dates on or before date.
+func (item loan) monthsElapsed(date string) int {
+	start, err := time.Parse("2006-01-02", item.StartDate)
+	if err != nil {
+		return 0
+	}
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return 0
+	}
+
+	months := (day.Year()-start.Year())*12 + int(day.Month()-start.Month())
+	if months > 0 && day.Before(addMonths(start, months)) {
+		months--
+	}
+
+	return max(months, 0)
+}
+
+func addMonths(date time.Time, months int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+
+	return first.AddDate(0, 0, min(date.Day(), lastDay)-1)
+}
@@ -0,0 +1,83 @@
+package backend
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLoanSchedule(t *testing.T) {
+	cases := []struct {
+		amortization string
+		first        loanInstallment
+		last         loanInstallment
+	}{
+		{amortizationFrench, loanInstallment{Number: 1, DueDate: "2026-02-28", Payment: 106.62, Principal: 94.62, Interest: 12, Balance: 1105.38}, loanInstallment{Number: 12, DueDate: "2027-01-31", Payment: 106.6, Principal: 105.54, Interest: 1.06, Balance: 0}},
+		{amortizationGerman, loanInstallment{Number: 1, DueDate: "2026-02-28", Payment: 112, Principal: 100, Interest: 12, Balance: 1100}, loanInstallment{Number: 12, DueDate: "2027-01-31", Payment: 101, Principal: 100, Interest: 1, Balance: 0}},
+		{amortizationInterestOnly, loanInstallment{Number: 1, DueDate: "2026-02-28", Payment: 12, Principal: 0, Interest: 12, Balance: 1200}, loanInstallment{Number: 12, DueDate: "2027-01-31", Payment: 1212, Principal: 1200, Interest: 12, Balance: 0}},
+	}
+
+	for _, item := range cases {
+		terms := loan{Principal: 1200, AnnualRate: 12, TermMonths: 12, StartDate: "2026-01-31", Amortization: item.amortization}
+		rows := terms.schedule()
+		if len(rows) != 12 {
+			t.Fatalf("expected 12 %s installments, got %d", item.amortization, len(rows))
+		}
+		if rows[0] != item.first || rows[11] != item.last {
+			t.Fatalf("expected %s schedule from %+v to %+v, got %+v to %+v", item.amortization, item.first, item.last, rows[0], rows[11])
+		}
+		if rows[2].DueDate != "2026-04-30" {
+			t.Fatalf("expected the third installment on 2026-04-30, got %s", rows[2].DueDate)
+		}
+
+		var principal float64
+		for _, row := range rows {
+			principal += row.Principal
+		}
+		if math.Abs(principal-1200) > 0.001 {
+			t.Fatalf("expected the %s schedule to repay 1200, got %.2f", item.amortization, principal)
+		}
+	}
+}
+
+func TestLoanRepayments(t *testing.T) {
+	terms := loan{Principal: 1200, AnnualRate: 12, TermMonths: 12, StartDate: "2026-01-31", Amortization: amortizationFrench}
+
+	repayments := []loanRepayment{
+		{ID: 2, PaymentDate: "2026-04-30", Amount: 400},
+		{ID: 1, PaymentDate: "2026-02-28", Amount: 106.62},
+	}
+	position, err := terms.applyRepayments(repayments)
+	if err != nil {
+		t.Fatalf("expected repayments to apply, got %v", err)
+	}
+	if repayments[0].ID != 1 || repayments[0].Interest != 12 || repayments[0].Principal != 94.62 {
+		t.Fatalf("expected the first repayment to pay 12 interest and 94.62 principal, got %+v", repayments[0])
+	}
+	// Two installment dates passed since the first repayment: 2 x 11.05.
+	if repayments[1].Interest != 22.1 || repayments[1].Principal != 377.9 {
+		t.Fatalf("expected the second repayment to pay 22.10 interest and 377.90 principal, got %+v", repayments[1])
+	}
+	if position.Outstanding != 727.48 || position.InterestPaid != 34.1 || position.Month != 3 {
+		t.Fatalf("unexpected position %+v", position)
+	}
+
+	active := terms.withPosition(position)
+	if active.Status != loanActive || active.PayoffDate != "2026-12-31" {
+		t.Fatalf("expected an active loan paid off on 2026-12-31, got %s on %s", active.Status, active.PayoffDate)
+	}
+
+	payoff := append(repayments, loanRepayment{ID: 3, PaymentDate: "2026-05-15", Amount: 727.48})
+	position, err = terms.applyRepayments(payoff)
+	if err != nil {
+		t.Fatalf("expected the payoff to apply, got %v", err)
+	}
+	paidOff := terms.withPosition(position)
+	if paidOff.Status != loanPaidOff || paidOff.PayoffDate != "2026-05-15" || paidOff.OutstandingPrincipal != 0 {
+		t.Fatalf("expected a loan paid off on 2026-05-15, got %+v", paidOff)
+	}
+
+	overpaid := append(repayments, loanRepayment{ID: 3, PaymentDate: "2026-05-15", Amount: 1000})
+	if _, err = terms.applyRepayments(overpaid); err == nil {
+		t.Fatal("expected a repayment of more than is owed to fail")
+	}
+}
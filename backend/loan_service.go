@@ -0,0 +1,267 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+type loanService struct {
+	store dataStore
+}
+
+func (payload loanPayload) normalize() (loanPayload, error) {
+	payload.Name = strings.TrimSpace(payload.Name)
+	payload.Direction = strings.TrimSpace(payload.Direction)
+	payload.StartDate = strings.TrimSpace(payload.StartDate)
+	payload.Amortization = strings.TrimSpace(payload.Amortization)
+	payload.Notes = normalizeTransactionNotes(payload.Notes)
+
+	if payload.Name == "" {
+		return loanPayload{}, invalidPayload("name is required")
+	}
+	if payload.Direction != loanLent && payload.Direction != loanBorrowed {
+		return loanPayload{}, invalidPayload("direction must be lent or borrowed")
+	}
+	if (payload.PersonID == nil) == (payload.BankID == nil) {
+		return loanPayload{}, invalidPayload("exactly one of person_id and bank_id is required")
+	}
+	if payload.PersonID != nil && *payload.PersonID <= 0 {
+		return loanPayload{}, invalidPayload("person_id must be a positive integer")
+	}
+	if payload.BankID != nil && *payload.BankID <= 0 {
+		return loanPayload{}, invalidPayload("bank_id must be a positive integer")
+	}
+	if math.IsNaN(payload.Principal) || math.IsInf(payload.Principal, 0) || payload.Principal <= 0 {
+		return loanPayload{}, invalidPayload("principal must be greater than zero")
+	}
+	if payload.CurrencyID <= 0 {
+		return loanPayload{}, invalidPayload("currency_id must be a positive integer")
+	}
+	if math.IsNaN(payload.AnnualRate) || math.IsInf(payload.AnnualRate, 0) || payload.AnnualRate < 0 {
+		return loanPayload{}, invalidPayload("annual_rate must be zero or greater")
+	}
+	if payload.TermMonths <= 0 || payload.TermMonths > maxLoanTermMonths {
+		return loanPayload{}, invalidPayload(fmt.Sprintf("term_months must be between 1 and %d", maxLoanTermMonths))
+	}
+	if !isValidISODate(payload.StartDate) {
+		return loanPayload{}, invalidPayload("start_date must be a valid date in YYYY-MM-DD format")
+	}
+	switch payload.Amortization {
+	case amortizationFrench, amortizationGerman, amortizationInterestOnly:
+	default:
+		return loanPayload{}, invalidPayload("amortization must be french, german or interest_only")
+	}
+
+	return payload, nil
+}
+
+func (service loanService) list(ctx context.Context) ([]loan, error) {
+	repos := service.store.reads()
+	items, err := repos.loans().list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	repayments, err := repos.loanRepayments().list(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	byLoan := make(map[int64][]loanRepayment)
+	for _, repayment := range repayments {
+		byLoan[repayment.LoanID] = append(byLoan[repayment.LoanID], repayment)
+	}
+	for index, item := range items {
+		position, _ := item.applyRepayments(byLoan[item.ID])
+		items[index] = item.withPosition(position)
+	}
+
+	return items, nil
+}
+
+func (service loanService) get(ctx context.Context, id int64) (loan, error) {
+	item, _, err := loadLoan(ctx, service.store.reads(), id)
+	return item, err
+}
+
+// schedule returns the contractual installments of the loan, regardless of
+// the repayments actually made.
+func (service loanService) schedule(ctx context.Context, id int64) ([]loanInstallment, error) {
+	item, err := service.store.reads().loans().get(ctx, id)
+	if err != nil {
+		return nil, orNotFound(err, "loan not found")
+	}
+
+	return item.schedule(), nil
+}
+
+func (service loanService) create(ctx context.Context, by change, payload loanPayload) (loan, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return loan{}, err
+	}
+
+	var created loan
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkLoanReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		id, err := tx.loans().create(ctx, payload)
+		if err != nil {
+			return loanWriteError(err)
+		}
+
+		if created, _, err = loadLoan(ctx, tx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityLoans, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return loan{}, err
+	}
+
+	return created, nil
+}
+
+// update changes the terms of a loan. The recorded repayments must still fit
+// the new terms: none may come before the start date or pay more than is
+// owed, and the currency cannot change under them.
+func (service loanService) update(ctx context.Context, by change, id int64, payload loanPayload) (loan, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return loan{}, err
+	}
+
+	var updated loan
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, repayments, err := loadLoan(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = checkLoanReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+		if len(repayments) > 0 {
+			if payload.CurrencyID != existing.CurrencyID {
+				return invalidPayload("currency_id cannot change once repayments are recorded")
+			}
+			if payload.StartDate > repayments[0].PaymentDate {
+				return invalidPayload(fmt.Sprintf("start_date must not be after %s, the first repayment", repayments[0].PaymentDate))
+			}
+			if _, err = loanFromPayload(id, payload).applyRepayments(repayments); err != nil {
+				return invalidPayload(err.Error())
+			}
+		}
+
+		if err = tx.loans().update(ctx, id, payload); err != nil {
+			return loanWriteError(err)
+		}
+
+		if updated, _, err = loadLoan(ctx, tx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityLoans, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return loan{}, err
+	}
+
+	return updated, nil
+}
+
+// delete soft deletes a loan without live repayments.
+func (service loanService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, _, err := loadLoan(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		inUse, err := tx.trash().hasDependents(ctx, auditEntityLoans, id)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return conflict("loan_in_use", "loan is in use")
+		}
+
+		if err = tx.loans().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityLoans, id, auditActionDelete, existing, nil))
+	})
+}
+
+// loadLoan returns the loan with its position and its repayments in the
+// order they were applied.
+func loadLoan(ctx context.Context, repos repositories, id int64) (loan, []loanRepayment, error) {
+	item, err := repos.loans().get(ctx, id)
+	if err != nil {
+		return loan{}, nil, orNotFound(err, "loan not found")
+	}
+	repayments, err := repos.loanRepayments().list(ctx, id)
+	if err != nil {
+		return loan{}, nil, err
+	}
+
+	position, _ := item.applyRepayments(repayments)
+	return item.withPosition(position), repayments, nil
+}
+
+func loanFromPayload(id int64, payload loanPayload) loan {
+	return loan{
+		ID:           id,
+		Name:         payload.Name,
+		Direction:    payload.Direction,
+		PersonID:     payload.PersonID,
+		BankID:       payload.BankID,
+		Principal:    payload.Principal,
+		CurrencyID:   payload.CurrencyID,
+		AnnualRate:   payload.AnnualRate,
+		TermMonths:   payload.TermMonths,
+		StartDate:    payload.StartDate,
+		Amortization: payload.Amortization,
+		Notes:        payload.Notes,
+	}
+}
+
+func checkLoanReferences(ctx context.Context, tx repositories, payload loanPayload) error {
+	values := map[string]int64{"currency_id": payload.CurrencyID}
+	if payload.PersonID != nil {
+		values["person_id"] = *payload.PersonID
+	}
+	if payload.BankID != nil {
+		values["bank_id"] = *payload.BankID
+	}
+
+	referencesExist, err := tx.trash().referencesExist(ctx, auditEntityLoans, values)
+	if err != nil {
+		return err
+	}
+	if !referencesExist {
+		return invalidPayload("counterparty and currency must exist")
+	}
+
+	return nil
+}
+
+func loanWriteError(err error) error {
+	if errors.Is(err, errMissingReference) {
+		return invalidPayload("counterparty and currency must exist")
+	}
+
+	return err
+}
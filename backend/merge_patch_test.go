@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	cases := []struct {
+		target   string
+		patch    string
+		expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, testCase := range cases {
+		var target, patch, expected any
+		if err := json.Unmarshal([]byte(testCase.target), &target); err != nil {
+			t.Fatalf("decode target %s: %v", testCase.target, err)
+		}
+		if err := json.Unmarshal([]byte(testCase.patch), &patch); err != nil {
+			t.Fatalf("decode patch %s: %v", testCase.patch, err)
+		}
+		if err := json.Unmarshal([]byte(testCase.expected), &expected); err != nil {
+			t.Fatalf("decode expected %s: %v", testCase.expected, err)
+		}
+
+		result := applyMergePatch(target, patch)
+		if !reflect.DeepEqual(result, expected) {
+			t.Fatalf("patch %s on %s: expected %v, got %v", testCase.patch, testCase.target, expected, result)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const metricsPath = "/metrics"
+
+// requestDurationBuckets and queryDurationBuckets are the histogram upper
+// bounds in seconds. Queries against the local SQLite file are much faster
+// than whole requests, so their buckets start lower.
+var (
+	requestDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	queryDurationBuckets   = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+)
+
+// metrics collects the counters and histograms served on /metrics in the
+// Prometheus text exposition format. A nil *metrics records nothing.
+type metrics struct {
+	mu               sync.Mutex
+	requests         map[requestMetricKey]uint64
+	responseBytes    map[routeMetricKey]uint64
+	requestDurations map[routeMetricKey]*histogram
+	queryDurations   map[string]*histogram
+	queryErrors      map[string]uint64
+}
+
+type routeMetricKey struct {
+	method string
+	route  string
+}
+
+type requestMetricKey struct {
+	routeMetricKey
+	status int
+}
+
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:         map[requestMetricKey]uint64{},
+		responseBytes:    map[routeMetricKey]uint64{},
+		requestDurations: map[routeMetricKey]*histogram{},
+		queryDurations:   map[string]*histogram{},
+		queryErrors:      map[string]uint64{},
+	}
+}
+
+func (item *histogram) observe(value float64) {
+	for index, bound := range item.bounds {
+		if value <= bound {
+			item.counts[index]++
+		}
+	}
+	item.count++
+	item.sum += value
+}
+
+func histogramFor[K comparable](histograms map[K]*histogram, key K, bounds []float64) *histogram {
+	item, ok := histograms[key]
+	if !ok {
+		item = &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+		histograms[key] = item
+	}
+
+	return item
+}
+
+func (collector *metrics) observeRequest(method string, route string, status int, bytes int64, elapsed time.Duration) {
+	if collector == nil {
+		return
+	}
+
+	key := routeMetricKey{method: method, route: route}
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	collector.requests[requestMetricKey{routeMetricKey: key, status: status}]++
+	collector.responseBytes[key] += uint64(bytes)
+	histogramFor(collector.requestDurations, key, requestDurationBuckets).observe(elapsed.Seconds())
+}
+
+// observeQuery records one statement under its leading SQL keyword (select,
+// insert, update, ...), which keeps the label set small.
+func (collector *metrics) observeQuery(query string, elapsed time.Duration, err error) {
+	if collector == nil {
+		return
+	}
+
+	statement := "other"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		statement = strings.ToLower(fields[0])
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	histogramFor(collector.queryDurations, statement, queryDurationBuckets).observe(elapsed.Seconds())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		collector.queryErrors[statement]++
+	}
+}
+
+func (application app) metricsHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		methodNotAllowed(writer, http.MethodGet)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writer.WriteHeader(http.StatusOK)
+	application.metrics.write(writer)
+	writeDatabaseStats(writer, application.pools())
+}
+
+func (collector *metrics) write(writer io.Writer) {
+	if collector == nil {
+		return
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	writeMetricHeader(writer, "http_requests_total", "counter", "HTTP requests served, by method, route and status.")
+	requestKeys := sortedKeys(collector.requests, func(a, b requestMetricKey) int {
+		if order := compareRouteKeys(a.routeMetricKey, b.routeMetricKey); order != 0 {
+			return order
+		}
+		return a.status - b.status
+	})
+	for _, key := range requestKeys {
+		fmt.Fprintf(writer, "http_requests_total{method=%s,route=%s,status=\"%d\"} %d\n", quoteLabel(key.method), quoteLabel(key.route), key.status, collector.requests[key])
+	}
+
+	writeMetricHeader(writer, "http_request_duration_seconds", "histogram", "HTTP request latency, by method and route.")
+	for _, key := range sortedKeys(collector.requestDurations, compareRouteKeys) {
+		labels := fmt.Sprintf("method=%s,route=%s", quoteLabel(key.method), quoteLabel(key.route))
+		writeHistogram(writer, "http_request_duration_seconds", labels, collector.requestDurations[key])
+	}
+
+	writeMetricHeader(writer, "http_response_size_bytes_total", "counter", "Response body bytes written, by method and route.")
+	for _, key := range sortedKeys(collector.responseBytes, compareRouteKeys) {
+		fmt.Fprintf(writer, "http_response_size_bytes_total{method=%s,route=%s} %d\n", quoteLabel(key.method), quoteLabel(key.route), collector.responseBytes[key])
+	}
+
+	writeMetricHeader(writer, "db_query_duration_seconds", "histogram", "Database statement latency, by leading SQL keyword.")
+	for _, statement := range sortedKeys(collector.queryDurations, strings.Compare) {
+		writeHistogram(writer, "db_query_duration_seconds", "statement="+quoteLabel(statement), collector.queryDurations[statement])
+	}
+
+	writeMetricHeader(writer, "db_query_errors_total", "counter", "Database statements that failed, by leading SQL keyword.")
+	for _, statement := range sortedKeys(collector.queryErrors, strings.Compare) {
+		fmt.Fprintf(writer, "db_query_errors_total{statement=%s} %d\n", quoteLabel(statement), collector.queryErrors[statement])
+	}
+}
+
+func writeDatabaseStats(writer io.Writer, pools []namedPool) {
+	gauges := []struct {
+		name  string
+		kind  string
+		help  string
+		value func(stats sql.DBStats) float64
+	}{
+		{"db_max_open_connections", "gauge", "Maximum number of open connections, by pool.", func(stats sql.DBStats) float64 { return float64(stats.MaxOpenConnections) }},
+		{"db_open_connections", "gauge", "Established connections, both in use and idle, by pool.", func(stats sql.DBStats) float64 { return float64(stats.OpenConnections) }},
+		{"db_in_use_connections", "gauge", "Connections currently in use, by pool.", func(stats sql.DBStats) float64 { return float64(stats.InUse) }},
+		{"db_idle_connections", "gauge", "Idle connections, by pool.", func(stats sql.DBStats) float64 { return float64(stats.Idle) }},
+		{"db_wait_count_total", "counter", "Connections waited for, by pool.", func(stats sql.DBStats) float64 { return float64(stats.WaitCount) }},
+		{"db_wait_duration_seconds_total", "counter", "Time spent waiting for connections, by pool.", func(stats sql.DBStats) float64 { return stats.WaitDuration.Seconds() }},
+	}
+
+	stats := make([]sql.DBStats, len(pools))
+	for index, pool := range pools {
+		stats[index] = pool.db.Stats()
+	}
+	for _, gauge := range gauges {
+		writeMetricHeader(writer, gauge.name, gauge.kind, gauge.help)
+		for index, pool := range pools {
+			fmt.Fprintf(writer, "%s{pool=%s} %s\n", gauge.name, quoteLabel(pool.name), formatMetricValue(gauge.value(stats[index])))
+		}
+	}
+}
+
+func writeMetricHeader(writer io.Writer, name string, kind string, help string) {
+	fmt.Fprintf(writer, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeHistogram(writer io.Writer, name string, labels string, item *histogram) {
+	for index, bound := range item.bounds {
+		fmt.Fprintf(writer, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatMetricValue(bound), item.counts[index])
+	}
+	fmt.Fprintf(writer, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, item.count)
+	fmt.Fprintf(writer, "%s_sum{%s} %s\n", name, labels, formatMetricValue(item.sum))
+	fmt.Fprintf(writer, "%s_count{%s} %d\n", name, labels, item.count)
+}
+
+func formatMetricValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func quoteLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+func sortedKeys[K comparable, V any](items map[K]V, compare func(a, b K) int) []K {
+	keys := make([]K, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, compare)
+
+	return keys
+}
+
+func compareRouteKeys(a, b routeMetricKey) int {
+	if order := strings.Compare(a.route, b.route); order != 0 {
+		return order
+	}
+
+	return strings.Compare(a.method, b.method)
+}
+
+// instrumentedQueryer times every statement run through the wrapped handle.
+type instrumentedQueryer struct {
+	queryer
+	metrics *metrics
+}
+
+func (instrumented instrumentedQueryer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	started := time.Now()
+	result, err := instrumented.queryer.ExecContext(ctx, query, args...)
+	instrumented.metrics.observeQuery(query, time.Since(started), err)
+	return result, err
+}
+
+func (instrumented instrumentedQueryer) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	started := time.Now()
+	rows, err := instrumented.queryer.QueryContext(ctx, query, args...)
+	instrumented.metrics.observeQuery(query, time.Since(started), err)
+	return rows, err
+}
+
+func (instrumented instrumentedQueryer) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	started := time.Now()
+	row := instrumented.queryer.QueryRowContext(ctx, query, args...)
+	instrumented.metrics.observeQuery(query, time.Since(started), row.Err())
+	return row
+}
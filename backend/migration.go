@@ -0,0 +1,517 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const migrationDownSuffix = ".down.sql"
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_[a-z0-9_]+\.sql$`)
+
+// migrationDataSteps holds Go code that runs inside the transaction of the
+// named migration, after its SQL, for data changes SQL cannot express.
+var migrationDataSteps = map[string]func(ctx context.Context, tx *sql.Tx) error{
+	"025_store_card_last4.sql": upgradeLegacyCardNumbers,
+}
+
+type migration struct {
+	version  int
+	name     string
+	up       string
+	down     string
+	hasDown  bool
+	checksum string
+}
+
+type appliedMigration struct {
+	checksum  sql.NullString
+	appliedAt string
+}
+
+// MigrationStatus describes a migration file and whether it has been applied.
+type MigrationStatus struct {
+	Version    int
+	Name       string
+	Applied    bool
+	AppliedAt  string
+	Reversible bool
+}
+
+// loadMigrations reads NNN_description.sql files and their optional
+// NNN_description.down.sql counterparts. Versions must be unique and
+// contiguous starting at 1.
+func loadMigrations(migrationFiles fs.FS) ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*migration)
+	byVersion := make(map[int]string)
+	downs := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		content, readErr := fs.ReadFile(migrationFiles, name)
+		if readErr != nil {
+			return nil, readErr
+		}
+
+		if strings.HasSuffix(name, migrationDownSuffix) {
+			downs[strings.TrimSuffix(name, migrationDownSuffix)+".sql"] = string(content)
+			continue
+		}
+
+		match := migrationFilePattern.FindStringSubmatch(name)
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q: expected NNN_description.sql", name)
+		}
+
+		version, convErr := strconv.Atoi(match[1])
+		if convErr != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", name)
+		}
+		if existing, duplicated := byVersion[version]; duplicated {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", existing, name, version)
+		}
+		byVersion[version] = name
+
+		byName[name] = &migration{
+			version:  version,
+			name:     name,
+			up:       string(content),
+			checksum: migrationChecksum(content),
+		}
+	}
+
+	for upName, down := range downs {
+		item, ok := byName[upName]
+		if !ok {
+			return nil, fmt.Errorf("down migration for %s has no matching up migration", upName)
+		}
+		item.down = down
+		item.hasDown = true
+	}
+
+	migrations := make([]migration, 0, len(byName))
+	for _, item := range byName {
+		migrations = append(migrations, *item)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	for index, item := range migrations {
+		if item.version != index+1 {
+			return nil, fmt.Errorf("migration version %d is missing before %s", index+1, item.name)
+		}
+	}
+
+	return migrations, nil
+}
+
+// loadDialectMigrations loads the migrations written for engine.
+func loadDialectMigrations(engine dialect, migrationFiles fs.FS) ([]migration, error) {
+	files, err := engine.migrationFiles(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadMigrations(files)
+}
+
+// migrationChecksum hashes a migration with normalized line endings, so a
+// checkout with different newline settings does not count as an edit.
+func migrationChecksum(content []byte) string {
+	normalized := bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	sum := sha256.Sum256(normalized)
+	return hex.EncodeToString(sum[:])
+}
+
+// applyMigrations runs pending migrations on a single pinned connection. On
+// SQLite foreign key enforcement is disabled meanwhile, following SQLite's
+// documented procedure for rebuilding tables: each migration is checked with
+// PRAGMA foreign_key_check before it commits, and enforcement is turned back
+// on afterwards. It fails without applying anything when an applied migration
+// was edited or removed.
+func applyMigrations(db *sql.DB, migrationFiles fs.FS) ([]string, error) {
+	engine := dialectOf(db)
+	migrations, err := loadDialectMigrations(engine, migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]string, 0)
+	err = withMigrationConn(db, engine, func(ctx context.Context, conn *sql.Conn) error {
+		if err := ensureMigrationTable(ctx, conn, engine); err != nil {
+			return err
+		}
+
+		records, err := loadAppliedMigrations(ctx, conn, engine)
+		if err != nil {
+			return err
+		}
+		if err = verifyAppliedMigrations(migrations, records); err != nil {
+			return err
+		}
+		if err = backfillMigrationChecksums(ctx, conn, migrations, records); err != nil {
+			return err
+		}
+
+		for _, item := range migrations {
+			if _, done := records[item.name]; done {
+				continue
+			}
+
+			err = runMigrationStep(ctx, conn, engine, item.name, item.up, func(tx *sql.Tx) error {
+				if dataStep, ok := migrationDataSteps[item.name]; ok {
+					if stepErr := dataStep(ctx, tx); stepErr != nil {
+						return fmt.Errorf("%s: %w", item.name, stepErr)
+					}
+				}
+				_, insertErr := tx.ExecContext(ctx, "INSERT INTO schema_migrations(version, checksum) VALUES (?, ?)", item.name, item.checksum)
+				return insertErr
+			})
+			if err != nil {
+				return err
+			}
+			applied = append(applied, item.name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// ApplyMigrations runs every pending migration and returns their file names.
+func ApplyMigrations(db *sql.DB, migrationFiles fs.FS) ([]string, error) {
+	return applyMigrations(db, migrationFiles)
+}
+
+// PendingMigrations lists the migrations that applyMigrations would run,
+// without changing the database.
+func PendingMigrations(ctx context.Context, db *sql.DB, migrationFiles fs.FS) ([]string, error) {
+	statuses, err := MigrationStatuses(ctx, db, migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]string, 0)
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status.Name)
+		}
+	}
+
+	return pending, nil
+}
+
+// MigrationStatuses reports every migration file with its applied state. It
+// fails on the same integrity problems that block applying migrations.
+func MigrationStatuses(ctx context.Context, db *sql.DB, migrationFiles fs.FS) ([]MigrationStatus, error) {
+	engine := dialectOf(db)
+	migrations, err := loadDialectMigrations(engine, migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	records, err := loadAppliedMigrations(ctx, conn, engine)
+	if err != nil {
+		return nil, err
+	}
+	if err = verifyAppliedMigrations(migrations, records); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, item := range migrations {
+		record, applied := records[item.name]
+		statuses = append(statuses, MigrationStatus{
+			Version:    item.version,
+			Name:       item.name,
+			Applied:    applied,
+			AppliedAt:  record.appliedAt,
+			Reversible: item.hasDown,
+		})
+	}
+
+	return statuses, nil
+}
+
+// LatestMigrationVersion returns the highest migration version in migrationFiles
+// for the engine behind db.
+func LatestMigrationVersion(db *sql.DB, migrationFiles fs.FS) (int, error) {
+	migrations, err := loadDialectMigrations(dialectOf(db), migrationFiles)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(migrations), nil
+}
+
+// RollbackMigrations reverts applied migrations newer than targetVersion,
+// newest first, using their .down.sql files. Nothing is reverted when any of
+// them lacks a down migration.
+func RollbackMigrations(db *sql.DB, migrationFiles fs.FS, targetVersion int) ([]string, error) {
+	engine := dialectOf(db)
+	migrations, err := loadDialectMigrations(engine, migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	if targetVersion < 0 || targetVersion > len(migrations) {
+		return nil, fmt.Errorf("target version must be between 0 and %d", len(migrations))
+	}
+
+	reverted := make([]string, 0)
+	err = withMigrationConn(db, engine, func(ctx context.Context, conn *sql.Conn) error {
+		if err := ensureMigrationTable(ctx, conn, engine); err != nil {
+			return err
+		}
+
+		records, err := loadAppliedMigrations(ctx, conn, engine)
+		if err != nil {
+			return err
+		}
+		if err = verifyAppliedMigrations(migrations, records); err != nil {
+			return err
+		}
+
+		toRevert := make([]migration, 0)
+		for index := len(migrations) - 1; index >= 0; index-- {
+			item := migrations[index]
+			if item.version <= targetVersion {
+				break
+			}
+			if _, done := records[item.name]; !done {
+				continue
+			}
+			if !item.hasDown {
+				return fmt.Errorf("migration %s has no down migration", item.name)
+			}
+			toRevert = append(toRevert, item)
+		}
+
+		for _, item := range toRevert {
+			err = runMigrationStep(ctx, conn, engine, item.name+" (down)", item.down, func(tx *sql.Tx) error {
+				_, deleteErr := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", item.name)
+				return deleteErr
+			})
+			if err != nil {
+				return err
+			}
+			reverted = append(reverted, item.name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reverted, nil
+}
+
+// withMigrationConn runs migrations on one connection prepared by the
+// dialect's migrationSetup, which turns foreign keys off on SQLite.
+// They take no caller context: once started, applying or reverting runs to
+// the end, so a signal during startup cannot stop a migration half way.
+func withMigrationConn(db *sql.DB, engine dialect, run func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if engine.migrationSetup != "" {
+		if _, err = conn.ExecContext(ctx, engine.migrationSetup); err != nil {
+			return err
+		}
+	}
+	if engine.migrationTeardown != "" {
+		defer conn.ExecContext(ctx, engine.migrationTeardown)
+	}
+
+	return run(ctx, conn)
+}
+
+func runMigrationStep(ctx context.Context, conn *sql.Conn, engine dialect, label string, statements string, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, statements); err != nil {
+		return fmt.Errorf("%s: %w", label, err)
+	}
+
+	if engine.checksForeignKeys {
+		if err = checkForeignKeys(ctx, tx); err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+	}
+
+	if err = record(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func ensureMigrationTable(ctx context.Context, conn *sql.Conn, engine dialect) error {
+	if _, err := conn.ExecContext(ctx, engine.migrationTable); err != nil {
+		return err
+	}
+
+	hasChecksum, err := migrationTableHasChecksum(ctx, conn, engine)
+	if err != nil {
+		return err
+	}
+	if !hasChecksum {
+		_, err = conn.ExecContext(ctx, "ALTER TABLE schema_migrations ADD COLUMN checksum TEXT")
+	}
+
+	return err
+}
+
+func migrationTableHasChecksum(ctx context.Context, conn *sql.Conn, engine dialect) (bool, error) {
+	var count int
+	err := conn.QueryRowContext(ctx, engine.columnExists, "schema_migrations", "checksum").Scan(&count)
+	return count > 0, err
+}
+
+// loadAppliedMigrations reads schema_migrations without modifying it, so it is
+// safe for dry runs against databases created before checksums existed.
+func loadAppliedMigrations(ctx context.Context, conn *sql.Conn, engine dialect) (map[string]appliedMigration, error) {
+	records := make(map[string]appliedMigration)
+
+	var tables int
+	if err := conn.QueryRowContext(ctx, engine.tableExists, "schema_migrations").Scan(&tables); err != nil {
+		return nil, err
+	}
+	if tables == 0 {
+		return records, nil
+	}
+
+	hasChecksum, err := migrationTableHasChecksum(ctx, conn, engine)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT version, applied_at, NULL FROM schema_migrations"
+	if hasChecksum {
+		query = "SELECT version, applied_at, checksum FROM schema_migrations"
+	}
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var appliedAt any
+		var record appliedMigration
+		if err = rows.Scan(&name, &appliedAt, &record.checksum); err != nil {
+			return nil, err
+		}
+		record.appliedAt = formatAuditTimestamp(appliedAt)
+		records[name] = record
+	}
+
+	return records, rows.Err()
+}
+
+func verifyAppliedMigrations(migrations []migration, records map[string]appliedMigration) error {
+	known := make(map[string]bool, len(migrations))
+	for _, item := range migrations {
+		known[item.name] = true
+	}
+	for name := range records {
+		if !known[name] {
+			return fmt.Errorf("applied migration %s is missing from the migration files", name)
+		}
+	}
+
+	pending := ""
+	for _, item := range migrations {
+		record, applied := records[item.name]
+		if !applied {
+			if pending == "" {
+				pending = item.name
+			}
+			continue
+		}
+		if pending != "" {
+			return fmt.Errorf("migration %s is pending but later migration %s is already applied", pending, item.name)
+		}
+		if record.checksum.Valid && record.checksum.String != item.checksum {
+			return fmt.Errorf("migration %s was modified after it was applied", item.name)
+		}
+	}
+
+	return nil
+}
+
+// backfillMigrationChecksums stores checksums for migrations applied before
+// checksums were recorded, trusting the files as they are now.
+func backfillMigrationChecksums(ctx context.Context, conn *sql.Conn, migrations []migration, records map[string]appliedMigration) error {
+	for _, item := range migrations {
+		record, applied := records[item.name]
+		if !applied || record.checksum.Valid {
+			continue
+		}
+
+		if _, err := conn.ExecContext(ctx, "UPDATE schema_migrations SET checksum = ? WHERE version = ?", item.checksum, item.name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkForeignKeys(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var table string
+		var rowID sql.NullInt64
+		var parent string
+		var constraintIndex int64
+		if err = rows.Scan(&table, &rowID, &parent, &constraintIndex); err != nil {
+			return err
+		}
+		return fmt.Errorf("foreign key violation in %s row %d referencing %s", table, rowID.Int64, parent)
+	}
+
+	return rows.Err()
+}
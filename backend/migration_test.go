@@ -0,0 +1,180 @@
+package backend
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newMigrationTestFiles() fstest.MapFS {
+	return fstest.MapFS{
+		"001_create_notes.sql":      {Data: []byte(`CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT NOT NULL);`)},
+		"001_create_notes.down.sql": {Data: []byte(`DROP TABLE notes;`)},
+		"002_create_tags.sql":       {Data: []byte(`CREATE TABLE tags (id INTEGER PRIMARY KEY, name TEXT NOT NULL);`)},
+		"002_create_tags.down.sql":  {Data: []byte(`DROP TABLE tags;`)},
+	}
+}
+
+func openMigrationTestDatabase(t *testing.T) string {
+	t.Helper()
+	return filepath.Join(t.TempDir(), "migrations.db")
+}
+
+func TestMigrationChecksumMismatchFails(t *testing.T) {
+	path := openMigrationTestDatabase(t)
+	files := newMigrationTestFiles()
+
+	db, err := SetupDatabase(path, files)
+	if err != nil {
+		t.Fatalf("setup database: %v", err)
+	}
+	db.Close()
+
+	files["001_create_notes.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT);`)}
+	if _, err = SetupDatabase(path, files); err == nil || !strings.Contains(err.Error(), "modified after it was applied") {
+		t.Fatalf("expected checksum mismatch error, got %v", err)
+	}
+
+	files["001_create_notes.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT NOT NULL);")}
+	db, err = SetupDatabase(path, files)
+	if err != nil {
+		t.Fatalf("expected restored migration to pass verification, got %v", err)
+	}
+	db.Close()
+
+	delete(files, "002_create_tags.sql")
+	delete(files, "002_create_tags.down.sql")
+	if _, err = SetupDatabase(path, files); err == nil || !strings.Contains(err.Error(), "missing from the migration files") {
+		t.Fatalf("expected missing applied migration error, got %v", err)
+	}
+}
+
+func TestMigrationLineEndingsDoNotChangeChecksum(t *testing.T) {
+	if migrationChecksum([]byte("SELECT 1;\r\nSELECT 2;\r\n")) != migrationChecksum([]byte("SELECT 1;\nSELECT 2;\n")) {
+		t.Fatalf("expected CRLF and LF content to share a checksum")
+	}
+}
+
+func TestMigrationRollbackAndDryRun(t *testing.T) {
+	path := openMigrationTestDatabase(t)
+	files := newMigrationTestFiles()
+
+	db, err := OpenDatabase(path)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+
+	pending, err := PendingMigrations(context.Background(), db, files)
+	if err != nil {
+		t.Fatalf("pending migrations: %v", err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending migrations, got %v", pending)
+	}
+
+	var tables int
+	if err = db.QueryRow(`SELECT COUNT(1) FROM sqlite_master WHERE name = 'schema_migrations'`).Scan(&tables); err != nil {
+		t.Fatalf("inspect schema: %v", err)
+	}
+	if tables != 0 {
+		t.Fatalf("expected dry run to leave the database untouched")
+	}
+
+	applied, err := applyMigrations(db, files)
+	if err != nil || len(applied) != 2 {
+		t.Fatalf("expected 2 applied migrations, got %v (%v)", applied, err)
+	}
+
+	reverted, err := RollbackMigrations(db, files, 1)
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if len(reverted) != 1 || reverted[0] != "002_create_tags.sql" {
+		t.Fatalf("expected 002 to be reverted, got %v", reverted)
+	}
+	if err = db.QueryRow(`SELECT COUNT(1) FROM sqlite_master WHERE name = 'tags'`).Scan(&tables); err != nil {
+		t.Fatalf("inspect schema: %v", err)
+	}
+	if tables != 0 {
+		t.Fatalf("expected tags table to be dropped")
+	}
+
+	statuses, err := MigrationStatuses(context.Background(), db, files)
+	if err != nil {
+		t.Fatalf("migration statuses: %v", err)
+	}
+	if !statuses[0].Applied || statuses[1].Applied || !statuses[1].Reversible {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+
+	delete(files, "001_create_notes.down.sql")
+	if _, err = RollbackMigrations(db, files, 0); err == nil || !strings.Contains(err.Error(), "no down migration") {
+		t.Fatalf("expected missing down migration error, got %v", err)
+	}
+
+	if _, err = RollbackMigrations(db, files, 5); err == nil {
+		t.Fatalf("expected out of range target version to fail")
+	}
+}
+
+func TestMigrationFileValidation(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"is missing": {
+			"001_a.sql": {Data: []byte(`SELECT 1;`)},
+			"003_c.sql": {Data: []byte(`SELECT 1;`)},
+		},
+		"share version": {
+			"001_a.sql": {Data: []byte(`SELECT 1;`)},
+			"001_b.sql": {Data: []byte(`SELECT 1;`)},
+		},
+		"invalid migration file name": {
+			"001_a.sql":      {Data: []byte(`SELECT 1;`)},
+			"second-one.sql": {Data: []byte(`SELECT 1;`)},
+		},
+		"no matching up migration": {
+			"001_a.sql":      {Data: []byte(`SELECT 1;`)},
+			"002_b.down.sql": {Data: []byte(`SELECT 1;`)},
+		},
+	}
+
+	for expected, files := range cases {
+		if _, err := loadMigrations(files); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected error containing %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestMigrationChecksumsBackfilledForLegacyDatabases(t *testing.T) {
+	path := openMigrationTestDatabase(t)
+	files := newMigrationTestFiles()
+
+	db, err := OpenDatabase(path)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err = db.Exec(`
+		CREATE TABLE schema_migrations (version TEXT PRIMARY KEY, applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP);
+		CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT NOT NULL);
+		INSERT INTO schema_migrations(version) VALUES ('001_create_notes.sql');
+	`); err != nil {
+		t.Fatalf("create legacy schema: %v", err)
+	}
+
+	applied, err := applyMigrations(db, files)
+	if err != nil || len(applied) != 1 || applied[0] != "002_create_tags.sql" {
+		t.Fatalf("expected only 002 to be applied, got %v (%v)", applied, err)
+	}
+
+	var checksum string
+	if err = db.QueryRow(`SELECT checksum FROM schema_migrations WHERE version = '001_create_notes.sql'`).Scan(&checksum); err != nil {
+		t.Fatalf("load checksum: %v", err)
+	}
+	if checksum != migrationChecksum(files["001_create_notes.sql"].Data) {
+		t.Fatalf("expected legacy checksum to be backfilled")
+	}
+}
@@ -0,0 +1,128 @@
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// incomingRequestIDPattern limits which client supplied request IDs are kept,
+// so log lines and the echoed header stay safe to print.
+var incomingRequestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIDKey struct{}
+
+// requestIDMiddleware makes sure every request carries an X-Request-ID. A
+// well-formed incoming ID is kept, otherwise a new one is generated. The ID is
+// echoed on the response and stored in the request context.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		id := request.Header.Get(requestIDHeader)
+		if !incomingRequestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+
+		writer.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(writer, request.WithContext(context.WithValue(request.Context(), requestIDKey{}, id)))
+	})
+}
+
+func newRequestID() string {
+	buffer := make([]byte, 16)
+	rand.Read(buffer)
+	return hex.EncodeToString(buffer)
+}
+
+// requestID returns the ID assigned by requestIDMiddleware, or "" outside it.
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// observeMiddleware writes one structured log line per request and feeds the
+// request metrics. The route is the ServeMux pattern that matched, which keeps
+// IDs out of the metric labels.
+func (application app) observeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		started := time.Now()
+		recorder := &statusRecorder{ResponseWriter: writer}
+
+		next.ServeHTTP(recorder, request)
+
+		elapsed := time.Since(started)
+		status := recorder.statusCode()
+		route := request.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+
+		application.metrics.observeRequest(request.Method, route, status, recorder.bytes, elapsed)
+		application.log().LogAttrs(request.Context(), requestLogLevel(status), "request",
+			slog.String("request_id", requestID(request.Context())),
+			slog.String("method", request.Method),
+			slog.String("route", route),
+			slog.String("path", request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(elapsed.Microseconds())/1000),
+			slog.Int64("bytes", recorder.bytes),
+		)
+	})
+}
+
+func requestLogLevel(status int) slog.Level {
+	if status >= http.StatusInternalServerError {
+		return slog.LevelError
+	}
+
+	return slog.LevelInfo
+}
+
+func (application app) log() *slog.Logger {
+	if application.logger == nil {
+		return slog.Default()
+	}
+
+	return application.logger
+}
+
+// statusRecorder remembers the status code and body size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *statusRecorder) Write(data []byte) (int, error) {
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	written, err := recorder.ResponseWriter.Write(data)
+	recorder.bytes += int64(written)
+	return written, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (recorder *statusRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
+
+func (recorder *statusRecorder) statusCode() int {
+	if recorder.status == 0 {
+		return http.StatusOK
+	}
+
+	return recorder.status
+}
@@ -0,0 +1,128 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestIDIsAssignedAndPropagated(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	generated := performRequest(router, http.MethodGet, "/api/health", nil)
+	if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(generated.Header().Get(requestIDHeader)) {
+		t.Fatalf("expected a generated request id, got %q", generated.Header().Get(requestIDHeader))
+	}
+
+	propagated := performRequestWithHeaders(router, http.MethodGet, "/api/health", nil, map[string]string{requestIDHeader: "client-42"})
+	if got := propagated.Header().Get(requestIDHeader); got != "client-42" {
+		t.Fatalf("expected incoming request id to be kept, got %q", got)
+	}
+
+	replaced := performRequestWithHeaders(router, http.MethodGet, "/api/health", nil, map[string]string{requestIDHeader: "not valid {id}"})
+	if got := replaced.Header().Get(requestIDHeader); got == "not valid {id}" || got == "" {
+		t.Fatalf("expected malformed request id to be replaced, got %q", got)
+	}
+}
+
+func TestRequestLogIsStructuredJSON(t *testing.T) {
+	application := newTestApplication(t)
+	var output bytes.Buffer
+	application.logger = slog.New(slog.NewJSONHandler(&output, nil))
+	router := application.routes()
+
+	response := performRequest(router, http.MethodGet, "/api/banks/999", nil)
+	if response.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", response.Code)
+	}
+
+	var line struct {
+		Level     string  `json:"level"`
+		Message   string  `json:"msg"`
+		RequestID string  `json:"request_id"`
+		Method    string  `json:"method"`
+		Route     string  `json:"route"`
+		Path      string  `json:"path"`
+		Status    int     `json:"status"`
+		LatencyMS float64 `json:"latency_ms"`
+		Bytes     int64   `json:"bytes"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &line); err != nil {
+		t.Fatalf("expected one JSON log line, got %q: %v", output.String(), err)
+	}
+	if line.Message != "request" || line.Level != "INFO" || line.Method != http.MethodGet || line.Path != "/api/banks/999" {
+		t.Fatalf("unexpected log line: %+v", line)
+	}
+	if line.Route != banksPathByID || line.Status != http.StatusNotFound {
+		t.Fatalf("expected route pattern and status in log line, got %+v", line)
+	}
+	if line.RequestID != response.Header().Get(requestIDHeader) || line.Bytes != int64(response.Body.Len()) || line.LatencyMS < 0 {
+		t.Fatalf("expected request id, bytes and latency in log line, got %+v", line)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	performRequest(router, http.MethodPost, "/api/banks", []byte(`{"name":"Bank One","country":"US"}`))
+	performRequest(router, http.MethodGet, "/api/banks", nil)
+	performRequest(router, http.MethodGet, "/api/banks", nil)
+	performRequest(router, http.MethodGet, "/api/banks/1", nil)
+	performRequest(router, http.MethodGet, "/api/banks/2", nil)
+
+	response := performRequest(router, http.MethodGet, metricsPath, nil)
+	if response.Code != http.StatusOK {
+		t.Fatalf("expected 200 for metrics, got %d", response.Code)
+	}
+	if contentType := response.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Fatalf("expected Prometheus text format, got %q", contentType)
+	}
+
+	body := response.Body.String()
+	for _, expected := range []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="POST",route="/api/banks",status="201"} 1`,
+		`http_requests_total{method="GET",route="/api/banks",status="200"} 2`,
+		`http_requests_total{method="GET",route="/api/banks/",status="200"} 1`,
+		`http_requests_total{method="GET",route="/api/banks/",status="404"} 1`,
+		"# TYPE http_request_duration_seconds histogram",
+		`http_request_duration_seconds_bucket{method="GET",route="/api/banks",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/api/banks/"} 2`,
+		`http_response_size_bytes_total{method="GET",route="/api/banks"}`,
+		`db_query_duration_seconds_bucket{statement="insert",le="+Inf"} `,
+		`db_query_duration_seconds_count{statement="select"} `,
+		"# TYPE db_open_connections gauge",
+		`db_max_open_connections{pool="writer"} 1`,
+		`db_open_connections{pool="reader"} `,
+		`db_wait_count_total{pool="writer"} `,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Fatalf("expected metrics to contain %q, got:\n%s", expected, body)
+		}
+	}
+
+	notAllowed := performRequest(router, http.MethodPost, metricsPath, nil)
+	if notAllowed.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for POST /metrics, got %d", notAllowed.Code)
+	}
+}
+
+func TestMetricsKeepRouteWithQueryDeadline(t *testing.T) {
+	application := newTestApplication(t)
+	application.queryTimeout = time.Minute
+	router := application.routes()
+
+	performRequest(router, http.MethodGet, "/api/banks", nil)
+
+	body := performRequest(router, http.MethodGet, metricsPath, nil).Body.String()
+	if !strings.Contains(body, `http_requests_total{method="GET",route="/api/banks",status="200"} 1`) {
+		t.Fatalf("expected the route pattern as metric label with a query deadline, got:\n%s", body)
+	}
+}
@@ -1,12 +1,8 @@
 package backend
 
 import (
-	"database/sql"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 )
 
 const (
@@ -27,12 +23,13 @@ type personPayload struct {
 func (application app) registerPeopleRoutes(mux *http.ServeMux) {
 	mux.HandleFunc(peoplePath, application.peopleHandler)
 	mux.HandleFunc(peoplePathByID, application.personByIDHandler)
+	mux.HandleFunc(peoplePath+batchPathSuffix, application.batchHandler(peoplePath, app.peopleHandler, app.personByIDHandler))
 }
 
 func (application app) peopleHandler(writer http.ResponseWriter, request *http.Request) {
 	switch request.Method {
 	case http.MethodGet:
-		application.listPeople(writer)
+		application.listPeople(writer, request)
 	case http.MethodPost:
 		application.createPerson(writer, request)
 	default:
@@ -49,135 +46,89 @@ func (application app) personByIDHandler(writer http.ResponseWriter, request *ht
 
 	switch request.Method {
 	case http.MethodGet:
-		application.getPerson(writer, id)
+		application.getPerson(writer, request, id)
 	case http.MethodPut:
 		application.updatePerson(writer, request, id)
+	case http.MethodPatch:
+		application.patchPerson(writer, request, id)
 	case http.MethodDelete:
-		application.deletePerson(writer, id)
+		application.deletePerson(writer, request, id)
 	default:
-		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodDelete)
+		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
 	}
 }
 
-func (application app) listPeople(writer http.ResponseWriter) {
-	rows, err := application.db.Query(`SELECT id, name FROM people ORDER BY id`)
+func (application app) listPeople(writer http.ResponseWriter, request *http.Request) {
+	items, err := application.services().people.list(request.Context())
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load people")
+		writeServiceError(writer, err, "failed to load people")
 		return
 	}
-	defer rows.Close()
-
-	items := make([]person, 0)
-	for rows.Next() {
-		var item person
-		if scanErr := rows.Scan(&item.ID, &item.Name); scanErr != nil {
-			writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read people")
-			return
-		}
-		items = append(items, item)
-	}
 
 	writeJSON(writer, http.StatusOK, items)
 }
 
-func (application app) getPerson(writer http.ResponseWriter, id int64) {
-	var item person
-	err := application.db.QueryRow(`SELECT id, name FROM people WHERE id = ?`, id).Scan(&item.ID, &item.Name)
-	if errors.Is(err, sql.ErrNoRows) {
-		writeError(writer, http.StatusNotFound, "not_found", "person not found")
-		return
-	}
+func (application app) getPerson(writer http.ResponseWriter, request *http.Request, id int64) {
+	item, err := application.services().people.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load person")
+		writeServiceError(writer, err, "failed to load person")
 		return
 	}
 
-	writeJSON(writer, http.StatusOK, item)
+	writeJSONWithETag(writer, http.StatusOK, item)
 }
 
 func (application app) createPerson(writer http.ResponseWriter, request *http.Request) {
-	payload, validationErr := decodePersonPayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
-		return
-	}
-
-	result, err := application.db.Exec(`INSERT INTO people(name) VALUES (?)`, payload.Name)
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to create person")
+	var payload personPayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	id, err := result.LastInsertId()
+	created, err := application.services().people.create(request.Context(), requestChange(request), payload)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read created person id")
+		writeServiceError(writer, err, "failed to create person")
 		return
 	}
 
-	created := person{ID: id, Name: payload.Name}
-	writer.Header().Set("Location", fmt.Sprintf(personPathPattern, id))
-	writeJSON(writer, http.StatusCreated, created)
+	writer.Header().Set("Location", fmt.Sprintf(personPathPattern, created.ID))
+	writeJSONWithETag(writer, http.StatusCreated, created)
 }
 
 func (application app) updatePerson(writer http.ResponseWriter, request *http.Request, id int64) {
-	payload, validationErr := decodePersonPayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
+	var payload personPayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	result, err := application.db.Exec(`UPDATE people SET name = ? WHERE id = ?`, payload.Name, id)
+	updated, err := application.services().people.update(request.Context(), requestChange(request), id, payload)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to update person")
+		writeServiceError(writer, err, "failed to update person")
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read update result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "person not found")
-		return
-	}
-
-	updated := person{ID: id, Name: payload.Name}
-	writeJSON(writer, http.StatusOK, updated)
+	writeJSONWithETag(writer, http.StatusOK, updated)
 }
 
-func (application app) deletePerson(writer http.ResponseWriter, id int64) {
-	result, err := application.db.Exec(`DELETE FROM people WHERE id = ?`, id)
+func (application app) patchPerson(writer http.ResponseWriter, request *http.Request, id int64) {
+	current, err := application.services().people.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to delete person")
+		writeServiceError(writer, err, "failed to load person")
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read delete result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "person not found")
+	mergedRequest, ok := mergePatchRequest(writer, request, current)
+	if !ok {
 		return
 	}
 
-	writer.WriteHeader(http.StatusNoContent)
+	application.updatePerson(writer, mergedRequest, id)
 }
 
-func decodePersonPayload(request *http.Request) (personPayload, error) {
-	defer request.Body.Close()
-
-	var payload personPayload
-	if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
-		return personPayload{}, fmt.Errorf("request body must be valid JSON")
-	}
-
-	payload.Name = strings.TrimSpace(payload.Name)
-	if payload.Name == "" {
-		return personPayload{}, fmt.Errorf("name is required")
+func (application app) deletePerson(writer http.ResponseWriter, request *http.Request, id int64) {
+	if err := application.services().people.delete(request.Context(), requestChange(request), id); err != nil {
+		writeServiceError(writer, err, "failed to delete person")
+		return
 	}
 
-	return payload, nil
+	writer.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,59 @@
+package backend
+
+import "context"
+
+type personRepository interface {
+	list(ctx context.Context) ([]person, error)
+	get(ctx context.Context, id int64) (person, error)
+	create(ctx context.Context, payload personPayload) (int64, error)
+	update(ctx context.Context, id int64, payload personPayload) error
+	// delete soft deletes the person.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlPersonRepository struct {
+	source queryer
+}
+
+func (repository sqlPersonRepository) list(ctx context.Context) ([]person, error) {
+	rows, err := repository.source.QueryContext(ctx, `SELECT id, name FROM people WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]person, 0)
+	for rows.Next() {
+		var item person
+		if scanErr := rows.Scan(&item.ID, &item.Name); scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlPersonRepository) get(ctx context.Context, id int64) (person, error) {
+	var item person
+	err := repository.source.QueryRowContext(ctx, `SELECT id, name FROM people WHERE id = ? AND deleted_at IS NULL`, id).Scan(&item.ID, &item.Name)
+	if err != nil {
+		return person{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlPersonRepository) create(ctx context.Context, payload personPayload) (int64, error) {
+	return insertRow(ctx, repository.source, `INSERT INTO people(name) VALUES (?)`, payload.Name)
+}
+
+func (repository sqlPersonRepository) update(ctx context.Context, id int64, payload personPayload) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE people SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, payload.Name, id)
+	return constraintError(err)
+}
+
+func (repository sqlPersonRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE people SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
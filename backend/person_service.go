@@ -0,0 +1,112 @@
+package backend
+
+import (
+	"context"
+	"strings"
+)
+
+type personService struct {
+	store dataStore
+}
+
+func (payload personPayload) normalize() (personPayload, error) {
+	payload.Name = strings.TrimSpace(payload.Name)
+	if payload.Name == "" {
+		return personPayload{}, invalidPayload("name is required")
+	}
+
+	return payload, nil
+}
+
+func (service personService) list(ctx context.Context) ([]person, error) {
+	return service.store.reads().people().list(ctx)
+}
+
+func (service personService) get(ctx context.Context, id int64) (person, error) {
+	item, err := service.store.reads().people().get(ctx, id)
+	if err != nil {
+		return person{}, orNotFound(err, "person not found")
+	}
+
+	return item, nil
+}
+
+func (service personService) create(ctx context.Context, by change, payload personPayload) (person, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return person{}, err
+	}
+
+	var created person
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		id, err := tx.people().create(ctx, payload)
+		if err != nil {
+			return err
+		}
+
+		created = person{ID: id, Name: payload.Name}
+		return tx.audit().record(ctx, by.record(auditEntityPeople, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return person{}, err
+	}
+
+	return created, nil
+}
+
+func (service personService) update(ctx context.Context, by change, id int64, payload personPayload) (person, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return person{}, err
+	}
+
+	var updated person
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.people().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "person not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = tx.people().update(ctx, id, payload); err != nil {
+			return err
+		}
+
+		updated = person{ID: id, Name: payload.Name}
+		return tx.audit().record(ctx, by.record(auditEntityPeople, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return person{}, err
+	}
+
+	return updated, nil
+}
+
+// delete soft deletes a person nothing live refers to.
+func (service personService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.people().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "person not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		inUse, err := tx.trash().hasDependents(ctx, auditEntityPeople, id)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return conflict("person_in_use", "person is in use")
+		}
+
+		if err = tx.people().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityPeople, id, auditActionDelete, existing, nil))
+	})
+}
@@ -0,0 +1,216 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// postgresBaseDriver is the PostgreSQL driver compiled into the binary, or
+// nil. It is set by postgres_pgx.go, which is only built with -tags postgres.
+var postgresBaseDriver driver.Driver
+
+// ErrPostgresUnavailable is returned for a PostgreSQL DSN when the binary was
+// built without a PostgreSQL driver.
+var ErrPostgresUnavailable = errors.New("this build has no PostgreSQL driver, rebuild with -tags postgres")
+
+// postgresSessionSettings run on every new connection, like the SQLite
+// connection pragmas. Timestamps are written and read in UTC, as SQLite's
+// CURRENT_TIMESTAMP does.
+var postgresSessionSettings = []string{
+	"SET TIME ZONE 'UTC'",
+}
+
+// openPostgres opens a pool on the PostgreSQL server named by dsn. Read-only
+// pools refuse writes with default_transaction_read_only.
+func openPostgres(dsn string, readOnly bool) (*sql.DB, error) {
+	if postgresBaseDriver == nil {
+		return nil, ErrPostgresUnavailable
+	}
+
+	settings := postgresSessionSettings
+	if readOnly {
+		settings = append(settings[:len(settings):len(settings)], "SET default_transaction_read_only = on")
+	}
+
+	db := sql.OpenDB(postgresConnector{dsn: dsn, settings: settings})
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ping db: %w", err)
+	}
+
+	return db, nil
+}
+
+// postgresDriver wraps the PostgreSQL driver so the queries written for
+// SQLite run unchanged: ? placeholders are rewritten to $1, $2 and so on.
+// dialectOf recognizes a PostgreSQL pool by this driver type.
+type postgresDriver struct{}
+
+func (postgresDriver) Open(dsn string) (driver.Conn, error) {
+	return postgresConnector{dsn: dsn, settings: postgresSessionSettings}.Connect(context.Background())
+}
+
+type postgresConnector struct {
+	dsn      string
+	settings []string
+}
+
+func (connector postgresConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := postgresBaseDriver.Open(connector.dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := postgresConn{conn: conn}
+	for _, setting := range connector.settings {
+		if _, err = wrapped.ExecContext(ctx, setting, nil); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s: %w", setting, err)
+		}
+	}
+
+	return wrapped, nil
+}
+
+func (postgresConnector) Driver() driver.Driver {
+	return postgresDriver{}
+}
+
+// postgresConn rewrites placeholders and otherwise hands every call to the
+// wrapped connection, reporting driver.ErrSkip for optional interfaces the
+// wrapped connection does not implement so database/sql falls back.
+type postgresConn struct {
+	conn driver.Conn
+}
+
+func (wrapped postgresConn) Prepare(query string) (driver.Stmt, error) {
+	return wrapped.conn.Prepare(rebindPlaceholders(query))
+}
+
+func (wrapped postgresConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := wrapped.conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, rebindPlaceholders(query))
+	}
+
+	return wrapped.Prepare(query)
+}
+
+func (wrapped postgresConn) Close() error {
+	return wrapped.conn.Close()
+}
+
+func (wrapped postgresConn) Begin() (driver.Tx, error) {
+	return wrapped.conn.Begin()
+}
+
+func (wrapped postgresConn) BeginTx(ctx context.Context, options driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := wrapped.conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, options)
+	}
+
+	return wrapped.Begin()
+}
+
+func (wrapped postgresConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := wrapped.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	return execer.ExecContext(ctx, rebindPlaceholders(query), args)
+}
+
+func (wrapped postgresConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := wrapped.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	return queryer.QueryContext(ctx, rebindPlaceholders(query), args)
+}
+
+func (wrapped postgresConn) Ping(ctx context.Context) error {
+	if pinger, ok := wrapped.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (wrapped postgresConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := wrapped.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (wrapped postgresConn) IsValid() bool {
+	if validator, ok := wrapped.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+func (wrapped postgresConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := wrapped.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+
+	return driver.ErrSkip
+}
+
+// rebindPlaceholders rewrites ? placeholders to PostgreSQL's numbered $n
+// form. Question marks inside string literals, quoted identifiers and
+// comments are left alone.
+func rebindPlaceholders(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+
+	var rebound strings.Builder
+	rebound.Grow(len(query) + 8)
+	next := 1
+	for index := 0; index < len(query); index++ {
+		char := query[index]
+		switch {
+		case char == '\'' || char == '"':
+			end := strings.IndexByte(query[index+1:], char)
+			if end < 0 {
+				rebound.WriteString(query[index:])
+				return rebound.String()
+			}
+			rebound.WriteString(query[index : index+end+2])
+			index += end + 1
+		case strings.HasPrefix(query[index:], "--"):
+			end := strings.IndexByte(query[index:], '\n')
+			if end < 0 {
+				rebound.WriteString(query[index:])
+				return rebound.String()
+			}
+			rebound.WriteString(query[index : index+end+1])
+			index += end
+		case strings.HasPrefix(query[index:], "/*"):
+			end := strings.Index(query[index+2:], "*/")
+			if end < 0 {
+				rebound.WriteString(query[index:])
+				return rebound.String()
+			}
+			rebound.WriteString(query[index : index+end+4])
+			index += end + 3
+		case char == '?':
+			rebound.WriteString("$" + strconv.Itoa(next))
+			next++
+		default:
+			rebound.WriteByte(char)
+		}
+	}
+
+	return rebound.String()
+}
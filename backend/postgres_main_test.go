@@ -0,0 +1,66 @@
+//go:build postgres
+
+package backend
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+)
+
+// TestMain runs the suite against a throwaway PostgreSQL server when built
+// with -tags postgres, unless TEST_DATABASE_URL already names one. The server
+// binaries are downloaded once into ~/.embedded-postgres-go.
+func TestMain(m *testing.M) {
+	if os.Getenv(testDatabaseEnv) != "" {
+		os.Exit(m.Run())
+	}
+
+	os.Exit(runWithEmbeddedPostgres(m))
+}
+
+func runWithEmbeddedPostgres(m *testing.M) int {
+	dir, err := os.MkdirTemp("", "personal-finances-postgres-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create PostgreSQL directory: %v\n", err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	port, err := freePort()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pick PostgreSQL port: %v\n", err)
+		return 1
+	}
+
+	config := embeddedpostgres.DefaultConfig().
+		Port(port).
+		Database("finances_test").
+		RuntimePath(filepath.Join(dir, "runtime")).
+		DataPath(filepath.Join(dir, "data")).
+		Logger(io.Discard)
+	server := embeddedpostgres.NewDatabase(config)
+	if err = server.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "start embedded PostgreSQL: %v\n", err)
+		return 1
+	}
+	defer server.Stop()
+
+	os.Setenv(testDatabaseEnv, config.GetConnectionURL()+"?sslmode=disable")
+	return m.Run()
+}
+
+func freePort() (uint32, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+
+	return uint32(listener.Addr().(*net.TCPAddr).Port), nil
+}
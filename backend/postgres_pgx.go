@@ -0,0 +1,10 @@
+//go:build postgres
+
+package backend
+
+import "github.com/jackc/pgx/v5/stdlib"
+
+// Building with -tags postgres links the pgx driver.
+func init() {
+	postgresBaseDriver = stdlib.GetDefaultDriver()
+}
@@ -0,0 +1,60 @@
+package backend
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"testing"
+
+	"personal-finances/migrations"
+)
+
+func TestRebindPlaceholders(t *testing.T) {
+	cases := map[string]string{
+		`SELECT id FROM people WHERE id = ?`:                      `SELECT id FROM people WHERE id = $1`,
+		`INSERT INTO banks(name, country) VALUES (?, ?)`:          `INSERT INTO banks(name, country) VALUES ($1, $2)`,
+		`SELECT '?', "odd?name", ? FROM t -- why?` + "\n" + `, ?`: `SELECT '?', "odd?name", $1 FROM t -- why?` + "\n" + `, $2`,
+		`SELECT 'it''s ?' /* ? */ WHERE a = ?`:                    `SELECT 'it''s ?' /* ? */ WHERE a = $1`,
+		`SELECT 1`:                                                `SELECT 1`,
+	}
+	for query, expected := range cases {
+		if rebound := rebindPlaceholders(query); rebound != expected {
+			t.Errorf("rebind %q: expected %q, got %q", query, expected, rebound)
+		}
+	}
+}
+
+func TestPostgresMigrationsMirrorSQLite(t *testing.T) {
+	sqliteNames, err := fs.Glob(migrations.Files, "*.sql")
+	if err != nil {
+		t.Fatalf("list sqlite migrations: %v", err)
+	}
+	postgresNames, err := fs.Glob(migrations.Files, "postgres/*.sql")
+	if err != nil {
+		t.Fatalf("list postgres migrations: %v", err)
+	}
+	for index, name := range postgresNames {
+		postgresNames[index] = name[len("postgres/"):]
+	}
+	if !slices.Equal(sqliteNames, postgresNames) {
+		t.Fatalf("every migration needs a PostgreSQL version with the same name:\nsqlite:   %v\npostgres: %v", sqliteNames, postgresNames)
+	}
+}
+
+func TestDatabaseEngineFollowsTheDSN(t *testing.T) {
+	application := newTestApplication(t)
+	expected := sqliteDialect.name
+	if IsPostgresDSN(os.Getenv(testDatabaseEnv)) {
+		expected = postgresDialect.name
+	}
+	if engine := dialectOf(application.db); engine.name != expected {
+		t.Fatalf("expected the %s dialect, got %s", expected, engine.name)
+	}
+
+	if postgresBaseDriver == nil {
+		if _, err := OpenDatabase("postgres://localhost/finances"); !errors.Is(err, ErrPostgresUnavailable) {
+			t.Fatalf("expected ErrPostgresUnavailable without a driver, got %v", err)
+		}
+	}
+}
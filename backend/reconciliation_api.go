@@ -0,0 +1,221 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	reconciliationsPath       = "/api/reconciliations"
+	reconciliationsPathByID   = "/api/reconciliations/"
+	reconciliationPathPattern = "/api/reconciliations/%d"
+)
+
+// Statuses of a reconciliation session.
+const (
+	reconciliationOpen   = "open"
+	reconciliationLocked = "locked"
+)
+
+// Reconciliation statuses of a transaction: not yet matched to a statement,
+// ticked off in an open session, or vouched for by a locked one.
+const (
+	transactionUncleared  = "uncleared"
+	transactionCleared    = "cleared"
+	transactionReconciled = "reconciled"
+)
+
+// reconciliationActions are the POST /api/reconciliations/{id}/<action>
+// routes of a session.
+var reconciliationActions = []string{"clear", "unclear", "lock", "unlock"}
+
+type reconciliation struct {
+	ID                  int64   `json:"id"`
+	BankAccountID       int64   `json:"bank_account_id"`
+	StatementDate       string  `json:"statement_date"`
+	OpeningBalance      float64 `json:"opening_balance"`
+	StatementBalance    float64 `json:"statement_balance"`
+	ClearedBalance      float64 `json:"cleared_balance"`
+	Difference          float64 `json:"difference"`
+	ClearedTransactions int     `json:"cleared_transactions"`
+	Status              string  `json:"status"`
+	LockedAt            *string `json:"locked_at"`
+}
+
+type reconciliationPayload struct {
+	BankAccountID    int64    `json:"bank_account_id"`
+	StatementDate    string   `json:"statement_date"`
+	StatementBalance *float64 `json:"statement_balance"`
+	// OpeningBalance defaults to the statement balance of the account's last
+	// locked session, or 0 for its first one.
+	OpeningBalance *float64 `json:"opening_balance"`
+}
+
+type reconciliationTransactionsPayload struct {
+	TransactionIDs []int64 `json:"transaction_ids"`
+}
+
+func (application app) registerReconciliationRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(reconciliationsPath, application.reconciliationsHandler)
+	mux.HandleFunc(reconciliationsPathByID, application.reconciliationByIDHandler)
+	mux.HandleFunc(reconciliationsPath+batchPathSuffix, application.batchHandler(reconciliationsPath, app.reconciliationsHandler, app.reconciliationByIDHandler))
+}
+
+func (application app) reconciliationsHandler(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		application.listReconciliations(writer, request)
+	case http.MethodPost:
+		application.createReconciliation(writer, request)
+	default:
+		methodNotAllowed(writer, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (application app) reconciliationByIDHandler(writer http.ResponseWriter, request *http.Request) {
+	for _, action := range reconciliationActions {
+		if path, ok := strings.CutSuffix(request.URL.Path, "/"+action); ok {
+			application.reconciliationActionHandler(writer, request, path, action)
+			return
+		}
+	}
+
+	id, err := parseIDFromPath(request.URL.Path, reconciliationsPathByID)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_id", "reconciliation id must be a positive integer")
+		return
+	}
+
+	switch request.Method {
+	case http.MethodGet:
+		application.getReconciliation(writer, request, id)
+	case http.MethodPut:
+		application.updateReconciliation(writer, request, id)
+	case http.MethodPatch:
+		application.patchReconciliation(writer, request, id)
+	case http.MethodDelete:
+		application.deleteReconciliation(writer, request, id)
+	default:
+		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
+	}
+}
+
+func (application app) reconciliationActionHandler(writer http.ResponseWriter, request *http.Request, path string, action string) {
+	id, err := parseIDFromPath(path, reconciliationsPathByID)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_id", "reconciliation id must be a positive integer")
+		return
+	}
+	if request.Method != http.MethodPost {
+		methodNotAllowed(writer, http.MethodPost)
+		return
+	}
+
+	service := application.services().reconciliations
+	var updated reconciliation
+	switch action {
+	case "clear", "unclear":
+		var payload reconciliationTransactionsPayload
+		if !decodeJSON(writer, request, &payload) {
+			return
+		}
+		updated, err = service.clear(request.Context(), requestChange(request), id, payload.TransactionIDs, action == "clear")
+	case "lock":
+		updated, err = service.lock(request.Context(), requestChange(request), id)
+	case "unlock":
+		updated, err = service.unlock(request.Context(), requestChange(request), id)
+	}
+	if err != nil {
+		writeServiceError(writer, err, "failed to "+action+" reconciliation")
+		return
+	}
+
+	writeJSONWithETag(writer, http.StatusOK, updated)
+}
+
+func (application app) listReconciliations(writer http.ResponseWriter, request *http.Request) {
+	var bankAccountID int64
+	if raw := strings.TrimSpace(request.URL.Query().Get("bank_account_id")); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 {
+			writeError(writer, http.StatusBadRequest, "invalid_query", "bank_account_id must be a positive integer")
+			return
+		}
+		bankAccountID = parsed
+	}
+
+	items, err := application.services().reconciliations.list(request.Context(), bankAccountID)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load reconciliations")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, items)
+}
+
+func (application app) getReconciliation(writer http.ResponseWriter, request *http.Request, id int64) {
+	item, err := application.services().reconciliations.get(request.Context(), id)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load reconciliation")
+		return
+	}
+
+	writeJSONWithETag(writer, http.StatusOK, item)
+}
+
+func (application app) createReconciliation(writer http.ResponseWriter, request *http.Request) {
+	var payload reconciliationPayload
+	if !decodeJSON(writer, request, &payload) {
+		return
+	}
+
+	created, err := application.services().reconciliations.create(request.Context(), requestChange(request), payload)
+	if err != nil {
+		writeServiceError(writer, err, "failed to create reconciliation")
+		return
+	}
+
+	writer.Header().Set("Location", fmt.Sprintf(reconciliationPathPattern, created.ID))
+	writeJSONWithETag(writer, http.StatusCreated, created)
+}
+
+func (application app) updateReconciliation(writer http.ResponseWriter, request *http.Request, id int64) {
+	var payload reconciliationPayload
+	if !decodeJSON(writer, request, &payload) {
+		return
+	}
+
+	updated, err := application.services().reconciliations.update(request.Context(), requestChange(request), id, payload)
+	if err != nil {
+		writeServiceError(writer, err, "failed to update reconciliation")
+		return
+	}
+
+	writeJSONWithETag(writer, http.StatusOK, updated)
+}
+
+func (application app) patchReconciliation(writer http.ResponseWriter, request *http.Request, id int64) {
+	current, err := application.services().reconciliations.get(request.Context(), id)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load reconciliation")
+		return
+	}
+
+	mergedRequest, ok := mergePatchRequest(writer, request, current)
+	if !ok {
+		return
+	}
+
+	application.updateReconciliation(writer, mergedRequest, id)
+}
+
+func (application app) deleteReconciliation(writer http.ResponseWriter, request *http.Request, id int64) {
+	if err := application.services().reconciliations.delete(request.Context(), requestChange(request), id); err != nil {
+		writeServiceError(writer, err, "failed to delete reconciliation")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,122 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestReconciliationSessionFlow(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedReconciliationDependencies(t, router)
+
+	var created reconciliation
+	decodeResponse(t, expectResponse(t, router, http.MethodPost, "/api/reconciliations", `{"bank_account_id":1,"statement_date":"2026-01-31","statement_balance":957.5}`, http.StatusCreated), &created)
+	if created.Status != reconciliationOpen || created.OpeningBalance != 0 || created.Difference != 957.5 || created.ClearedTransactions != 0 {
+		t.Fatalf("unexpected new session: %+v", created)
+	}
+	expectResponse(t, router, http.MethodPost, "/api/reconciliations", `{"bank_account_id":1,"statement_date":"2026-02-28","statement_balance":1}`, http.StatusConflict)
+	expectResponse(t, router, http.MethodPost, "/api/reconciliations", `{"bank_account_id":1,"statement_date":"2026-02-28"}`, http.StatusBadRequest)
+	expectResponse(t, router, http.MethodPost, "/api/reconciliations", `{"bank_account_id":99,"statement_date":"2026-02-28","statement_balance":1}`, http.StatusBadRequest)
+
+	var partial reconciliation
+	decodeResponse(t, expectResponse(t, router, http.MethodPost, "/api/reconciliations/1/clear", `{"transaction_ids":[1]}`, http.StatusOK), &partial)
+	if partial.ClearedBalance != 1000 || partial.Difference != -42.5 || partial.ClearedTransactions != 1 {
+		t.Fatalf("unexpected running difference: %+v", partial)
+	}
+	expectResponse(t, router, http.MethodPost, "/api/reconciliations/1/clear", `{"transaction_ids":[3]}`, http.StatusBadRequest)
+	expectResponse(t, router, http.MethodPost, "/api/reconciliations/1/clear", `{"transaction_ids":[4]}`, http.StatusBadRequest)
+	expectResponse(t, router, http.MethodPost, "/api/reconciliations/1/clear", `{"transaction_ids":[99]}`, http.StatusBadRequest)
+	expectResponse(t, router, http.MethodPost, "/api/reconciliations/1/clear", `{"transaction_ids":[]}`, http.StatusBadRequest)
+	expectResponse(t, router, http.MethodPost, "/api/reconciliations/1/lock", "", http.StatusConflict)
+
+	var balanced reconciliation
+	decodeResponse(t, expectResponse(t, router, http.MethodPost, "/api/reconciliations/1/clear", `{"transaction_ids":[1,2]}`, http.StatusOK), &balanced)
+	if balanced.Difference != 0 || balanced.ClearedTransactions != 2 {
+		t.Fatalf("expected a balanced session, got %+v", balanced)
+	}
+
+	var cleared transaction
+	if err := json.Unmarshal(expectResponse(t, router, http.MethodGet, "/api/transactions/2", "", http.StatusOK), &cleared); err != nil {
+		t.Fatalf("decode transaction: %v", err)
+	}
+	if cleared.ReconciliationStatus != transactionCleared || cleared.ReconciliationID == nil || *cleared.ReconciliationID != 1 {
+		t.Fatalf("expected transaction 2 to be cleared in session 1, got %+v", cleared)
+	}
+
+	var locked reconciliation
+	decodeResponse(t, expectResponse(t, router, http.MethodPost, "/api/reconciliations/1/lock", "", http.StatusOK), &locked)
+	if locked.Status != reconciliationLocked || locked.LockedAt == nil {
+		t.Fatalf("expected a locked session, got %+v", locked)
+	}
+
+	update := `{"transaction_date":"2026-01-05","type":"income","amount":900,"person_id":1,"bank_account_id":1,"category_id":1}`
+	expectResponse(t, router, http.MethodPut, "/api/transactions/1", update, http.StatusConflict)
+	expectResponse(t, router, http.MethodDelete, "/api/transactions/1", "", http.StatusConflict)
+	expectResponse(t, router, http.MethodPost, "/api/transactions/3/merge", `{"duplicate_id":2}`, http.StatusConflict)
+	expectResponse(t, router, http.MethodPost, "/api/reconciliations/1/unclear", `{"transaction_ids":[2]}`, http.StatusConflict)
+	expectResponse(t, router, http.MethodDelete, "/api/reconciliations/1", "", http.StatusConflict)
+
+	expectResponse(t, router, http.MethodPost, "/api/reconciliations", `{"bank_account_id":1,"statement_date":"2026-01-15","statement_balance":1}`, http.StatusBadRequest)
+	var next reconciliation
+	decodeResponse(t, expectResponse(t, router, http.MethodPost, "/api/reconciliations", `{"bank_account_id":1,"statement_date":"2026-02-28","statement_balance":947.5}`, http.StatusCreated), &next)
+	if next.OpeningBalance != 957.5 || next.Difference != -10 {
+		t.Fatalf("expected the next session to open at the last statement balance, got %+v", next)
+	}
+
+	expectResponse(t, router, http.MethodPost, "/api/reconciliations/1/unlock", "", http.StatusConflict)
+	expectResponse(t, router, http.MethodDelete, "/api/reconciliations/2", "", http.StatusNoContent)
+
+	var unlocked reconciliation
+	decodeResponse(t, expectResponse(t, router, http.MethodPost, "/api/reconciliations/1/unlock", "", http.StatusOK), &unlocked)
+	if unlocked.Status != reconciliationOpen || unlocked.LockedAt != nil || unlocked.ClearedTransactions != 2 {
+		t.Fatalf("expected the session to reopen with its transactions, got %+v", unlocked)
+	}
+	expectResponse(t, router, http.MethodPut, "/api/transactions/1", update, http.StatusOK)
+	expectResponse(t, router, http.MethodPost, "/api/reconciliations/1/unlock", "", http.StatusConflict)
+	expectResponse(t, router, http.MethodPost, "/api/reconciliations/1/unclear", `{"transaction_ids":[2]}`, http.StatusOK)
+	expectResponse(t, router, http.MethodPost, "/api/reconciliations/1/unclear", `{"transaction_ids":[2]}`, http.StatusBadRequest)
+	expectResponse(t, router, http.MethodGet, "/api/reconciliations/1/lock", "", http.StatusMethodNotAllowed)
+
+	var history []reconciliation
+	if err := json.Unmarshal(expectResponse(t, router, http.MethodGet, "/api/reconciliations?bank_account_id=1", "", http.StatusOK), &history); err != nil {
+		t.Fatalf("decode reconciliations: %v", err)
+	}
+	if len(history) != 1 || history[0].ID != 1 || history[0].ClearedBalance != 900 {
+		t.Fatalf("expected the reopened session only, got %+v", history)
+	}
+	expectResponse(t, router, http.MethodGet, "/api/reconciliations?bank_account_id=x", "", http.StatusBadRequest)
+
+	var events []auditEvent
+	if err := json.Unmarshal(expectResponse(t, router, http.MethodGet, "/api/audit?entity=reconciliations&id=1", "", http.StatusOK), &events); err != nil {
+		t.Fatalf("decode audit events: %v", err)
+	}
+	if len(events) != 3 || events[1].Changes["status"].After != reconciliationLocked || events[2].Changes["status"].After != reconciliationOpen {
+		t.Fatalf("expected create, lock and unlock events, got %+v", events)
+	}
+}
+
+func seedReconciliationDependencies(t *testing.T, router http.Handler) {
+	t.Helper()
+
+	seedTransactionDependencies(t, router)
+
+	account := performRequest(router, http.MethodPost, "/api/bank-accounts", []byte(`{"bank_id":1,"currency_id":1,"account_number":"ACC-002","balance":0}`))
+	if account.Code != http.StatusCreated {
+		t.Fatalf("expected second bank account seed to return 201, got %d", account.Code)
+	}
+
+	for _, body := range []string{
+		`{"transaction_date":"2026-01-05","type":"income","amount":1000,"person_id":1,"bank_account_id":1,"category_id":1}`,
+		`{"transaction_date":"2026-01-20","type":"expense","amount":42.5,"person_id":1,"bank_account_id":1,"category_id":1}`,
+		`{"transaction_date":"2026-02-03","type":"expense","amount":10,"person_id":1,"bank_account_id":1,"category_id":1}`,
+		`{"transaction_date":"2026-01-10","type":"expense","amount":5,"person_id":1,"bank_account_id":2,"category_id":1}`,
+	} {
+		response := performRequest(router, http.MethodPost, "/api/transactions", []byte(body))
+		if response.Code != http.StatusCreated {
+			t.Fatalf("expected transaction seed to return 201, got %d", response.Code)
+		}
+	}
+}
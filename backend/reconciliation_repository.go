@@ -0,0 +1,179 @@
+package backend
+
+import (
+	"context"
+	"math"
+)
+
+type reconciliationRepository interface {
+	// list returns the sessions of one bank account, or of every account when
+	// bankAccountID is 0.
+	list(ctx context.Context, bankAccountID int64) ([]reconciliation, error)
+	get(ctx context.Context, id int64) (reconciliation, error)
+	// lastLocked returns the locked session of the account with the latest
+	// statement date, or errNotFound.
+	lastLocked(ctx context.Context, bankAccountID int64) (reconciliation, error)
+	create(ctx context.Context, payload reconciliationPayload) (int64, error)
+	update(ctx context.Context, id int64, payload reconciliationPayload) error
+	// setStatus locks or reopens the session, stamping locked_at on lock.
+	setStatus(ctx context.Context, id int64, status string) error
+	// delete soft deletes the session.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlReconciliationRepository struct {
+	source queryer
+}
+
+// reconciliationSelect computes the cleared balance from the live
+// transactions ticked off in each session.
+const reconciliationSelect = `
+	SELECT
+		r.id, r.bank_account_id, r.statement_date, r.opening_balance, r.statement_balance, r.status, r.locked_at,
+		COALESCE((
+			SELECT SUM(CASE WHEN t.type = 'income' THEN t.amount ELSE -t.amount END)
+			FROM transactions t
+			WHERE t.reconciliation_id = r.id AND t.deleted_at IS NULL
+		), 0),
+		(SELECT COUNT(1) FROM transactions t WHERE t.reconciliation_id = r.id AND t.deleted_at IS NULL)
+	FROM reconciliations r
+	WHERE r.deleted_at IS NULL`
+
+func (repository sqlReconciliationRepository) list(ctx context.Context, bankAccountID int64) ([]reconciliation, error) {
+	query := reconciliationSelect
+	args := make([]any, 0)
+	if bankAccountID > 0 {
+		query += ` AND r.bank_account_id = ?`
+		args = append(args, bankAccountID)
+	}
+	query += ` ORDER BY r.id`
+
+	rows, err := repository.source.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]reconciliation, 0)
+	for rows.Next() {
+		item, scanErr := scanReconciliation(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlReconciliationRepository) get(ctx context.Context, id int64) (reconciliation, error) {
+	row := repository.source.QueryRowContext(ctx, reconciliationSelect+` AND r.id = ?`, id)
+
+	item, err := scanReconciliation(row)
+	if err != nil {
+		return reconciliation{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlReconciliationRepository) lastLocked(ctx context.Context, bankAccountID int64) (reconciliation, error) {
+	row := repository.source.QueryRowContext(
+		ctx,
+		reconciliationSelect+` AND r.bank_account_id = ? AND r.status = ? ORDER BY r.statement_date DESC, r.id DESC LIMIT 1`,
+		bankAccountID,
+		reconciliationLocked,
+	)
+
+	item, err := scanReconciliation(row)
+	if err != nil {
+		return reconciliation{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlReconciliationRepository) create(ctx context.Context, payload reconciliationPayload) (int64, error) {
+	return insertRow(
+		ctx,
+		repository.source,
+		`INSERT INTO reconciliations(bank_account_id, statement_date, opening_balance, statement_balance) VALUES (?, ?, ?, ?)`,
+		payload.BankAccountID,
+		payload.StatementDate,
+		*payload.OpeningBalance,
+		*payload.StatementBalance,
+	)
+}
+
+func (repository sqlReconciliationRepository) update(ctx context.Context, id int64, payload reconciliationPayload) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE reconciliations
+		 SET statement_date = ?, opening_balance = ?, statement_balance = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND deleted_at IS NULL`,
+		payload.StatementDate,
+		*payload.OpeningBalance,
+		*payload.StatementBalance,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlReconciliationRepository) setStatus(ctx context.Context, id int64, status string) error {
+	lockedAt := "NULL"
+	if status == reconciliationLocked {
+		lockedAt = "CURRENT_TIMESTAMP"
+	}
+
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE reconciliations
+		 SET status = ?, locked_at = `+lockedAt+`, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND deleted_at IS NULL`,
+		status,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlReconciliationRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE reconciliations SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func scanReconciliation(source scanner) (reconciliation, error) {
+	var item reconciliation
+	var lockedAt any
+	var cleared float64
+
+	err := source.Scan(
+		&item.ID,
+		&item.BankAccountID,
+		&item.StatementDate,
+		&item.OpeningBalance,
+		&item.StatementBalance,
+		&item.Status,
+		&lockedAt,
+		&cleared,
+		&item.ClearedTransactions,
+	)
+	if err != nil {
+		return reconciliation{}, err
+	}
+
+	if lockedAt != nil {
+		value := formatAuditTimestamp(lockedAt)
+		item.LockedAt = &value
+	}
+	// Balances are rounded to cents so float sums of the cleared amounts do
+	// not leave a difference of 0.0000001 that blocks locking.
+	item.ClearedBalance = roundCents(item.OpeningBalance + cleared)
+	item.Difference = roundCents(item.StatementBalance - item.ClearedBalance)
+
+	return item, nil
+}
+
+// roundCents rounds to cents; adding 0 turns a negative zero into 0.
+func roundCents(value float64) float64 {
+	return math.Round(value*100)/100 + 0
+}
@@ -0,0 +1,346 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+type reconciliationService struct {
+	store dataStore
+}
+
+func (payload reconciliationPayload) normalize() (reconciliationPayload, error) {
+	payload.StatementDate = strings.TrimSpace(payload.StatementDate)
+
+	if payload.BankAccountID <= 0 {
+		return reconciliationPayload{}, invalidPayload("bank_account_id must be a positive integer")
+	}
+	if !isValidISODate(payload.StatementDate) {
+		return reconciliationPayload{}, invalidPayload("statement_date must be a valid date in YYYY-MM-DD format")
+	}
+	if payload.StatementBalance == nil || math.IsNaN(*payload.StatementBalance) || math.IsInf(*payload.StatementBalance, 0) {
+		return reconciliationPayload{}, invalidPayload("statement_balance is required")
+	}
+	if payload.OpeningBalance != nil && (math.IsNaN(*payload.OpeningBalance) || math.IsInf(*payload.OpeningBalance, 0)) {
+		return reconciliationPayload{}, invalidPayload("opening_balance must be a number")
+	}
+
+	return payload, nil
+}
+
+func (service reconciliationService) list(ctx context.Context, bankAccountID int64) ([]reconciliation, error) {
+	return service.store.reads().reconciliations().list(ctx, bankAccountID)
+}
+
+func (service reconciliationService) get(ctx context.Context, id int64) (reconciliation, error) {
+	item, err := service.store.reads().reconciliations().get(ctx, id)
+	if err != nil {
+		return reconciliation{}, orNotFound(err, "reconciliation not found")
+	}
+
+	return item, nil
+}
+
+// create starts a session. The opening balance defaults to the closing
+// balance of the account's last locked session.
+func (service reconciliationService) create(ctx context.Context, by change, payload reconciliationPayload) (reconciliation, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return reconciliation{}, err
+	}
+
+	var created reconciliation
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		exists, err := tx.trash().exists(ctx, auditEntityBankAccounts, payload.BankAccountID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return invalidPayload("bank account must exist")
+		}
+
+		previous, err := tx.reconciliations().lastLocked(ctx, payload.BankAccountID)
+		switch {
+		case errors.Is(err, errNotFound):
+			if payload.OpeningBalance == nil {
+				payload.OpeningBalance = new(float64)
+			}
+		case err != nil:
+			return err
+		default:
+			if err = checkAfterPrevious(payload, previous); err != nil {
+				return err
+			}
+			if payload.OpeningBalance == nil {
+				payload.OpeningBalance = &previous.StatementBalance
+			}
+		}
+
+		id, err := tx.reconciliations().create(ctx, payload)
+		if err != nil {
+			return reconciliationWriteError(err)
+		}
+
+		if created, err = tx.reconciliations().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityReconciliations, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return reconciliation{}, err
+	}
+
+	return created, nil
+}
+
+// update changes the statement of an open session. The bank account cannot
+// change and a missing opening balance keeps the current one.
+func (service reconciliationService) update(ctx context.Context, by change, id int64, payload reconciliationPayload) (reconciliation, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return reconciliation{}, err
+	}
+
+	var updated reconciliation
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := service.openSession(ctx, tx, by, id)
+		if err != nil {
+			return err
+		}
+		if payload.BankAccountID != existing.BankAccountID {
+			return invalidPayload("bank_account_id of a reconciliation cannot change")
+		}
+		if payload.OpeningBalance == nil {
+			payload.OpeningBalance = &existing.OpeningBalance
+		}
+
+		if previous, err := tx.reconciliations().lastLocked(ctx, existing.BankAccountID); err == nil {
+			if err = checkAfterPrevious(payload, previous); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, errNotFound) {
+			return err
+		}
+
+		if err = tx.reconciliations().update(ctx, id, payload); err != nil {
+			return reconciliationWriteError(err)
+		}
+
+		if updated, err = tx.reconciliations().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityReconciliations, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return reconciliation{}, err
+	}
+
+	return updated, nil
+}
+
+// delete abandons an open session; its transactions become uncleared again.
+// Locked sessions are the reconciliation history and must be unlocked first.
+func (service reconciliationService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := service.openSession(ctx, tx, by, id)
+		if err != nil {
+			return err
+		}
+
+		if err = tx.transactions().releaseReconciliation(ctx, id); err != nil {
+			return err
+		}
+		if err = tx.reconciliations().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityReconciliations, id, auditActionDelete, existing, nil))
+	})
+}
+
+// clear ticks transactions off in an open session, or takes them out again
+// when cleared is false. Only transactions of the session's account dated on
+// or before the statement date can be cleared.
+func (service reconciliationService) clear(ctx context.Context, by change, id int64, transactionIDs []int64, cleared bool) (reconciliation, error) {
+	if len(transactionIDs) == 0 {
+		return reconciliation{}, invalidPayload("transaction_ids must list at least one transaction")
+	}
+
+	var updated reconciliation
+	err := service.store.withTx(ctx, func(tx repositories) error {
+		session, err := service.openSession(ctx, tx, by, id)
+		if err != nil {
+			return err
+		}
+
+		for _, transactionID := range transactionIDs {
+			existing, err := tx.transactions().get(ctx, transactionID)
+			if errors.Is(err, errNotFound) {
+				return invalidPayload(fmt.Sprintf("transaction %d does not exist", transactionID))
+			}
+			if err != nil {
+				return err
+			}
+			inSession := existing.ReconciliationID != nil && *existing.ReconciliationID == id
+
+			if cleared {
+				if err = checkNotReconciled(existing); err != nil {
+					return err
+				}
+				if existing.BankAccountID != session.BankAccountID {
+					return invalidPayload(fmt.Sprintf("transaction %d belongs to another bank account", transactionID))
+				}
+				if existing.TransactionDate > session.StatementDate {
+					return invalidPayload(fmt.Sprintf("transaction %d is dated after the statement", transactionID))
+				}
+				if inSession {
+					continue
+				}
+				err = tx.transactions().setReconciliation(ctx, transactionID, &id, transactionCleared)
+			} else {
+				if !inSession {
+					return invalidPayload(fmt.Sprintf("transaction %d is not cleared in this reconciliation", transactionID))
+				}
+				err = tx.transactions().setReconciliation(ctx, transactionID, nil, transactionUncleared)
+			}
+			if err != nil {
+				return err
+			}
+
+			after, err := tx.transactions().get(ctx, transactionID)
+			if err != nil {
+				return err
+			}
+			if err = tx.audit().record(ctx, by.record(auditEntityTransactions, transactionID, auditActionUpdate, existing, after)); err != nil {
+				return err
+			}
+		}
+
+		updated, err = tx.reconciliations().get(ctx, id)
+		return err
+	})
+	if err != nil {
+		return reconciliation{}, err
+	}
+
+	return updated, nil
+}
+
+// lock closes a balanced session: its cleared transactions become reconciled
+// and can no longer be edited, deleted or merged.
+func (service reconciliationService) lock(ctx context.Context, by change, id int64) (reconciliation, error) {
+	var updated reconciliation
+	err := service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := service.openSession(ctx, tx, by, id)
+		if err != nil {
+			return err
+		}
+		if existing.Difference != 0 {
+			return conflict("reconciliation_unbalanced", fmt.Sprintf("the cleared balance differs from the statement balance by %.2f", existing.Difference))
+		}
+
+		if err = tx.transactions().setReconciliationStatus(ctx, id, transactionReconciled); err != nil {
+			return err
+		}
+
+		return service.setStatus(ctx, tx, by, existing, reconciliationLocked, &updated)
+	})
+	if err != nil {
+		return reconciliation{}, err
+	}
+
+	return updated, nil
+}
+
+// unlock reopens the latest locked session of an account so its transactions
+// can be corrected. Earlier sessions stay locked: the opening balances of the
+// sessions after them depend on their statements.
+func (service reconciliationService) unlock(ctx context.Context, by change, id int64) (reconciliation, error) {
+	var updated reconciliation
+	err := service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.reconciliations().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "reconciliation not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+		if existing.Status != reconciliationLocked {
+			return conflict("reconciliation_not_locked", "reconciliation is not locked")
+		}
+
+		latest, err := tx.reconciliations().lastLocked(ctx, existing.BankAccountID)
+		if err != nil {
+			return err
+		}
+		if latest.ID != id {
+			return conflict("reconciliation_not_latest", "only the latest locked reconciliation of a bank account can be unlocked")
+		}
+
+		if err = tx.transactions().setReconciliationStatus(ctx, id, transactionCleared); err != nil {
+			return err
+		}
+
+		return service.setStatus(ctx, tx, by, existing, reconciliationOpen, &updated)
+	})
+	if err != nil {
+		return reconciliation{}, err
+	}
+
+	return updated, nil
+}
+
+// openSession loads a session that may still change.
+func (service reconciliationService) openSession(ctx context.Context, tx repositories, by change, id int64) (reconciliation, error) {
+	existing, err := tx.reconciliations().get(ctx, id)
+	if err != nil {
+		return reconciliation{}, orNotFound(err, "reconciliation not found")
+	}
+	if err = by.checkVersion(existing); err != nil {
+		return reconciliation{}, err
+	}
+	if existing.Status != reconciliationOpen {
+		return reconciliation{}, conflict("reconciliation_locked", "reconciliation is locked, unlock it first")
+	}
+
+	return existing, nil
+}
+
+func (service reconciliationService) setStatus(ctx context.Context, tx repositories, by change, existing reconciliation, status string, updated *reconciliation) error {
+	err := tx.reconciliations().setStatus(ctx, existing.ID, status)
+	if err != nil {
+		return reconciliationWriteError(err)
+	}
+
+	if *updated, err = tx.reconciliations().get(ctx, existing.ID); err != nil {
+		return err
+	}
+
+	return tx.audit().record(ctx, by.record(auditEntityReconciliations, existing.ID, auditActionUpdate, existing, *updated))
+}
+
+// checkAfterPrevious keeps statements of an account in order: a session
+// starts where the last locked one ended.
+func checkAfterPrevious(payload reconciliationPayload, previous reconciliation) error {
+	if payload.StatementDate <= previous.StatementDate {
+		return invalidPayload(fmt.Sprintf("statement_date must be after %s, the last reconciled statement of the bank account", previous.StatementDate))
+	}
+
+	return nil
+}
+
+func reconciliationWriteError(err error) error {
+	if errors.Is(err, errDuplicate) {
+		return conflict("reconciliation_open", "the bank account already has an open reconciliation")
+	}
+	if errors.Is(err, errMissingReference) {
+		return invalidPayload("bank account must exist")
+	}
+
+	return err
+}
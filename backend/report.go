@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// MonthlySummary totals the active transactions of one month in one currency.
+type MonthlySummary struct {
+	Month        string  `json:"month"`
+	Currency     string  `json:"currency"`
+	Income       float64 `json:"income"`
+	Expense      float64 `json:"expense"`
+	Net          float64 `json:"net"`
+	Transactions int     `json:"transactions"`
+}
+
+// MonthlyReport returns income, expense and net per month and currency for the
+// given year, ordered by month and currency code. Amounts in different
+// currencies are never added together.
+func MonthlyReport(ctx context.Context, db *sql.DB, year int) ([]MonthlySummary, error) {
+	if year < 1 || year > 9999 {
+		return nil, fmt.Errorf("year must be between 1 and 9999")
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			substr(t.transaction_date, 1, 7) AS month,
+			c.code,
+			COALESCE(SUM(CASE WHEN t.type = 'income' THEN t.amount END), 0),
+			COALESCE(SUM(CASE WHEN t.type = 'expense' THEN t.amount END), 0),
+			COUNT(1)
+		FROM transactions t
+		JOIN bank_accounts a ON a.id = t.bank_account_id
+		JOIN currencies c ON c.id = a.currency_id
+		WHERE t.deleted_at IS NULL AND substr(t.transaction_date, 1, 4) = ?
+		GROUP BY month, c.code
+		ORDER BY month, c.code
+	`, fmt.Sprintf("%04d", year))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]MonthlySummary, 0)
+	for rows.Next() {
+		var summary MonthlySummary
+		if err = rows.Scan(&summary.Month, &summary.Currency, &summary.Income, &summary.Expense, &summary.Transactions); err != nil {
+			return nil, err
+		}
+		summary.Net = summary.Income - summary.Expense
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, rows.Err()
+}
+
+// BreakdownSummary totals the active transactions of one year booked on one
+// category or person, in one currency. Split transactions count through their
+// split lines, so one receipt can land on several categories and people.
+type BreakdownSummary struct {
+	ID       int64   `json:"id"`
+	Name     string  `json:"name"`
+	Currency string  `json:"currency"`
+	Income   float64 `json:"income"`
+	Expense  float64 `json:"expense"`
+	Net      float64 `json:"net"`
+	Lines    int     `json:"lines"`
+}
+
+// CategoryReport returns income, expense and net per transaction category and
+// currency for the given year, ordered by category name and currency code.
+func CategoryReport(ctx context.Context, db *sql.DB, year int) ([]BreakdownSummary, error) {
+	return breakdownReport(ctx, db, year, "category_id", "transaction_categories")
+}
+
+// PersonReport returns income, expense and net per person and currency for the
+// given year, ordered by name and currency code.
+func PersonReport(ctx context.Context, db *sql.DB, year int) ([]BreakdownSummary, error) {
+	return breakdownReport(ctx, db, year, "person_id", "people")
+}
+
+// breakdownReport groups transaction lines by column, which both transactions
+// and transaction_splits carry. The LEFT JOIN yields one line per split, or
+// the transaction itself when it has none.
+func breakdownReport(ctx context.Context, db *sql.DB, year int, column string, table string) ([]BreakdownSummary, error) {
+	if year < 1 || year > 9999 {
+		return nil, fmt.Errorf("year must be between 1 and 9999")
+	}
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT
+			g.id,
+			g.name,
+			c.code,
+			COALESCE(SUM(CASE WHEN t.type = 'income' THEN COALESCE(s.amount, t.amount) END), 0),
+			COALESCE(SUM(CASE WHEN t.type = 'expense' THEN COALESCE(s.amount, t.amount) END), 0),
+			COUNT(1)
+		FROM transactions t
+		LEFT JOIN transaction_splits s ON s.transaction_id = t.id
+		JOIN %[2]s g ON g.id = COALESCE(s.%[1]s, t.%[1]s)
+		JOIN bank_accounts a ON a.id = t.bank_account_id
+		JOIN currencies c ON c.id = a.currency_id
+		WHERE t.deleted_at IS NULL AND substr(t.transaction_date, 1, 4) = ?
+		GROUP BY g.id, g.name, c.code
+		ORDER BY g.name, g.id, c.code
+	`, column, table), fmt.Sprintf("%04d", year))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]BreakdownSummary, 0)
+	for rows.Next() {
+		var summary BreakdownSummary
+		if err = rows.Scan(&summary.ID, &summary.Name, &summary.Currency, &summary.Income, &summary.Expense, &summary.Lines); err != nil {
+			return nil, err
+		}
+		summary.Net = summary.Income - summary.Expense
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, rows.Err()
+}
@@ -0,0 +1,106 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestMonthlyReportGroupsByMonthAndCurrency(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+
+	for _, body := range []string{
+		`{"transaction_date":"2026-01-05","type":"income","amount":1000,"person_id":1,"bank_account_id":1,"category_id":1}`,
+		`{"transaction_date":"2026-01-20","type":"expense","amount":250.5,"person_id":1,"bank_account_id":1,"category_id":1}`,
+		`{"transaction_date":"2026-02-01","type":"expense","amount":40,"person_id":1,"bank_account_id":1,"category_id":1}`,
+		`{"transaction_date":"2025-12-31","type":"income","amount":999,"person_id":1,"bank_account_id":1,"category_id":1}`,
+	} {
+		response := performRequest(router, http.MethodPost, "/api/transactions", []byte(body))
+		if response.Code != http.StatusCreated {
+			t.Fatalf("expected transaction seed to return 201, got %d", response.Code)
+		}
+	}
+
+	summaries, err := MonthlyReport(context.Background(), application.db, 2026)
+	if err != nil {
+		t.Fatalf("monthly report: %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("expected 2 monthly rows, got %+v", summaries)
+	}
+
+	january := summaries[0]
+	if january.Month != "2026-01" || january.Currency != "USD" || january.Income != 1000 || january.Expense != 250.5 || january.Net != 749.5 || january.Transactions != 2 {
+		t.Fatalf("unexpected January summary: %+v", january)
+	}
+	if summaries[1].Month != "2026-02" || summaries[1].Net != -40 {
+		t.Fatalf("unexpected February summary: %+v", summaries[1])
+	}
+
+	if _, err = MonthlyReport(context.Background(), application.db, 0); err == nil {
+		t.Fatal("expected invalid year to fail")
+	}
+}
+
+func TestBreakdownReportsUseSplitLines(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionSplitDependencies(t, router)
+
+	for _, body := range []string{
+		`{"transaction_date":"2026-03-02","type":"expense","amount":100,"person_id":1,"bank_account_id":1,"category_id":1,"splits":[{"category_id":1,"person_id":1,"amount":60},{"category_id":2,"person_id":2,"amount":40}]}`,
+		`{"transaction_date":"2026-03-05","type":"income","amount":500,"person_id":2,"bank_account_id":1,"category_id":1}`,
+		`{"transaction_date":"2025-03-05","type":"expense","amount":7,"person_id":2,"bank_account_id":1,"category_id":2}`,
+	} {
+		response := performRequest(router, http.MethodPost, "/api/transactions", []byte(body))
+		if response.Code != http.StatusCreated {
+			t.Fatalf("expected transaction seed to return 201, got %d: %s", response.Code, response.Body.String())
+		}
+	}
+
+	categories, err := CategoryReport(context.Background(), application.db, 2026)
+	if err != nil {
+		t.Fatalf("category report: %v", err)
+	}
+	if len(categories) != 2 {
+		t.Fatalf("expected 2 category rows, got %+v", categories)
+	}
+	if gifts := categories[0]; gifts.Name != "Gifts" || gifts.Expense != 40 || gifts.Income != 0 || gifts.Lines != 1 {
+		t.Fatalf("unexpected Gifts row: %+v", gifts)
+	}
+	if salary := categories[1]; salary.Name != "Salary" || salary.Currency != "USD" || salary.Expense != 60 || salary.Income != 500 || salary.Net != 440 || salary.Lines != 2 {
+		t.Fatalf("unexpected Salary row: %+v", salary)
+	}
+
+	people, err := PersonReport(context.Background(), application.db, 2026)
+	if err != nil {
+		t.Fatalf("person report: %v", err)
+	}
+	if len(people) != 2 || people[0].Name != "Jane Doe" || people[0].Expense != 60 || people[1].Name != "John Doe" || people[1].Expense != 40 || people[1].Income != 500 {
+		t.Fatalf("unexpected person rows: %+v", people)
+	}
+
+	monthly, err := MonthlyReport(context.Background(), application.db, 2026)
+	if err != nil {
+		t.Fatalf("monthly report: %v", err)
+	}
+	if len(monthly) != 1 || monthly[0].Expense != 100 || monthly[0].Transactions != 2 {
+		t.Fatalf("expected split lines not to change monthly totals, got %+v", monthly)
+	}
+
+	exported, err := ExportData(context.Background(), application.db, []string{transactionSplitsTable})
+	if err != nil {
+		t.Fatalf("export split lines: %v", err)
+	}
+	if len(exported[0].Rows) != 2 || exported[0].Rows[0]["transaction_id"] != int64(1) {
+		t.Fatalf("expected both split lines to be exported, got %+v", exported[0].Rows)
+	}
+
+	if _, err = CategoryReport(context.Background(), application.db, 0); err == nil {
+		t.Fatal("expected invalid year to fail")
+	}
+}
@@ -0,0 +1,216 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Errors the repositories return in place of driver specific ones, so the
+// services above them do not depend on the database engine.
+var (
+	// errNotFound means the row does not exist or has been soft deleted.
+	errNotFound = errors.New("not found")
+	// errDuplicate means a write broke a unique constraint.
+	errDuplicate = errors.New("duplicate")
+	// errMissingReference means a write broke a foreign key constraint.
+	errMissingReference = errors.New("missing reference")
+)
+
+// constraintError translates constraint failures reported by the database
+// into errDuplicate and errMissingReference, keeping the original message.
+func constraintError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case isUniqueConstraintError(err):
+		return fmt.Errorf("%w: %v", errDuplicate, err)
+	case isForeignKeyConstraintError(err):
+		return fmt.Errorf("%w: %v", errMissingReference, err)
+	default:
+		return err
+	}
+}
+
+// insertRow runs an INSERT and returns the id of the new row. The id is read
+// back with RETURNING rather than LastInsertId, which PostgreSQL drivers do
+// not implement.
+func insertRow(ctx context.Context, source queryer, query string, args ...any) (int64, error) {
+	var id int64
+	if err := source.QueryRowContext(ctx, query+` RETURNING id`, args...).Scan(&id); err != nil {
+		return 0, constraintError(err)
+	}
+
+	return id, nil
+}
+
+// rowError turns sql.ErrNoRows into errNotFound.
+func rowError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return errNotFound
+	}
+
+	return err
+}
+
+// dataStore hands out repositories. Reads outside a unit of work use the
+// reader pool; withTx runs a unit of work on the writer. While a batch request
+// is replayed, both go through the batch transaction so every operation sees
+// the writes of the earlier ones.
+type dataStore struct {
+	db      *sql.DB
+	reader  *sql.DB
+	batch   *sql.Tx
+	fields  *FieldCipher
+	metrics *metrics
+}
+
+// reads returns the repositories for queries that need no transaction.
+func (store dataStore) reads() repositories {
+	switch {
+	case store.batch != nil:
+		return store.repositoriesOn(store.batch)
+	case store.reader != nil:
+		return store.repositoriesOn(store.reader)
+	default:
+		return store.repositoriesOn(store.db)
+	}
+}
+
+// withTx runs fn as one unit of work: the repositories it receives share a
+// transaction that is committed when fn returns nil and rolled back
+// otherwise. Inside a batch the unit of work is a savepoint on the batch
+// transaction.
+func (store dataStore) withTx(ctx context.Context, fn func(tx repositories) error) error {
+	tx, err := store.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = fn(store.repositoriesOn(tx)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (store dataStore) begin(ctx context.Context) (dbTx, error) {
+	if store.batch != nil {
+		return beginSavepoint(ctx, store.batch)
+	}
+
+	return store.db.BeginTx(ctx, nil)
+}
+
+func (store dataStore) repositoriesOn(source queryer) repositories {
+	return repositories{
+		source: instrumentedQueryer{queryer: source, metrics: store.metrics},
+		fields: store.fields,
+	}
+}
+
+// repositories binds the repository of every aggregate to one database
+// handle: a pool for plain reads or the transaction of a unit of work.
+type repositories struct {
+	source queryer
+	fields *FieldCipher
+}
+
+func (repos repositories) audit() auditRepository {
+	return sqlAuditRepository{source: repos.source}
+}
+
+func (repos repositories) trash() trashRepository {
+	return sqlTrashRepository{source: repos.source, fields: repos.fields}
+}
+
+func (repos repositories) countries() countryRepository {
+	return sqlCountryRepository{source: repos.source}
+}
+
+func (repos repositories) currencies() currencyRepository {
+	return sqlCurrencyRepository{source: repos.source}
+}
+
+func (repos repositories) banks() bankRepository {
+	return sqlBankRepository{source: repos.source}
+}
+
+func (repos repositories) bankAccounts() bankAccountRepository {
+	return sqlBankAccountRepository{source: repos.source, fields: repos.fields}
+}
+
+func (repos repositories) people() personRepository {
+	return sqlPersonRepository{source: repos.source}
+}
+
+func (repos repositories) transactionCategories() transactionCategoryRepository {
+	return sqlTransactionCategoryRepository{source: repos.source}
+}
+
+func (repos repositories) transactions() transactionRepository {
+	return sqlTransactionRepository{source: repos.source}
+}
+
+func (repos repositories) creditCards() creditCardRepository {
+	return sqlCreditCardRepository{source: repos.source, fields: repos.fields}
+}
+
+func (repos repositories) creditCardCycles() creditCardCycleRepository {
+	return sqlCreditCardCycleRepository{source: repos.source}
+}
+
+func (repos repositories) creditCardCycleBalances() creditCardCycleBalanceRepository {
+	return sqlCreditCardCycleBalanceRepository{source: repos.source}
+}
+
+func (repos repositories) creditCardInstallments() creditCardInstallmentRepository {
+	return sqlCreditCardInstallmentRepository{source: repos.source}
+}
+
+func (repos repositories) creditCardSubscriptions() creditCardSubscriptionRepository {
+	return sqlCreditCardSubscriptionRepository{source: repos.source}
+}
+
+func (repos repositories) expenses() expenseRepository {
+	return sqlExpenseRepository{source: repos.source}
+}
+
+func (repos repositories) expensePayments() expensePaymentRepository {
+	return sqlExpensePaymentRepository{source: repos.source}
+}
+
+func (repos repositories) reconciliations() reconciliationRepository {
+	return sqlReconciliationRepository{source: repos.source}
+}
+
+func (repos repositories) sharedExpenses() sharedExpenseRepository {
+	return sqlSharedExpenseRepository{source: repos.source}
+}
+
+func (repos repositories) settlements() settlementRepository {
+	return sqlSettlementRepository{source: repos.source}
+}
+
+func (repos repositories) loans() loanRepository {
+	return sqlLoanRepository{source: repos.source}
+}
+
+func (repos repositories) loanRepayments() loanRepaymentRepository {
+	return sqlLoanRepaymentRepository{source: repos.source}
+}
+
+func (repos repositories) savingsGoals() savingsGoalRepository {
+	return sqlSavingsGoalRepository{source: repos.source}
+}
+
+// scanner is the Scan method shared by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
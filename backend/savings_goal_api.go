@@ -0,0 +1,273 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	savingsGoalsPath       = "/api/savings-goals"
+	savingsGoalsPathByID   = "/api/savings-goals/"
+	savingsGoalPathPattern = "/api/savings-goals/%d"
+	// savingsGoalsProgressPath reports the progress of every goal; GET
+	// /api/savings-goals/{id}/progress reports that of one.
+	savingsGoalsProgressPath  = "/api/savings-goals/progress"
+	savingsGoalProgressSuffix = "/progress"
+	// savingsGoalAccountsTable holds the bank accounts linked to goals.
+	savingsGoalAccountsTable = "savings_goal_accounts"
+
+	defaultSavingsTrailingMonths = 3
+	maxSavingsTrailingMonths     = 24
+)
+
+// Sources of a goal's progress: the balances of its linked bank accounts, or
+// the transactions tagged to it when it has none.
+const (
+	savingsSourceAccounts     = "accounts"
+	savingsSourceTransactions = "transactions"
+)
+
+// Statuses of a goal's progress.
+const (
+	savingsGoalCompleted = "completed"
+	savingsGoalOnTrack   = "on_track"
+	savingsGoalBehind    = "behind"
+)
+
+type savingsGoal struct {
+	ID             int64   `json:"id"`
+	Name           string  `json:"name"`
+	TargetAmount   float64 `json:"target_amount"`
+	CurrencyID     int64   `json:"currency_id"`
+	Deadline       string  `json:"deadline"`
+	BankAccountIDs []int64 `json:"bank_account_ids"`
+	Notes          *string `json:"notes"`
+}
+
+type savingsGoalPayload struct {
+	Name         string  `json:"name"`
+	TargetAmount float64 `json:"target_amount"`
+	CurrencyID   int64   `json:"currency_id"`
+	Deadline     string  `json:"deadline"`
+	// BankAccountIDs replace the linked accounts of the goal; none makes
+	// it count the transactions tagged to it instead.
+	BankAccountIDs []int64 `json:"bank_account_ids"`
+	Notes          *string `json:"notes"`
+}
+
+// savingsGoalProgress is how far a goal is on a date. The contributions of a
+// month are the net flow (income less expenses) of the transactions on the
+// goal's accounts, or of those tagged to it.
+type savingsGoalProgress struct {
+	SavingsGoalID             int64   `json:"savings_goal_id"`
+	AsOf                      string  `json:"as_of"`
+	Source                    string  `json:"source"`
+	Saved                     float64 `json:"saved"`
+	TargetAmount              float64 `json:"target_amount"`
+	Remaining                 float64 `json:"remaining"`
+	PercentComplete           float64 `json:"percent_complete"`
+	Deadline                  string  `json:"deadline"`
+	MonthsLeft                int     `json:"months_left"`
+	MonthlyContributionNeeded float64 `json:"monthly_contribution_needed"`
+	TrailingMonths            int     `json:"trailing_months"`
+	AverageContribution       float64 `json:"average_monthly_contribution"`
+	// ProjectedCompletionDate is null for a completed goal and for one that
+	// the trailing contributions would never complete.
+	ProjectedCompletionDate *string `json:"projected_completion_date"`
+	Status                  string  `json:"status"`
+}
+
+func (application app) registerSavingsGoalRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(savingsGoalsPath, application.savingsGoalsHandler)
+	mux.HandleFunc(savingsGoalsPathByID, application.savingsGoalByIDHandler)
+	mux.HandleFunc(savingsGoalsProgressPath, application.savingsGoalsProgressHandler)
+	mux.HandleFunc(savingsGoalsPath+batchPathSuffix, application.batchHandler(savingsGoalsPath, app.savingsGoalsHandler, app.savingsGoalByIDHandler))
+}
+
+func (application app) savingsGoalsHandler(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		application.listSavingsGoals(writer, request)
+	case http.MethodPost:
+		application.createSavingsGoal(writer, request)
+	default:
+		methodNotAllowed(writer, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (application app) savingsGoalByIDHandler(writer http.ResponseWriter, request *http.Request) {
+	if path, ok := strings.CutSuffix(request.URL.Path, savingsGoalProgressSuffix); ok {
+		application.savingsGoalProgressHandler(writer, request, path)
+		return
+	}
+
+	id, err := parseIDFromPath(request.URL.Path, savingsGoalsPathByID)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_id", "savings goal id must be a positive integer")
+		return
+	}
+
+	switch request.Method {
+	case http.MethodGet:
+		application.getSavingsGoal(writer, request, id)
+	case http.MethodPut:
+		application.updateSavingsGoal(writer, request, id)
+	case http.MethodPatch:
+		application.patchSavingsGoal(writer, request, id)
+	case http.MethodDelete:
+		application.deleteSavingsGoal(writer, request, id)
+	default:
+		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
+	}
+}
+
+func (application app) savingsGoalProgressHandler(writer http.ResponseWriter, request *http.Request, path string) {
+	id, err := parseIDFromPath(path, savingsGoalsPathByID)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_id", "savings goal id must be a positive integer")
+		return
+	}
+	if request.Method != http.MethodGet {
+		methodNotAllowed(writer, http.MethodGet)
+		return
+	}
+
+	asOf, months, ok := parseSavingsProgressQuery(writer, request)
+	if !ok {
+		return
+	}
+
+	progress, err := application.services().savingsGoals.progress(request.Context(), id, asOf, months)
+	if err != nil {
+		writeServiceError(writer, err, "failed to compute savings goal progress")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, progress)
+}
+
+func (application app) savingsGoalsProgressHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		methodNotAllowed(writer, http.MethodGet)
+		return
+	}
+
+	asOf, months, ok := parseSavingsProgressQuery(writer, request)
+	if !ok {
+		return
+	}
+
+	progress, err := application.services().savingsGoals.progressAll(request.Context(), asOf, months)
+	if err != nil {
+		writeServiceError(writer, err, "failed to compute savings goal progress")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, progress)
+}
+
+// parseSavingsProgressQuery reads the optional as_of date, today by default,
+// and the number of trailing months averaged for the projection.
+func parseSavingsProgressQuery(writer http.ResponseWriter, request *http.Request) (time.Time, int, bool) {
+	query := request.URL.Query()
+
+	asOf := time.Now().UTC().Truncate(24 * time.Hour)
+	if raw := strings.TrimSpace(query.Get("as_of")); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			writeError(writer, http.StatusBadRequest, "invalid_query", "as_of must be a valid date in YYYY-MM-DD format")
+			return time.Time{}, 0, false
+		}
+		asOf = parsed
+	}
+
+	months := defaultSavingsTrailingMonths
+	if raw := strings.TrimSpace(query.Get("months")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxSavingsTrailingMonths {
+			writeError(writer, http.StatusBadRequest, "invalid_query", fmt.Sprintf("months must be between 1 and %d", maxSavingsTrailingMonths))
+			return time.Time{}, 0, false
+		}
+		months = parsed
+	}
+
+	return asOf, months, true
+}
+
+func (application app) listSavingsGoals(writer http.ResponseWriter, request *http.Request) {
+	items, err := application.services().savingsGoals.list(request.Context())
+	if err != nil {
+		writeServiceError(writer, err, "failed to load savings goals")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, items)
+}
+
+func (application app) getSavingsGoal(writer http.ResponseWriter, request *http.Request, id int64) {
+	item, err := application.services().savingsGoals.get(request.Context(), id)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load savings goal")
+		return
+	}
+
+	writeJSONWithETag(writer, http.StatusOK, item)
+}
+
+func (application app) createSavingsGoal(writer http.ResponseWriter, request *http.Request) {
+	var payload savingsGoalPayload
+	if !decodeJSON(writer, request, &payload) {
+		return
+	}
+
+	created, err := application.services().savingsGoals.create(request.Context(), requestChange(request), payload)
+	if err != nil {
+		writeServiceError(writer, err, "failed to create savings goal")
+		return
+	}
+
+	writer.Header().Set("Location", fmt.Sprintf(savingsGoalPathPattern, created.ID))
+	writeJSONWithETag(writer, http.StatusCreated, created)
+}
+
+func (application app) updateSavingsGoal(writer http.ResponseWriter, request *http.Request, id int64) {
+	var payload savingsGoalPayload
+	if !decodeJSON(writer, request, &payload) {
+		return
+	}
+
+	updated, err := application.services().savingsGoals.update(request.Context(), requestChange(request), id, payload)
+	if err != nil {
+		writeServiceError(writer, err, "failed to update savings goal")
+		return
+	}
+
+	writeJSONWithETag(writer, http.StatusOK, updated)
+}
+
+func (application app) patchSavingsGoal(writer http.ResponseWriter, request *http.Request, id int64) {
+	current, err := application.services().savingsGoals.get(request.Context(), id)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load savings goal")
+		return
+	}
+
+	mergedRequest, ok := mergePatchRequest(writer, request, current)
+	if !ok {
+		return
+	}
+
+	application.updateSavingsGoal(writer, mergedRequest, id)
+}
+
+func (application app) deleteSavingsGoal(writer http.ResponseWriter, request *http.Request, id int64) {
+	if err := application.services().savingsGoals.delete(request.Context(), requestChange(request), id); err != nil {
+		writeServiceError(writer, err, "failed to delete savings goal")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,102 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestSavingsGoalProgressFlow(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+
+	expectResponse(t, router, http.MethodPost, "/api/currencies", `{"name":"Euro","code":"EUR"}`, http.StatusCreated)
+	expectResponse(t, router, http.MethodPost, "/api/bank-accounts", `{"bank_id":1,"currency_id":2,"account_number":"ACC-EUR","balance":250}`, http.StatusCreated)
+	expectResponse(t, router, http.MethodPost, "/api/bank-accounts", `{"bank_id":1,"currency_id":1,"account_number":"ACC-SAVE","balance":400}`, http.StatusCreated)
+
+	var goal savingsGoal
+	decodeResponse(t, expectResponse(t, router, http.MethodPost, "/api/savings-goals", `{"name":"Vacation","target_amount":1000,"currency_id":1,"deadline":"2026-12-31"}`, http.StatusCreated), &goal)
+	if goal.ID != 1 || goal.BankAccountIDs == nil || len(goal.BankAccountIDs) != 0 {
+		t.Fatalf("unexpected savings goal: %+v", goal)
+	}
+
+	for _, invalid := range []string{
+		`{"target_amount":1000,"currency_id":1,"deadline":"2026-12-31"}`,
+		`{"name":"Car","target_amount":0,"currency_id":1,"deadline":"2026-12-31"}`,
+		`{"name":"Car","target_amount":1000,"currency_id":1,"deadline":"31/12/2026"}`,
+		`{"name":"Car","target_amount":1000,"currency_id":9,"deadline":"2026-12-31"}`,
+		`{"name":"Car","target_amount":1000,"currency_id":1,"deadline":"2026-12-31","bank_account_ids":[2]}`,
+		`{"name":"Car","target_amount":1000,"currency_id":1,"deadline":"2026-12-31","bank_account_ids":[99]}`,
+		`{"name":"Car","target_amount":1000,"currency_id":1,"deadline":"2026-12-31","bank_account_ids":[1,1]}`,
+	} {
+		expectResponse(t, router, http.MethodPost, "/api/savings-goals", invalid, http.StatusBadRequest)
+	}
+
+	var tagged transaction
+	decodeResponse(t, expectResponse(t, router, http.MethodPost, "/api/transactions", `{"transaction_date":"2026-04-10","type":"income","amount":300,"person_id":1,"bank_account_id":1,"category_id":1,"savings_goal_id":1}`, http.StatusCreated), &tagged)
+	if tagged.SavingsGoalID == nil || *tagged.SavingsGoalID != 1 {
+		t.Fatalf("expected the transaction to be tagged to goal 1, got %+v", tagged)
+	}
+	taggedPath := fmt.Sprintf(transactionPathPattern, tagged.ID)
+	const edited = `{"transaction_date":"2026-04-10","type":"income","amount":300,"notes":"Bonus","person_id":1,"bank_account_id":1,"category_id":1}`
+	decodeResponse(t, expectResponse(t, router, http.MethodPut, taggedPath, edited, http.StatusOK), &tagged)
+	if tagged.SavingsGoalID == nil || *tagged.SavingsGoalID != 1 {
+		t.Fatalf("expected a PUT without savings_goal_id to keep the goal, got %+v", tagged)
+	}
+	decodeResponse(t, expectResponse(t, router, http.MethodPut, taggedPath, `{"transaction_date":"2026-04-10","type":"income","amount":300,"person_id":1,"bank_account_id":1,"category_id":1,"savings_goal_id":null}`, http.StatusOK), &tagged)
+	if tagged.SavingsGoalID != nil {
+		t.Fatalf("expected a null savings_goal_id to untag the transaction, got %+v", tagged)
+	}
+	expectResponse(t, router, http.MethodPatch, taggedPath, `{"savings_goal_id":1}`, http.StatusOK)
+	expectResponse(t, router, http.MethodPost, "/api/transactions", `{"transaction_date":"2026-05-10","type":"expense","amount":50,"person_id":1,"bank_account_id":1,"category_id":1,"savings_goal_id":1}`, http.StatusCreated)
+	expectResponse(t, router, http.MethodPost, "/api/transactions", `{"transaction_date":"2026-06-05","type":"income","amount":150,"person_id":1,"bank_account_id":1,"category_id":1,"savings_goal_id":1}`, http.StatusCreated)
+	expectResponse(t, router, http.MethodPost, "/api/transactions", `{"transaction_date":"2026-06-05","type":"income","amount":999,"person_id":1,"bank_account_id":1,"category_id":1}`, http.StatusCreated)
+	expectResponse(t, router, http.MethodPost, "/api/transactions", `{"transaction_date":"2026-06-05","type":"income","amount":10,"person_id":1,"bank_account_id":2,"category_id":1,"savings_goal_id":1}`, http.StatusBadRequest)
+	expectResponse(t, router, http.MethodPost, "/api/transactions", `{"transaction_date":"2026-06-05","type":"income","amount":10,"person_id":1,"bank_account_id":1,"category_id":1,"savings_goal_id":9}`, http.StatusBadRequest)
+
+	var progress savingsGoalProgress
+	decodeResponse(t, expectResponse(t, router, http.MethodGet, "/api/savings-goals/1/progress?as_of=2026-06-30&months=3", "", http.StatusOK), &progress)
+	if progress.Source != savingsSourceTransactions || progress.Saved != 400 || progress.PercentComplete != 40 || progress.Remaining != 600 {
+		t.Fatalf("unexpected progress: %+v", progress)
+	}
+	if progress.MonthsLeft != 6 || progress.MonthlyContributionNeeded != 100 || progress.AverageContribution != 133.33 {
+		t.Fatalf("unexpected contribution rates: %+v", progress)
+	}
+	if progress.ProjectedCompletionDate == nil || *progress.ProjectedCompletionDate != "2026-11-30" || progress.Status != savingsGoalOnTrack {
+		t.Fatalf("expected completion on 2026-11-30, on track, got %+v", progress)
+	}
+
+	expectResponse(t, router, http.MethodPatch, "/api/savings-goals/1", `{"currency_id":2}`, http.StatusBadRequest)
+	expectResponse(t, router, http.MethodDelete, "/api/savings-goals/1", "", http.StatusConflict)
+
+	expectResponse(t, router, http.MethodPost, "/api/savings-goals", `{"name":"Emergency fund","target_amount":1000,"currency_id":1,"deadline":"2027-06-30","bank_account_ids":[3,1]}`, http.StatusCreated)
+	decodeResponse(t, expectResponse(t, router, http.MethodGet, "/api/savings-goals/2/progress?as_of=2026-06-30&months=3", "", http.StatusOK), &progress)
+	if progress.Source != savingsSourceAccounts || progress.Saved != 500 || progress.AverageContribution != 466.33 || progress.Status != savingsGoalOnTrack {
+		t.Fatalf("unexpected account progress: %+v", progress)
+	}
+	expectResponse(t, router, http.MethodGet, "/api/savings-goals/2/progress", "", http.StatusOK)
+
+	var all []savingsGoalProgress
+	decodeResponse(t, expectResponse(t, router, http.MethodGet, "/api/savings-goals/progress?as_of=2026-06-30", "", http.StatusOK), &all)
+	if len(all) != 2 || all[0].SavingsGoalID != 1 || all[1].SavingsGoalID != 2 {
+		t.Fatalf("unexpected progress of every goal: %+v", all)
+	}
+
+	expectResponse(t, router, http.MethodGet, "/api/savings-goals/progress?months=0", "", http.StatusBadRequest)
+	expectResponse(t, router, http.MethodGet, "/api/savings-goals/1/progress?as_of=30/06/2026", "", http.StatusBadRequest)
+	expectResponse(t, router, http.MethodGet, "/api/savings-goals/9/progress", "", http.StatusNotFound)
+	expectResponse(t, router, http.MethodDelete, "/api/bank-accounts/3", "", http.StatusConflict)
+
+	var goals []savingsGoal
+	expectResponse(t, router, http.MethodPatch, "/api/savings-goals/2", `{"bank_account_ids":[3]}`, http.StatusOK)
+	decodeResponse(t, expectResponse(t, router, http.MethodGet, "/api/savings-goals", "", http.StatusOK), &goals)
+	if len(goals) != 2 || len(goals[1].BankAccountIDs) != 1 || goals[1].BankAccountIDs[0] != 3 {
+		t.Fatalf("unexpected savings goals: %+v", goals)
+	}
+
+	expectResponse(t, router, http.MethodDelete, "/api/savings-goals/2", "", http.StatusNoContent)
+	expectResponse(t, router, http.MethodGet, "/api/savings-goals/2", "", http.StatusNotFound)
+	expectResponse(t, router, http.MethodDelete, "/api/bank-accounts/3", "", http.StatusNoContent)
+}
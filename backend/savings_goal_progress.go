@@ -0,0 +1,76 @@
+package backend
+
+import (
+	"math"
+	"time"
+)
+
+// progress works out how far the goal is on asOf given what is saved and the
+// contributions up to that day. The projection assumes the average monthly
+// contribution over the trailing months keeps up; the contribution needed is
+// what each month until the deadline has to add, rounded up to cents.
+func (goal savingsGoal) progress(source string, saved float64, contributions []savingsContribution, asOf time.Time, months int) savingsGoalProgress {
+	progress := savingsGoalProgress{
+		SavingsGoalID:   goal.ID,
+		AsOf:            asOf.Format("2006-01-02"),
+		Source:          source,
+		Saved:           roundCents(saved),
+		TargetAmount:    goal.TargetAmount,
+		Remaining:       roundCents(math.Max(goal.TargetAmount-saved, 0)),
+		PercentComplete: roundCents(saved / goal.TargetAmount * 100),
+		Deadline:        goal.Deadline,
+		TrailingMonths:  months,
+	}
+
+	windowStart := addMonths(asOf, -months).Format("2006-01-02")
+	var trailing float64
+	for _, contribution := range contributions {
+		if contribution.Date > windowStart && contribution.Date <= progress.AsOf {
+			trailing += contribution.Amount
+		}
+	}
+	progress.AverageContribution = roundCents(trailing / float64(months))
+
+	progress.MonthsLeft = monthsUntil(asOf, goal.Deadline)
+	switch {
+	case progress.Remaining == 0:
+		progress.MonthlyContributionNeeded = 0
+	case progress.MonthsLeft == 0:
+		progress.MonthlyContributionNeeded = progress.Remaining
+	default:
+		progress.MonthlyContributionNeeded = math.Ceil(math.Round(progress.Remaining*100)/float64(progress.MonthsLeft)) / 100
+	}
+
+	switch {
+	case progress.Remaining == 0:
+		progress.Status = savingsGoalCompleted
+	case progress.AverageContribution <= 0:
+		progress.Status = savingsGoalBehind
+	default:
+		projected := addMonths(asOf, int(math.Ceil(progress.Remaining/progress.AverageContribution))).Format("2006-01-02")
+		progress.ProjectedCompletionDate = &projected
+		progress.Status = savingsGoalOnTrack
+		if projected > goal.Deadline {
+			progress.Status = savingsGoalBehind
+		}
+	}
+
+	return progress
+}
+
+// monthsUntil counts the monthly contributions that still fit before the
+// deadline: one on each monthly date after asOf up to the deadline, and at
+// least one while the deadline is still ahead.
+func monthsUntil(asOf time.Time, deadline string) int {
+	day, err := time.Parse("2006-01-02", deadline)
+	if err != nil || !day.After(asOf) {
+		return 0
+	}
+
+	months := (day.Year()-asOf.Year())*12 + int(day.Month()-asOf.Month())
+	if months > 0 && addMonths(asOf, months).After(day) {
+		months--
+	}
+
+	return max(months, 1)
+}
@@ -0,0 +1,64 @@
+package backend
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSavingsGoalProgress(t *testing.T) {
+	goal := savingsGoal{ID: 1, TargetAmount: 1200, Deadline: "2026-12-31"}
+	asOf := time.Date(2026, time.June, 30, 0, 0, 0, 0, time.UTC)
+	contributions := []savingsContribution{
+		{Date: "2026-03-15", Amount: 500},
+		{Date: "2026-04-15", Amount: 100},
+		{Date: "2026-05-15", Amount: 150},
+		{Date: "2026-06-10", Amount: -20},
+		{Date: "2026-06-15", Amount: 70},
+	}
+
+	progress := goal.progress(savingsSourceTransactions, 800, contributions, asOf, 3)
+	if progress.Remaining != 400 || progress.PercentComplete != 66.67 || progress.MonthsLeft != 6 {
+		t.Fatalf("unexpected progress: %+v", progress)
+	}
+	if progress.MonthlyContributionNeeded != 66.67 || progress.AverageContribution != 100 {
+		t.Fatalf("expected 66.67 needed and 100 saved a month, got %+v", progress)
+	}
+	if progress.ProjectedCompletionDate == nil || *progress.ProjectedCompletionDate != "2026-10-30" || progress.Status != savingsGoalOnTrack {
+		t.Fatalf("expected completion on 2026-10-30, on track, got %+v", progress)
+	}
+
+	slow := goal.progress(savingsSourceTransactions, 800, contributions, asOf, 1)
+	if slow.AverageContribution != 50 || *slow.ProjectedCompletionDate != "2027-02-28" || slow.Status != savingsGoalBehind {
+		t.Fatalf("expected a one month average of 50 to finish on 2027-02-28, behind, got %+v", slow)
+	}
+
+	stalled := goal.progress(savingsSourceTransactions, 800, nil, asOf, 3)
+	if stalled.ProjectedCompletionDate != nil || stalled.Status != savingsGoalBehind {
+		t.Fatalf("expected no projection without contributions, got %+v", stalled)
+	}
+
+	done := goal.progress(savingsSourceAccounts, 1300, nil, asOf, 3)
+	if done.Remaining != 0 || done.MonthlyContributionNeeded != 0 || done.PercentComplete != 108.33 || done.Status != savingsGoalCompleted {
+		t.Fatalf("expected a completed goal, got %+v", done)
+	}
+
+	late := goal.progress(savingsSourceAccounts, 800, nil, time.Date(2027, time.January, 5, 0, 0, 0, 0, time.UTC), 3)
+	if late.MonthsLeft != 0 || late.MonthlyContributionNeeded != 400 {
+		t.Fatalf("expected the whole remainder due past the deadline, got %+v", late)
+	}
+
+	for _, item := range []struct {
+		asOf     time.Time
+		deadline string
+		expected int
+	}{
+		{time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC), "2026-02-28", 1},
+		{time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC), "2026-03-20", 1},
+		{time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC), "2026-06-09", 2},
+		{time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC), "2026-03-10", 0},
+	} {
+		if months := monthsUntil(item.asOf, item.deadline); months != item.expected {
+			t.Fatalf("expected %d months from %s to %s, got %d", item.expected, item.asOf.Format("2006-01-02"), item.deadline, months)
+		}
+	}
+}
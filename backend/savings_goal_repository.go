@@ -0,0 +1,225 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+type savingsGoalRepository interface {
+	list(ctx context.Context) ([]savingsGoal, error)
+	get(ctx context.Context, id int64) (savingsGoal, error)
+	create(ctx context.Context, payload savingsGoalPayload) (int64, error)
+	update(ctx context.Context, id int64, payload savingsGoalPayload) error
+	// replaceAccounts replaces the bank accounts linked to the goal.
+	replaceAccounts(ctx context.Context, id int64, bankAccountIDs []int64) error
+	// delete soft deletes the goal.
+	delete(ctx context.Context, id int64) error
+	// contributions returns, in date order, the live transactions on the
+	// goal's accounts, or tagged to it when it has none, dated on or before
+	// asOf. Income counts as a positive amount and expenses as negative.
+	contributions(ctx context.Context, goal savingsGoal, asOf string) ([]savingsContribution, error)
+}
+
+// savingsContribution is money put into (or taken out of) a goal.
+type savingsContribution struct {
+	Date   string
+	Amount float64
+}
+
+type sqlSavingsGoalRepository struct {
+	source queryer
+}
+
+const savingsGoalSelect = `
+	SELECT id, name, target_amount, currency_id, deadline, notes
+	FROM savings_goals
+	WHERE deleted_at IS NULL`
+
+func (repository sqlSavingsGoalRepository) list(ctx context.Context) ([]savingsGoal, error) {
+	rows, err := repository.source.QueryContext(ctx, savingsGoalSelect+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]savingsGoal, 0)
+	for rows.Next() {
+		item, scanErr := scanSavingsGoal(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	accounts, err := repository.accounts(ctx, `
+		SELECT a.savings_goal_id, a.bank_account_id
+		FROM savings_goal_accounts a
+		JOIN savings_goals g ON g.id = a.savings_goal_id
+		WHERE g.deleted_at IS NULL
+		ORDER BY a.savings_goal_id, a.bank_account_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for index := range items {
+		if ids, ok := accounts[items[index].ID]; ok {
+			items[index].BankAccountIDs = ids
+		}
+	}
+
+	return items, nil
+}
+
+func (repository sqlSavingsGoalRepository) get(ctx context.Context, id int64) (savingsGoal, error) {
+	row := repository.source.QueryRowContext(ctx, savingsGoalSelect+` AND id = ?`, id)
+
+	item, err := scanSavingsGoal(row)
+	if err != nil {
+		return savingsGoal{}, rowError(err)
+	}
+
+	accounts, err := repository.accounts(ctx, `
+		SELECT savings_goal_id, bank_account_id
+		FROM savings_goal_accounts
+		WHERE savings_goal_id = ?
+		ORDER BY bank_account_id
+	`, id)
+	if err != nil {
+		return savingsGoal{}, err
+	}
+	if ids, ok := accounts[id]; ok {
+		item.BankAccountIDs = ids
+	}
+
+	return item, nil
+}
+
+// accounts runs a query selecting savings_goal_id and bank_account_id and
+// groups the account ids by goal.
+func (repository sqlSavingsGoalRepository) accounts(ctx context.Context, query string, args ...any) (map[int64][]int64, error) {
+	rows, err := repository.source.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := make(map[int64][]int64)
+	for rows.Next() {
+		var goalID, bankAccountID int64
+		if err = rows.Scan(&goalID, &bankAccountID); err != nil {
+			return nil, err
+		}
+		accounts[goalID] = append(accounts[goalID], bankAccountID)
+	}
+
+	return accounts, rows.Err()
+}
+
+func (repository sqlSavingsGoalRepository) create(ctx context.Context, payload savingsGoalPayload) (int64, error) {
+	return insertRow(
+		ctx,
+		repository.source,
+		`INSERT INTO savings_goals(name, target_amount, currency_id, deadline, notes) VALUES (?, ?, ?, ?, ?)`,
+		payload.Name,
+		payload.TargetAmount,
+		payload.CurrencyID,
+		payload.Deadline,
+		payload.Notes,
+	)
+}
+
+func (repository sqlSavingsGoalRepository) update(ctx context.Context, id int64, payload savingsGoalPayload) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE savings_goals
+		 SET name = ?, target_amount = ?, currency_id = ?, deadline = ?, notes = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND deleted_at IS NULL`,
+		payload.Name,
+		payload.TargetAmount,
+		payload.CurrencyID,
+		payload.Deadline,
+		payload.Notes,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlSavingsGoalRepository) replaceAccounts(ctx context.Context, id int64, bankAccountIDs []int64) error {
+	if _, err := repository.source.ExecContext(ctx, `DELETE FROM savings_goal_accounts WHERE savings_goal_id = ?`, id); err != nil {
+		return err
+	}
+
+	for _, bankAccountID := range bankAccountIDs {
+		_, err := repository.source.ExecContext(
+			ctx,
+			`INSERT INTO savings_goal_accounts(savings_goal_id, bank_account_id) VALUES (?, ?)`,
+			id,
+			bankAccountID,
+		)
+		if err != nil {
+			return constraintError(err)
+		}
+	}
+
+	return nil
+}
+
+func (repository sqlSavingsGoalRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE savings_goals SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func (repository sqlSavingsGoalRepository) contributions(ctx context.Context, goal savingsGoal, asOf string) ([]savingsContribution, error) {
+	filter := `savings_goal_id = ?`
+	args := []any{goal.ID}
+	if len(goal.BankAccountIDs) > 0 {
+		filter = `bank_account_id IN (?` + strings.Repeat(`, ?`, len(goal.BankAccountIDs)-1) + `)`
+		args = args[:0]
+		for _, bankAccountID := range goal.BankAccountIDs {
+			args = append(args, bankAccountID)
+		}
+	}
+	args = append(args, asOf)
+
+	rows, err := repository.source.QueryContext(ctx, `
+		SELECT transaction_date, CASE WHEN type = 'income' THEN amount ELSE -amount END
+		FROM transactions
+		WHERE deleted_at IS NULL AND `+filter+` AND transaction_date <= ?
+		ORDER BY transaction_date, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]savingsContribution, 0)
+	for rows.Next() {
+		var item savingsContribution
+		if err = rows.Scan(&item.Date, &item.Amount); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func scanSavingsGoal(source scanner) (savingsGoal, error) {
+	item := savingsGoal{BankAccountIDs: make([]int64, 0)}
+	var notes sql.NullString
+	if err := source.Scan(&item.ID, &item.Name, &item.TargetAmount, &item.CurrencyID, &item.Deadline, &notes); err != nil {
+		return savingsGoal{}, err
+	}
+
+	if notes.Valid {
+		value := notes.String
+		item.Notes = &value
+	}
+
+	return item, nil
+}
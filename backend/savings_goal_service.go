@@ -0,0 +1,280 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+type savingsGoalService struct {
+	store dataStore
+}
+
+func (payload savingsGoalPayload) normalize() (savingsGoalPayload, error) {
+	payload.Name = strings.TrimSpace(payload.Name)
+	payload.Deadline = strings.TrimSpace(payload.Deadline)
+	payload.Notes = normalizeTransactionNotes(payload.Notes)
+
+	if payload.Name == "" {
+		return savingsGoalPayload{}, invalidPayload("name is required")
+	}
+	if math.IsNaN(payload.TargetAmount) || math.IsInf(payload.TargetAmount, 0) || payload.TargetAmount <= 0 {
+		return savingsGoalPayload{}, invalidPayload("target_amount must be greater than zero")
+	}
+	if payload.CurrencyID <= 0 {
+		return savingsGoalPayload{}, invalidPayload("currency_id must be a positive integer")
+	}
+	if !isValidISODate(payload.Deadline) {
+		return savingsGoalPayload{}, invalidPayload("deadline must be a valid date in YYYY-MM-DD format")
+	}
+
+	seen := make(map[int64]bool, len(payload.BankAccountIDs))
+	for index, bankAccountID := range payload.BankAccountIDs {
+		if bankAccountID <= 0 {
+			return savingsGoalPayload{}, invalidPayload(fmt.Sprintf("bank_account_ids[%d] must be a positive integer", index))
+		}
+		if seen[bankAccountID] {
+			return savingsGoalPayload{}, invalidPayload(fmt.Sprintf("bank account %d is linked twice", bankAccountID))
+		}
+		seen[bankAccountID] = true
+	}
+
+	return payload, nil
+}
+
+func (service savingsGoalService) list(ctx context.Context) ([]savingsGoal, error) {
+	return service.store.reads().savingsGoals().list(ctx)
+}
+
+func (service savingsGoalService) get(ctx context.Context, id int64) (savingsGoal, error) {
+	item, err := service.store.reads().savingsGoals().get(ctx, id)
+	if err != nil {
+		return savingsGoal{}, orNotFound(err, "savings goal not found")
+	}
+
+	return item, nil
+}
+
+func (service savingsGoalService) create(ctx context.Context, by change, payload savingsGoalPayload) (savingsGoal, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return savingsGoal{}, err
+	}
+
+	var created savingsGoal
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkSavingsGoalReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		id, err := tx.savingsGoals().create(ctx, payload)
+		if err != nil {
+			return savingsGoalWriteError(err)
+		}
+		if err = tx.savingsGoals().replaceAccounts(ctx, id, payload.BankAccountIDs); err != nil {
+			return savingsGoalWriteError(err)
+		}
+
+		if created, err = tx.savingsGoals().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntitySavingsGoals, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return savingsGoal{}, err
+	}
+
+	return created, nil
+}
+
+// update changes the goal and replaces its linked accounts. The currency
+// cannot change under transactions tagged to the goal.
+func (service savingsGoalService) update(ctx context.Context, by change, id int64, payload savingsGoalPayload) (savingsGoal, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return savingsGoal{}, err
+	}
+
+	var updated savingsGoal
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.savingsGoals().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "savings goal not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = checkSavingsGoalReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+		if payload.CurrencyID != existing.CurrencyID {
+			tagged, err := tx.trash().hasDependents(ctx, auditEntitySavingsGoals, id)
+			if err != nil {
+				return err
+			}
+			if tagged {
+				return invalidPayload("currency_id cannot change while transactions are tagged to the goal")
+			}
+		}
+
+		if err = tx.savingsGoals().update(ctx, id, payload); err != nil {
+			return savingsGoalWriteError(err)
+		}
+		if err = tx.savingsGoals().replaceAccounts(ctx, id, payload.BankAccountIDs); err != nil {
+			return savingsGoalWriteError(err)
+		}
+
+		if updated, err = tx.savingsGoals().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntitySavingsGoals, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return savingsGoal{}, err
+	}
+
+	return updated, nil
+}
+
+// delete soft deletes a goal no live transaction is tagged to.
+func (service savingsGoalService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.savingsGoals().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "savings goal not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		inUse, err := tx.trash().hasDependents(ctx, auditEntitySavingsGoals, id)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return conflict("savings_goal_in_use", "savings goal is in use")
+		}
+
+		if err = tx.savingsGoals().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntitySavingsGoals, id, auditActionDelete, existing, nil))
+	})
+}
+
+func (service savingsGoalService) progress(ctx context.Context, id int64, asOf time.Time, months int) (savingsGoalProgress, error) {
+	repos := service.store.reads()
+	goal, err := repos.savingsGoals().get(ctx, id)
+	if err != nil {
+		return savingsGoalProgress{}, orNotFound(err, "savings goal not found")
+	}
+
+	return goalProgress(ctx, repos, goal, asOf, months)
+}
+
+func (service savingsGoalService) progressAll(ctx context.Context, asOf time.Time, months int) ([]savingsGoalProgress, error) {
+	repos := service.store.reads()
+	goals, err := repos.savingsGoals().list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]savingsGoalProgress, 0, len(goals))
+	for _, goal := range goals {
+		item, err := goalProgress(ctx, repos, goal, asOf, months)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// goalProgress measures what is saved: the current balances of the linked
+// accounts, or the net of the transactions tagged to the goal up to asOf.
+func goalProgress(ctx context.Context, repos repositories, goal savingsGoal, asOf time.Time, months int) (savingsGoalProgress, error) {
+	contributions, err := repos.savingsGoals().contributions(ctx, goal, asOf.Format("2006-01-02"))
+	if err != nil {
+		return savingsGoalProgress{}, err
+	}
+
+	var saved float64
+	source := savingsSourceTransactions
+	if len(goal.BankAccountIDs) > 0 {
+		source = savingsSourceAccounts
+		for _, bankAccountID := range goal.BankAccountIDs {
+			account, err := repos.bankAccounts().get(ctx, bankAccountID)
+			if err != nil {
+				return savingsGoalProgress{}, err
+			}
+			saved += account.Balance
+		}
+	} else {
+		for _, contribution := range contributions {
+			saved += contribution.Amount
+		}
+	}
+
+	return goal.progress(source, saved, contributions, asOf, months), nil
+}
+
+// checkSavingsGoalReferences checks the currency and that every linked bank
+// account exists and holds the goal currency.
+func checkSavingsGoalReferences(ctx context.Context, tx repositories, payload savingsGoalPayload) error {
+	exists, err := tx.trash().exists(ctx, auditEntityCurrencies, payload.CurrencyID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return invalidPayload("currency must exist")
+	}
+
+	for _, bankAccountID := range payload.BankAccountIDs {
+		account, err := tx.bankAccounts().get(ctx, bankAccountID)
+		if errors.Is(err, errNotFound) {
+			return invalidPayload(fmt.Sprintf("bank account %d must exist", bankAccountID))
+		}
+		if err != nil {
+			return err
+		}
+		if account.CurrencyID != payload.CurrencyID {
+			return invalidPayload(fmt.Sprintf("bank account %d is not in the goal currency", bankAccountID))
+		}
+	}
+
+	return nil
+}
+
+// checkSavingsGoalCurrency checks that a transaction tagged to the goal is
+// booked on a bank account in the goal currency.
+func checkSavingsGoalCurrency(ctx context.Context, tx repositories, goalID int64, bankAccountID int64) error {
+	goal, err := tx.savingsGoals().get(ctx, goalID)
+	if err != nil {
+		return orNotFound(err, "savings goal not found")
+	}
+	account, err := tx.bankAccounts().get(ctx, bankAccountID)
+	if err != nil {
+		return orNotFound(err, "bank account not found")
+	}
+	if account.CurrencyID != goal.CurrencyID {
+		return invalidPayload("bank account currency must match the savings goal currency")
+	}
+
+	return nil
+}
+
+func savingsGoalWriteError(err error) error {
+	if errors.Is(err, errMissingReference) {
+		return invalidPayload("currency and bank accounts must exist")
+	}
+
+	return err
+}
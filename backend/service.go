@@ -0,0 +1,218 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Kinds of serviceError. writeServiceError maps each one to a status code.
+var (
+	errInvalid            = errors.New("invalid")
+	errConflict           = errors.New("conflict")
+	errPreconditionFailed = errors.New("precondition failed")
+)
+
+// serviceError is a failure caused by the caller rather than by the
+// database: code and message are what the client gets to see, kind is one of
+// errInvalid, errNotFound, errConflict or errPreconditionFailed.
+type serviceError struct {
+	kind    error
+	code    string
+	message string
+	// etag is the current tag of the resource when kind is
+	// errPreconditionFailed.
+	etag string
+}
+
+func (failure *serviceError) Error() string {
+	return failure.message
+}
+
+func (failure *serviceError) Unwrap() error {
+	return failure.kind
+}
+
+func invalidPayload(message string) error {
+	return &serviceError{kind: errInvalid, code: "invalid_payload", message: message}
+}
+
+func notFound(message string) error {
+	return &serviceError{kind: errNotFound, code: "not_found", message: message}
+}
+
+func conflict(code string, message string) error {
+	return &serviceError{kind: errConflict, code: code, message: message}
+}
+
+// orNotFound replaces errNotFound from a repository with a not_found service
+// error carrying message.
+func orNotFound(err error, message string) error {
+	if errors.Is(err, errNotFound) {
+		return notFound(message)
+	}
+
+	return err
+}
+
+// statusClientClosedRequest is the non-standard status for requests whose
+// client went away before the answer was ready.
+const statusClientClosedRequest = 499
+
+// writeServiceError writes the response for an error returned by a service.
+// Queries cut off by the request deadline or by the client hanging up are
+// reported as query_timeout and request_canceled. Other errors that are not a
+// serviceError are reported as internal errors with the given message.
+func writeServiceError(writer http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(writer, http.StatusGatewayTimeout, "query_timeout", "the request took longer than the query timeout")
+		return
+	case errors.Is(err, context.Canceled):
+		writeError(writer, statusClientClosedRequest, "request_canceled", "the request was canceled")
+		return
+	}
+
+	var failure *serviceError
+	if !errors.As(err, &failure) {
+		writeError(writer, http.StatusInternalServerError, "internal_error", message)
+		return
+	}
+
+	status := http.StatusInternalServerError
+	switch failure.kind {
+	case errInvalid:
+		status = http.StatusBadRequest
+	case errNotFound:
+		status = http.StatusNotFound
+	case errConflict:
+		status = http.StatusConflict
+	case errPreconditionFailed:
+		status = http.StatusPreconditionFailed
+		writer.Header().Set(etagHeader, failure.etag)
+	}
+
+	writeError(writer, status, failure.code, failure.message)
+}
+
+// change describes who makes a mutation and, through the If-Match tag, which
+// version of the resource it was based on.
+type change struct {
+	actor   string
+	ifMatch string
+}
+
+func requestChange(request *http.Request) change {
+	return change{
+		actor:   requestActor(request),
+		ifMatch: strings.TrimSpace(request.Header.Get(ifMatchHeader)),
+	}
+}
+
+// checkVersion fails with errPreconditionFailed when the change names an
+// If-Match tag that current no longer has. Changes without a tag pass.
+func (by change) checkVersion(current any) error {
+	if by.ifMatch == "" {
+		return nil
+	}
+
+	tag, err := entityTag(current)
+	if err != nil {
+		return err
+	}
+	if ifMatchSatisfied(by.ifMatch, tag) {
+		return nil
+	}
+
+	return &serviceError{
+		kind:    errPreconditionFailed,
+		code:    "precondition_failed",
+		message: "resource was modified by another request",
+		etag:    tag,
+	}
+}
+
+// record builds the audit record of the change.
+func (by change) record(entity string, id int64, action string, before any, after any) auditRecord {
+	return auditRecord{
+		Entity:   entity,
+		EntityID: id,
+		Action:   action,
+		Actor:    by.actor,
+		Before:   before,
+		After:    after,
+	}
+}
+
+// decodeJSON decodes the request body into payload. It writes a 400 response
+// and returns false when the body is not valid JSON.
+func decodeJSON(writer http.ResponseWriter, request *http.Request, payload any) bool {
+	defer request.Body.Close()
+
+	if err := json.NewDecoder(request.Body).Decode(payload); err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_payload", "request body must be valid JSON")
+		return false
+	}
+
+	return true
+}
+
+// services groups the services of every aggregate over one store.
+type services struct {
+	audit                   auditService
+	trash                   trashService
+	countries               countryService
+	currencies              currencyService
+	banks                   bankService
+	bankAccounts            bankAccountService
+	people                  personService
+	transactionCategories   transactionCategoryService
+	transactions            transactionService
+	creditCards             creditCardService
+	creditCardCycles        creditCardCycleService
+	creditCardCycleBalances creditCardCycleBalanceService
+	creditCardInstallments  creditCardInstallmentService
+	creditCardSubscriptions creditCardSubscriptionService
+	expenses                expenseService
+	expensePayments         expensePaymentService
+	reconciliations         reconciliationService
+	sharedExpenses          sharedExpenseService
+	settlements             settlementService
+	loans                   loanService
+	loanRepayments          loanRepaymentService
+	savingsGoals            savingsGoalService
+}
+
+func newServices(store dataStore) services {
+	return services{
+		audit:                   auditService{store: store},
+		trash:                   trashService{store: store},
+		countries:               countryService{store: store},
+		currencies:              currencyService{store: store},
+		banks:                   bankService{store: store},
+		bankAccounts:            bankAccountService{store: store},
+		people:                  personService{store: store},
+		transactionCategories:   transactionCategoryService{store: store},
+		transactions:            transactionService{store: store},
+		creditCards:             creditCardService{store: store},
+		creditCardCycles:        creditCardCycleService{store: store},
+		creditCardCycleBalances: creditCardCycleBalanceService{store: store},
+		creditCardInstallments:  creditCardInstallmentService{store: store},
+		creditCardSubscriptions: creditCardSubscriptionService{store: store},
+		expenses:                expenseService{store: store},
+		expensePayments:         expensePaymentService{store: store},
+		reconciliations:         reconciliationService{store: store},
+		sharedExpenses:          sharedExpenseService{store: store},
+		settlements:             settlementService{store: store},
+		loans:                   loanService{store: store},
+		loanRepayments:          loanRepaymentService{store: store},
+		savingsGoals:            savingsGoalService{store: store},
+	}
+}
+
+// services returns the services of this request.
+func (application app) services() services {
+	return newServices(application.data())
+}
@@ -0,0 +1,62 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithTxRollsBackWhenTheUnitOfWorkFails(t *testing.T) {
+	application := newTestApplication(t)
+	ctx := context.Background()
+	failure := errors.New("stop")
+
+	err := application.data().withTx(ctx, func(tx repositories) error {
+		if _, err := tx.people().create(ctx, personPayload{Name: "Rolled Back"}); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the unit of work error, got %v", err)
+	}
+
+	people, err := application.services().people.list(ctx)
+	if err != nil {
+		t.Fatalf("list people: %v", err)
+	}
+	if len(people) != 0 {
+		t.Fatalf("expected the insert to be rolled back, got %v", people)
+	}
+}
+
+func TestServiceErrorsWithoutHTTP(t *testing.T) {
+	application := newTestApplication(t)
+	ctx := context.Background()
+	people := application.services().people
+
+	if _, err := people.create(ctx, change{actor: "test"}, personPayload{Name: "  "}); !errors.Is(err, errInvalid) {
+		t.Fatalf("expected invalid payload, got %v", err)
+	}
+	if _, err := people.get(ctx, 42); !errors.Is(err, errNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	created, err := people.create(ctx, change{actor: "test"}, personPayload{Name: "Ada"})
+	if err != nil {
+		t.Fatalf("create person: %v", err)
+	}
+
+	_, err = people.update(ctx, change{actor: "test", ifMatch: `"stale"`}, created.ID, personPayload{Name: "Grace"})
+	if !errors.Is(err, errPreconditionFailed) {
+		t.Fatalf("expected precondition failure, got %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	writeServiceError(recorder, err, "unused")
+	if recorder.Code != http.StatusPreconditionFailed || recorder.Header().Get(etagHeader) == "" {
+		t.Fatalf("expected 412 with the current tag, got %d %q", recorder.Code, recorder.Header().Get(etagHeader))
+	}
+}
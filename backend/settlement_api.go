@@ -0,0 +1,146 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const (
+	settlementsPath       = "/api/settlements"
+	settlementsPathByID   = "/api/settlements/"
+	settlementPathPattern = "/api/settlements/%d"
+)
+
+// settlement is a payment from one person to another that pays off costs
+// they shared; it counts against the balances of shared expenses.
+type settlement struct {
+	ID             int64   `json:"id"`
+	FromPersonID   int64   `json:"from_person_id"`
+	ToPersonID     int64   `json:"to_person_id"`
+	Amount         float64 `json:"amount"`
+	CurrencyID     int64   `json:"currency_id"`
+	SettlementDate string  `json:"settlement_date"`
+	Notes          *string `json:"notes"`
+}
+
+type settlementPayload struct {
+	FromPersonID   int64   `json:"from_person_id"`
+	ToPersonID     int64   `json:"to_person_id"`
+	Amount         float64 `json:"amount"`
+	CurrencyID     int64   `json:"currency_id"`
+	SettlementDate string  `json:"settlement_date"`
+	Notes          *string `json:"notes"`
+}
+
+func (application app) registerSettlementRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(settlementsPath, application.settlementsHandler)
+	mux.HandleFunc(settlementsPathByID, application.settlementByIDHandler)
+	mux.HandleFunc(settlementsPath+batchPathSuffix, application.batchHandler(settlementsPath, app.settlementsHandler, app.settlementByIDHandler))
+}
+
+func (application app) settlementsHandler(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		application.listSettlements(writer, request)
+	case http.MethodPost:
+		application.createSettlement(writer, request)
+	default:
+		methodNotAllowed(writer, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (application app) settlementByIDHandler(writer http.ResponseWriter, request *http.Request) {
+	id, err := parseIDFromPath(request.URL.Path, settlementsPathByID)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_id", "settlement id must be a positive integer")
+		return
+	}
+
+	switch request.Method {
+	case http.MethodGet:
+		application.getSettlement(writer, request, id)
+	case http.MethodPut:
+		application.updateSettlement(writer, request, id)
+	case http.MethodPatch:
+		application.patchSettlement(writer, request, id)
+	case http.MethodDelete:
+		application.deleteSettlement(writer, request, id)
+	default:
+		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
+	}
+}
+
+func (application app) listSettlements(writer http.ResponseWriter, request *http.Request) {
+	items, err := application.services().settlements.list(request.Context())
+	if err != nil {
+		writeServiceError(writer, err, "failed to load settlements")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, items)
+}
+
+func (application app) getSettlement(writer http.ResponseWriter, request *http.Request, id int64) {
+	item, err := application.services().settlements.get(request.Context(), id)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load settlement")
+		return
+	}
+
+	writeJSONWithETag(writer, http.StatusOK, item)
+}
+
+func (application app) createSettlement(writer http.ResponseWriter, request *http.Request) {
+	var payload settlementPayload
+	if !decodeJSON(writer, request, &payload) {
+		return
+	}
+
+	created, err := application.services().settlements.create(request.Context(), requestChange(request), payload)
+	if err != nil {
+		writeServiceError(writer, err, "failed to create settlement")
+		return
+	}
+
+	writer.Header().Set("Location", fmt.Sprintf(settlementPathPattern, created.ID))
+	writeJSONWithETag(writer, http.StatusCreated, created)
+}
+
+func (application app) updateSettlement(writer http.ResponseWriter, request *http.Request, id int64) {
+	var payload settlementPayload
+	if !decodeJSON(writer, request, &payload) {
+		return
+	}
+
+	updated, err := application.services().settlements.update(request.Context(), requestChange(request), id, payload)
+	if err != nil {
+		writeServiceError(writer, err, "failed to update settlement")
+		return
+	}
+
+	writeJSONWithETag(writer, http.StatusOK, updated)
+}
+
+func (application app) patchSettlement(writer http.ResponseWriter, request *http.Request, id int64) {
+	current, err := application.services().settlements.get(request.Context(), id)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load settlement")
+		return
+	}
+
+	mergedRequest, ok := mergePatchRequest(writer, request, current)
+	if !ok {
+		return
+	}
+
+	application.updateSettlement(writer, mergedRequest, id)
+}
+
+func (application app) deleteSettlement(writer http.ResponseWriter, request *http.Request, id int64) {
+	if err := application.services().settlements.delete(request.Context(), requestChange(request), id); err != nil {
+		writeServiceError(writer, err, "failed to delete settlement")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,65 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestSettlementCRUDFlow(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedSharedExpenseDependencies(t, router)
+
+	createResponse := performRequest(router, http.MethodPost, "/api/settlements", []byte(`{"from_person_id":2,"to_person_id":1,"amount":25.5,"currency_id":1,"settlement_date":"2026-03-10"}`))
+	if createResponse.Code != http.StatusCreated {
+		t.Fatalf("expected create to return 201, got %d: %s", createResponse.Code, createResponse.Body.String())
+	}
+	if location := createResponse.Header().Get("Location"); location != "/api/settlements/1" {
+		t.Fatalf("unexpected Location header %q", location)
+	}
+
+	for _, invalid := range []string{
+		`{"from_person_id":0,"to_person_id":1,"amount":1,"currency_id":1,"settlement_date":"2026-03-10"}`,
+		`{"from_person_id":2,"to_person_id":1,"amount":0,"currency_id":1,"settlement_date":"2026-03-10"}`,
+		`{"from_person_id":2,"to_person_id":1,"amount":1,"currency_id":1,"settlement_date":"10/03/2026"}`,
+		`{"from_person_id":2,"to_person_id":99,"amount":1,"currency_id":1,"settlement_date":"2026-03-10"}`,
+	} {
+		response := performRequest(router, http.MethodPost, "/api/settlements", []byte(invalid))
+		if response.Code != http.StatusBadRequest {
+			t.Fatalf("expected %s to return 400, got %d", invalid, response.Code)
+		}
+	}
+
+	patchResponse := performRequestWithHeaders(router, http.MethodPatch, "/api/settlements/1", []byte(`{"amount":30,"notes":"cash"}`), map[string]string{"Content-Type": "application/merge-patch+json"})
+	if patchResponse.Code != http.StatusOK {
+		t.Fatalf("expected patch to return 200, got %d: %s", patchResponse.Code, patchResponse.Body.String())
+	}
+
+	listResponse := performRequest(router, http.MethodGet, "/api/settlements", nil)
+	var items []settlement
+	if err := json.NewDecoder(listResponse.Body).Decode(&items); err != nil {
+		t.Fatalf("decode settlements: %v", err)
+	}
+	if len(items) != 1 || items[0].Amount != 30 || items[0].Notes == nil || *items[0].Notes != "cash" || items[0].FromPersonID != 2 {
+		t.Fatalf("unexpected settlements: %+v", items)
+	}
+
+	if response := performRequest(router, http.MethodPut, "/api/settlements/1", []byte(`{"from_person_id":2,"to_person_id":2,"amount":30,"currency_id":1,"settlement_date":"2026-03-10"}`)); response.Code != http.StatusBadRequest {
+		t.Fatalf("expected a settlement to oneself to return 400, got %d", response.Code)
+	}
+	if response := performRequest(router, http.MethodDelete, "/api/people/2", nil); response.Code != http.StatusConflict {
+		t.Fatalf("expected deleting a person with settlements to return 409, got %d", response.Code)
+	}
+
+	if response := performRequest(router, http.MethodDelete, "/api/settlements/1", nil); response.Code != http.StatusNoContent {
+		t.Fatalf("expected delete to return 204, got %d", response.Code)
+	}
+	if response := performRequest(router, http.MethodGet, "/api/settlements/1", nil); response.Code != http.StatusNotFound {
+		t.Fatalf("expected deleted settlement to return 404, got %d", response.Code)
+	}
+	if response := performRequest(router, http.MethodGet, "/api/settlements/abc", nil); response.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid id to return 400, got %d", response.Code)
+	}
+}
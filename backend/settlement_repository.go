@@ -0,0 +1,109 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+)
+
+type settlementRepository interface {
+	list(ctx context.Context) ([]settlement, error)
+	get(ctx context.Context, id int64) (settlement, error)
+	create(ctx context.Context, payload settlementPayload) (int64, error)
+	update(ctx context.Context, id int64, payload settlementPayload) error
+	// delete soft deletes the settlement.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlSettlementRepository struct {
+	source queryer
+}
+
+func (repository sqlSettlementRepository) list(ctx context.Context) ([]settlement, error) {
+	rows, err := repository.source.QueryContext(ctx, `
+		SELECT id, from_person_id, to_person_id, amount, currency_id, settlement_date, notes
+		FROM settlements
+		WHERE deleted_at IS NULL
+		ORDER BY settlement_date, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]settlement, 0)
+	for rows.Next() {
+		item, scanErr := scanSettlement(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlSettlementRepository) get(ctx context.Context, id int64) (settlement, error) {
+	row := repository.source.QueryRowContext(ctx, `
+		SELECT id, from_person_id, to_person_id, amount, currency_id, settlement_date, notes
+		FROM settlements
+		WHERE id = ? AND deleted_at IS NULL
+	`, id)
+
+	item, err := scanSettlement(row)
+	if err != nil {
+		return settlement{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlSettlementRepository) create(ctx context.Context, payload settlementPayload) (int64, error) {
+	return insertRow(
+		ctx,
+		repository.source,
+		`INSERT INTO settlements(from_person_id, to_person_id, amount, currency_id, settlement_date, notes) VALUES (?, ?, ?, ?, ?, ?)`,
+		payload.FromPersonID,
+		payload.ToPersonID,
+		payload.Amount,
+		payload.CurrencyID,
+		payload.SettlementDate,
+		payload.Notes,
+	)
+}
+
+func (repository sqlSettlementRepository) update(ctx context.Context, id int64, payload settlementPayload) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE settlements
+		 SET from_person_id = ?, to_person_id = ?, amount = ?, currency_id = ?, settlement_date = ?, notes = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND deleted_at IS NULL`,
+		payload.FromPersonID,
+		payload.ToPersonID,
+		payload.Amount,
+		payload.CurrencyID,
+		payload.SettlementDate,
+		payload.Notes,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlSettlementRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE settlements SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func scanSettlement(source scanner) (settlement, error) {
+	var item settlement
+	var notes sql.NullString
+	if err := source.Scan(&item.ID, &item.FromPersonID, &item.ToPersonID, &item.Amount, &item.CurrencyID, &item.SettlementDate, &notes); err != nil {
+		return settlement{}, err
+	}
+
+	if notes.Valid {
+		value := notes.String
+		item.Notes = &value
+	}
+
+	return item, nil
+}
@@ -0,0 +1,160 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+)
+
+type settlementService struct {
+	store dataStore
+}
+
+func (payload settlementPayload) normalize() (settlementPayload, error) {
+	payload.SettlementDate = strings.TrimSpace(payload.SettlementDate)
+	payload.Notes = normalizeTransactionNotes(payload.Notes)
+
+	if payload.FromPersonID <= 0 {
+		return settlementPayload{}, invalidPayload("from_person_id must be a positive integer")
+	}
+	if payload.ToPersonID <= 0 {
+		return settlementPayload{}, invalidPayload("to_person_id must be a positive integer")
+	}
+	if payload.FromPersonID == payload.ToPersonID {
+		return settlementPayload{}, invalidPayload("from_person_id and to_person_id must be different people")
+	}
+	if math.IsNaN(payload.Amount) || math.IsInf(payload.Amount, 0) || payload.Amount <= 0 {
+		return settlementPayload{}, invalidPayload("amount must be greater than zero")
+	}
+	if payload.CurrencyID <= 0 {
+		return settlementPayload{}, invalidPayload("currency_id must be a positive integer")
+	}
+	if !isValidISODate(payload.SettlementDate) {
+		return settlementPayload{}, invalidPayload("settlement_date must be a valid date in YYYY-MM-DD format")
+	}
+
+	return payload, nil
+}
+
+func (service settlementService) list(ctx context.Context) ([]settlement, error) {
+	return service.store.reads().settlements().list(ctx)
+}
+
+func (service settlementService) get(ctx context.Context, id int64) (settlement, error) {
+	item, err := service.store.reads().settlements().get(ctx, id)
+	if err != nil {
+		return settlement{}, orNotFound(err, "settlement not found")
+	}
+
+	return item, nil
+}
+
+func (service settlementService) create(ctx context.Context, by change, payload settlementPayload) (settlement, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return settlement{}, err
+	}
+
+	var created settlement
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkSettlementReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		id, err := tx.settlements().create(ctx, payload)
+		if err != nil {
+			return settlementWriteError(err)
+		}
+
+		if created, err = tx.settlements().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntitySettlements, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return settlement{}, err
+	}
+
+	return created, nil
+}
+
+func (service settlementService) update(ctx context.Context, by change, id int64, payload settlementPayload) (settlement, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return settlement{}, err
+	}
+
+	var updated settlement
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.settlements().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "settlement not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = checkSettlementReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		if err = tx.settlements().update(ctx, id, payload); err != nil {
+			return settlementWriteError(err)
+		}
+
+		if updated, err = tx.settlements().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntitySettlements, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return settlement{}, err
+	}
+
+	return updated, nil
+}
+
+func (service settlementService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.settlements().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "settlement not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = tx.settlements().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntitySettlements, id, auditActionDelete, existing, nil))
+	})
+}
+
+func checkSettlementReferences(ctx context.Context, tx repositories, payload settlementPayload) error {
+	referencesExist, err := tx.trash().referencesExist(ctx, auditEntitySettlements, map[string]int64{
+		"from_person_id": payload.FromPersonID,
+		"to_person_id":   payload.ToPersonID,
+		"currency_id":    payload.CurrencyID,
+	})
+	if err != nil {
+		return err
+	}
+	if !referencesExist {
+		return invalidPayload("people and currency must exist")
+	}
+
+	return nil
+}
+
+func settlementWriteError(err error) error {
+	if errors.Is(err, errMissingReference) {
+		return invalidPayload("people and currency must exist")
+	}
+
+	return err
+}
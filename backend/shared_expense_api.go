@@ -0,0 +1,238 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	sharedExpensesPath       = "/api/shared-expenses"
+	sharedExpensesPathByID   = "/api/shared-expenses/"
+	sharedExpensePathPattern = "/api/shared-expenses/%d"
+	// sharedExpenseBalancesPath shows who owes whom and
+	// sharedExpenseSettleUpPath the payments that would even it out.
+	sharedExpenseBalancesPath = "/api/shared-expenses/balances"
+	sharedExpenseSettleUpPath = "/api/shared-expenses/settle-up"
+	// sharedExpenseSharesTable holds the people sharing each expense.
+	sharedExpenseSharesTable = "shared_expense_shares"
+)
+
+// Ways of sharing the cost of a transaction.
+const (
+	splitMethodEqual      = "equal"
+	splitMethodPercentage = "percentage"
+	splitMethodExact      = "exact"
+)
+
+// sharedExpense records that PayerID paid a transaction on behalf of the
+// people in Shares. Amount, CurrencyID and TransactionDate come from the
+// transaction, so the shares follow later edits of its amount.
+type sharedExpense struct {
+	ID              int64                `json:"id"`
+	TransactionID   int64                `json:"transaction_id"`
+	PayerID         int64                `json:"payer_id"`
+	SplitMethod     string               `json:"split_method"`
+	Amount          float64              `json:"amount"`
+	CurrencyID      int64                `json:"currency_id"`
+	TransactionDate string               `json:"transaction_date"`
+	Shares          []sharedExpenseShare `json:"shares"`
+}
+
+// sharedExpenseShare is what one person's part of a shared expense comes to.
+// Percentage is set for percentage splits only.
+type sharedExpenseShare struct {
+	PersonID   int64    `json:"person_id"`
+	Percentage *float64 `json:"percentage"`
+	Amount     float64  `json:"amount"`
+}
+
+type sharedExpensePayload struct {
+	TransactionID int64 `json:"transaction_id"`
+	// PayerID defaults to the person of the transaction.
+	PayerID     int64                       `json:"payer_id"`
+	SplitMethod string                      `json:"split_method"`
+	Shares      []sharedExpenseSharePayload `json:"shares"`
+}
+
+// sharedExpenseSharePayload names a person sharing the expense. Percentage is
+// read for percentage splits and Amount for exact splits; equal splits read
+// neither.
+type sharedExpenseSharePayload struct {
+	PersonID   int64    `json:"person_id"`
+	Percentage *float64 `json:"percentage"`
+	Amount     *float64 `json:"amount"`
+}
+
+func (application app) registerSharedExpenseRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(sharedExpensesPath, application.sharedExpensesHandler)
+	mux.HandleFunc(sharedExpensesPathByID, application.sharedExpenseByIDHandler)
+	mux.HandleFunc(sharedExpenseBalancesPath, application.sharedExpenseBalancesHandler)
+	mux.HandleFunc(sharedExpenseSettleUpPath, application.sharedExpenseSettleUpHandler)
+	mux.HandleFunc(sharedExpensesPath+batchPathSuffix, application.batchHandler(sharedExpensesPath, app.sharedExpensesHandler, app.sharedExpenseByIDHandler))
+}
+
+func (application app) sharedExpensesHandler(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		application.listSharedExpenses(writer, request)
+	case http.MethodPost:
+		application.createSharedExpense(writer, request)
+	default:
+		methodNotAllowed(writer, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (application app) sharedExpenseByIDHandler(writer http.ResponseWriter, request *http.Request) {
+	id, err := parseIDFromPath(request.URL.Path, sharedExpensesPathByID)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_id", "shared expense id must be a positive integer")
+		return
+	}
+
+	switch request.Method {
+	case http.MethodGet:
+		application.getSharedExpense(writer, request, id)
+	case http.MethodPut:
+		application.updateSharedExpense(writer, request, id)
+	case http.MethodPatch:
+		application.patchSharedExpense(writer, request, id)
+	case http.MethodDelete:
+		application.deleteSharedExpense(writer, request, id)
+	default:
+		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
+	}
+}
+
+func (application app) sharedExpenseBalancesHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		methodNotAllowed(writer, http.MethodGet)
+		return
+	}
+
+	currencyID, ok := parseCurrencyFilter(writer, request)
+	if !ok {
+		return
+	}
+
+	balances, err := application.services().sharedExpenses.balances(request.Context(), currencyID)
+	if err != nil {
+		writeServiceError(writer, err, "failed to compute shared expense balances")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, balances)
+}
+
+func (application app) sharedExpenseSettleUpHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		methodNotAllowed(writer, http.MethodGet)
+		return
+	}
+
+	currencyID, ok := parseCurrencyFilter(writer, request)
+	if !ok {
+		return
+	}
+
+	plans, err := application.services().sharedExpenses.settleUp(request.Context(), currencyID)
+	if err != nil {
+		writeServiceError(writer, err, "failed to compute settle-up payments")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, plans)
+}
+
+// parseCurrencyFilter reads the optional currency_id query parameter; 0 means
+// every currency.
+func parseCurrencyFilter(writer http.ResponseWriter, request *http.Request) (int64, bool) {
+	raw := strings.TrimSpace(request.URL.Query().Get("currency_id"))
+	if raw == "" {
+		return 0, true
+	}
+
+	currencyID, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || currencyID <= 0 {
+		writeError(writer, http.StatusBadRequest, "invalid_query", "currency_id must be a positive integer")
+		return 0, false
+	}
+
+	return currencyID, true
+}
+
+func (application app) listSharedExpenses(writer http.ResponseWriter, request *http.Request) {
+	items, err := application.services().sharedExpenses.list(request.Context())
+	if err != nil {
+		writeServiceError(writer, err, "failed to load shared expenses")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, items)
+}
+
+func (application app) getSharedExpense(writer http.ResponseWriter, request *http.Request, id int64) {
+	item, err := application.services().sharedExpenses.get(request.Context(), id)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load shared expense")
+		return
+	}
+
+	writeJSONWithETag(writer, http.StatusOK, item)
+}
+
+func (application app) createSharedExpense(writer http.ResponseWriter, request *http.Request) {
+	var payload sharedExpensePayload
+	if !decodeJSON(writer, request, &payload) {
+		return
+	}
+
+	created, err := application.services().sharedExpenses.create(request.Context(), requestChange(request), payload)
+	if err != nil {
+		writeServiceError(writer, err, "failed to create shared expense")
+		return
+	}
+
+	writer.Header().Set("Location", fmt.Sprintf(sharedExpensePathPattern, created.ID))
+	writeJSONWithETag(writer, http.StatusCreated, created)
+}
+
+func (application app) updateSharedExpense(writer http.ResponseWriter, request *http.Request, id int64) {
+	var payload sharedExpensePayload
+	if !decodeJSON(writer, request, &payload) {
+		return
+	}
+
+	updated, err := application.services().sharedExpenses.update(request.Context(), requestChange(request), id, payload)
+	if err != nil {
+		writeServiceError(writer, err, "failed to update shared expense")
+		return
+	}
+
+	writeJSONWithETag(writer, http.StatusOK, updated)
+}
+
+func (application app) patchSharedExpense(writer http.ResponseWriter, request *http.Request, id int64) {
+	current, err := application.services().sharedExpenses.get(request.Context(), id)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load shared expense")
+		return
+	}
+
+	mergedRequest, ok := mergePatchRequest(writer, request, current)
+	if !ok {
+		return
+	}
+
+	application.updateSharedExpense(writer, mergedRequest, id)
+}
+
+func (application app) deleteSharedExpense(writer http.ResponseWriter, request *http.Request, id int64) {
+	if err := application.services().sharedExpenses.delete(request.Context(), requestChange(request), id); err != nil {
+		writeServiceError(writer, err, "failed to delete shared expense")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
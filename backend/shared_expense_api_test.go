@@ -0,0 +1,134 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSharedExpensesAndSettleUp(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedSharedExpenseDependencies(t, router)
+
+	amounts := func(item sharedExpense) []float64 {
+		values := make([]float64, len(item.Shares))
+		for index, share := range item.Shares {
+			values[index] = share.Amount
+		}
+		return values
+	}
+
+	for _, invalid := range []string{
+		`{"transaction_id":3,"split_method":"equal","shares":[{"person_id":1},{"person_id":2}]}`,
+		`{"transaction_id":1,"split_method":"equal","shares":[{"person_id":1}]}`,
+		`{"transaction_id":1,"split_method":"equal","shares":[{"person_id":1},{"person_id":1}]}`,
+		`{"transaction_id":1,"split_method":"equal","shares":[{"person_id":1},{"person_id":99}]}`,
+		`{"transaction_id":1,"split_method":"halves","shares":[{"person_id":1},{"person_id":2}]}`,
+		`{"transaction_id":1,"split_method":"percentage","shares":[{"person_id":1,"percentage":50},{"person_id":2,"percentage":40}]}`,
+		`{"transaction_id":1,"split_method":"exact","shares":[{"person_id":1,"amount":50},{"person_id":2,"amount":30}]}`,
+		`{"transaction_id":99,"split_method":"equal","shares":[{"person_id":1},{"person_id":2}]}`,
+	} {
+		expectResponse(t, router, http.MethodPost, "/api/shared-expenses", invalid, http.StatusBadRequest)
+	}
+
+	var dinner sharedExpense
+	decodeResponse(t, expectResponse(t, router, http.MethodPost, "/api/shared-expenses", `{"transaction_id":1,"split_method":"equal","shares":[{"person_id":1},{"person_id":2},{"person_id":3}]}`, http.StatusCreated), &dinner)
+	if dinner.PayerID != 1 || dinner.CurrencyID != 1 || dinner.Amount != 90 || !reflect.DeepEqual(amounts(dinner), []float64{30, 30, 30}) {
+		t.Fatalf("unexpected equal shared expense: %+v", dinner)
+	}
+	expectResponse(t, router, http.MethodPost, "/api/shared-expenses", `{"transaction_id":1,"split_method":"equal","shares":[{"person_id":2},{"person_id":3}]}`, http.StatusConflict)
+
+	var groceries sharedExpense
+	decodeResponse(t, expectResponse(t, router, http.MethodPost, "/api/shared-expenses", `{"transaction_id":2,"payer_id":2,"split_method":"percentage","shares":[{"person_id":1,"percentage":50},{"person_id":2,"percentage":25},{"person_id":3,"percentage":25}]}`, http.StatusCreated), &groceries)
+	if !reflect.DeepEqual(amounts(groceries), []float64{30, 15, 15}) {
+		t.Fatalf("unexpected percentage shares: %+v", groceries.Shares)
+	}
+
+	// Jane and John owe each other 30, so only Ann owes anything.
+	var balances []sharedBalance
+	decodeResponse(t, expectResponse(t, router, http.MethodGet, "/api/shared-expenses/balances", "", http.StatusOK), &balances)
+	expectedDebts := []sharedDebt{{FromPersonID: 3, ToPersonID: 1, Amount: 30}, {FromPersonID: 3, ToPersonID: 2, Amount: 15}}
+	if len(balances) != 1 || balances[0].Currency != "USD" || !reflect.DeepEqual(balances[0].Debts, expectedDebts) {
+		t.Fatalf("unexpected balances: %+v", balances)
+	}
+	expectedPeople := []sharedPersonBalance{{PersonID: 1, Net: 30}, {PersonID: 2, Net: 15}, {PersonID: 3, Net: -45}}
+	if !reflect.DeepEqual(balances[0].People, expectedPeople) {
+		t.Fatalf("unexpected net balances: %+v", balances[0].People)
+	}
+
+	var plans []settleUpPlan
+	decodeResponse(t, expectResponse(t, router, http.MethodGet, "/api/shared-expenses/settle-up", "", http.StatusOK), &plans)
+	if len(plans) != 1 || !reflect.DeepEqual(plans[0].Payments, expectedDebts) {
+		t.Fatalf("unexpected settle-up plan: %+v", plans)
+	}
+
+	expectResponse(t, router, http.MethodPost, "/api/settlements", `{"from_person_id":3,"to_person_id":3,"amount":30,"currency_id":1,"settlement_date":"2026-03-10"}`, http.StatusBadRequest)
+	expectResponse(t, router, http.MethodPost, "/api/settlements", `{"from_person_id":3,"to_person_id":1,"amount":30,"currency_id":9,"settlement_date":"2026-03-10"}`, http.StatusBadRequest)
+	var paid settlement
+	decodeResponse(t, expectResponse(t, router, http.MethodPost, "/api/settlements", `{"from_person_id":3,"to_person_id":1,"amount":30,"currency_id":1,"settlement_date":"2026-03-10","notes":" bank transfer "}`, http.StatusCreated), &paid)
+	if paid.Notes == nil || *paid.Notes != "bank transfer" {
+		t.Fatalf("unexpected settlement: %+v", paid)
+	}
+
+	decodeResponse(t, expectResponse(t, router, http.MethodGet, "/api/shared-expenses/settle-up", "", http.StatusOK), &plans)
+	if len(plans) != 1 || !reflect.DeepEqual(plans[0].Payments, []sharedDebt{{FromPersonID: 3, ToPersonID: 2, Amount: 15}}) {
+		t.Fatalf("expected the settlement to leave one payment, got %+v", plans)
+	}
+	decodeResponse(t, expectResponse(t, router, http.MethodGet, "/api/shared-expenses/balances?currency_id=2", "", http.StatusOK), &balances)
+	if len(balances) != 0 {
+		t.Fatalf("expected no balances in another currency, got %+v", balances)
+	}
+	expectResponse(t, router, http.MethodGet, "/api/shared-expenses/balances?currency_id=x", "", http.StatusBadRequest)
+	expectResponse(t, router, http.MethodPost, "/api/shared-expenses/settle-up", "", http.StatusMethodNotAllowed)
+
+	expectResponse(t, router, http.MethodDelete, "/api/transactions/1", "", http.StatusConflict)
+	expectResponse(t, router, http.MethodDelete, "/api/people/3", "", http.StatusConflict)
+
+	// Exact shares keep their amounts when the transaction changes, which the
+	// integrity check reports.
+	decodeResponse(t, expectResponse(t, router, http.MethodPatch, "/api/shared-expenses/2", `{"split_method":"exact"}`, http.StatusOK), &groceries)
+	if groceries.Shares[0].Percentage != nil || !reflect.DeepEqual(amounts(groceries), []float64{30, 15, 15}) {
+		t.Fatalf("unexpected exact shares: %+v", groceries.Shares)
+	}
+	expectResponse(t, router, http.MethodPut, "/api/transactions/2", `{"transaction_date":"2026-03-04","type":"expense","amount":70,"person_id":2,"bank_account_id":1,"category_id":1}`, http.StatusOK)
+	report, err := RunIntegrityChecks(context.Background(), application.db, []string{"shared_expense_totals"})
+	if err != nil {
+		t.Fatalf("integrity checks: %v", err)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].Entities[0].Link != "/api/shared-expenses/2" {
+		t.Fatalf("expected one shared expense total finding, got %+v", report.Findings)
+	}
+
+	expectResponse(t, router, http.MethodDelete, "/api/shared-expenses/1", "", http.StatusNoContent)
+	expectResponse(t, router, http.MethodDelete, "/api/transactions/1", "", http.StatusNoContent)
+	expectResponse(t, router, http.MethodPost, "/api/trash/shared_expenses/1/restore", "", http.StatusConflict)
+}
+
+// seedSharedExpenseDependencies adds two more people and three transactions
+// paid by different people to the transaction dependencies.
+func seedSharedExpenseDependencies(t *testing.T, router http.Handler) {
+	t.Helper()
+
+	seedTransactionDependencies(t, router)
+
+	for _, name := range []string{"John Doe", "Ann Smith"} {
+		person := performRequest(router, http.MethodPost, "/api/people", []byte(`{"name":"`+name+`"}`))
+		if person.Code != http.StatusCreated {
+			t.Fatalf("expected person seed to return 201, got %d", person.Code)
+		}
+	}
+
+	for _, body := range []string{
+		`{"transaction_date":"2026-03-01","type":"expense","amount":90,"notes":"Dinner","person_id":1,"bank_account_id":1,"category_id":1}`,
+		`{"transaction_date":"2026-03-04","type":"expense","amount":60,"notes":"Groceries","person_id":2,"bank_account_id":1,"category_id":1}`,
+		`{"transaction_date":"2026-03-05","type":"income","amount":10,"person_id":1,"bank_account_id":1,"category_id":1}`,
+	} {
+		response := performRequest(router, http.MethodPost, "/api/transactions", []byte(body))
+		if response.Code != http.StatusCreated {
+			t.Fatalf("expected transaction seed to return 201, got %d", response.Code)
+		}
+	}
+}
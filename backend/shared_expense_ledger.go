@@ -0,0 +1,232 @@
+package backend
+
+import (
+	"math"
+	"sort"
+)
+
+// sharedBalance is who owes whom in one currency, after the settlements
+// recorded so far. A positive Net means the others owe the person money.
+type sharedBalance struct {
+	CurrencyID int64                 `json:"currency_id"`
+	Currency   string                `json:"currency"`
+	People     []sharedPersonBalance `json:"people"`
+	Debts      []sharedDebt          `json:"debts"`
+}
+
+type sharedPersonBalance struct {
+	PersonID int64   `json:"person_id"`
+	Net      float64 `json:"net"`
+}
+
+// sharedDebt is an amount one person owes, or should pay, another.
+type sharedDebt struct {
+	FromPersonID int64   `json:"from_person_id"`
+	ToPersonID   int64   `json:"to_person_id"`
+	Amount       float64 `json:"amount"`
+}
+
+// settleUpPlan lists the payments that bring every balance of a currency to
+// zero.
+type settleUpPlan struct {
+	CurrencyID int64        `json:"currency_id"`
+	Currency   string       `json:"currency"`
+	Payments   []sharedDebt `json:"payments"`
+}
+
+// allocateShares works out the amount of each share of an equal or percentage
+// split. Amounts are shared out in cents and the cents left over by rounding go
+// to the largest remainders, so the shares always add up to the amount. Exact
+// shares are returned as stored.
+func allocateShares(method string, amount float64, shares []sharedExpenseShare) []sharedExpenseShare {
+	allocated := make([]sharedExpenseShare, len(shares))
+	copy(allocated, shares)
+	if method == splitMethodExact || len(allocated) == 0 {
+		return allocated
+	}
+
+	weights := make([]float64, len(allocated))
+	for index, share := range allocated {
+		weights[index] = 1
+		if method == splitMethodPercentage && share.Percentage != nil {
+			weights[index] = *share.Percentage
+		}
+	}
+	for index, cents := range allocateCents(toCents(amount), weights) {
+		allocated[index].Amount = fromCents(cents)
+	}
+
+	return allocated
+}
+
+// allocateCents divides total cents in proportion to weights with the largest
+// remainder method; ties go to the earlier share.
+func allocateCents(total int64, weights []float64) []int64 {
+	sum := 0.0
+	for _, weight := range weights {
+		sum += weight
+	}
+
+	cents := make([]int64, len(weights))
+	remainders := make([]float64, len(weights))
+	left := total
+	for index, weight := range weights {
+		exact := float64(total) * weight / sum
+		cents[index] = int64(math.Floor(exact))
+		remainders[index] = exact - float64(cents[index])
+		left -= cents[index]
+	}
+
+	order := make([]int, len(weights))
+	for index := range order {
+		order[index] = index
+	}
+	sort.SliceStable(order, func(left, right int) bool {
+		return remainders[order[left]] > remainders[order[right]]
+	})
+	for index := 0; left > 0; index++ {
+		cents[order[index%len(order)]]++
+		left--
+	}
+
+	return cents
+}
+
+// computeSharedBalances nets, per currency, what each person owes the payers of
+// the expenses they share, less the settlements they paid or received.
+func computeSharedBalances(expenses []sharedExpense, settlements []settlement) []sharedBalance {
+	type pair struct{ from, to int64 }
+	owed := make(map[int64]map[pair]int64)
+	people := make(map[int64]map[int64]bool)
+	add := func(currencyID int64, from int64, to int64, cents int64) {
+		if owed[currencyID] == nil {
+			owed[currencyID] = make(map[pair]int64)
+			people[currencyID] = make(map[int64]bool)
+		}
+		people[currencyID][from] = true
+		people[currencyID][to] = true
+		if from > to {
+			from, to, cents = to, from, -cents
+		}
+		owed[currencyID][pair{from, to}] += cents
+	}
+
+	for _, expense := range expenses {
+		for _, share := range expense.Shares {
+			if share.PersonID != expense.PayerID {
+				add(expense.CurrencyID, share.PersonID, expense.PayerID, toCents(share.Amount))
+			}
+		}
+	}
+	for _, paid := range settlements {
+		add(paid.CurrencyID, paid.FromPersonID, paid.ToPersonID, -toCents(paid.Amount))
+	}
+
+	balances := make([]sharedBalance, 0, len(owed))
+	for currencyID, pairs := range owed {
+		balance := sharedBalance{CurrencyID: currencyID, People: make([]sharedPersonBalance, 0), Debts: make([]sharedDebt, 0)}
+		nets := make(map[int64]int64)
+		for debt, cents := range pairs {
+			from, to := debt.from, debt.to
+			if cents < 0 {
+				from, to, cents = to, from, -cents
+			}
+			if cents == 0 {
+				continue
+			}
+			nets[from] -= cents
+			nets[to] += cents
+			balance.Debts = append(balance.Debts, sharedDebt{FromPersonID: from, ToPersonID: to, Amount: fromCents(cents)})
+		}
+		for personID := range people[currencyID] {
+			balance.People = append(balance.People, sharedPersonBalance{PersonID: personID, Net: fromCents(nets[personID])})
+		}
+
+		sort.Slice(balance.People, func(left, right int) bool {
+			return balance.People[left].PersonID < balance.People[right].PersonID
+		})
+		sortDebts(balance.Debts)
+		balances = append(balances, balance)
+	}
+
+	sort.Slice(balances, func(left, right int) bool {
+		return balances[left].CurrencyID < balances[right].CurrencyID
+	})
+
+	return balances
+}
+
+// planSettleUp suggests the payments that clear the balances of one currency.
+// Finding the fewest payments is NP-hard in general, so it settles debtors and
+// creditors owed exactly the same amount first, then pays the largest debtor
+// into the largest creditor until every balance is zero. That never takes more
+// payments than people with a balance, less one.
+func planSettleUp(balance sharedBalance) []sharedDebt {
+	type party struct {
+		personID int64
+		cents    int64
+	}
+	debtors, creditors := make([]party, 0), make([]party, 0)
+	for _, person := range balance.People {
+		cents := toCents(person.Net)
+		switch {
+		case cents < 0:
+			debtors = append(debtors, party{person.PersonID, -cents})
+		case cents > 0:
+			creditors = append(creditors, party{person.PersonID, cents})
+		}
+	}
+
+	payments := make([]sharedDebt, 0)
+	pay := func(debtor *party, creditor *party, cents int64) {
+		payments = append(payments, sharedDebt{FromPersonID: debtor.personID, ToPersonID: creditor.personID, Amount: fromCents(cents)})
+		debtor.cents -= cents
+		creditor.cents -= cents
+	}
+
+	for debtorIndex := range debtors {
+		for creditorIndex := range creditors {
+			if debtors[debtorIndex].cents > 0 && debtors[debtorIndex].cents == creditors[creditorIndex].cents {
+				pay(&debtors[debtorIndex], &creditors[creditorIndex], debtors[debtorIndex].cents)
+				break
+			}
+		}
+	}
+
+	largest := func(parties []party) *party {
+		var found *party
+		for index := range parties {
+			if parties[index].cents > 0 && (found == nil || parties[index].cents > found.cents) {
+				found = &parties[index]
+			}
+		}
+		return found
+	}
+	for {
+		debtor, creditor := largest(debtors), largest(creditors)
+		if debtor == nil || creditor == nil {
+			break
+		}
+		pay(debtor, creditor, min(debtor.cents, creditor.cents))
+	}
+
+	sortDebts(payments)
+	return payments
+}
+
+func sortDebts(debts []sharedDebt) {
+	sort.Slice(debts, func(left, right int) bool {
+		if debts[left].FromPersonID != debts[right].FromPersonID {
+			return debts[left].FromPersonID < debts[right].FromPersonID
+		}
+		return debts[left].ToPersonID < debts[right].ToPersonID
+	})
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
@@ -0,0 +1,70 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAllocateShares(t *testing.T) {
+	percentage := func(value float64) *float64 { return &value }
+	cases := []struct {
+		method   string
+		amount   float64
+		shares   []sharedExpenseShare
+		expected []float64
+	}{
+		{splitMethodEqual, 100, []sharedExpenseShare{{PersonID: 1}, {PersonID: 2}, {PersonID: 3}}, []float64{33.34, 33.33, 33.33}},
+		{splitMethodEqual, 0.02, []sharedExpenseShare{{PersonID: 1}, {PersonID: 2}, {PersonID: 3}}, []float64{0.01, 0.01, 0}},
+		{splitMethodPercentage, 10, []sharedExpenseShare{{PersonID: 1, Percentage: percentage(33.333)}, {PersonID: 2, Percentage: percentage(66.667)}}, []float64{3.33, 6.67}},
+		{splitMethodExact, 10, []sharedExpenseShare{{PersonID: 1, Amount: 7.5}, {PersonID: 2, Amount: 2.5}}, []float64{7.5, 2.5}},
+	}
+
+	for _, item := range cases {
+		allocated := allocateShares(item.method, item.amount, item.shares)
+		amounts := make([]float64, len(allocated))
+		for index, share := range allocated {
+			amounts[index] = share.Amount
+		}
+		if !reflect.DeepEqual(amounts, item.expected) {
+			t.Fatalf("expected %s split of %.2f to be %v, got %v", item.method, item.amount, item.expected, amounts)
+		}
+	}
+}
+
+func TestPlanSettleUp(t *testing.T) {
+	cases := []struct {
+		name     string
+		people   []sharedPersonBalance
+		expected []sharedDebt
+	}{
+		{
+			name:   "equal amounts pair up first",
+			people: []sharedPersonBalance{{PersonID: 1, Net: 10}, {PersonID: 2, Net: 5}, {PersonID: 3, Net: -5}, {PersonID: 4, Net: -10}},
+			expected: []sharedDebt{
+				{FromPersonID: 3, ToPersonID: 2, Amount: 5},
+				{FromPersonID: 4, ToPersonID: 1, Amount: 10},
+			},
+		},
+		{
+			name:   "largest debtor pays largest creditor",
+			people: []sharedPersonBalance{{PersonID: 1, Net: 45.5}, {PersonID: 2, Net: -30}, {PersonID: 3, Net: -10.25}, {PersonID: 4, Net: -5.25}},
+			expected: []sharedDebt{
+				{FromPersonID: 2, ToPersonID: 1, Amount: 30},
+				{FromPersonID: 3, ToPersonID: 1, Amount: 10.25},
+				{FromPersonID: 4, ToPersonID: 1, Amount: 5.25},
+			},
+		},
+		{
+			name:     "settled balances need no payment",
+			people:   []sharedPersonBalance{{PersonID: 1, Net: 0}, {PersonID: 2, Net: 0}},
+			expected: []sharedDebt{},
+		},
+	}
+
+	for _, item := range cases {
+		payments := planSettleUp(sharedBalance{People: item.people})
+		if !reflect.DeepEqual(payments, item.expected) {
+			t.Fatalf("%s: expected %+v, got %+v", item.name, item.expected, payments)
+		}
+	}
+}
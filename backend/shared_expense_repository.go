@@ -0,0 +1,190 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+)
+
+type sharedExpenseRepository interface {
+	// list and get return shared expenses with their shares worked out
+	// against the current amount of the transaction.
+	list(ctx context.Context) ([]sharedExpense, error)
+	get(ctx context.Context, id int64) (sharedExpense, error)
+	// create and update write the shared expense row; replaceShares writes
+	// the people sharing it.
+	create(ctx context.Context, payload sharedExpensePayload) (int64, error)
+	update(ctx context.Context, id int64, payload sharedExpensePayload) error
+	replaceShares(ctx context.Context, id int64, payload sharedExpensePayload) error
+	// delete soft deletes the shared expense.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlSharedExpenseRepository struct {
+	source queryer
+}
+
+const sharedExpenseSelect = `
+	SELECT e.id, e.transaction_id, e.payer_id, e.split_method, t.amount, a.currency_id, t.transaction_date
+	FROM shared_expenses e
+	JOIN transactions t ON t.id = e.transaction_id
+	JOIN bank_accounts a ON a.id = t.bank_account_id
+	WHERE e.deleted_at IS NULL`
+
+func (repository sqlSharedExpenseRepository) list(ctx context.Context) ([]sharedExpense, error) {
+	rows, err := repository.source.QueryContext(ctx, sharedExpenseSelect+` ORDER BY e.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]sharedExpense, 0)
+	for rows.Next() {
+		item, scanErr := scanSharedExpense(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	shares, err := repository.shares(ctx, `
+		SELECT s.shared_expense_id, s.person_id, s.percentage, s.amount
+		FROM shared_expense_shares s
+		JOIN shared_expenses e ON e.id = s.shared_expense_id
+		WHERE e.deleted_at IS NULL
+		ORDER BY s.shared_expense_id, s.position
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for index := range items {
+		items[index].Shares = allocateShares(items[index].SplitMethod, items[index].Amount, shares[items[index].ID])
+	}
+
+	return items, nil
+}
+
+func (repository sqlSharedExpenseRepository) get(ctx context.Context, id int64) (sharedExpense, error) {
+	row := repository.source.QueryRowContext(ctx, sharedExpenseSelect+` AND e.id = ?`, id)
+
+	item, err := scanSharedExpense(row)
+	if err != nil {
+		return sharedExpense{}, rowError(err)
+	}
+
+	shares, err := repository.shares(ctx, `
+		SELECT shared_expense_id, person_id, percentage, amount
+		FROM shared_expense_shares
+		WHERE shared_expense_id = ?
+		ORDER BY position
+	`, id)
+	if err != nil {
+		return sharedExpense{}, err
+	}
+	item.Shares = allocateShares(item.SplitMethod, item.Amount, shares[id])
+
+	return item, nil
+}
+
+// shares runs a query selecting shared_expense_id followed by the share
+// columns and groups the shares by expense, as stored: Amount is only set for
+// exact splits until allocateShares works the others out.
+func (repository sqlSharedExpenseRepository) shares(ctx context.Context, query string, args ...any) (map[int64][]sharedExpenseShare, error) {
+	rows, err := repository.source.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := make(map[int64][]sharedExpenseShare)
+	for rows.Next() {
+		var expenseID int64
+		var share sharedExpenseShare
+		var percentage, amount sql.NullFloat64
+		if err = rows.Scan(&expenseID, &share.PersonID, &percentage, &amount); err != nil {
+			return nil, err
+		}
+		if percentage.Valid {
+			value := percentage.Float64
+			share.Percentage = &value
+		}
+		share.Amount = amount.Float64
+		shares[expenseID] = append(shares[expenseID], share)
+	}
+
+	return shares, rows.Err()
+}
+
+func (repository sqlSharedExpenseRepository) create(ctx context.Context, payload sharedExpensePayload) (int64, error) {
+	return insertRow(
+		ctx,
+		repository.source,
+		`INSERT INTO shared_expenses(transaction_id, payer_id, split_method) VALUES (?, ?, ?)`,
+		payload.TransactionID,
+		payload.PayerID,
+		payload.SplitMethod,
+	)
+}
+
+func (repository sqlSharedExpenseRepository) update(ctx context.Context, id int64, payload sharedExpensePayload) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE shared_expenses SET transaction_id = ?, payer_id = ?, split_method = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`,
+		payload.TransactionID,
+		payload.PayerID,
+		payload.SplitMethod,
+		id,
+	)
+	return constraintError(err)
+}
+
+// replaceShares stores the percentage of percentage splits and the amount of
+// exact splits; equal splits store neither.
+func (repository sqlSharedExpenseRepository) replaceShares(ctx context.Context, id int64, payload sharedExpensePayload) error {
+	if _, err := repository.source.ExecContext(ctx, `DELETE FROM shared_expense_shares WHERE shared_expense_id = ?`, id); err != nil {
+		return err
+	}
+
+	for position, share := range payload.Shares {
+		var percentage, amount *float64
+		switch payload.SplitMethod {
+		case splitMethodPercentage:
+			percentage = share.Percentage
+		case splitMethodExact:
+			amount = share.Amount
+		}
+
+		_, err := repository.source.ExecContext(
+			ctx,
+			`INSERT INTO shared_expense_shares(shared_expense_id, position, person_id, percentage, amount) VALUES (?, ?, ?, ?, ?)`,
+			id,
+			position+1,
+			share.PersonID,
+			percentage,
+			amount,
+		)
+		if err != nil {
+			return constraintError(err)
+		}
+	}
+
+	return nil
+}
+
+func (repository sqlSharedExpenseRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE shared_expenses SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func scanSharedExpense(source scanner) (sharedExpense, error) {
+	item := sharedExpense{Shares: make([]sharedExpenseShare, 0)}
+	err := source.Scan(&item.ID, &item.TransactionID, &item.PayerID, &item.SplitMethod, &item.Amount, &item.CurrencyID, &item.TransactionDate)
+	if err != nil {
+		return sharedExpense{}, err
+	}
+
+	return item, nil
+}
@@ -0,0 +1,267 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+type sharedExpenseService struct {
+	store dataStore
+}
+
+func (payload sharedExpensePayload) normalize() (sharedExpensePayload, error) {
+	if payload.TransactionID <= 0 {
+		return sharedExpensePayload{}, invalidPayload("transaction_id must be a positive integer")
+	}
+	if payload.PayerID < 0 {
+		return sharedExpensePayload{}, invalidPayload("payer_id must be a positive integer")
+	}
+	if payload.SplitMethod != splitMethodEqual && payload.SplitMethod != splitMethodPercentage && payload.SplitMethod != splitMethodExact {
+		return sharedExpensePayload{}, invalidPayload("split_method must be equal, percentage or exact")
+	}
+	if len(payload.Shares) == 0 {
+		return sharedExpensePayload{}, invalidPayload("shares must list at least one person")
+	}
+
+	seen := make(map[int64]bool, len(payload.Shares))
+	percentages := 0.0
+	for index, share := range payload.Shares {
+		if share.PersonID <= 0 {
+			return sharedExpensePayload{}, invalidPayload(fmt.Sprintf("shares[%d].person_id must be a positive integer", index))
+		}
+		if seen[share.PersonID] {
+			return sharedExpensePayload{}, invalidPayload(fmt.Sprintf("shares[%d]: person %d is listed twice", index, share.PersonID))
+		}
+		seen[share.PersonID] = true
+
+		switch payload.SplitMethod {
+		case splitMethodPercentage:
+			if share.Percentage == nil || math.IsNaN(*share.Percentage) || *share.Percentage <= 0 || *share.Percentage > 100 {
+				return sharedExpensePayload{}, invalidPayload(fmt.Sprintf("shares[%d].percentage must be greater than 0 and at most 100", index))
+			}
+			percentages += *share.Percentage
+		case splitMethodExact:
+			if share.Amount == nil || math.IsNaN(*share.Amount) || math.IsInf(*share.Amount, 0) || *share.Amount <= 0 {
+				return sharedExpensePayload{}, invalidPayload(fmt.Sprintf("shares[%d].amount must be greater than zero", index))
+			}
+		}
+	}
+	if payload.SplitMethod == splitMethodPercentage && math.Abs(percentages-100) > 0.001 {
+		return sharedExpensePayload{}, invalidPayload(fmt.Sprintf("share percentages must add up to 100, got %g", percentages))
+	}
+
+	return payload, nil
+}
+
+func (service sharedExpenseService) list(ctx context.Context) ([]sharedExpense, error) {
+	return service.store.reads().sharedExpenses().list(ctx)
+}
+
+func (service sharedExpenseService) get(ctx context.Context, id int64) (sharedExpense, error) {
+	item, err := service.store.reads().sharedExpenses().get(ctx, id)
+	if err != nil {
+		return sharedExpense{}, orNotFound(err, "shared expense not found")
+	}
+
+	return item, nil
+}
+
+func (service sharedExpenseService) create(ctx context.Context, by change, payload sharedExpensePayload) (sharedExpense, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return sharedExpense{}, err
+	}
+
+	var created sharedExpense
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if payload, err = checkSharedExpense(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		id, err := tx.sharedExpenses().create(ctx, payload)
+		if err != nil {
+			return sharedExpenseWriteError(err)
+		}
+		if err = tx.sharedExpenses().replaceShares(ctx, id, payload); err != nil {
+			return sharedExpenseWriteError(err)
+		}
+
+		if created, err = tx.sharedExpenses().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntitySharedExpenses, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return sharedExpense{}, err
+	}
+
+	return created, nil
+}
+
+func (service sharedExpenseService) update(ctx context.Context, by change, id int64, payload sharedExpensePayload) (sharedExpense, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return sharedExpense{}, err
+	}
+
+	var updated sharedExpense
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.sharedExpenses().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "shared expense not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if payload, err = checkSharedExpense(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		if err = tx.sharedExpenses().update(ctx, id, payload); err != nil {
+			return sharedExpenseWriteError(err)
+		}
+		if err = tx.sharedExpenses().replaceShares(ctx, id, payload); err != nil {
+			return sharedExpenseWriteError(err)
+		}
+
+		if updated, err = tx.sharedExpenses().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntitySharedExpenses, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return sharedExpense{}, err
+	}
+
+	return updated, nil
+}
+
+func (service sharedExpenseService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.sharedExpenses().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "shared expense not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = tx.sharedExpenses().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntitySharedExpenses, id, auditActionDelete, existing, nil))
+	})
+}
+
+// balances returns who owes whom per currency, or in one currency when
+// currencyID is not 0.
+func (service sharedExpenseService) balances(ctx context.Context, currencyID int64) ([]sharedBalance, error) {
+	reads := service.store.reads()
+
+	expenses, err := reads.sharedExpenses().list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	settlements, err := reads.settlements().list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	currencies, err := reads.currencies().list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	codes := make(map[int64]string, len(currencies))
+	for _, item := range currencies {
+		codes[item.ID] = item.Code
+	}
+
+	balances := make([]sharedBalance, 0)
+	for _, balance := range computeSharedBalances(expenses, settlements) {
+		if currencyID != 0 && balance.CurrencyID != currencyID {
+			continue
+		}
+		balance.Currency = codes[balance.CurrencyID]
+		balances = append(balances, balance)
+	}
+
+	return balances, nil
+}
+
+// settleUp suggests, per currency, the payments that clear every balance.
+func (service sharedExpenseService) settleUp(ctx context.Context, currencyID int64) ([]settleUpPlan, error) {
+	balances, err := service.balances(ctx, currencyID)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make([]settleUpPlan, 0, len(balances))
+	for _, balance := range balances {
+		plans = append(plans, settleUpPlan{CurrencyID: balance.CurrencyID, Currency: balance.Currency, Payments: planSettleUp(balance)})
+	}
+
+	return plans, nil
+}
+
+// checkSharedExpense checks the transaction and the people sharing it, and
+// defaults the payer to the person of the transaction.
+func checkSharedExpense(ctx context.Context, tx repositories, payload sharedExpensePayload) (sharedExpensePayload, error) {
+	shared, err := tx.transactions().get(ctx, payload.TransactionID)
+	if errors.Is(err, errNotFound) {
+		return sharedExpensePayload{}, invalidPayload("transaction must exist")
+	}
+	if err != nil {
+		return sharedExpensePayload{}, err
+	}
+	if shared.Type != "expense" {
+		return sharedExpensePayload{}, invalidPayload("only expense transactions can be shared")
+	}
+	if payload.PayerID == 0 {
+		payload.PayerID = shared.PersonID
+	}
+
+	others := false
+	people := []int64{payload.PayerID}
+	total := 0.0
+	for _, share := range payload.Shares {
+		people = append(people, share.PersonID)
+		others = others || share.PersonID != payload.PayerID
+		if share.Amount != nil {
+			total += *share.Amount
+		}
+	}
+	if !others {
+		return sharedExpensePayload{}, invalidPayload("shares must include someone other than the payer")
+	}
+	if payload.SplitMethod == splitMethodExact && math.Abs(total-shared.Amount) >= splitAmountTolerance {
+		return sharedExpensePayload{}, invalidPayload(fmt.Sprintf("share amounts must add up to the transaction amount %.2f, got %.2f", shared.Amount, total))
+	}
+
+	for _, personID := range people {
+		exists, err := tx.trash().exists(ctx, auditEntityPeople, personID)
+		if err != nil {
+			return sharedExpensePayload{}, err
+		}
+		if !exists {
+			return sharedExpensePayload{}, invalidPayload(fmt.Sprintf("person %d must exist", personID))
+		}
+	}
+
+	return payload, nil
+}
+
+func sharedExpenseWriteError(err error) error {
+	if errors.Is(err, errDuplicate) {
+		return conflict("transaction_already_shared", "the transaction already has a shared expense")
+	}
+	if errors.Is(err, errMissingReference) {
+		return invalidPayload("transaction and people must exist")
+	}
+
+	return err
+}
@@ -2,24 +2,90 @@ package backend
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"personal-finances/migrations"
+	"personal-finances/web"
 )
 
+// testDatabaseEnv names a PostgreSQL server, such as
+// postgres://localhost/finances_test, to run the tests built on
+// newTestApplication against instead of a SQLite file.
+const testDatabaseEnv = "TEST_DATABASE_URL"
+
 func newTestApplication(t *testing.T) app {
 	t.Helper()
 	dbPath := filepath.Join(t.TempDir(), "test.db")
-	db, err := SetupDatabase(dbPath)
+	dataDir := filepath.Dir(dbPath)
+	if dsn := os.Getenv(testDatabaseEnv); dsn != "" {
+		dbPath = newTestSchema(t, dsn)
+		dataDir = ""
+	}
+	pool, err := SetupDatabasePool(dbPath, migrations.Files)
 	if err != nil {
 		t.Fatalf("setup database: %v", err)
 	}
 	t.Cleanup(func() {
-		db.Close()
+		pool.Close()
+	})
+
+	return app{
+		db:               pool.Writer,
+		reader:           pool.Reader,
+		web:              web.Files,
+		backups:          BackupConfig{Dir: filepath.Join(t.TempDir(), "backups")},
+		logger:           slog.New(slog.DiscardHandler),
+		metrics:          newMetrics(),
+		migrations:       migrations.Files,
+		dataDir:          dataDir,
+		minFreeDiskBytes: 1,
+	}
+}
+
+// newTestSchema creates an empty PostgreSQL schema for one test, dropped when
+// it ends, and returns a DSN whose connections work in it.
+func newTestSchema(t *testing.T, dsn string) string {
+	t.Helper()
+	admin, err := OpenDatabase(dsn)
+	if err != nil {
+		t.Fatalf("open %s: %v", testDatabaseEnv, err)
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err = admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		admin.Close()
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
 	})
 
-	return app{db: db}
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("parse %s: %v", testDatabaseEnv, err)
+	}
+	query := parsed.Query()
+	query.Set("search_path", schema)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+// requireSQLite skips tests of SQLite files, pragmas and backups when the
+// suite runs against PostgreSQL.
+func requireSQLite(t *testing.T) {
+	t.Helper()
+	if os.Getenv(testDatabaseEnv) != "" {
+		t.Skip("covers SQLite only")
+	}
 }
 
 func performRequest(handler http.Handler, method string, path string, body []byte) *httptest.ResponseRecorder {
@@ -31,3 +97,44 @@ func performRequest(handler http.Handler, method string, path string, body []byt
 	handler.ServeHTTP(responseRecorder, request)
 	return responseRecorder
 }
+
+func performRequestWithHeaders(handler http.Handler, method string, path string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, bytes.NewReader(body))
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+	return responseRecorder
+}
+
+// expectResponse performs a request with body, if not empty, and fails the
+// test unless it answers code. PATCH bodies are sent as merge patches. It
+// returns the response body.
+func expectResponse(t *testing.T, handler http.Handler, method string, path string, body string, code int) []byte {
+	t.Helper()
+	var payload []byte
+	if body != "" {
+		payload = []byte(body)
+	}
+	headers := map[string]string{}
+	if method == http.MethodPatch {
+		headers["Content-Type"] = "application/merge-patch+json"
+	}
+	response := performRequestWithHeaders(handler, method, path, payload, headers)
+	if response.Code != code {
+		t.Fatalf("expected %s %s to return %d, got %d: %s", method, path, code, response.Code, response.Body.String())
+	}
+	return response.Body.Bytes()
+}
+
+// decodeResponse unmarshals a response body into target.
+func decodeResponse(t *testing.T, body []byte, target any) {
+	t.Helper()
+	if err := json.Unmarshal(body, target); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+}
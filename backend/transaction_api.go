@@ -1,20 +1,26 @@
 package backend
 
 import (
-	"database/sql"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"math"
 	"net/http"
+	"strconv"
 	"strings"
-	"time"
 )
 
 const (
 	transactionsPath       = "/api/transactions"
 	transactionsPathByID   = "/api/transactions/"
 	transactionPathPattern = "/api/transactions/%d"
+	// transactionDuplicatesPath lists likely duplicates; POST
+	// /api/transactions/{id}/merge folds one of them into another.
+	transactionDuplicatesPath = "/api/transactions/duplicates"
+	transactionMergeSuffix    = "/merge"
+	// transactionSplitsTable holds the split lines of transactions.
+	transactionSplitsTable = "transaction_splits"
+	// splitAmountTolerance is how far the split lines may add up from the
+	// transaction amount, to allow for float rounding of cents.
+	splitAmountTolerance = 0.005
 )
 
 type transaction struct {
@@ -26,6 +32,22 @@ type transaction struct {
 	PersonID        int64   `json:"person_id"`
 	BankAccountID   int64   `json:"bank_account_id"`
 	CategoryID      int64   `json:"category_id"`
+	SavingsGoalID   *int64  `json:"savings_goal_id"`
+	// ReconciliationStatus and ReconciliationID are set by reconciliation
+	// sessions, never by the transaction payload.
+	ReconciliationStatus string `json:"reconciliation_status"`
+	ReconciliationID     *int64 `json:"reconciliation_id"`
+	// Splits divide the amount between categories and people. It is empty
+	// for a transaction booked on its own category and person.
+	Splits []transactionSplit `json:"splits"`
+}
+
+type transactionSplit struct {
+	ID         int64   `json:"id"`
+	CategoryID int64   `json:"category_id"`
+	PersonID   int64   `json:"person_id"`
+	Amount     float64 `json:"amount"`
+	Notes      *string `json:"notes"`
 }
 
 type transactionPayload struct {
@@ -36,17 +58,59 @@ type transactionPayload struct {
 	PersonID        int64   `json:"person_id"`
 	BankAccountID   int64   `json:"bank_account_id"`
 	CategoryID      int64   `json:"category_id"`
+	// SavingsGoalID tags the transaction as a contribution to, or a
+	// withdrawal from, a savings goal in the currency of its bank account.
+	// An update that leaves it out keeps the current goal.
+	SavingsGoalID *int64 `json:"savings_goal_id"`
+	// Splits replace the split lines of the transaction; none leaves it
+	// unsplit. An update that leaves splits out keeps the current lines.
+	Splits []transactionSplitPayload `json:"splits"`
+
+	// splitsSent and savingsGoalSent report whether the body had the splits
+	// and savings_goal_id fields.
+	splitsSent      bool
+	savingsGoalSent bool
+}
+
+// UnmarshalJSON records which fields the body sent, so an update can tell a
+// field left out from one sent empty.
+func (payload *transactionPayload) UnmarshalJSON(data []byte) error {
+	type fields transactionPayload
+	var sent map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sent); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, (*fields)(payload)); err != nil {
+		return err
+	}
+
+	_, payload.splitsSent = sent["splits"]
+	_, payload.savingsGoalSent = sent["savings_goal_id"]
+	return nil
+}
+
+type transactionSplitPayload struct {
+	CategoryID int64   `json:"category_id"`
+	PersonID   int64   `json:"person_id"`
+	Amount     float64 `json:"amount"`
+	Notes      *string `json:"notes"`
+}
+
+type transactionMergePayload struct {
+	DuplicateID int64 `json:"duplicate_id"`
 }
 
 func (application app) registerTransactionRoutes(mux *http.ServeMux) {
 	mux.HandleFunc(transactionsPath, application.transactionsHandler)
 	mux.HandleFunc(transactionsPathByID, application.transactionByIDHandler)
+	mux.HandleFunc(transactionDuplicatesPath, application.transactionDuplicatesHandler)
+	mux.HandleFunc(transactionsPath+batchPathSuffix, application.batchHandler(transactionsPath, app.transactionsHandler, app.transactionByIDHandler))
 }
 
 func (application app) transactionsHandler(writer http.ResponseWriter, request *http.Request) {
 	switch request.Method {
 	case http.MethodGet:
-		application.listTransactions(writer)
+		application.listTransactions(writer, request)
 	case http.MethodPost:
 		application.createTransaction(writer, request)
 	default:
@@ -55,6 +119,11 @@ func (application app) transactionsHandler(writer http.ResponseWriter, request *
 }
 
 func (application app) transactionByIDHandler(writer http.ResponseWriter, request *http.Request) {
+	if path, ok := strings.CutSuffix(request.URL.Path, transactionMergeSuffix); ok {
+		application.transactionMergeHandler(writer, request, path)
+		return
+	}
+
 	id, err := parseIDFromPath(request.URL.Path, transactionsPathByID)
 	if err != nil {
 		writeError(writer, http.StatusBadRequest, "invalid_id", "transaction id must be a positive integer")
@@ -63,309 +132,175 @@ func (application app) transactionByIDHandler(writer http.ResponseWriter, reques
 
 	switch request.Method {
 	case http.MethodGet:
-		application.getTransaction(writer, id)
+		application.getTransaction(writer, request, id)
 	case http.MethodPut:
 		application.updateTransaction(writer, request, id)
+	case http.MethodPatch:
+		application.patchTransaction(writer, request, id)
 	case http.MethodDelete:
-		application.deleteTransaction(writer, id)
+		application.deleteTransaction(writer, request, id)
 	default:
-		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodDelete)
+		methodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
 	}
 }
 
-func (application app) listTransactions(writer http.ResponseWriter) {
-	rows, err := application.db.Query(`
-		SELECT id, transaction_date, type, amount, notes, person_id, bank_account_id, category_id
-		FROM transactions
-		ORDER BY id
-	`)
+func (application app) listTransactions(writer http.ResponseWriter, request *http.Request) {
+	items, err := application.services().transactions.list(request.Context())
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load transactions")
+		writeServiceError(writer, err, "failed to load transactions")
 		return
 	}
-	defer rows.Close()
-
-	items := make([]transaction, 0)
-	for rows.Next() {
-		item, scanErr := scanTransaction(rows)
-		if scanErr != nil {
-			writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read transactions")
-			return
-		}
-		items = append(items, item)
-	}
 
 	writeJSON(writer, http.StatusOK, items)
 }
 
-func (application app) getTransaction(writer http.ResponseWriter, id int64) {
-	item, err := application.fetchTransaction(id)
-	if errors.Is(err, sql.ErrNoRows) {
-		writeError(writer, http.StatusNotFound, "not_found", "transaction not found")
-		return
-	}
+func (application app) getTransaction(writer http.ResponseWriter, request *http.Request, id int64) {
+	item, err := application.services().transactions.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load transaction")
+		writeServiceError(writer, err, "failed to load transaction")
 		return
 	}
 
-	writeJSON(writer, http.StatusOK, item)
+	writeJSONWithETag(writer, http.StatusOK, item)
 }
 
 func (application app) createTransaction(writer http.ResponseWriter, request *http.Request) {
-	payload, validationErr := decodeTransactionPayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
-		return
-	}
-
-	if validationErr = application.validateTransactionPayload(payload); validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
-		return
-	}
-
-	result, err := application.db.Exec(
-		`INSERT INTO transactions(transaction_date, type, amount, notes, person_id, bank_account_id, category_id)
-		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		payload.TransactionDate,
-		payload.Type,
-		payload.Amount,
-		payload.Notes,
-		payload.PersonID,
-		payload.BankAccountID,
-		payload.CategoryID,
-	)
-	if err != nil {
-		if isForeignKeyConstraintError(err) {
-			writeError(writer, http.StatusBadRequest, "invalid_payload", "person, bank account and transaction category must exist")
-			return
-		}
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to create transaction")
-		return
-	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read created transaction id")
+	var payload transactionPayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	created, err := application.fetchTransaction(id)
+	created, err := application.services().transactions.create(request.Context(), requestChange(request), payload)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load created transaction")
+		writeServiceError(writer, err, "failed to create transaction")
 		return
 	}
 
-	writer.Header().Set("Location", fmt.Sprintf(transactionPathPattern, id))
-	writeJSON(writer, http.StatusCreated, created)
+	writer.Header().Set("Location", fmt.Sprintf(transactionPathPattern, created.ID))
+	writeJSONWithETag(writer, http.StatusCreated, created)
 }
 
 func (application app) updateTransaction(writer http.ResponseWriter, request *http.Request, id int64) {
-	payload, validationErr := decodeTransactionPayload(request)
-	if validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
+	var payload transactionPayload
+	if !decodeJSON(writer, request, &payload) {
 		return
 	}
 
-	if validationErr = application.validateTransactionPayload(payload); validationErr != nil {
-		writeError(writer, http.StatusBadRequest, "invalid_payload", validationErr.Error())
-		return
-	}
+	application.saveTransaction(writer, request, id, payload)
+}
 
-	result, err := application.db.Exec(
-		`UPDATE transactions
-		 SET transaction_date = ?, type = ?, amount = ?, notes = ?, person_id = ?, bank_account_id = ?, category_id = ?
-		 WHERE id = ?`,
-		payload.TransactionDate,
-		payload.Type,
-		payload.Amount,
-		payload.Notes,
-		payload.PersonID,
-		payload.BankAccountID,
-		payload.CategoryID,
-		id,
-	)
+func (application app) saveTransaction(writer http.ResponseWriter, request *http.Request, id int64, payload transactionPayload) {
+	updated, err := application.services().transactions.update(request.Context(), requestChange(request), id, payload)
 	if err != nil {
-		if isForeignKeyConstraintError(err) {
-			writeError(writer, http.StatusBadRequest, "invalid_payload", "person, bank account and transaction category must exist")
-			return
-		}
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to update transaction")
+		writeServiceError(writer, err, "failed to update transaction")
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	writeJSONWithETag(writer, http.StatusOK, updated)
+}
+
+func (application app) patchTransaction(writer http.ResponseWriter, request *http.Request, id int64) {
+	current, err := application.services().transactions.get(request.Context(), id)
 	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read update result")
+		writeServiceError(writer, err, "failed to load transaction")
 		return
 	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "transaction not found")
+
+	mergedRequest, ok := mergePatchRequest(writer, request, current)
+	if !ok {
 		return
 	}
 
-	updated, err := application.fetchTransaction(id)
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to load updated transaction")
+	var payload transactionPayload
+	if !decodeJSON(writer, mergedRequest, &payload) {
 		return
 	}
+	// The merged body starts from the whole transaction, so a field missing
+	// from it was cleared by the patch with null.
+	payload.splitsSent, payload.savingsGoalSent = true, true
 
-	writeJSON(writer, http.StatusOK, updated)
+	application.saveTransaction(writer, mergedRequest, id, payload)
 }
 
-func (application app) deleteTransaction(writer http.ResponseWriter, id int64) {
-	result, err := application.db.Exec(`DELETE FROM transactions WHERE id = ?`, id)
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to delete transaction")
-		return
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		writeError(writer, http.StatusInternalServerError, "internal_error", "failed to read delete result")
-		return
-	}
-	if rowsAffected == 0 {
-		writeError(writer, http.StatusNotFound, "not_found", "transaction not found")
+func (application app) deleteTransaction(writer http.ResponseWriter, request *http.Request, id int64) {
+	if err := application.services().transactions.delete(request.Context(), requestChange(request), id); err != nil {
+		writeServiceError(writer, err, "failed to delete transaction")
 		return
 	}
 
 	writer.WriteHeader(http.StatusNoContent)
 }
 
-func decodeTransactionPayload(request *http.Request) (transactionPayload, error) {
-	defer request.Body.Close()
-
-	var payload transactionPayload
-	if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
-		return transactionPayload{}, fmt.Errorf("request body must be valid JSON")
-	}
-
-	payload.TransactionDate = strings.TrimSpace(payload.TransactionDate)
-	if payload.TransactionDate == "" {
-		return transactionPayload{}, fmt.Errorf("transaction_date is required")
-	}
-	if _, err := time.Parse("2006-01-02", payload.TransactionDate); err != nil {
-		return transactionPayload{}, fmt.Errorf("transaction_date must be a valid date in YYYY-MM-DD format")
-	}
-
-	payload.Type = strings.ToLower(strings.TrimSpace(payload.Type))
-	if payload.Type != "income" && payload.Type != "expense" {
-		return transactionPayload{}, fmt.Errorf("type must be either income or expense")
-	}
-
-	if math.IsNaN(payload.Amount) || math.IsInf(payload.Amount, 0) || payload.Amount <= 0 {
-		return transactionPayload{}, fmt.Errorf("amount must be greater than zero")
-	}
-
-	if payload.PersonID <= 0 {
-		return transactionPayload{}, fmt.Errorf("person_id must be a positive integer")
-	}
-	if payload.BankAccountID <= 0 {
-		return transactionPayload{}, fmt.Errorf("bank_account_id must be a positive integer")
-	}
-	if payload.CategoryID <= 0 {
-		return transactionPayload{}, fmt.Errorf("category_id must be a positive integer")
-	}
-
-	if payload.Notes != nil {
-		trimmedNotes := strings.TrimSpace(*payload.Notes)
-		if trimmedNotes == "" {
-			payload.Notes = nil
-		} else {
-			payload.Notes = &trimmedNotes
-		}
+func (application app) transactionDuplicatesHandler(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		application.listTransactionDuplicates(writer, request)
+	default:
+		methodNotAllowed(writer, http.MethodGet)
 	}
-
-	return payload, nil
 }
 
-func (application app) validateTransactionPayload(payload transactionPayload) error {
-	personExists, err := application.personExists(payload.PersonID)
-	if err != nil {
-		return fmt.Errorf("failed to validate person")
-	}
-	if !personExists {
-		return fmt.Errorf("person must exist")
-	}
-
-	bankAccountExists, err := application.bankAccountExists(payload.BankAccountID)
+func (application app) transactionMergeHandler(writer http.ResponseWriter, request *http.Request, path string) {
+	id, err := parseIDFromPath(path, transactionsPathByID)
 	if err != nil {
-		return fmt.Errorf("failed to validate bank account")
-	}
-	if !bankAccountExists {
-		return fmt.Errorf("bank account must exist")
+		writeError(writer, http.StatusBadRequest, "invalid_id", "transaction id must be a positive integer")
+		return
 	}
 
-	categoryExists, err := application.transactionCategoryExists(payload.CategoryID)
-	if err != nil {
-		return fmt.Errorf("failed to validate transaction category")
-	}
-	if !categoryExists {
-		return fmt.Errorf("transaction category must exist")
+	switch request.Method {
+	case http.MethodPost:
+		application.mergeTransaction(writer, request, id)
+	default:
+		methodNotAllowed(writer, http.MethodPost)
 	}
-
-	return nil
 }
 
-func (application app) personExists(id int64) (bool, error) {
-	var storedID int64
-	err := application.db.QueryRow(`SELECT id FROM people WHERE id = ?`, id).Scan(&storedID)
-	if errors.Is(err, sql.ErrNoRows) {
-		return false, nil
-	}
-	if err != nil {
-		return false, err
+// listTransactionDuplicates returns candidate duplicate pairs. window_days
+// (default 3) bounds how far apart their dates may be and min_score (default
+// 0.7) drops weaker pairs.
+func (application app) listTransactionDuplicates(writer http.ResponseWriter, request *http.Request) {
+	values := request.URL.Query()
+
+	windowDays := defaultDuplicateWindowDays
+	if raw := strings.TrimSpace(values.Get("window_days")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 || parsed > maxDuplicateWindowDays {
+			writeError(writer, http.StatusBadRequest, "invalid_query", fmt.Sprintf("window_days must be an integer between 0 and %d", maxDuplicateWindowDays))
+			return
+		}
+		windowDays = parsed
 	}
 
-	return true, nil
-}
-
-func (application app) bankAccountExists(id int64) (bool, error) {
-	var storedID int64
-	err := application.db.QueryRow(`SELECT id FROM bank_accounts WHERE id = ?`, id).Scan(&storedID)
-	if errors.Is(err, sql.ErrNoRows) {
-		return false, nil
+	minScore := defaultDuplicateMinScore
+	if raw := strings.TrimSpace(values.Get("min_score")); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || !(parsed >= 0 && parsed <= 1) {
+			writeError(writer, http.StatusBadRequest, "invalid_query", "min_score must be a number between 0 and 1")
+			return
+		}
+		minScore = parsed
 	}
+
+	candidates, err := application.services().transactions.duplicates(request.Context(), windowDays, minScore)
 	if err != nil {
-		return false, err
+		writeServiceError(writer, err, "failed to find duplicate transactions")
+		return
 	}
 
-	return true, nil
+	writeJSON(writer, http.StatusOK, candidates)
 }
 
-func (application app) fetchTransaction(id int64) (transaction, error) {
-	row := application.db.QueryRow(`
-		SELECT id, transaction_date, type, amount, notes, person_id, bank_account_id, category_id
-		FROM transactions
-		WHERE id = ?
-	`, id)
-
-	return scanTransaction(row)
-}
-
-func scanTransaction(source scanner) (transaction, error) {
-	var item transaction
-	var notes sql.NullString
-
-	err := source.Scan(
-		&item.ID,
-		&item.TransactionDate,
-		&item.Type,
-		&item.Amount,
-		&notes,
-		&item.PersonID,
-		&item.BankAccountID,
-		&item.CategoryID,
-	)
-	if err != nil {
-		return transaction{}, err
+func (application app) mergeTransaction(writer http.ResponseWriter, request *http.Request, id int64) {
+	var payload transactionMergePayload
+	if !decodeJSON(writer, request, &payload) {
+		return
 	}
 
-	if notes.Valid {
-		value := notes.String
-		item.Notes = &value
+	merged, err := application.services().transactions.merge(request.Context(), requestChange(request), id, payload.DuplicateID)
+	if err != nil {
+		writeServiceError(writer, err, "failed to merge transactions")
+		return
 	}
 
-	return item, nil
+	writeJSONWithETag(writer, http.StatusOK, merged)
 }
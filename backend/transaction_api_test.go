@@ -3,6 +3,7 @@ package backend
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -125,6 +126,281 @@ func TestTransactionValidationErrors(t *testing.T) {
 	}
 }
 
+func TestTransactionOptimisticConcurrency(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+
+	createResponse := performRequest(
+		router,
+		http.MethodPost,
+		"/api/transactions",
+		[]byte(`{"transaction_date":"2026-02-18","type":"income","amount":10,"person_id":1,"bank_account_id":1,"category_id":1}`),
+	)
+	if createResponse.Code != http.StatusCreated {
+		t.Fatalf("expected create to return 201, got %d", createResponse.Code)
+	}
+	if createResponse.Header().Get("ETag") == "" {
+		t.Fatalf("expected create to return an ETag")
+	}
+
+	getResponse := performRequest(router, http.MethodGet, "/api/transactions/1", nil)
+	originalTag := getResponse.Header().Get("ETag")
+	if originalTag == "" || originalTag != createResponse.Header().Get("ETag") {
+		t.Fatalf("expected get to return the created ETag, got %q", originalTag)
+	}
+
+	if _, err := application.db.Exec(`UPDATE transactions SET updated_at = '2000-01-01 00:00:00' WHERE id = 1`); err != nil {
+		t.Fatalf("reset updated_at: %v", err)
+	}
+
+	firstTab := performRequestWithHeaders(
+		router,
+		http.MethodPut,
+		"/api/transactions/1",
+		[]byte(`{"transaction_date":"2026-02-18","type":"income","amount":20,"person_id":1,"bank_account_id":1,"category_id":1}`),
+		map[string]string{"If-Match": originalTag},
+	)
+	if firstTab.Code != http.StatusOK {
+		t.Fatalf("expected update with current ETag to return 200, got %d", firstTab.Code)
+	}
+	updatedTag := firstTab.Header().Get("ETag")
+	if updatedTag == "" || updatedTag == originalTag {
+		t.Fatalf("expected update to return a new ETag, got %q", updatedTag)
+	}
+
+	var refreshed int
+	if err := application.db.QueryRow(`SELECT COUNT(1) FROM transactions WHERE id = 1 AND updated_at > '2000-01-01 00:00:00'`).Scan(&refreshed); err != nil {
+		t.Fatalf("load updated_at: %v", err)
+	}
+	if refreshed != 1 {
+		t.Fatalf("expected update to refresh updated_at")
+	}
+
+	secondTab := performRequestWithHeaders(
+		router,
+		http.MethodPut,
+		"/api/transactions/1",
+		[]byte(`{"transaction_date":"2026-02-18","type":"income","amount":30,"person_id":1,"bank_account_id":1,"category_id":1}`),
+		map[string]string{"If-Match": originalTag},
+	)
+	if secondTab.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected update with stale ETag to return 412, got %d", secondTab.Code)
+	}
+	if secondTab.Header().Get("ETag") != updatedTag {
+		t.Fatalf("expected 412 response to carry the current ETag")
+	}
+
+	var current transaction
+	currentResponse := performRequest(router, http.MethodGet, "/api/transactions/1", nil)
+	if err := json.NewDecoder(currentResponse.Body).Decode(&current); err != nil {
+		t.Fatalf("decode transaction: %v", err)
+	}
+	if current.Amount != 20 {
+		t.Fatalf("expected stale update to be rejected, got amount %v", current.Amount)
+	}
+
+	staleDelete := performRequestWithHeaders(router, http.MethodDelete, "/api/transactions/1", nil, map[string]string{"If-Match": originalTag})
+	if staleDelete.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected delete with stale ETag to return 412, got %d", staleDelete.Code)
+	}
+
+	weakDelete := performRequestWithHeaders(router, http.MethodDelete, "/api/transactions/1", nil, map[string]string{"If-Match": "W/" + updatedTag})
+	if weakDelete.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected delete with weak ETag to return 412, got %d", weakDelete.Code)
+	}
+
+	deleteResponse := performRequestWithHeaders(router, http.MethodDelete, "/api/transactions/1", nil, map[string]string{"If-Match": `"other", ` + updatedTag})
+	if deleteResponse.Code != http.StatusNoContent {
+		t.Fatalf("expected delete with matching ETag to return 204, got %d", deleteResponse.Code)
+	}
+}
+
+func TestTransactionMergePatch(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+
+	createResponse := performRequest(
+		router,
+		http.MethodPost,
+		"/api/transactions",
+		[]byte(`{"transaction_date":"2026-02-18","type":"income","amount":10,"notes":"Salary","person_id":1,"bank_account_id":1,"category_id":1}`),
+	)
+	if createResponse.Code != http.StatusCreated {
+		t.Fatalf("expected create to return 201, got %d", createResponse.Code)
+	}
+
+	mergePatchHeaders := map[string]string{"Content-Type": "application/merge-patch+json"}
+
+	patchResponse := performRequestWithHeaders(router, http.MethodPatch, "/api/transactions/1", []byte(`{"notes":"February salary"}`), mergePatchHeaders)
+	if patchResponse.Code != http.StatusOK {
+		t.Fatalf("expected patch to return 200, got %d", patchResponse.Code)
+	}
+	if patchResponse.Header().Get("ETag") == "" {
+		t.Fatalf("expected patch to return an ETag")
+	}
+
+	var patched transaction
+	if err := json.NewDecoder(patchResponse.Body).Decode(&patched); err != nil {
+		t.Fatalf("decode transaction: %v", err)
+	}
+	if patched.Notes == nil || *patched.Notes != "February salary" || patched.Amount != 10 || patched.TransactionDate != "2026-02-18" {
+		t.Fatalf("expected only notes to change, got %+v", patched)
+	}
+
+	removeNotes := performRequestWithHeaders(router, http.MethodPatch, "/api/transactions/1", []byte(`{"notes":null}`), mergePatchHeaders)
+	if removeNotes.Code != http.StatusOK {
+		t.Fatalf("expected null patch to return 200, got %d", removeNotes.Code)
+	}
+	if err := json.NewDecoder(removeNotes.Body).Decode(&patched); err != nil {
+		t.Fatalf("decode transaction: %v", err)
+	}
+	if patched.Notes != nil {
+		t.Fatalf("expected null to remove notes, got %q", *patched.Notes)
+	}
+
+	invalidMerged := performRequestWithHeaders(router, http.MethodPatch, "/api/transactions/1", []byte(`{"amount":-5}`), mergePatchHeaders)
+	if invalidMerged.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid merged result to return 400, got %d", invalidMerged.Code)
+	}
+
+	removeRequired := performRequestWithHeaders(router, http.MethodPatch, "/api/transactions/1", []byte(`{"transaction_date":null}`), mergePatchHeaders)
+	if removeRequired.Code != http.StatusBadRequest {
+		t.Fatalf("expected removing a required field to return 400, got %d", removeRequired.Code)
+	}
+
+	unknownPerson := performRequestWithHeaders(router, http.MethodPatch, "/api/transactions/1", []byte(`{"person_id":99}`), mergePatchHeaders)
+	if unknownPerson.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown person to return 400, got %d", unknownPerson.Code)
+	}
+
+	invalidJSON := performRequestWithHeaders(router, http.MethodPatch, "/api/transactions/1", []byte(`{"notes":`), mergePatchHeaders)
+	if invalidJSON.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid JSON to return 400, got %d", invalidJSON.Code)
+	}
+
+	wrongMediaType := performRequestWithHeaders(router, http.MethodPatch, "/api/transactions/1", []byte(`{"notes":"x"}`), map[string]string{"Content-Type": "text/plain"})
+	if wrongMediaType.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected unsupported content type to return 415, got %d", wrongMediaType.Code)
+	}
+
+	staleTag := performRequestWithHeaders(
+		router,
+		http.MethodPatch,
+		"/api/transactions/1",
+		[]byte(`{"notes":"x"}`),
+		map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": createResponse.Header().Get("ETag")},
+	)
+	if staleTag.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected stale If-Match to return 412, got %d", staleTag.Code)
+	}
+
+	missing := performRequestWithHeaders(router, http.MethodPatch, "/api/transactions/99", []byte(`{"notes":"x"}`), mergePatchHeaders)
+	if missing.Code != http.StatusNotFound {
+		t.Fatalf("expected missing transaction to return 404, got %d", missing.Code)
+	}
+}
+
+func TestTransactionDuplicatesAndMerge(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+	for _, body := range []string{
+		`{"transaction_date":"2026-01-05","type":"expense","amount":42.5,"notes":"Grocery Store #12","person_id":1,"bank_account_id":1,"category_id":1}`,
+		`{"transaction_date":"2026-01-06","type":"expense","amount":42.5,"notes":"GROCERY STORE 12 POS","person_id":1,"bank_account_id":1,"category_id":1}`,
+		`{"transaction_date":"2026-01-05","type":"income","amount":42.5,"person_id":1,"bank_account_id":1,"category_id":1}`,
+	} {
+		response := performRequest(router, http.MethodPost, "/api/transactions", []byte(body))
+		if response.Code != http.StatusCreated {
+			t.Fatalf("expected transaction seed to return 201, got %d", response.Code)
+		}
+	}
+
+	listed := performRequest(router, http.MethodGet, "/api/transactions/duplicates", nil)
+	if listed.Code != http.StatusOK {
+		t.Fatalf("expected duplicates to return 200, got %d", listed.Code)
+	}
+	var candidates []duplicateCandidate
+	if err := json.NewDecoder(listed.Body).Decode(&candidates); err != nil {
+		t.Fatalf("decode duplicates: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].First.ID != 1 || candidates[0].Second.ID != 2 || candidates[0].Score < 0.9 {
+		t.Fatalf("expected transactions 1 and 2 to be a strong candidate, got %+v", candidates)
+	}
+
+	narrow := performRequest(router, http.MethodGet, "/api/transactions/duplicates?window_days=0", nil)
+	if narrow.Code != http.StatusOK || strings.TrimSpace(narrow.Body.String()) != "[]" {
+		t.Fatalf("expected no candidates on the same day only, got %d %s", narrow.Code, narrow.Body.String())
+	}
+	for _, query := range []string{"window_days=-1", "window_days=90", "min_score=2", "min_score=abc"} {
+		invalid := performRequest(router, http.MethodGet, "/api/transactions/duplicates?"+query, nil)
+		if invalid.Code != http.StatusBadRequest {
+			t.Fatalf("expected %s to return 400, got %d", query, invalid.Code)
+		}
+	}
+
+	for _, item := range []struct {
+		path string
+		body string
+		code int
+	}{
+		{"/api/transactions/1/merge", `{"duplicate_id":1}`, http.StatusBadRequest},
+		{"/api/transactions/1/merge", `{"duplicate_id":3}`, http.StatusBadRequest},
+		{"/api/transactions/1/merge", `{"duplicate_id":99}`, http.StatusNotFound},
+		{"/api/transactions/99/merge", `{"duplicate_id":2}`, http.StatusNotFound},
+		{"/api/transactions/abc/merge", `{"duplicate_id":2}`, http.StatusBadRequest},
+	} {
+		response := performRequest(router, http.MethodPost, item.path, []byte(item.body))
+		if response.Code != item.code {
+			t.Fatalf("expected %s %s to return %d, got %d", item.path, item.body, item.code, response.Code)
+		}
+	}
+
+	merged := performRequest(router, http.MethodPost, "/api/transactions/1/merge", []byte(`{"duplicate_id":2}`))
+	if merged.Code != http.StatusOK || merged.Header().Get(etagHeader) == "" {
+		t.Fatalf("expected merge to return 200 with an ETag, got %d", merged.Code)
+	}
+	var kept transaction
+	if err := json.NewDecoder(merged.Body).Decode(&kept); err != nil {
+		t.Fatalf("decode merged transaction: %v", err)
+	}
+	if kept.ID != 1 || kept.Notes == nil || *kept.Notes != "Grocery Store #12; GROCERY STORE 12 POS" {
+		t.Fatalf("expected combined notes on the kept transaction, got %+v", kept)
+	}
+
+	if gone := performRequest(router, http.MethodGet, "/api/transactions/2", nil); gone.Code != http.StatusNotFound {
+		t.Fatalf("expected merged duplicate to be deleted, got %d", gone.Code)
+	}
+	if method := performRequest(router, http.MethodGet, "/api/transactions/1/merge", nil); method.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected GET merge to return 405, got %d", method.Code)
+	}
+
+	history := performRequest(router, http.MethodGet, "/api/audit?entity=transactions", nil)
+	var events []auditEvent
+	if err := json.NewDecoder(history.Body).Decode(&events); err != nil {
+		t.Fatalf("decode audit events: %v", err)
+	}
+	merges := make(map[int64]auditEvent)
+	for _, event := range events {
+		if event.Action == auditActionMerge {
+			merges[event.EntityID] = event
+		}
+	}
+	if len(merges) != 2 {
+		t.Fatalf("expected a merge event for both transactions, got %+v", events)
+	}
+	if merges[1].Changes["merged_from"].After != float64(2) || merges[1].Changes["notes"].After != *kept.Notes {
+		t.Fatalf("unexpected merge event of the kept transaction: %+v", merges[1])
+	}
+	if merges[2].Changes["merged_into"].After != float64(1) {
+		t.Fatalf("unexpected merge event of the duplicate: %+v", merges[2])
+	}
+}
+
 func seedTransactionDependencies(t *testing.T, router http.Handler) {
 	t.Helper()
 
@@ -158,3 +434,117 @@ func seedTransactionDependencies(t *testing.T, router http.Handler) {
 		t.Fatalf("expected bank account seed to return 201, got %d", bankAccount.Code)
 	}
 }
+
+func TestTransactionSplits(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionSplitDependencies(t, router)
+
+	const receipt = `{"transaction_date":"2026-03-02","type":"expense","amount":100,"notes":"Supermarket","person_id":1,"bank_account_id":1,"category_id":1,"splits":[` +
+		`{"category_id":1,"person_id":1,"amount":60,"notes":"groceries"},` +
+		`{"category_id":2,"person_id":2,"amount":40,"notes":"  gift  "}]}`
+
+	for _, invalid := range []string{
+		strings.Replace(receipt, `"amount":40`, `"amount":39`, 1),
+		`{"transaction_date":"2026-03-02","type":"expense","amount":100,"person_id":1,"bank_account_id":1,"category_id":1,"splits":[{"category_id":1,"person_id":1,"amount":100}]}`,
+		strings.Replace(receipt, `"person_id":2`, `"person_id":99`, 1),
+		strings.Replace(receipt, `"category_id":2`, `"category_id":0`, 1),
+		strings.Replace(receipt, `"amount":40`, `"amount":-40`, 1),
+	} {
+		expectResponse(t, router, http.MethodPost, "/api/transactions", invalid, http.StatusBadRequest)
+	}
+
+	var created transaction
+	decodeResponse(t, expectResponse(t, router, http.MethodPost, "/api/transactions", receipt, http.StatusCreated), &created)
+	if len(created.Splits) != 2 || created.Splits[1].CategoryID != 2 || created.Splits[1].PersonID != 2 || created.Splits[1].Notes == nil || *created.Splits[1].Notes != "gift" {
+		t.Fatalf("unexpected split lines: %+v", created.Splits)
+	}
+
+	var listed []transaction
+	if err := json.Unmarshal(expectResponse(t, router, http.MethodGet, "/api/transactions", "", http.StatusOK), &listed); err != nil {
+		t.Fatalf("decode transactions: %v", err)
+	}
+	if len(listed) != 1 || len(listed[0].Splits) != 2 || listed[0].Splits[0].Amount != 60 {
+		t.Fatalf("expected the list to carry split lines, got %+v", listed)
+	}
+
+	expectResponse(t, router, http.MethodPatch, "/api/transactions/1", `{"amount":120}`, http.StatusBadRequest)
+	var patched transaction
+	decodeResponse(t, expectResponse(t, router, http.MethodPatch, "/api/transactions/1", `{"notes":"Supermarket receipt"}`, http.StatusOK), &patched)
+	if len(patched.Splits) != 2 {
+		t.Fatalf("expected a patch to keep the split lines, got %+v", patched.Splits)
+	}
+
+	expectResponse(t, router, http.MethodDelete, "/api/transaction-categories/2", "", http.StatusConflict)
+	expectResponse(t, router, http.MethodDelete, "/api/people/2", "", http.StatusConflict)
+
+	const withoutSplits = `{"transaction_date":"2026-03-02","type":"expense","amount":100,"notes":"Edited","person_id":1,"bank_account_id":1,"category_id":1}`
+	var edited transaction
+	decodeResponse(t, expectResponse(t, router, http.MethodPut, "/api/transactions/1", withoutSplits, http.StatusOK), &edited)
+	if len(edited.Splits) != 2 || edited.Splits[0].Amount != 60 {
+		t.Fatalf("expected a PUT without splits to keep them, got %+v", edited.Splits)
+	}
+	expectResponse(t, router, http.MethodPut, "/api/transactions/1", strings.Replace(withoutSplits, `"amount":100`, `"amount":120`, 1), http.StatusBadRequest)
+
+	var unsplit transaction
+	decodeResponse(t, expectResponse(t, router, http.MethodPut, "/api/transactions/1", strings.Replace(withoutSplits, `}`, `,"splits":[]}`, 1), http.StatusOK), &unsplit)
+	if unsplit.Splits == nil || len(unsplit.Splits) != 0 {
+		t.Fatalf("expected a PUT with empty splits to remove them, got %+v", unsplit.Splits)
+	}
+	expectResponse(t, router, http.MethodPut, "/api/transactions/1", receipt, http.StatusOK)
+	var cleared transaction
+	decodeResponse(t, expectResponse(t, router, http.MethodPatch, "/api/transactions/1", `{"splits":null}`, http.StatusOK), &cleared)
+	if len(cleared.Splits) != 0 {
+		t.Fatalf("expected a patch with null splits to remove them, got %+v", cleared.Splits)
+	}
+	expectResponse(t, router, http.MethodPut, "/api/transactions/1", receipt, http.StatusOK)
+
+	var events []auditEvent
+	if err := json.Unmarshal(expectResponse(t, router, http.MethodGet, "/api/audit?entity=transactions&id=1", "", http.StatusOK), &events); err != nil {
+		t.Fatalf("decode audit events: %v", err)
+	}
+	if _, ok := events[len(events)-1].Changes["splits"]; !ok {
+		t.Fatalf("expected the audit to record split changes, got %+v", events[len(events)-1].Changes)
+	}
+
+	// Split lines follow their transaction into the trash and back.
+	expectResponse(t, router, http.MethodDelete, "/api/transactions/1", "", http.StatusNoContent)
+	expectResponse(t, router, http.MethodDelete, "/api/transaction-categories/2", "", http.StatusNoContent)
+	expectResponse(t, router, http.MethodPost, "/api/trash/transactions/1/restore", "", http.StatusConflict)
+	expectResponse(t, router, http.MethodPost, "/api/trash/transaction_categories/2/restore", "", http.StatusNoContent)
+	expectResponse(t, router, http.MethodPost, "/api/trash/transactions/1/restore", "", http.StatusNoContent)
+	var restored transaction
+	decodeResponse(t, expectResponse(t, router, http.MethodGet, "/api/transactions/1", "", http.StatusOK), &restored)
+	if len(restored.Splits) != 2 {
+		t.Fatalf("expected restored transaction to keep its split lines, got %+v", restored.Splits)
+	}
+
+	expectResponse(t, router, http.MethodDelete, "/api/transactions/1", "", http.StatusNoContent)
+	expectResponse(t, router, http.MethodDelete, "/api/trash/transactions/1", "", http.StatusNoContent)
+	var remaining int
+	if err := application.db.QueryRow(`SELECT COUNT(1) FROM transaction_splits`).Scan(&remaining); err != nil {
+		t.Fatalf("count split lines: %v", err)
+	}
+	if remaining != 0 {
+		t.Fatalf("expected purge to remove split lines, %d left", remaining)
+	}
+}
+
+// seedTransactionSplitDependencies adds a second category and person to the
+// transaction dependencies, for split lines.
+func seedTransactionSplitDependencies(t *testing.T, router http.Handler) {
+	t.Helper()
+
+	seedTransactionDependencies(t, router)
+
+	category := performRequest(router, http.MethodPost, "/api/transaction-categories", []byte(`{"name":"Gifts"}`))
+	if category.Code != http.StatusCreated {
+		t.Fatalf("expected transaction category seed to return 201, got %d", category.Code)
+	}
+
+	person := performRequest(router, http.MethodPost, "/api/people", []byte(`{"name":"John Doe"}`))
+	if person.Code != http.StatusCreated {
+		t.Fatalf("expected person seed to return 201, got %d", person.Code)
+	}
+}
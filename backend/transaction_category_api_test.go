@@ -149,3 +149,30 @@ func TestTransactionCategoryConflicts(t *testing.T) {
 		t.Fatalf("expected 409 for deleting parent with child categories, got %d", deleteParentWithChild.Code)
 	}
 }
+
+func TestTransactionCategoryPatchMovesToRoot(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	performRequest(router, http.MethodPost, "/api/transaction-categories", []byte(`{"name":"Salary"}`))
+	performRequest(router, http.MethodPost, "/api/transaction-categories", []byte(`{"name":"Bonus","parent_id":1}`))
+
+	patchResponse := performRequestWithHeaders(
+		router,
+		http.MethodPatch,
+		"/api/transaction-categories/2",
+		[]byte(`{"parent_id":null}`),
+		map[string]string{"Content-Type": "application/merge-patch+json"},
+	)
+	if patchResponse.Code != http.StatusOK {
+		t.Fatalf("expected patch to return 200, got %d", patchResponse.Code)
+	}
+
+	var patched transactionCategory
+	if err := json.NewDecoder(patchResponse.Body).Decode(&patched); err != nil {
+		t.Fatalf("decode category: %v", err)
+	}
+	if patched.Name != "Bonus" || patched.ParentID != nil {
+		t.Fatalf("expected category to become a root named Bonus, got %+v", patched)
+	}
+}
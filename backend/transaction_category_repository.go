@@ -0,0 +1,103 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+)
+
+type transactionCategoryRepository interface {
+	list(ctx context.Context) ([]transactionCategory, error)
+	get(ctx context.Context, id int64) (transactionCategory, error)
+	create(ctx context.Context, payload transactionCategoryPayload) (int64, error)
+	update(ctx context.Context, id int64, payload transactionCategoryPayload) error
+	// delete soft deletes the transaction category.
+	delete(ctx context.Context, id int64) error
+}
+
+type sqlTransactionCategoryRepository struct {
+	source queryer
+}
+
+func (repository sqlTransactionCategoryRepository) list(ctx context.Context) ([]transactionCategory, error) {
+	rows, err := repository.source.QueryContext(ctx, `
+		SELECT c.id, c.name, c.parent_id, p.name
+		FROM transaction_categories c
+		LEFT JOIN transaction_categories p ON p.id = c.parent_id
+		WHERE c.deleted_at IS NULL
+		ORDER BY c.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]transactionCategory, 0)
+	for rows.Next() {
+		item, scanErr := scanTransactionCategory(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (repository sqlTransactionCategoryRepository) get(ctx context.Context, id int64) (transactionCategory, error) {
+	row := repository.source.QueryRowContext(ctx, `
+		SELECT c.id, c.name, c.parent_id, p.name
+		FROM transaction_categories c
+		LEFT JOIN transaction_categories p ON p.id = c.parent_id
+		WHERE c.id = ? AND c.deleted_at IS NULL
+	`, id)
+
+	item, err := scanTransactionCategory(row)
+	if err != nil {
+		return transactionCategory{}, rowError(err)
+	}
+
+	return item, nil
+}
+
+func (repository sqlTransactionCategoryRepository) create(ctx context.Context, payload transactionCategoryPayload) (int64, error) {
+	return insertRow(ctx, repository.source, `INSERT INTO transaction_categories(name, parent_id) VALUES (?, ?)`, payload.Name, payload.ParentID)
+}
+
+func (repository sqlTransactionCategoryRepository) update(ctx context.Context, id int64, payload transactionCategoryPayload) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE transaction_categories SET name = ?, parent_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`,
+		payload.Name,
+		payload.ParentID,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlTransactionCategoryRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE transaction_categories SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func scanTransactionCategory(source scanner) (transactionCategory, error) {
+	var item transactionCategory
+	var parentID sql.NullInt64
+	var parentName sql.NullString
+
+	err := source.Scan(&item.ID, &item.Name, &parentID, &parentName)
+	if err != nil {
+		return transactionCategory{}, err
+	}
+
+	if parentID.Valid {
+		id := parentID.Int64
+		item.ParentID = &id
+	}
+
+	if parentName.Valid {
+		name := parentName.String
+		item.ParentName = &name
+	}
+
+	return item, nil
+}
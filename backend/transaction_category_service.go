@@ -0,0 +1,164 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+type transactionCategoryService struct {
+	store dataStore
+}
+
+func (payload transactionCategoryPayload) normalize() (transactionCategoryPayload, error) {
+	payload.Name = strings.TrimSpace(payload.Name)
+	if payload.Name == "" {
+		return transactionCategoryPayload{}, invalidPayload("name is required")
+	}
+
+	if payload.ParentID != nil && *payload.ParentID <= 0 {
+		return transactionCategoryPayload{}, invalidPayload("parent_id must be a positive integer")
+	}
+
+	return payload, nil
+}
+
+func (service transactionCategoryService) list(ctx context.Context) ([]transactionCategory, error) {
+	return service.store.reads().transactionCategories().list(ctx)
+}
+
+func (service transactionCategoryService) get(ctx context.Context, id int64) (transactionCategory, error) {
+	item, err := service.store.reads().transactionCategories().get(ctx, id)
+	if err != nil {
+		return transactionCategory{}, orNotFound(err, "transaction category not found")
+	}
+
+	return item, nil
+}
+
+func (service transactionCategoryService) create(ctx context.Context, by change, payload transactionCategoryPayload) (transactionCategory, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return transactionCategory{}, err
+	}
+
+	var created transactionCategory
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkTransactionCategoryParent(ctx, tx, payload, 0); err != nil {
+			return err
+		}
+
+		id, err := tx.transactionCategories().create(ctx, payload)
+		if err != nil {
+			return transactionCategoryWriteError(err)
+		}
+
+		if created, err = tx.transactionCategories().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityTransactionCategories, id, auditActionCreate, nil, created))
+	})
+	if err != nil {
+		return transactionCategory{}, err
+	}
+
+	return created, nil
+}
+
+func (service transactionCategoryService) update(ctx context.Context, by change, id int64, payload transactionCategoryPayload) (transactionCategory, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return transactionCategory{}, err
+	}
+
+	var updated transactionCategory
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkTransactionCategoryParent(ctx, tx, payload, id); err != nil {
+			return err
+		}
+
+		existing, err := tx.transactionCategories().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "transaction category not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		if err = tx.transactionCategories().update(ctx, id, payload); err != nil {
+			return transactionCategoryWriteError(err)
+		}
+
+		if updated, err = tx.transactionCategories().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityTransactionCategories, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return transactionCategory{}, err
+	}
+
+	return updated, nil
+}
+
+// delete soft deletes a transaction category nothing live refers to.
+func (service transactionCategoryService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.transactionCategories().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "transaction category not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+
+		inUse, err := tx.trash().hasDependents(ctx, auditEntityTransactionCategories, id)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return conflict("category_in_use", "transaction category is in use")
+		}
+
+		if err = tx.transactionCategories().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityTransactionCategories, id, auditActionDelete, existing, nil))
+	})
+}
+
+// checkTransactionCategoryParent checks that the parent, when there is one,
+// exists and is not the category itself.
+func checkTransactionCategoryParent(ctx context.Context, tx repositories, payload transactionCategoryPayload, categoryID int64) error {
+	if payload.ParentID == nil {
+		return nil
+	}
+
+	if categoryID > 0 && *payload.ParentID == categoryID {
+		return invalidPayload("category cannot be its own parent")
+	}
+
+	exists, err := tx.trash().exists(ctx, auditEntityTransactionCategories, *payload.ParentID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return invalidPayload("parent category must exist")
+	}
+
+	return nil
+}
+
+func transactionCategoryWriteError(err error) error {
+	switch {
+	case errors.Is(err, errDuplicate):
+		return conflict(duplicateTransactionCategoryCode, "category name must be unique under the same parent")
+	case errors.Is(err, errMissingReference):
+		return invalidPayload("parent category must exist")
+	default:
+		return err
+	}
+}
@@ -0,0 +1,202 @@
+package backend
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	defaultDuplicateWindowDays = 3
+	maxDuplicateWindowDays     = 31
+	defaultDuplicateMinScore   = 0.7
+)
+
+// duplicateWeights weigh the signals of a candidate pair. They add up to 1, so
+// a score is the weighted mean of signals that each run from 0 to 1.
+var duplicateWeights = duplicateSignals{
+	Date:     0.2,
+	Amount:   0.35,
+	Account:  0.2,
+	Category: 0.1,
+	Notes:    0.15,
+}
+
+// duplicateSignals says, from 0 to 1, how much two transactions agree on each
+// field compared by the duplicate detector.
+type duplicateSignals struct {
+	Date     float64 `json:"date"`
+	Amount   float64 `json:"amount"`
+	Account  float64 `json:"account"`
+	Category float64 `json:"category"`
+	Notes    float64 `json:"notes"`
+}
+
+// duplicateCandidate is a pair of transactions that may record the same
+// movement twice. First is the older row, which a merge would normally keep.
+type duplicateCandidate struct {
+	Score   float64          `json:"score"`
+	Signals duplicateSignals `json:"signals"`
+	First   transaction      `json:"first"`
+	Second  transaction      `json:"second"`
+}
+
+// findDuplicateCandidates pairs transactions of the same type booked at most
+// windowDays apart and returns the pairs scoring minScore or more, best first.
+func findDuplicateCandidates(items []transaction, windowDays int, minScore float64) []duplicateCandidate {
+	type dated struct {
+		item transaction
+		day  time.Time
+	}
+	sorted := make([]dated, 0, len(items))
+	for _, item := range items {
+		day, err := time.Parse("2006-01-02", item.TransactionDate)
+		if err != nil {
+			continue
+		}
+		sorted = append(sorted, dated{item: item, day: day})
+	}
+	sort.SliceStable(sorted, func(left, right int) bool {
+		return sorted[left].day.Before(sorted[right].day)
+	})
+
+	window := time.Duration(windowDays) * 24 * time.Hour
+	candidates := make([]duplicateCandidate, 0)
+	for index, current := range sorted {
+		for _, other := range sorted[index+1:] {
+			gap := other.day.Sub(current.day)
+			if gap > window {
+				break
+			}
+			if other.item.Type != current.item.Type {
+				continue
+			}
+
+			first, second := current.item, other.item
+			if second.ID < first.ID {
+				first, second = second, first
+			}
+			signals := duplicateSignals{
+				Date:     1 - float64(gap/(24*time.Hour))/float64(windowDays+1),
+				Amount:   sameSignal(math.Abs(first.Amount-second.Amount) < 0.005),
+				Account:  sameSignal(first.BankAccountID == second.BankAccountID),
+				Category: sameSignal(first.CategoryID == second.CategoryID),
+				Notes:    notesSimilarity(first.Notes, second.Notes),
+			}
+			score := signals.Date*duplicateWeights.Date +
+				signals.Amount*duplicateWeights.Amount +
+				signals.Account*duplicateWeights.Account +
+				signals.Category*duplicateWeights.Category +
+				signals.Notes*duplicateWeights.Notes
+			score = math.Round(score*1000) / 1000
+			if score < minScore {
+				continue
+			}
+			candidates = append(candidates, duplicateCandidate{Score: score, Signals: signals, First: first, Second: second})
+		}
+	}
+
+	sort.SliceStable(candidates, func(left, right int) bool {
+		if candidates[left].Score != candidates[right].Score {
+			return candidates[left].Score > candidates[right].Score
+		}
+		if candidates[left].First.ID != candidates[right].First.ID {
+			return candidates[left].First.ID < candidates[right].First.ID
+		}
+		return candidates[left].Second.ID < candidates[right].Second.ID
+	})
+
+	return candidates
+}
+
+func sameSignal(same bool) float64 {
+	if same {
+		return 1
+	}
+	return 0
+}
+
+// notesSimilarity compares notes with the Dice coefficient of their letter
+// pairs, ignoring case, punctuation and spacing, so "AMZN Mktp US*1A2B" and
+// "amzn mktp us" still score high. A pair where only one side has notes is
+// neither evidence for nor against a duplicate and scores 0.5.
+func notesSimilarity(left *string, right *string) float64 {
+	leftText, rightText := normalizeNotes(left), normalizeNotes(right)
+	switch {
+	case leftText == "" && rightText == "":
+		return 1
+	case leftText == "" || rightText == "":
+		return 0.5
+	case leftText == rightText:
+		return 1
+	}
+
+	leftPairs, rightPairs := letterPairs(leftText), letterPairs(rightText)
+	if len(leftPairs) == 0 || len(rightPairs) == 0 {
+		return 0
+	}
+
+	remaining := make(map[string]int, len(rightPairs))
+	for _, pair := range rightPairs {
+		remaining[pair]++
+	}
+	shared := 0
+	for _, pair := range leftPairs {
+		if remaining[pair] > 0 {
+			remaining[pair]--
+			shared++
+		}
+	}
+
+	similarity := 2 * float64(shared) / float64(len(leftPairs)+len(rightPairs))
+	return math.Round(similarity*1000) / 1000
+}
+
+func normalizeNotes(notes *string) string {
+	if notes == nil {
+		return ""
+	}
+
+	return strings.Join(strings.FieldsFunc(strings.ToLower(*notes), func(char rune) bool {
+		return !unicode.IsLetter(char) && !unicode.IsDigit(char)
+	}), " ")
+}
+
+func letterPairs(text string) []string {
+	pairs := make([]string, 0, len(text))
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		if len(runes) == 1 {
+			pairs = append(pairs, word)
+			continue
+		}
+		for index := 0; index < len(runes)-1; index++ {
+			pairs = append(pairs, string(runes[index:index+2]))
+		}
+	}
+	return pairs
+}
+
+// combineNotes joins the notes of two merged transactions. Notes that are
+// empty, equal or contained in the other, ignoring case, are not repeated.
+func combineNotes(kept *string, merged *string) *string {
+	switch {
+	case merged == nil:
+		return kept
+	case kept == nil:
+		return merged
+	}
+
+	keptText, mergedText := strings.ToLower(*kept), strings.ToLower(*merged)
+	if strings.Contains(keptText, mergedText) {
+		return kept
+	}
+	if strings.Contains(mergedText, keptText) {
+		return merged
+	}
+
+	combined := *kept + "; " + *merged
+	return &combined
+}
@@ -0,0 +1,82 @@
+package backend
+
+import "testing"
+
+func TestNotesSimilarity(t *testing.T) {
+	text := func(value string) *string { return &value }
+	cases := []struct {
+		left, right *string
+		minimum     float64
+		maximum     float64
+	}{
+		{nil, nil, 1, 1},
+		{text("Coffee"), nil, 0.5, 0.5},
+		{text("AMZN Mktp US*1A2B"), text("amzn mktp us 1a2b"), 1, 1},
+		{text("Grocery Store #12"), text("GROCERY STORE 12 POS"), 0.8, 0.99},
+		{text("Rent"), text("Gym membership"), 0, 0.1},
+	}
+
+	for _, item := range cases {
+		similarity := notesSimilarity(item.left, item.right)
+		if similarity < item.minimum || similarity > item.maximum {
+			t.Fatalf("expected similarity of %v and %v in [%v, %v], got %v", item.left, item.right, item.minimum, item.maximum, similarity)
+		}
+	}
+}
+
+func TestCombineNotes(t *testing.T) {
+	text := func(value string) *string { return &value }
+	cases := []struct {
+		kept, merged *string
+		expected     string
+	}{
+		{text("Lunch"), nil, "Lunch"},
+		{nil, text("Lunch"), "Lunch"},
+		{text("Lunch with Ana"), text("lunch"), "Lunch with Ana"},
+		{text("lunch"), text("Lunch with Ana"), "Lunch with Ana"},
+		{text("Lunch"), text("POS 4411"), "Lunch; POS 4411"},
+	}
+
+	for _, item := range cases {
+		combined := combineNotes(item.kept, item.merged)
+		if combined == nil || *combined != item.expected {
+			t.Fatalf("expected %q, got %v", item.expected, combined)
+		}
+	}
+	if combineNotes(nil, nil) != nil {
+		t.Fatalf("expected no notes to stay empty")
+	}
+}
+
+func TestFindDuplicateCandidatesScoresPairs(t *testing.T) {
+	notes := "Grocery Store #12"
+	similar := "GROCERY STORE 12 POS"
+	items := []transaction{
+		{ID: 1, TransactionDate: "2026-01-05", Type: "expense", Amount: 42.5, Notes: &notes, BankAccountID: 1, CategoryID: 1},
+		{ID: 2, TransactionDate: "2026-01-20", Type: "expense", Amount: 42.5, BankAccountID: 1, CategoryID: 1},
+		{ID: 3, TransactionDate: "2026-01-06", Type: "expense", Amount: 42.5, Notes: &similar, BankAccountID: 1, CategoryID: 1},
+		{ID: 4, TransactionDate: "2026-01-05", Type: "income", Amount: 42.5, BankAccountID: 1, CategoryID: 1},
+		{ID: 5, TransactionDate: "2026-01-05", Type: "expense", Amount: 10, BankAccountID: 2, CategoryID: 2},
+	}
+
+	candidates := findDuplicateCandidates(items, 3, 0.7)
+	if len(candidates) != 1 || candidates[0].First.ID != 1 || candidates[0].Second.ID != 3 {
+		t.Fatalf("expected transactions 1 and 3 to pair, got %+v", candidates)
+	}
+	signals := candidates[0].Signals
+	if signals.Amount != 1 || signals.Account != 1 || signals.Category != 1 || signals.Date != 0.75 || signals.Notes < 0.8 {
+		t.Fatalf("unexpected signals: %+v", signals)
+	}
+
+	all := findDuplicateCandidates(items, 3, 0)
+	for index := 1; index < len(all); index++ {
+		if all[index].Score > all[index-1].Score {
+			t.Fatalf("expected candidates sorted by score, got %+v", all)
+		}
+	}
+	for _, candidate := range all {
+		if candidate.First.Type != candidate.Second.Type || candidate.First.ID == 2 || candidate.Second.ID == 2 {
+			t.Fatalf("expected only same-type pairs inside the window, got %+v", candidate)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+)
+
+type transactionRepository interface {
+	// list and get return transactions with their split lines.
+	list(ctx context.Context) ([]transaction, error)
+	get(ctx context.Context, id int64) (transaction, error)
+	// create and update write the transaction row; replaceSplits writes its
+	// split lines.
+	create(ctx context.Context, payload transactionPayload) (int64, error)
+	update(ctx context.Context, id int64, payload transactionPayload) error
+	replaceSplits(ctx context.Context, id int64, splits []transactionSplitPayload) error
+	// delete soft deletes the transaction.
+	delete(ctx context.Context, id int64) error
+	// setReconciliation moves one transaction into or out of a reconciliation
+	// session; reconciliationID is nil for uncleared transactions.
+	setReconciliation(ctx context.Context, id int64, reconciliationID *int64, status string) error
+	// setReconciliationStatus changes the status of every transaction of a
+	// session, when it is locked or unlocked.
+	setReconciliationStatus(ctx context.Context, reconciliationID int64, status string) error
+	// releaseReconciliation unclears every transaction of a deleted session.
+	releaseReconciliation(ctx context.Context, reconciliationID int64) error
+}
+
+type sqlTransactionRepository struct {
+	source queryer
+}
+
+func (repository sqlTransactionRepository) list(ctx context.Context) ([]transaction, error) {
+	rows, err := repository.source.QueryContext(ctx, `
+		SELECT id, transaction_date, type, amount, notes, person_id, bank_account_id, category_id, savings_goal_id, reconciliation_status, reconciliation_id
+		FROM transactions
+		WHERE deleted_at IS NULL
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]transaction, 0)
+	for rows.Next() {
+		item, scanErr := scanTransaction(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	splits, err := repository.splits(ctx, `
+		SELECT s.transaction_id, s.id, s.category_id, s.person_id, s.amount, s.notes
+		FROM transaction_splits s
+		JOIN transactions t ON t.id = s.transaction_id
+		WHERE t.deleted_at IS NULL
+		ORDER BY s.transaction_id, s.position
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for index := range items {
+		if lines, ok := splits[items[index].ID]; ok {
+			items[index].Splits = lines
+		}
+	}
+
+	return items, nil
+}
+
+func (repository sqlTransactionRepository) get(ctx context.Context, id int64) (transaction, error) {
+	row := repository.source.QueryRowContext(ctx, `
+		SELECT id, transaction_date, type, amount, notes, person_id, bank_account_id, category_id, savings_goal_id, reconciliation_status, reconciliation_id
+		FROM transactions
+		WHERE id = ? AND deleted_at IS NULL
+	`, id)
+
+	item, err := scanTransaction(row)
+	if err != nil {
+		return transaction{}, rowError(err)
+	}
+
+	splits, err := repository.splits(ctx, `
+		SELECT transaction_id, id, category_id, person_id, amount, notes
+		FROM transaction_splits
+		WHERE transaction_id = ?
+		ORDER BY position
+	`, id)
+	if err != nil {
+		return transaction{}, err
+	}
+	if lines, ok := splits[id]; ok {
+		item.Splits = lines
+	}
+
+	return item, nil
+}
+
+// splits runs a query selecting transaction_id followed by the split columns
+// and groups the lines by transaction.
+func (repository sqlTransactionRepository) splits(ctx context.Context, query string, args ...any) (map[int64][]transactionSplit, error) {
+	rows, err := repository.source.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	splits := make(map[int64][]transactionSplit)
+	for rows.Next() {
+		var transactionID int64
+		var split transactionSplit
+		var notes sql.NullString
+		if err = rows.Scan(&transactionID, &split.ID, &split.CategoryID, &split.PersonID, &split.Amount, &notes); err != nil {
+			return nil, err
+		}
+		if notes.Valid {
+			value := notes.String
+			split.Notes = &value
+		}
+		splits[transactionID] = append(splits[transactionID], split)
+	}
+
+	return splits, rows.Err()
+}
+
+func (repository sqlTransactionRepository) create(ctx context.Context, payload transactionPayload) (int64, error) {
+	return insertRow(
+		ctx,
+		repository.source,
+		`INSERT INTO transactions(transaction_date, type, amount, notes, person_id, bank_account_id, category_id, savings_goal_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		payload.TransactionDate,
+		payload.Type,
+		payload.Amount,
+		payload.Notes,
+		payload.PersonID,
+		payload.BankAccountID,
+		payload.CategoryID,
+		payload.SavingsGoalID,
+	)
+}
+
+func (repository sqlTransactionRepository) update(ctx context.Context, id int64, payload transactionPayload) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE transactions
+		 SET transaction_date = ?, type = ?, amount = ?, notes = ?, person_id = ?, bank_account_id = ?, category_id = ?, savings_goal_id = ?,
+		     updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND deleted_at IS NULL`,
+		payload.TransactionDate,
+		payload.Type,
+		payload.Amount,
+		payload.Notes,
+		payload.PersonID,
+		payload.BankAccountID,
+		payload.CategoryID,
+		payload.SavingsGoalID,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlTransactionRepository) replaceSplits(ctx context.Context, id int64, splits []transactionSplitPayload) error {
+	if _, err := repository.source.ExecContext(ctx, `DELETE FROM transaction_splits WHERE transaction_id = ?`, id); err != nil {
+		return err
+	}
+
+	for position, split := range splits {
+		_, err := repository.source.ExecContext(
+			ctx,
+			`INSERT INTO transaction_splits(transaction_id, position, category_id, person_id, amount, notes) VALUES (?, ?, ?, ?, ?, ?)`,
+			id,
+			position+1,
+			split.CategoryID,
+			split.PersonID,
+			split.Amount,
+			split.Notes,
+		)
+		if err != nil {
+			return constraintError(err)
+		}
+	}
+
+	return nil
+}
+
+func (repository sqlTransactionRepository) delete(ctx context.Context, id int64) error {
+	_, err := repository.source.ExecContext(ctx, `UPDATE transactions SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`, id)
+	return err
+}
+
+func (repository sqlTransactionRepository) setReconciliation(ctx context.Context, id int64, reconciliationID *int64, status string) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE transactions SET reconciliation_id = ?, reconciliation_status = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`,
+		reconciliationID,
+		status,
+		id,
+	)
+	return constraintError(err)
+}
+
+func (repository sqlTransactionRepository) setReconciliationStatus(ctx context.Context, reconciliationID int64, status string) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE transactions SET reconciliation_status = ?, updated_at = CURRENT_TIMESTAMP WHERE reconciliation_id = ? AND deleted_at IS NULL`,
+		status,
+		reconciliationID,
+	)
+	return err
+}
+
+func (repository sqlTransactionRepository) releaseReconciliation(ctx context.Context, reconciliationID int64) error {
+	_, err := repository.source.ExecContext(
+		ctx,
+		`UPDATE transactions SET reconciliation_id = NULL, reconciliation_status = ?, updated_at = CURRENT_TIMESTAMP WHERE reconciliation_id = ?`,
+		transactionUncleared,
+		reconciliationID,
+	)
+	return err
+}
+
+func scanTransaction(source scanner) (transaction, error) {
+	item := transaction{Splits: make([]transactionSplit, 0)}
+	var notes sql.NullString
+	var savingsGoalID, reconciliationID sql.NullInt64
+
+	err := source.Scan(
+		&item.ID,
+		&item.TransactionDate,
+		&item.Type,
+		&item.Amount,
+		&notes,
+		&item.PersonID,
+		&item.BankAccountID,
+		&item.CategoryID,
+		&savingsGoalID,
+		&item.ReconciliationStatus,
+		&reconciliationID,
+	)
+	if err != nil {
+		return transaction{}, err
+	}
+
+	if savingsGoalID.Valid {
+		value := savingsGoalID.Int64
+		item.SavingsGoalID = &value
+	}
+	if reconciliationID.Valid {
+		value := reconciliationID.Int64
+		item.ReconciliationID = &value
+	}
+
+	if notes.Valid {
+		value := notes.String
+		item.Notes = &value
+	}
+
+	return item, nil
+}
@@ -0,0 +1,439 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+type transactionService struct {
+	store dataStore
+}
+
+// normalize trims and validates a transaction payload. It is shared by the
+// HTTP handlers and the statement importer.
+func (payload transactionPayload) normalize() (transactionPayload, error) {
+	payload.TransactionDate = strings.TrimSpace(payload.TransactionDate)
+	if payload.TransactionDate == "" {
+		return transactionPayload{}, invalidPayload("transaction_date is required")
+	}
+	if _, err := time.Parse("2006-01-02", payload.TransactionDate); err != nil {
+		return transactionPayload{}, invalidPayload("transaction_date must be a valid date in YYYY-MM-DD format")
+	}
+
+	payload.Type = strings.ToLower(strings.TrimSpace(payload.Type))
+	if payload.Type != "income" && payload.Type != "expense" {
+		return transactionPayload{}, invalidPayload("type must be either income or expense")
+	}
+
+	if math.IsNaN(payload.Amount) || math.IsInf(payload.Amount, 0) || payload.Amount <= 0 {
+		return transactionPayload{}, invalidPayload("amount must be greater than zero")
+	}
+
+	if payload.PersonID <= 0 {
+		return transactionPayload{}, invalidPayload("person_id must be a positive integer")
+	}
+	if payload.BankAccountID <= 0 {
+		return transactionPayload{}, invalidPayload("bank_account_id must be a positive integer")
+	}
+	if payload.CategoryID <= 0 {
+		return transactionPayload{}, invalidPayload("category_id must be a positive integer")
+	}
+	if payload.SavingsGoalID != nil && *payload.SavingsGoalID <= 0 {
+		return transactionPayload{}, invalidPayload("savings_goal_id must be a positive integer")
+	}
+
+	payload.Notes = normalizeTransactionNotes(payload.Notes)
+
+	return payload.normalizeSplits()
+}
+
+// normalizeSplits validates the split lines, which must add up to the
+// transaction amount.
+func (payload transactionPayload) normalizeSplits() (transactionPayload, error) {
+	if len(payload.Splits) == 0 {
+		payload.Splits = nil
+		return payload, nil
+	}
+	if len(payload.Splits) == 1 {
+		return transactionPayload{}, invalidPayload("splits must have at least two lines; book a single line on the transaction itself")
+	}
+
+	total := 0.0
+	splits := make([]transactionSplitPayload, len(payload.Splits))
+	for index, split := range payload.Splits {
+		if split.CategoryID <= 0 {
+			return transactionPayload{}, invalidPayload(fmt.Sprintf("splits[%d].category_id must be a positive integer", index))
+		}
+		if split.PersonID <= 0 {
+			return transactionPayload{}, invalidPayload(fmt.Sprintf("splits[%d].person_id must be a positive integer", index))
+		}
+		if math.IsNaN(split.Amount) || math.IsInf(split.Amount, 0) || split.Amount <= 0 {
+			return transactionPayload{}, invalidPayload(fmt.Sprintf("splits[%d].amount must be greater than zero", index))
+		}
+		split.Notes = normalizeTransactionNotes(split.Notes)
+		splits[index] = split
+		total += split.Amount
+	}
+	if math.Abs(total-payload.Amount) >= splitAmountTolerance {
+		return transactionPayload{}, invalidPayload(fmt.Sprintf("splits must add up to the transaction amount %.2f, got %.2f", payload.Amount, total))
+	}
+	payload.Splits = splits
+
+	return payload, nil
+}
+
+// withSplitsOf keeps the split lines of existing, which must still add up to
+// the amount of the payload.
+func (payload transactionPayload) withSplitsOf(existing transaction) (transactionPayload, error) {
+	payload.Splits = make([]transactionSplitPayload, len(existing.Splits))
+	for index, split := range existing.Splits {
+		payload.Splits[index] = transactionSplitPayload{CategoryID: split.CategoryID, PersonID: split.PersonID, Amount: split.Amount, Notes: split.Notes}
+	}
+
+	return payload.normalizeSplits()
+}
+
+// normalizeTransactionNotes trims notes; blank notes become nil.
+func normalizeTransactionNotes(notes *string) *string {
+	if notes == nil {
+		return nil
+	}
+
+	trimmedNotes := strings.TrimSpace(*notes)
+	if trimmedNotes == "" {
+		return nil
+	}
+
+	return &trimmedNotes
+}
+
+func (service transactionService) list(ctx context.Context) ([]transaction, error) {
+	return service.store.reads().transactions().list(ctx)
+}
+
+func (service transactionService) get(ctx context.Context, id int64) (transaction, error) {
+	item, err := service.store.reads().transactions().get(ctx, id)
+	if err != nil {
+		return transaction{}, orNotFound(err, "transaction not found")
+	}
+
+	return item, nil
+}
+
+func (service transactionService) create(ctx context.Context, by change, payload transactionPayload) (transaction, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return transaction{}, err
+	}
+
+	var created transaction
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		if err := checkTransactionReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		created, err = bookTransaction(ctx, tx, by, payload)
+		return err
+	})
+	if err != nil {
+		return transaction{}, err
+	}
+
+	return created, nil
+}
+
+func (service transactionService) update(ctx context.Context, by change, id int64, payload transactionPayload) (transaction, error) {
+	payload, err := payload.normalize()
+	if err != nil {
+		return transaction{}, err
+	}
+
+	var updated transaction
+	err = service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.transactions().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "transaction not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+		if err = checkNotReconciled(existing); err != nil {
+			return err
+		}
+		if !payload.splitsSent {
+			if payload, err = payload.withSplitsOf(existing); err != nil {
+				return err
+			}
+		}
+		if !payload.savingsGoalSent {
+			payload.SavingsGoalID = existing.SavingsGoalID
+		}
+		if err = checkTransactionReferences(ctx, tx, payload); err != nil {
+			return err
+		}
+
+		if err = tx.transactions().update(ctx, id, payload); err != nil {
+			return transactionWriteError(err)
+		}
+		if err = tx.transactions().replaceSplits(ctx, id, payload.Splits); err != nil {
+			return transactionWriteError(err)
+		}
+		// A cleared transaction moved to another account no longer belongs to
+		// the session of its old account.
+		if existing.ReconciliationID != nil && payload.BankAccountID != existing.BankAccountID {
+			if err = tx.transactions().setReconciliation(ctx, id, nil, transactionUncleared); err != nil {
+				return err
+			}
+		}
+
+		if updated, err = tx.transactions().get(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityTransactions, id, auditActionUpdate, existing, updated))
+	})
+	if err != nil {
+		return transaction{}, err
+	}
+
+	return updated, nil
+}
+
+func (service transactionService) delete(ctx context.Context, by change, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.transactions().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "transaction not found")
+		}
+		if err = by.checkVersion(existing); err != nil {
+			return err
+		}
+		if err = checkNotReconciled(existing); err != nil {
+			return err
+		}
+		if err = checkTransactionNotShared(ctx, tx, id); err != nil {
+			return err
+		}
+
+		if err = unclearDeletedTransaction(ctx, tx, existing); err != nil {
+			return err
+		}
+		if err = tx.transactions().delete(ctx, id); err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(auditEntityTransactions, id, auditActionDelete, existing, nil))
+	})
+}
+
+func (service transactionService) duplicates(ctx context.Context, windowDays int, minScore float64) ([]duplicateCandidate, error) {
+	items, err := service.store.reads().transactions().list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return findDuplicateCandidates(items, windowDays, minScore), nil
+}
+
+// merge folds the duplicate into the transaction with the given id: the kept
+// row gets the combined notes and the duplicate is soft deleted. Both rows get
+// a merge audit event naming the other one.
+func (service transactionService) merge(ctx context.Context, by change, id int64, duplicateID int64) (transaction, error) {
+	if duplicateID <= 0 {
+		return transaction{}, invalidPayload("duplicate_id must be a positive integer")
+	}
+	if duplicateID == id {
+		return transaction{}, invalidPayload("a transaction cannot be merged into itself")
+	}
+
+	var merged transaction
+	err := service.store.withTx(ctx, func(tx repositories) error {
+		kept, err := tx.transactions().get(ctx, id)
+		if err != nil {
+			return orNotFound(err, "transaction not found")
+		}
+		if err = by.checkVersion(kept); err != nil {
+			return err
+		}
+
+		duplicate, err := tx.transactions().get(ctx, duplicateID)
+		if err != nil {
+			return orNotFound(err, "duplicate transaction not found")
+		}
+		if duplicate.Type != kept.Type {
+			return invalidPayload("an income and an expense cannot be merged")
+		}
+		if err = checkNotReconciled(kept, duplicate); err != nil {
+			return err
+		}
+		if err = checkTransactionNotShared(ctx, tx, duplicateID); err != nil {
+			return err
+		}
+
+		payload := transactionPayload{
+			TransactionDate: kept.TransactionDate,
+			Type:            kept.Type,
+			Amount:          kept.Amount,
+			Notes:           combineNotes(kept.Notes, duplicate.Notes),
+			PersonID:        kept.PersonID,
+			BankAccountID:   kept.BankAccountID,
+			CategoryID:      kept.CategoryID,
+			SavingsGoalID:   kept.SavingsGoalID,
+		}
+		if payload.SavingsGoalID == nil {
+			payload.SavingsGoalID = duplicate.SavingsGoalID
+		}
+		if err = tx.transactions().update(ctx, id, payload); err != nil {
+			return transactionWriteError(err)
+		}
+		if err = unclearDeletedTransaction(ctx, tx, duplicate); err != nil {
+			return err
+		}
+		if err = tx.transactions().delete(ctx, duplicateID); err != nil {
+			return err
+		}
+
+		if merged, err = tx.transactions().get(ctx, id); err != nil {
+			return err
+		}
+
+		err = tx.audit().record(ctx, by.record(auditEntityTransactions, id, auditActionMerge,
+			kept,
+			transactionMergeSnapshot{transaction: merged, MergedFrom: duplicateID},
+		))
+		if err != nil {
+			return err
+		}
+		return tx.audit().record(ctx, by.record(auditEntityTransactions, duplicateID, auditActionMerge,
+			duplicate,
+			map[string]any{"merged_into": id},
+		))
+	})
+	if err != nil {
+		return transaction{}, err
+	}
+
+	return merged, nil
+}
+
+// transactionMergeSnapshot is the kept transaction as audited after a merge.
+type transactionMergeSnapshot struct {
+	transaction
+	MergedFrom int64 `json:"merged_from"`
+}
+
+// bookTransaction books a normalized payload whose references have been
+// checked, and audits it. The importer reuses it for every statement line.
+func bookTransaction(ctx context.Context, tx repositories, by change, payload transactionPayload) (transaction, error) {
+	id, err := tx.transactions().create(ctx, payload)
+	if err != nil {
+		return transaction{}, transactionWriteError(err)
+	}
+	if len(payload.Splits) > 0 {
+		if err = tx.transactions().replaceSplits(ctx, id, payload.Splits); err != nil {
+			return transaction{}, transactionWriteError(err)
+		}
+	}
+
+	created, err := tx.transactions().get(ctx, id)
+	if err != nil {
+		return transaction{}, err
+	}
+
+	if err = tx.audit().record(ctx, by.record(auditEntityTransactions, id, auditActionCreate, nil, created)); err != nil {
+		return transaction{}, err
+	}
+
+	return created, nil
+}
+
+// checkTransactionReferences names the first of the person, bank account and
+// category, of the people and categories of the split lines, or of the
+// savings goal, that does not exist. A goal must be saved for in the currency
+// of the bank account.
+func checkTransactionReferences(ctx context.Context, tx repositories, payload transactionPayload) error {
+	type reference struct {
+		table   string
+		id      int64
+		message string
+	}
+	references := []reference{
+		{auditEntityPeople, payload.PersonID, "person must exist"},
+		{auditEntityBankAccounts, payload.BankAccountID, "bank account must exist"},
+		{auditEntityTransactionCategories, payload.CategoryID, "transaction category must exist"},
+	}
+	for index, split := range payload.Splits {
+		references = append(references,
+			reference{auditEntityPeople, split.PersonID, fmt.Sprintf("splits[%d]: person must exist", index)},
+			reference{auditEntityTransactionCategories, split.CategoryID, fmt.Sprintf("splits[%d]: transaction category must exist", index)},
+		)
+	}
+
+	if payload.SavingsGoalID != nil {
+		references = append(references, reference{auditEntitySavingsGoals, *payload.SavingsGoalID, "savings goal must exist"})
+	}
+
+	for _, reference := range references {
+		exists, err := tx.trash().exists(ctx, reference.table, reference.id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return invalidPayload(reference.message)
+		}
+	}
+
+	if payload.SavingsGoalID != nil {
+		return checkSavingsGoalCurrency(ctx, tx, *payload.SavingsGoalID, payload.BankAccountID)
+	}
+
+	return nil
+}
+
+// checkNotReconciled refuses changes to transactions that a locked
+// reconciliation vouches for.
+func checkNotReconciled(items ...transaction) error {
+	for _, item := range items {
+		if item.ReconciliationStatus == transactionReconciled {
+			return conflict("transaction_reconciled", fmt.Sprintf("transaction %d is reconciled, unlock reconciliation %d to change it", item.ID, *item.ReconciliationID))
+		}
+	}
+
+	return nil
+}
+
+// checkTransactionNotShared refuses to delete a transaction that a shared
+// expense still splits between people.
+func checkTransactionNotShared(ctx context.Context, tx repositories, id int64) error {
+	inUse, err := tx.trash().hasDependents(ctx, auditEntityTransactions, id)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return conflict("transaction_in_use", fmt.Sprintf("transaction %d is shared, delete its shared expense first", id))
+	}
+
+	return nil
+}
+
+// unclearDeletedTransaction takes a transaction about to be soft deleted out
+// of its session, so restoring it later does not bring it back as cleared.
+func unclearDeletedTransaction(ctx context.Context, tx repositories, item transaction) error {
+	if item.ReconciliationID == nil {
+		return nil
+	}
+
+	return tx.transactions().setReconciliation(ctx, item.ID, nil, transactionUncleared)
+}
+
+func transactionWriteError(err error) error {
+	if errors.Is(err, errMissingReference) {
+		return invalidPayload("person, bank account and transaction category must exist")
+	}
+
+	return err
+}
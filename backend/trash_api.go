@@ -0,0 +1,242 @@
+package backend
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	trashPath       = "/api/trash"
+	trashPathByItem = "/api/trash/"
+)
+
+// softDeleteReference is a foreign key column pointing at another soft-deletable table.
+type softDeleteReference struct {
+	Column string
+	Table  string
+}
+
+// softDeleteChild is a table whose rows belong to a row of a soft-deletable
+// table. Its rows have no deleted_at of their own: they are live while their
+// parent is, are restored with it and are purged with it by ON DELETE CASCADE.
+type softDeleteChild struct {
+	Table        string
+	ParentColumn string
+	References   []softDeleteReference
+}
+
+type softDeleteTable struct {
+	Table      string
+	References []softDeleteReference
+	Children   []softDeleteChild
+	// Redact rewrites a trashed row the way the entity's own endpoints show
+	// it, for tables holding sensitive columns or their lookup hashes.
+	Redact func(fields *FieldCipher, data map[string]any) error
+}
+
+// softDeleteTables lists every table that supports soft deletes together with
+// the soft-deletable tables it references. It drives the trash endpoints, the
+// in-use checks performed before a soft delete and the reference checks
+// performed before writes and restores.
+var softDeleteTables = []softDeleteTable{
+	{Table: auditEntityCurrencies},
+	{Table: auditEntityBanks},
+	{Table: auditEntityBankAccounts, References: []softDeleteReference{
+		{Column: "bank_id", Table: auditEntityBanks},
+		{Column: "currency_id", Table: auditEntityCurrencies},
+	}, Redact: redactBankAccountRow},
+	{Table: auditEntityPeople},
+	{Table: auditEntityTransactionCategories, References: []softDeleteReference{
+		{Column: "parent_id", Table: auditEntityTransactionCategories},
+	}},
+	{Table: auditEntityReconciliations, References: []softDeleteReference{
+		{Column: "bank_account_id", Table: auditEntityBankAccounts},
+	}},
+	{Table: auditEntitySavingsGoals, References: []softDeleteReference{
+		{Column: "currency_id", Table: auditEntityCurrencies},
+	}, Children: []softDeleteChild{
+		{Table: savingsGoalAccountsTable, ParentColumn: "savings_goal_id", References: []softDeleteReference{
+			{Column: "bank_account_id", Table: auditEntityBankAccounts},
+		}},
+	}},
+	{Table: auditEntityTransactions, References: []softDeleteReference{
+		{Column: "person_id", Table: auditEntityPeople},
+		{Column: "bank_account_id", Table: auditEntityBankAccounts},
+		{Column: "category_id", Table: auditEntityTransactionCategories},
+		{Column: "reconciliation_id", Table: auditEntityReconciliations},
+		{Column: "savings_goal_id", Table: auditEntitySavingsGoals},
+	}, Children: []softDeleteChild{
+		{Table: transactionSplitsTable, ParentColumn: "transaction_id", References: []softDeleteReference{
+			{Column: "category_id", Table: auditEntityTransactionCategories},
+			{Column: "person_id", Table: auditEntityPeople},
+		}},
+	}},
+	{Table: auditEntitySharedExpenses, References: []softDeleteReference{
+		{Column: "transaction_id", Table: auditEntityTransactions},
+		{Column: "payer_id", Table: auditEntityPeople},
+	}, Children: []softDeleteChild{
+		{Table: sharedExpenseSharesTable, ParentColumn: "shared_expense_id", References: []softDeleteReference{
+			{Column: "person_id", Table: auditEntityPeople},
+		}},
+	}},
+	{Table: auditEntitySettlements, References: []softDeleteReference{
+		{Column: "from_person_id", Table: auditEntityPeople},
+		{Column: "to_person_id", Table: auditEntityPeople},
+		{Column: "currency_id", Table: auditEntityCurrencies},
+	}},
+	{Table: auditEntityLoans, References: []softDeleteReference{
+		{Column: "person_id", Table: auditEntityPeople},
+		{Column: "bank_id", Table: auditEntityBanks},
+		{Column: "currency_id", Table: auditEntityCurrencies},
+	}},
+	{Table: auditEntityLoanRepayments, References: []softDeleteReference{
+		{Column: "loan_id", Table: auditEntityLoans},
+		{Column: "bank_account_id", Table: auditEntityBankAccounts},
+	}},
+	{Table: auditEntityCreditCards, References: []softDeleteReference{
+		{Column: "bank_id", Table: auditEntityBanks},
+		{Column: "person_id", Table: auditEntityPeople},
+	}, Redact: redactCreditCardRow},
+	{Table: auditEntityCreditCardCycles, References: []softDeleteReference{
+		{Column: "credit_card_id", Table: auditEntityCreditCards},
+	}},
+	{Table: auditEntityCreditCardCycleBalances, References: []softDeleteReference{
+		{Column: "credit_card_cycle_id", Table: auditEntityCreditCardCycles},
+		{Column: "currency_id", Table: auditEntityCurrencies},
+	}},
+	{Table: auditEntityCreditCardInstallments, References: []softDeleteReference{
+		{Column: "credit_card_id", Table: auditEntityCreditCards},
+		{Column: "currency_id", Table: auditEntityCurrencies},
+	}},
+	{Table: auditEntityCreditCardSubscriptions, References: []softDeleteReference{
+		{Column: "credit_card_id", Table: auditEntityCreditCards},
+		{Column: "currency_id", Table: auditEntityCurrencies},
+	}},
+	{Table: auditEntityExpenses},
+	{Table: auditEntityExpensePayments, References: []softDeleteReference{
+		{Column: "expense_id", Table: auditEntityExpenses},
+		{Column: "currency_id", Table: auditEntityCurrencies},
+	}},
+}
+
+type trashItem struct {
+	Entity    string         `json:"entity"`
+	ID        int64          `json:"id"`
+	DeletedAt string         `json:"deleted_at"`
+	Data      map[string]any `json:"data"`
+}
+
+func (application app) registerTrashRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(trashPath, application.trashHandler)
+	mux.HandleFunc(trashPathByItem, application.trashItemHandler)
+}
+
+func (application app) trashHandler(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		application.listTrash(writer, request)
+	default:
+		methodNotAllowed(writer, http.MethodGet)
+	}
+}
+
+// trashItemHandler serves /api/trash/{entity}/{id} (purge) and
+// /api/trash/{entity}/{id}/restore.
+func (application app) trashItemHandler(writer http.ResponseWriter, request *http.Request) {
+	segments := strings.Split(strings.TrimPrefix(request.URL.Path, trashPathByItem), "/")
+	if len(segments) < 2 || len(segments) > 3 || (len(segments) == 3 && segments[2] != "restore") {
+		writeError(writer, http.StatusNotFound, "not_found", "trash route not found")
+		return
+	}
+
+	table, ok := findSoftDeleteTable(segments[0])
+	if !ok {
+		writeError(writer, http.StatusBadRequest, "invalid_entity", "entity does not support trash")
+		return
+	}
+
+	id, err := strconv.ParseInt(segments[1], 10, 64)
+	if err != nil || id <= 0 {
+		writeError(writer, http.StatusBadRequest, "invalid_id", "trash item id must be a positive integer")
+		return
+	}
+
+	if len(segments) == 3 {
+		if request.Method != http.MethodPost {
+			methodNotAllowed(writer, http.MethodPost)
+			return
+		}
+		application.restoreTrashItem(writer, request, table, id)
+		return
+	}
+
+	switch request.Method {
+	case http.MethodDelete:
+		application.purgeTrashItem(writer, request, table, id)
+	default:
+		methodNotAllowed(writer, http.MethodDelete)
+	}
+}
+
+func (application app) listTrash(writer http.ResponseWriter, request *http.Request) {
+	tables := softDeleteTables
+	if entity := strings.TrimSpace(request.URL.Query().Get("entity")); entity != "" {
+		table, ok := findSoftDeleteTable(entity)
+		if !ok {
+			writeError(writer, http.StatusBadRequest, "invalid_entity", "entity does not support trash")
+			return
+		}
+		tables = []softDeleteTable{table}
+	}
+
+	items, err := application.services().trash.list(request.Context(), tables)
+	if err != nil {
+		writeServiceError(writer, err, "failed to load trash")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, items)
+}
+
+func (application app) restoreTrashItem(writer http.ResponseWriter, request *http.Request, table softDeleteTable, id int64) {
+	if err := application.services().trash.restore(request.Context(), requestChange(request), table, id); err != nil {
+		writeServiceError(writer, err, "failed to restore trash item")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (application app) purgeTrashItem(writer http.ResponseWriter, request *http.Request, table softDeleteTable, id int64) {
+	if err := application.services().trash.purge(request.Context(), requestChange(request), table, id); err != nil {
+		writeServiceError(writer, err, "failed to purge trash item")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func findSoftDeleteTable(name string) (softDeleteTable, bool) {
+	for _, table := range softDeleteTables {
+		if table.Table == name {
+			return table, true
+		}
+	}
+
+	return softDeleteTable{}, false
+}
+
+// findSoftDeleteChild returns a child table together with the table owning
+// its rows.
+func findSoftDeleteChild(name string) (softDeleteChild, softDeleteTable, bool) {
+	for _, table := range softDeleteTables {
+		for _, child := range table.Children {
+			if child.Table == name {
+				return child, table, true
+			}
+		}
+	}
+
+	return softDeleteChild{}, softDeleteTable{}, false
+}
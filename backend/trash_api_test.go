@@ -0,0 +1,199 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTrashRestoreAndPurgeFlow(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+
+	createResponse := performRequest(
+		router,
+		http.MethodPost,
+		"/api/transactions",
+		[]byte(`{"transaction_date":"2026-02-18","type":"income","amount":10,"person_id":1,"bank_account_id":1,"category_id":1}`),
+	)
+	if createResponse.Code != http.StatusCreated {
+		t.Fatalf("expected create to return 201, got %d", createResponse.Code)
+	}
+
+	deleteResponse := performRequest(router, http.MethodDelete, "/api/transactions/1", nil)
+	if deleteResponse.Code != http.StatusNoContent {
+		t.Fatalf("expected delete to return 204, got %d", deleteResponse.Code)
+	}
+
+	var listed []transaction
+	listResponse := performRequest(router, http.MethodGet, "/api/transactions", nil)
+	if err := json.NewDecoder(listResponse.Body).Decode(&listed); err != nil {
+		t.Fatalf("decode transactions: %v", err)
+	}
+	if len(listed) != 0 {
+		t.Fatalf("expected deleted transaction to be hidden from list, got %d items", len(listed))
+	}
+
+	trashResponse := performRequest(router, http.MethodGet, "/api/trash?entity=transactions", nil)
+	if trashResponse.Code != http.StatusOK {
+		t.Fatalf("expected trash list to return 200, got %d", trashResponse.Code)
+	}
+	var items []trashItem
+	if err := json.NewDecoder(trashResponse.Body).Decode(&items); err != nil {
+		t.Fatalf("decode trash: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != 1 || items[0].DeletedAt == "" {
+		t.Fatalf("unexpected trash items: %+v", items)
+	}
+
+	restoreResponse := performRequest(router, http.MethodPost, "/api/trash/transactions/1/restore", nil)
+	if restoreResponse.Code != http.StatusNoContent {
+		t.Fatalf("expected restore to return 204, got %d", restoreResponse.Code)
+	}
+
+	getAfterRestore := performRequest(router, http.MethodGet, "/api/transactions/1", nil)
+	if getAfterRestore.Code != http.StatusOK {
+		t.Fatalf("expected restored transaction to be readable, got %d", getAfterRestore.Code)
+	}
+
+	purgeLiveItem := performRequest(router, http.MethodDelete, "/api/trash/transactions/1", nil)
+	if purgeLiveItem.Code != http.StatusNotFound {
+		t.Fatalf("expected purge of live item to return 404, got %d", purgeLiveItem.Code)
+	}
+
+	performRequest(router, http.MethodDelete, "/api/transactions/1", nil)
+	purgeResponse := performRequest(router, http.MethodDelete, "/api/trash/transactions/1", nil)
+	if purgeResponse.Code != http.StatusNoContent {
+		t.Fatalf("expected purge to return 204, got %d", purgeResponse.Code)
+	}
+
+	restoreAfterPurge := performRequest(router, http.MethodPost, "/api/trash/transactions/1/restore", nil)
+	if restoreAfterPurge.Code != http.StatusNotFound {
+		t.Fatalf("expected restore after purge to return 404, got %d", restoreAfterPurge.Code)
+	}
+}
+
+func TestTrashMasksSensitiveColumns(t *testing.T) {
+	useFastFieldKeys(t)
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+	if card := performRequest(router, http.MethodPost, "/api/credit-cards", []byte(`{"bank_id":1,"person_id":1,"number":"4111111111111111"}`)); card.Code != http.StatusCreated {
+		t.Fatalf("expected card create to return 201, got %d", card.Code)
+	}
+	if err := EnableFieldEncryption(context.Background(), application.db, "secret"); err != nil {
+		t.Fatalf("enable encryption: %v", err)
+	}
+	fields, err := LoadFieldCipher(context.Background(), application.db, "secret")
+	if err != nil {
+		t.Fatalf("load field cipher: %v", err)
+	}
+	application.fields = fields
+	router = application.routes()
+
+	for _, path := range []string{"/api/credit-cards/1", "/api/bank-accounts/1"} {
+		if response := performRequest(router, http.MethodDelete, path, nil); response.Code != http.StatusNoContent {
+			t.Fatalf("expected delete of %s to return 204, got %d", path, response.Code)
+		}
+	}
+
+	trashResponse := performRequest(router, http.MethodGet, "/api/trash", nil)
+	body := trashResponse.Body.String()
+	if trashResponse.Code != http.StatusOK || !strings.Contains(body, `"account_number":"**** -001"`) || !strings.Contains(body, `"number":"**** 1111"`) {
+		t.Fatalf("expected masked numbers in the trash, got %d %s", trashResponse.Code, body)
+	}
+	for _, secret := range []string{"ACC-001", "4111111111111111", "_lookup", encryptedFieldPrefix} {
+		if strings.Contains(body, secret) {
+			t.Fatalf("expected the trash to hide %q, got %s", secret, body)
+		}
+	}
+
+	for _, path := range []string{"/api/trash/credit_cards/1", "/api/trash/bank_accounts/1"} {
+		if response := performRequest(router, http.MethodDelete, path, nil); response.Code != http.StatusNoContent {
+			t.Fatalf("expected purge of %s to return 204, got %d", path, response.Code)
+		}
+	}
+	var leaked int
+	if err = application.db.QueryRow(
+		`SELECT COUNT(1) FROM audit_events WHERE changes LIKE '%ACC-001%' OR changes LIKE '%4111111111111111%' OR changes LIKE '%\_lookup%' ESCAPE '\' OR changes LIKE '%enc:v1:%'`,
+	).Scan(&leaked); err != nil {
+		t.Fatalf("search audit events: %v", err)
+	}
+	if leaked != 0 {
+		t.Fatalf("expected purge snapshots to hold masked numbers, found %d", leaked)
+	}
+}
+
+func TestSoftDeleteRejectsItemsInUse(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	seedTransactionDependencies(t, router)
+
+	deleteBank := performRequest(router, http.MethodDelete, "/api/banks/1", nil)
+	if deleteBank.Code != http.StatusConflict {
+		t.Fatalf("expected delete of bank with accounts to return 409, got %d", deleteBank.Code)
+	}
+
+	deleteAccount := performRequest(router, http.MethodDelete, "/api/bank-accounts/1", nil)
+	if deleteAccount.Code != http.StatusNoContent {
+		t.Fatalf("expected delete of unused bank account to return 204, got %d", deleteAccount.Code)
+	}
+
+	deleteBank = performRequest(router, http.MethodDelete, "/api/banks/1", nil)
+	if deleteBank.Code != http.StatusNoContent {
+		t.Fatalf("expected delete of unused bank to return 204, got %d", deleteBank.Code)
+	}
+
+	restoreAccount := performRequest(router, http.MethodPost, "/api/trash/bank_accounts/1/restore", nil)
+	if restoreAccount.Code != http.StatusConflict {
+		t.Fatalf("expected restore with deleted bank to return 409, got %d", restoreAccount.Code)
+	}
+
+	purgeBank := performRequest(router, http.MethodDelete, "/api/trash/banks/1", nil)
+	if purgeBank.Code != http.StatusConflict {
+		t.Fatalf("expected purge of referenced bank to return 409, got %d", purgeBank.Code)
+	}
+
+	newAccount := performRequest(
+		router,
+		http.MethodPost,
+		"/api/bank-accounts",
+		[]byte(`{"bank_id":1,"currency_id":1,"account_number":"ACC-002","balance":0}`),
+	)
+	if newAccount.Code != http.StatusBadRequest {
+		t.Fatalf("expected account referencing deleted bank to return 400, got %d", newAccount.Code)
+	}
+}
+
+func TestSoftDeletedRowsIgnoredByUniqueness(t *testing.T) {
+	application := newTestApplication(t)
+	router := application.routes()
+
+	first := performRequest(router, http.MethodPost, "/api/currencies", []byte(`{"name":"US Dollar","code":"USD"}`))
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected first currency to return 201, got %d", first.Code)
+	}
+
+	performRequest(router, http.MethodDelete, "/api/currencies/1", nil)
+
+	second := performRequest(router, http.MethodPost, "/api/currencies", []byte(`{"name":"US Dollar","code":"USD"}`))
+	if second.Code != http.StatusCreated {
+		t.Fatalf("expected recreate after soft delete to return 201, got %d", second.Code)
+	}
+
+	restore := performRequest(router, http.MethodPost, "/api/trash/currencies/1/restore", nil)
+	if restore.Code != http.StatusConflict {
+		t.Fatalf("expected restore with duplicate active row to return 409, got %d", restore.Code)
+	}
+
+	unknownEntity := performRequest(router, http.MethodGet, "/api/trash?entity=countries", nil)
+	if unknownEntity.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown trash entity to return 400, got %d", unknownEntity.Code)
+	}
+}
@@ -0,0 +1,187 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// trashRepository works on any table listed in softDeleteTables: it reads and
+// restores soft deleted rows and answers the liveness questions other
+// aggregates ask before they write.
+type trashRepository interface {
+	listDeleted(ctx context.Context, table softDeleteTable) ([]trashItem, error)
+	getDeleted(ctx context.Context, table softDeleteTable, id int64) (trashItem, error)
+	restore(ctx context.Context, table softDeleteTable, id int64) error
+	purge(ctx context.Context, table softDeleteTable, id int64) error
+	// exists reports whether the row exists and is not soft deleted.
+	exists(ctx context.Context, table string, id int64) (bool, error)
+	// referencesExist reports whether every soft-deletable row referenced by
+	// values (keyed by foreign key column) exists and has not been deleted.
+	referencesExist(ctx context.Context, table string, values map[string]int64) (bool, error)
+	// hasDependents reports whether any live row, or any child row of a live
+	// row, references the given row, which would be left dangling by a soft
+	// delete.
+	hasDependents(ctx context.Context, table string, id int64) (bool, error)
+	// deletedChildReference returns the first row referenced through the given
+	// column by the child rows of parentID that is missing or soft deleted.
+	deletedChildReference(ctx context.Context, child softDeleteChild, reference softDeleteReference, parentID int64) (int64, bool, error)
+}
+
+type sqlTrashRepository struct {
+	source queryer
+	fields *FieldCipher
+}
+
+func (repository sqlTrashRepository) listDeleted(ctx context.Context, table softDeleteTable) ([]trashItem, error) {
+	rows, err := repository.source.QueryContext(ctx, fmt.Sprintf(`SELECT * FROM %s WHERE deleted_at IS NOT NULL ORDER BY id`, table.Table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return repository.scanItems(table, rows)
+}
+
+func (repository sqlTrashRepository) getDeleted(ctx context.Context, table softDeleteTable, id int64) (trashItem, error) {
+	rows, err := repository.source.QueryContext(ctx, fmt.Sprintf(`SELECT * FROM %s WHERE id = ? AND deleted_at IS NOT NULL`, table.Table), id)
+	if err != nil {
+		return trashItem{}, err
+	}
+	defer rows.Close()
+
+	items, err := repository.scanItems(table, rows)
+	if err != nil {
+		return trashItem{}, err
+	}
+	if len(items) == 0 {
+		return trashItem{}, errNotFound
+	}
+
+	return items[0], nil
+}
+
+func (repository sqlTrashRepository) restore(ctx context.Context, table softDeleteTable, id int64) error {
+	_, err := repository.source.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, table.Table), id)
+	return constraintError(err)
+}
+
+func (repository sqlTrashRepository) purge(ctx context.Context, table softDeleteTable, id int64) error {
+	_, err := repository.source.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, table.Table), id)
+	return constraintError(err)
+}
+
+func (repository sqlTrashRepository) exists(ctx context.Context, table string, id int64) (bool, error) {
+	var storedID int64
+	err := repository.source.QueryRowContext(ctx, fmt.Sprintf(`SELECT id FROM %s WHERE id = ? AND deleted_at IS NULL`, table), id).Scan(&storedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (repository sqlTrashRepository) referencesExist(ctx context.Context, table string, values map[string]int64) (bool, error) {
+	definition, ok := findSoftDeleteTable(table)
+	if !ok {
+		return true, nil
+	}
+
+	for _, reference := range definition.References {
+		referencedID, present := values[reference.Column]
+		if !present {
+			continue
+		}
+		exists, err := repository.exists(ctx, reference.Table, referencedID)
+		if err != nil || !exists {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (repository sqlTrashRepository) hasDependents(ctx context.Context, table string, id int64) (bool, error) {
+	for _, dependent := range softDeleteTables {
+		for _, reference := range dependent.References {
+			if reference.Table != table {
+				continue
+			}
+
+			var count int64
+			query := fmt.Sprintf(`SELECT COUNT(1) FROM %s WHERE %s = ? AND deleted_at IS NULL`, dependent.Table, reference.Column)
+			if err := repository.source.QueryRowContext(ctx, query, id).Scan(&count); err != nil {
+				return false, err
+			}
+			if count > 0 {
+				return true, nil
+			}
+		}
+
+		for _, child := range dependent.Children {
+			for _, reference := range child.References {
+				if reference.Table != table {
+					continue
+				}
+
+				var count int64
+				query := fmt.Sprintf(
+					`SELECT COUNT(1) FROM %s c JOIN %s p ON p.id = c.%s WHERE c.%s = ? AND p.deleted_at IS NULL`,
+					child.Table, dependent.Table, child.ParentColumn, reference.Column,
+				)
+				if err := repository.source.QueryRowContext(ctx, query, id).Scan(&count); err != nil {
+					return false, err
+				}
+				if count > 0 {
+					return true, nil
+				}
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func (repository sqlTrashRepository) deletedChildReference(ctx context.Context, child softDeleteChild, reference softDeleteReference, parentID int64) (int64, bool, error) {
+	var referencedID int64
+	query := fmt.Sprintf(
+		`SELECT c.%[3]s FROM %[1]s c LEFT JOIN %[4]s r ON r.id = c.%[3]s AND r.deleted_at IS NULL WHERE c.%[2]s = ? AND r.id IS NULL ORDER BY c.id LIMIT 1`,
+		child.Table, child.ParentColumn, reference.Column, reference.Table,
+	)
+	err := repository.source.QueryRowContext(ctx, query, parentID).Scan(&referencedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return referencedID, true, nil
+}
+
+func (repository sqlTrashRepository) scanItems(table softDeleteTable, rows *sql.Rows) ([]trashItem, error) {
+	_, records, err := scanRowMaps(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]trashItem, 0, len(records))
+	for _, record := range records {
+		item := trashItem{Entity: table.Table, Data: record}
+		item.ID, _ = record["id"].(int64)
+		item.DeletedAt, _ = record["deleted_at"].(string)
+		delete(record, "deleted_at")
+		if table.Redact != nil {
+			if err = table.Redact(repository.fields, record); err != nil {
+				return nil, fmt.Errorf("%s %d: %w", table.Table, item.ID, err)
+			}
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
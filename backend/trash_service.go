@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type trashService struct {
+	store dataStore
+}
+
+func (service trashService) list(ctx context.Context, tables []softDeleteTable) ([]trashItem, error) {
+	repository := service.store.reads().trash()
+
+	items := make([]trashItem, 0)
+	for _, table := range tables {
+		tableItems, err := repository.listDeleted(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, tableItems...)
+	}
+
+	return items, nil
+}
+
+// restore undeletes a row once every row it references is live again.
+func (service trashService) restore(ctx context.Context, by change, table softDeleteTable, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.trash().getDeleted(ctx, table, id)
+		if errors.Is(err, errNotFound) {
+			return notFound("trash item not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, reference := range table.References {
+			referencedID, ok := existing.Data[reference.Column].(int64)
+			if !ok {
+				continue
+			}
+			exists, existsErr := tx.trash().exists(ctx, reference.Table, referencedID)
+			if existsErr != nil {
+				return existsErr
+			}
+			if !exists {
+				return conflict("restore_conflict", fmt.Sprintf("referenced %s %d must be restored first", reference.Table, referencedID))
+			}
+		}
+		for _, child := range table.Children {
+			for _, reference := range child.References {
+				referencedID, deleted, deletedErr := tx.trash().deletedChildReference(ctx, child, reference, id)
+				if deletedErr != nil {
+					return deletedErr
+				}
+				if deleted {
+					return conflict("restore_conflict", fmt.Sprintf("referenced %s %d must be restored first", reference.Table, referencedID))
+				}
+			}
+		}
+
+		err = tx.trash().restore(ctx, table, id)
+		if errors.Is(err, errDuplicate) {
+			return conflict("restore_conflict", "an active item with the same unique values already exists")
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(table.Table, id, auditActionRestore,
+			map[string]any{"deleted_at": existing.DeletedAt},
+			map[string]any{"deleted_at": nil},
+		))
+	})
+}
+
+// purge deletes a soft deleted row for good.
+func (service trashService) purge(ctx context.Context, by change, table softDeleteTable, id int64) error {
+	return service.store.withTx(ctx, func(tx repositories) error {
+		existing, err := tx.trash().getDeleted(ctx, table, id)
+		if errors.Is(err, errNotFound) {
+			return notFound("trash item not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.trash().purge(ctx, table, id)
+		if errors.Is(err, errMissingReference) {
+			return conflict("item_in_use", "trash item is still referenced by other records")
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.audit().record(ctx, by.record(table.Table, id, auditActionPurge, existing.Data, nil))
+	})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"personal-finances/backend"
+)
+
+// Default retention keeps a week of daily, a month of weekly and a year of
+// monthly backups.
+const (
+	defaultKeepDaily   = 7
+	defaultKeepWeekly  = 4
+	defaultKeepMonthly = 12
+)
+
+func runBackup(cli commandLine, args []string) error {
+	flags, databasePath := newFlagSet(cli, "backup")
+	output := flags.String("output", "", "write the backup to this file instead of the backup directory (no pruning)")
+	config := addBackupFlags(flags)
+	if positional, err := parseArgs(flags, args); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return errUsage
+	}
+
+	db, err := backend.OpenDatabase(*databasePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if *output != "" {
+		if err = backend.BackupDatabase(context.Background(), db, *output); err != nil {
+			return err
+		}
+		fmt.Fprintln(cli.stdout, "backup written to", *output)
+		return nil
+	}
+
+	backupConfig := config.resolve(*databasePath)
+	backup, removed, err := backend.CreateBackup(context.Background(), db, backupConfig, time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cli.stdout, "backup written to", filepath.Join(backupConfig.Dir, backup.Name))
+	for _, name := range removed {
+		fmt.Fprintln(cli.stdout, "pruned", name)
+	}
+	return nil
+}
+
+func runRestore(cli commandLine, args []string) error {
+	flags, databasePath := newFlagSet(cli, "restore")
+	at := flags.String("at", "", "restore the newest backup taken at or before this RFC 3339 time")
+	config := addBackupFlags(flags)
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if (len(positional) == 1) == (*at != "") || len(positional) > 1 {
+		return errUsage
+	}
+
+	source := ""
+	if *at != "" {
+		pointInTime, parseErr := time.Parse(time.RFC3339, *at)
+		if parseErr != nil {
+			return fmt.Errorf("-at must be an RFC 3339 time such as 2026-03-01T12:00:00Z")
+		}
+
+		backupConfig := config.resolve(*databasePath)
+		backup, findErr := backend.FindBackup(backupConfig.Dir, pointInTime)
+		if findErr != nil {
+			return findErr
+		}
+		source = filepath.Join(backupConfig.Dir, backup.Name)
+	} else {
+		source = positional[0]
+	}
+
+	if err = backend.RestoreDatabase(context.Background(), source, *databasePath, migrationFiles()); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cli.stdout, "database restored from", source)
+	return nil
+}
+
+// backupFlags holds the backup settings shared by serve, backup and restore.
+// Defaults come from BACKUP_DIR and BACKUP_KEEP_DAILY/WEEKLY/MONTHLY.
+type backupFlags struct {
+	dir     *string
+	daily   *int
+	weekly  *int
+	monthly *int
+}
+
+func addBackupFlags(flags *flag.FlagSet) backupFlags {
+	return backupFlags{
+		dir:     flags.String("backup-dir", strings.TrimSpace(os.Getenv("BACKUP_DIR")), "backup directory (default: backups/ next to the database)"),
+		daily:   flags.Int("keep-daily", defaultKeepDaily, "daily backups to keep"),
+		weekly:  flags.Int("keep-weekly", defaultKeepWeekly, "weekly backups to keep"),
+		monthly: flags.Int("keep-monthly", defaultKeepMonthly, "monthly backups to keep"),
+	}
+}
+
+func (config backupFlags) resolve(databasePath string) backend.BackupConfig {
+	dir := *config.dir
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(databasePath), "backups")
+	}
+
+	return backend.BackupConfig{
+		Dir: dir,
+		Retention: backend.BackupRetention{
+			Daily:   *config.daily,
+			Weekly:  *config.weekly,
+			Monthly: *config.monthly,
+		},
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const configFileEnv = "CONFIG_FILE"
+
+// flagEnvironment maps every flag that may be set in the config file to the
+// environment variable that overrides it. Settings are resolved as built-in
+// default < config file < environment variable < command line flag.
+var flagEnvironment = map[string]string{
+	"db":                  "DATABASE_PATH",
+	"port":                "PORT",
+	"listen":              "LISTEN_ADDRESS",
+	"read-header-timeout": "READ_HEADER_TIMEOUT",
+	"read-timeout":        "READ_TIMEOUT",
+	"write-timeout":       "WRITE_TIMEOUT",
+	"idle-timeout":        "IDLE_TIMEOUT",
+	"shutdown-timeout":    "SHUTDOWN_TIMEOUT",
+	"query-timeout":       "QUERY_TIMEOUT",
+	"max-body-bytes":      "MAX_BODY_BYTES",
+	"min-free-disk-bytes": "MIN_FREE_DISK_BYTES",
+	"backup-interval":     "BACKUP_INTERVAL",
+	"backup-dir":          "BACKUP_DIR",
+	"keep-daily":          "BACKUP_KEEP_DAILY",
+	"keep-weekly":         "BACKUP_KEEP_WEEKLY",
+	"keep-monthly":        "BACKUP_KEEP_MONTHLY",
+}
+
+// applyEnvironment sets the flags that were not given on the command line
+// from their environment variable. A malformed value is an error naming the
+// variable rather than a silent fallback to the default.
+func applyEnvironment(flags *flag.FlagSet) error {
+	explicit := map[string]bool{}
+	flags.Visit(func(item *flag.Flag) {
+		explicit[item.Name] = true
+	})
+
+	for name, variable := range flagEnvironment {
+		value := strings.TrimSpace(os.Getenv(variable))
+		if value == "" || explicit[name] || flags.Lookup(name) == nil {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("%s: invalid value %q for %s: %v", variable, value, name, err)
+		}
+	}
+
+	return nil
+}
+
+// applyConfigFile fills the flags that were neither given on the command line
+// nor through their environment variable from the file named by -config.
+// Keys the current command does not have are skipped, so one file can serve
+// every command.
+func applyConfigFile(flags *flag.FlagSet) error {
+	configFlag := flags.Lookup("config")
+	if configFlag == nil || configFlag.Value.String() == "" {
+		return nil
+	}
+
+	path := configFlag.Value.String()
+	values, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	explicit := map[string]bool{}
+	flags.Visit(func(item *flag.Flag) {
+		explicit[item.Name] = true
+	})
+
+	for _, entry := range values {
+		if explicit[entry.key] || strings.TrimSpace(os.Getenv(flagEnvironment[entry.key])) != "" || flags.Lookup(entry.key) == nil {
+			continue
+		}
+		if err := flags.Set(entry.key, entry.value); err != nil {
+			return fmt.Errorf("%s:%d: invalid value %q for %s: %v", path, entry.line, entry.value, entry.key, err)
+		}
+	}
+
+	return nil
+}
+
+type configEntry struct {
+	key   string
+	value string
+	line  int
+}
+
+// readConfigFile reads the flat subset of TOML the settings need: one
+// key = value pair per line, with quoted strings, bare numbers or booleans and
+// # comments. Keys are the flag names, e.g. read-timeout = "30s".
+func readConfigFile(path string) ([]configEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	defer file.Close()
+
+	entries := make([]configEntry, 0)
+	seen := map[string]int{}
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("%s:%d: tables are not supported, settings must be top-level keys", path, number)
+		}
+
+		key, raw, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected key = value", path, number)
+		}
+		if _, known := flagEnvironment[key]; !known {
+			return nil, fmt.Errorf("%s:%d: unknown setting %q", path, number, key)
+		}
+		if previous, duplicate := seen[key]; duplicate {
+			return nil, fmt.Errorf("%s:%d: %s is already set on line %d", path, number, key, previous)
+		}
+		seen[key] = number
+
+		value, err := parseConfigValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %v", path, number, key, err)
+		}
+		entries = append(entries, configEntry{key: key, value: value, line: number})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	return entries, nil
+}
+
+func parseConfigValue(raw string) (string, error) {
+	if strings.HasPrefix(raw, `"`) || strings.HasPrefix(raw, "'") {
+		end := closingQuote(raw)
+		if end < 0 || !isConfigComment(raw[end+1:]) {
+			return "", fmt.Errorf("unterminated string")
+		}
+		if raw[0] == '\'' {
+			return raw[1:end], nil
+		}
+		return strconv.Unquote(raw[:end+1])
+	}
+
+	value, _, _ := strings.Cut(raw, "#")
+	value = strings.ReplaceAll(strings.TrimSpace(value), "_", "")
+	if value == "true" || value == "false" {
+		return value, nil
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+		return "", fmt.Errorf("value must be a quoted string, an integer or a boolean")
+	}
+
+	return value, nil
+}
+
+// closingQuote returns the index of the quote ending the string that starts
+// raw. Basic ("...") strings may escape quotes, literal ('...') strings may not.
+func closingQuote(raw string) int {
+	for index := 1; index < len(raw); index++ {
+		if raw[0] == '"' && raw[index] == '\\' {
+			index++
+			continue
+		}
+		if raw[index] == raw[0] {
+			return index
+		}
+	}
+
+	return -1
+}
+
+func isConfigComment(rest string) bool {
+	rest = strings.TrimSpace(rest)
+	return rest == "" || strings.HasPrefix(rest, "#")
+}
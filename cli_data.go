@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"personal-finances/backend"
+)
+
+func runImport(cli commandLine, args []string) error {
+	flags, databasePath := newFlagSet(cli, "import")
+	accountID := flags.Int64("account", 0, "bank account id to book the statement on")
+	personID := flags.Int64("person", 0, "person id for the imported transactions")
+	categoryID := flags.Int64("category", 0, "transaction category id for the imported transactions")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || *accountID <= 0 || *personID <= 0 || *categoryID <= 0 {
+		return errUsage
+	}
+
+	statement, err := os.Open(positional[0])
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	db, err := backend.SetupDatabase(*databasePath, migrationFiles())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	result, err := backend.ImportTransactionsCSV(context.Background(), db, statement, backend.ImportOptions{
+		BankAccountID: *accountID,
+		PersonID:      *personID,
+		CategoryID:    *categoryID,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cli.stdout, "imported %d transactions\n", result.Imported)
+	return nil
+}
+
+func runExport(cli commandLine, args []string) error {
+	flags, databasePath := newFlagSet(cli, "export")
+	format := flags.String("format", "json", "output format: json or csv")
+	entity := flags.String("entity", "", "comma-separated tables to export (csv requires exactly one)")
+	output := flags.String("output", "", "output file (default: stdout)")
+	if positional, err := parseArgs(flags, args); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return errUsage
+	}
+
+	entities := make([]string, 0)
+	for _, name := range strings.Split(*entity, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			entities = append(entities, name)
+		}
+	}
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("format must be json or csv")
+	}
+	if *format == "csv" && len(entities) != 1 {
+		return fmt.Errorf("csv export needs exactly one -entity (one of %s)", strings.Join(backend.ExportEntities(), ", "))
+	}
+
+	db, err := backend.SetupDatabase(*databasePath, migrationFiles())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tables, err := backend.ExportData(context.Background(), db, entities)
+	if err != nil {
+		return err
+	}
+
+	destination := cli.stdout
+	if *output != "" {
+		file, createErr := os.Create(*output)
+		if createErr != nil {
+			return createErr
+		}
+		defer file.Close()
+		destination = file
+	}
+
+	if *format == "csv" {
+		return writeExportCSV(destination, tables[0])
+	}
+
+	encoder := json.NewEncoder(destination)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(tables)
+}
+
+func writeExportCSV(destination io.Writer, table backend.ExportTable) error {
+	writer := csv.NewWriter(destination)
+	if err := writer.Write(table.Columns); err != nil {
+		return err
+	}
+
+	for _, row := range table.Rows {
+		record := make([]string, len(table.Columns))
+		for index, column := range table.Columns {
+			record[index] = formatExportValue(row[column])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatExportValue(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case int64:
+		return strconv.FormatInt(typed, 10)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(typed)
+	default:
+		return fmt.Sprint(typed)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"personal-finances/backend"
+)
+
+// Passphrases are read from the environment rather than flags so they do not
+// show up in the process list or shell history.
+const (
+	encryptionPassphraseEnv    = "ENCRYPTION_PASSPHRASE"
+	encryptionNewPassphraseEnv = "ENCRYPTION_NEW_PASSPHRASE"
+)
+
+func runEncryption(cli commandLine, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	action := args[0]
+	flags, databasePath := newFlagSet(cli, "encryption "+action)
+	if positional, err := parseArgs(flags, args[1:]); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return errUsage
+	}
+
+	db, err := backend.SetupDatabase(*databasePath, migrationFiles())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	passphrase := os.Getenv(encryptionPassphraseEnv)
+	switch action {
+	case "enable":
+		if err = backend.EnableFieldEncryption(context.Background(), db, passphrase); err != nil {
+			return err
+		}
+		fmt.Fprintln(cli.stdout, "field encryption enabled; keep", encryptionPassphraseEnv, "set when starting the server")
+	case "rotate":
+		newPassphrase := os.Getenv(encryptionNewPassphraseEnv)
+		if newPassphrase == "" {
+			newPassphrase = passphrase
+		}
+		if err = backend.RotateFieldEncryption(context.Background(), db, passphrase, newPassphrase); err != nil {
+			return err
+		}
+		fmt.Fprintln(cli.stdout, "field encryption key rotated")
+	case "disable":
+		if err = backend.DisableFieldEncryption(context.Background(), db, passphrase); err != nil {
+			return err
+		}
+		fmt.Fprintln(cli.stdout, "field encryption disabled; sensitive columns are stored as plaintext")
+	case "status":
+		enabled, statusErr := backend.FieldEncryptionEnabled(context.Background(), db)
+		if statusErr != nil {
+			return statusErr
+		}
+		if enabled {
+			fmt.Fprintln(cli.stdout, "field encryption is enabled")
+		} else {
+			fmt.Fprintln(cli.stdout, "field encryption is disabled")
+		}
+	default:
+		return errUsage
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"personal-finances/backend"
+)
+
+func runIntegrity(cli commandLine, args []string) error {
+	flags, databasePath := newFlagSet(cli, "integrity")
+	check := flags.String("check", "", "comma-separated checks to run (default: all)")
+	format := flags.String("format", "text", "output format: text or json")
+	if positional, err := parseArgs(flags, args); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return errUsage
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("format must be text or json")
+	}
+
+	names := make([]string, 0)
+	for _, name := range strings.Split(*check, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	db, err := backend.SetupDatabase(*databasePath, migrationFiles())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	report, err := backend.RunIntegrityChecks(context.Background(), db, names)
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(cli.stdout)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(report); err != nil {
+			return err
+		}
+	} else if err = writeIntegrityReport(cli, report); err != nil {
+		return err
+	}
+
+	// Errors and checks that could not run make the command fail, so a
+	// scheduled run can alert on them; warnings and notices do not.
+	if report.Status == "failed" {
+		return fmt.Errorf("some integrity checks could not run")
+	}
+	if errors := report.Count(backend.IntegrityError); errors > 0 {
+		return fmt.Errorf("found %d integrity errors", errors)
+	}
+	return nil
+}
+
+func writeIntegrityReport(cli commandLine, report backend.IntegrityReport) error {
+	table := tabwriter.NewWriter(cli.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "CHECK\tSTATUS\tFINDINGS")
+	for _, result := range report.Checks {
+		status := result.Status
+		if result.Error != "" {
+			status += ": " + result.Error
+		}
+		fmt.Fprintf(table, "%s\t%s\t%d\n", result.Name, status, result.Findings)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	for _, finding := range report.Findings {
+		links := make([]string, 0, len(finding.Entities))
+		for _, entity := range finding.Entities {
+			if entity.Link == "" {
+				links = append(links, fmt.Sprintf("%s #%d", entity.Entity, entity.ID))
+				continue
+			}
+			links = append(links, entity.Link)
+		}
+		fmt.Fprintf(cli.stdout, "\n%s [%s] %s\n", strings.ToUpper(string(finding.Severity)), finding.Check, finding.Message)
+		if len(links) > 0 {
+			fmt.Fprintf(cli.stdout, "  see: %s\n", strings.Join(links, " "))
+		}
+		fmt.Fprintf(cli.stdout, "  repair: %s\n", finding.Repair)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"personal-finances/backend"
+)
+
+func runMigrate(cli commandLine, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	action := args[0]
+	flags, databasePath := newFlagSet(cli, "migrate "+action)
+	dryRun := flags.Bool("dry-run", false, "print pending migrations without applying them")
+	targetVersion := flags.Int("to", -1, "version to roll back to (down only)")
+	if positional, err := parseArgs(flags, args[1:]); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return errUsage
+	}
+
+	db, err := backend.OpenDatabase(*databasePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch action {
+	case "up":
+		if *dryRun {
+			pending, err := backend.PendingMigrations(context.Background(), db, migrationFiles())
+			if err != nil {
+				return err
+			}
+			return printMigrationNames(cli, "pending", pending, "No pending migrations")
+		}
+
+		applied, err := backend.ApplyMigrations(db, migrationFiles())
+		if err != nil {
+			return err
+		}
+		return printMigrationNames(cli, "applied", applied, "No pending migrations")
+	case "down":
+		if *targetVersion < 0 {
+			return errUsage
+		}
+
+		reverted, err := backend.RollbackMigrations(db, migrationFiles(), *targetVersion)
+		if err != nil {
+			return err
+		}
+		return printMigrationNames(cli, "reverted", reverted, "No migrations to revert")
+	case "status":
+		statuses, err := backend.MigrationStatuses(context.Background(), db, migrationFiles())
+		if err != nil {
+			return err
+		}
+
+		table := tabwriter.NewWriter(cli.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "VERSION\tNAME\tSTATUS\tAPPLIED AT\tDOWN")
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			down := "no"
+			if status.Reversible {
+				down = "yes"
+			}
+			fmt.Fprintf(table, "%03d\t%s\t%s\t%s\t%s\n", status.Version, status.Name, state, status.AppliedAt, down)
+		}
+		return table.Flush()
+	default:
+		return errUsage
+	}
+}
+
+func printMigrationNames(cli commandLine, verb string, names []string, empty string) error {
+	if len(names) == 0 {
+		fmt.Fprintln(cli.stdout, empty)
+		return nil
+	}
+
+	for _, name := range names {
+		fmt.Fprintln(cli.stdout, verb, name)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"personal-finances/backend"
+)
+
+// breakdownReports are the report kinds grouped by category or person, which
+// count split transactions through their split lines.
+var breakdownReports = map[string]struct {
+	heading string
+	run     func(context.Context, *sql.DB, int) ([]backend.BreakdownSummary, error)
+}{
+	"categories": {heading: "CATEGORY", run: backend.CategoryReport},
+	"people":     {heading: "PERSON", run: backend.PersonReport},
+}
+
+func runReport(cli commandLine, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	breakdown, isBreakdown := breakdownReports[args[0]]
+	if args[0] != "monthly" && !isBreakdown {
+		return errUsage
+	}
+
+	flags, databasePath := newFlagSet(cli, "report "+args[0])
+	year := flags.Int("year", time.Now().Year(), "calendar year to report")
+	if positional, err := parseArgs(flags, args[1:]); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return errUsage
+	}
+
+	db, err := backend.SetupDatabase(*databasePath, migrationFiles())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if isBreakdown {
+		return printBreakdownReport(cli, db, *year, breakdown.heading, breakdown.run)
+	}
+
+	summaries, err := backend.MonthlyReport(context.Background(), db, *year)
+	if err != nil {
+		return err
+	}
+	if len(summaries) == 0 {
+		fmt.Fprintf(cli.stdout, "No transactions in %d\n", *year)
+		return nil
+	}
+
+	table := tabwriter.NewWriter(cli.stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "MONTH\tCURRENCY\tINCOME\tEXPENSE\tNET\tTRANSACTIONS\t")
+	for _, summary := range summaries {
+		fmt.Fprintf(table, "%s\t%s\t%.2f\t%.2f\t%.2f\t%d\t\n", summary.Month, summary.Currency, summary.Income, summary.Expense, summary.Net, summary.Transactions)
+	}
+	return table.Flush()
+}
+
+func printBreakdownReport(cli commandLine, db *sql.DB, year int, heading string, run func(context.Context, *sql.DB, int) ([]backend.BreakdownSummary, error)) error {
+	summaries, err := run(context.Background(), db, year)
+	if err != nil {
+		return err
+	}
+	if len(summaries) == 0 {
+		fmt.Fprintf(cli.stdout, "No transactions in %d\n", year)
+		return nil
+	}
+
+	table := tabwriter.NewWriter(cli.stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(table, "%s\tCURRENCY\tINCOME\tEXPENSE\tNET\tLINES\t\n", heading)
+	for _, summary := range summaries {
+		fmt.Fprintf(table, "%s\t%s\t%.2f\t%.2f\t%.2f\t%d\t\n", summary.Name, summary.Currency, summary.Income, summary.Expense, summary.Net, summary.Lines)
+	}
+	return table.Flush()
+}
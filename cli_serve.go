@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"personal-finances/backend"
+)
+
+const (
+	defaultServerPort        = "8080"
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultReadTimeout       = 30 * time.Second
+	defaultWriteTimeout      = 60 * time.Second
+	defaultIdleTimeout       = 120 * time.Second
+	defaultShutdownTimeout   = 30 * time.Second
+	defaultQueryTimeout      = 30 * time.Second
+	defaultMaxBodyBytes      = 10 << 20
+)
+
+// serveConfig holds the resolved settings of the serve command.
+type serveConfig struct {
+	databasePath      string
+	address           string
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	shutdownTimeout   time.Duration
+	queryTimeout      time.Duration
+	maxBodyBytes      int64
+	minFreeDiskBytes  uint64
+	backupInterval    time.Duration
+	backups           backend.BackupConfig
+}
+
+func runServe(cli commandLine, args []string) error {
+	config, err := parseServeConfig(cli, args)
+	if err != nil {
+		return err
+	}
+
+	// Catch SIGINT/SIGTERM before migrating, so a signal during startup lets
+	// the migration finish instead of killing the process half way.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	pool, err := backend.SetupDatabasePool(config.databasePath, migrationFiles())
+	if err != nil {
+		return err
+	}
+	db := pool.Writer
+	defer func() {
+		if closeErr := pool.Close(); closeErr != nil {
+			logger.Error("closing database failed", slog.Any("error", closeErr))
+		}
+	}()
+	if ctx.Err() != nil {
+		logger.Info("stopped before serving")
+		return nil
+	}
+
+	fields, err := backend.LoadFieldCipher(ctx, db, os.Getenv(encryptionPassphraseEnv))
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", config.address)
+	if err != nil {
+		return err
+	}
+
+	// A backup that is running when the server stops finishes before the
+	// database is closed.
+	if config.backupInterval > 0 {
+		logger.Info("scheduled backups enabled", slog.String("dir", config.backups.Dir), slog.Duration("interval", config.backupInterval))
+		scheduleCtx, stopSchedule := context.WithCancel(ctx)
+		scheduleDone := make(chan struct{})
+		go func() {
+			defer close(scheduleDone)
+			backend.RunBackupSchedule(scheduleCtx, db, config.backups, config.backupInterval)
+		}()
+		defer func() {
+			stopSchedule()
+			<-scheduleDone
+		}()
+	}
+
+	server := &http.Server{
+		Handler: backend.NewMux(db, webFiles(), backend.Options{
+			Reader:           pool.Reader,
+			Backups:          config.backups,
+			Fields:           fields,
+			Logger:           logger,
+			MaxBodyBytes:     config.maxBodyBytes,
+			QueryTimeout:     config.queryTimeout,
+			Migrations:       migrationFiles(),
+			DataDir:          config.dataDir(),
+			MinFreeDiskBytes: config.minFreeDiskBytes,
+		}),
+		ReadHeaderTimeout: config.readHeaderTimeout,
+		ReadTimeout:       config.readTimeout,
+		WriteTimeout:      config.writeTimeout,
+		IdleTimeout:       config.idleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	logger.Info("server is running", slog.String("address", listener.Addr().String()))
+	return serveUntilStopped(ctx, server, listener, config.shutdownTimeout)
+}
+
+// parseServeConfig resolves the serve settings from flags, environment
+// variables and the config file.
+func parseServeConfig(cli commandLine, args []string) (serveConfig, error) {
+	flags, databasePath := newFlagSet(cli, "serve")
+	port := flags.String("port", defaultPort(), "HTTP listen port, used when -listen is empty")
+	listen := flags.String("listen", strings.TrimSpace(os.Getenv("LISTEN_ADDRESS")), "HTTP listen address such as 127.0.0.1:8080 (default: all interfaces on -port)")
+	readHeaderTimeout := flags.Duration("read-header-timeout", defaultReadHeaderTimeout, "time allowed to read request headers")
+	readTimeout := flags.Duration("read-timeout", defaultReadTimeout, "time allowed to read a whole request")
+	writeTimeout := flags.Duration("write-timeout", defaultWriteTimeout, "time allowed to write a response")
+	idleTimeout := flags.Duration("idle-timeout", defaultIdleTimeout, "how long keep-alive connections stay open between requests")
+	shutdownTimeout := flags.Duration("shutdown-timeout", defaultShutdownTimeout, "how long to wait for in-flight requests on shutdown")
+	queryTimeout := flags.Duration("query-timeout", defaultQueryTimeout, "deadline for the database work of one API request")
+	maxBodyBytes := flags.Int64("max-body-bytes", defaultMaxBodyBytes, "largest accepted request body in bytes (0: unlimited)")
+	minFreeDiskBytes := flags.Uint64("min-free-disk-bytes", backend.DefaultMinFreeDiskBytes, "free space the database directory needs for /api/health/ready to pass")
+	backupInterval := flags.String("backup-interval", strings.TrimSpace(os.Getenv("BACKUP_INTERVAL")), "take a backup this often, e.g. 24h (default: no scheduled backups)")
+	backups := addBackupFlags(flags)
+	if positional, err := parseArgs(flags, args); err != nil {
+		return serveConfig{}, err
+	} else if len(positional) > 0 {
+		return serveConfig{}, errUsage
+	}
+
+	interval := time.Duration(0)
+	if *backupInterval != "" {
+		parsed, err := time.ParseDuration(*backupInterval)
+		if err != nil || parsed <= 0 {
+			return serveConfig{}, fmt.Errorf("backup interval must be a positive duration such as 24h")
+		}
+		interval = parsed
+		if backend.IsPostgresDSN(*databasePath) {
+			return serveConfig{}, fmt.Errorf("scheduled backups need a SQLite database, back up PostgreSQL with pg_dump")
+		}
+	}
+
+	for name, value := range map[string]time.Duration{
+		"read-header-timeout": *readHeaderTimeout,
+		"read-timeout":        *readTimeout,
+		"write-timeout":       *writeTimeout,
+		"idle-timeout":        *idleTimeout,
+		"shutdown-timeout":    *shutdownTimeout,
+		"query-timeout":       *queryTimeout,
+	} {
+		if value <= 0 {
+			return serveConfig{}, fmt.Errorf("%s must be a positive duration", name)
+		}
+	}
+	if *maxBodyBytes < 0 {
+		return serveConfig{}, fmt.Errorf("max-body-bytes must not be negative")
+	}
+	if *minFreeDiskBytes == 0 {
+		return serveConfig{}, fmt.Errorf("min-free-disk-bytes must be positive")
+	}
+
+	address := strings.TrimSpace(*listen)
+	if address == "" {
+		address = ":" + strings.TrimPrefix(strings.TrimSpace(*port), ":")
+	}
+
+	return serveConfig{
+		databasePath:      *databasePath,
+		address:           address,
+		readHeaderTimeout: *readHeaderTimeout,
+		readTimeout:       *readTimeout,
+		writeTimeout:      *writeTimeout,
+		idleTimeout:       *idleTimeout,
+		shutdownTimeout:   *shutdownTimeout,
+		queryTimeout:      *queryTimeout,
+		maxBodyBytes:      *maxBodyBytes,
+		minFreeDiskBytes:  *minFreeDiskBytes,
+		backupInterval:    interval,
+		backups:           backups.resolve(*databasePath),
+	}, nil
+}
+
+// dataDir is the directory holding the SQLite database, whose free space the
+// readiness check watches. A PostgreSQL server's disk is not ours to watch.
+func (config serveConfig) dataDir() string {
+	if backend.IsPostgresDSN(config.databasePath) {
+		return ""
+	}
+
+	return filepath.Dir(config.databasePath)
+}
+
+// serveUntilStopped serves on listener until ctx is cancelled, then stops
+// accepting connections and waits up to shutdownTimeout for in-flight
+// requests to finish.
+func serveUntilStopped(ctx context.Context, server *http.Server, listener net.Listener, shutdownTimeout time.Duration) error {
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down", slog.Duration("timeout", shutdownTimeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	slog.Info("server stopped")
+	return nil
+}
+
+func defaultPort() string {
+	serverPort := strings.TrimSpace(os.Getenv("PORT"))
+	if serverPort == "" {
+		return defaultServerPort
+	}
+
+	return serverPort
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeConfigPrecedence(t *testing.T) {
+	for _, name := range flagEnvironment {
+		t.Setenv(name, "")
+	}
+	t.Setenv(configFileEnv, "")
+
+	configPath := filepath.Join(t.TempDir(), "personal-finances.toml")
+	content := strings.Join([]string{
+		"# server settings",
+		`db = "/srv/finances/finances.db"`,
+		`listen = '127.0.0.1:9000'  # loopback only`,
+		`read-timeout = "10s"`,
+		`write-timeout = "2m"`,
+		"max-body-bytes = 1_048_576",
+		"keep-daily = 3",
+		"",
+	}, "\n")
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	t.Setenv("WRITE_TIMEOUT", "45s")
+	config, err := parseServeConfig(commandLine{stdout: io.Discard, stderr: io.Discard}, []string{"-config", configPath, "-max-body-bytes", "2048"})
+	if err != nil {
+		t.Fatalf("parse serve config: %v", err)
+	}
+
+	if config.databasePath != "/srv/finances/finances.db" || config.address != "127.0.0.1:9000" || config.readTimeout != 10*time.Second {
+		t.Fatalf("expected config file values, got %+v", config)
+	}
+	if config.writeTimeout != 45*time.Second {
+		t.Fatalf("expected environment to override the config file, got %s", config.writeTimeout)
+	}
+	if config.maxBodyBytes != 2048 {
+		t.Fatalf("expected flag to override the config file, got %d", config.maxBodyBytes)
+	}
+	if config.backups.Retention.Daily != 3 || config.backups.Dir != filepath.Join("/srv/finances", "backups") {
+		t.Fatalf("expected backup settings from the config file, got %+v", config.backups)
+	}
+	if config.idleTimeout != defaultIdleTimeout || config.shutdownTimeout != defaultShutdownTimeout {
+		t.Fatalf("expected defaults for unset values, got %+v", config)
+	}
+
+	t.Setenv(configFileEnv, configPath)
+	t.Setenv("PORT", "7000")
+	if config, err = parseServeConfig(commandLine{stdout: io.Discard, stderr: io.Discard}, []string{"-listen", ""}); err != nil {
+		t.Fatalf("parse serve config from CONFIG_FILE: %v", err)
+	}
+	if config.address != ":7000" || config.readTimeout != 10*time.Second {
+		t.Fatalf("expected CONFIG_FILE to be read and -listen \"\" to fall back to PORT, got %+v", config)
+	}
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	for _, name := range flagEnvironment {
+		t.Setenv(name, "")
+	}
+
+	cases := map[string]string{
+		"unknown setting":                         "colour = \"blue\"\n",
+		"tables are not supported":                "[server]\nlisten = \":80\"\n",
+		"expected key = value":                    "listen\n",
+		"already set on line 1":                   "listen = \":80\"\nlisten = \":81\"\n",
+		"unterminated string":                     "listen = \":80\n",
+		"must be a quoted string":                 "read-timeout = 10s\n",
+		"invalid value \"soon\" for read-timeout": "read-timeout = \"soon\"\n",
+	}
+	for expected, content := range cases {
+		configPath := filepath.Join(t.TempDir(), "config.toml")
+		if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+
+		_, err := parseServeConfig(commandLine{stdout: io.Discard, stderr: io.Discard}, []string{"-config", configPath})
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected %q error for %q, got %v", expected, content, err)
+		}
+	}
+}
+
+func TestEnvironmentErrors(t *testing.T) {
+	for _, name := range flagEnvironment {
+		t.Setenv(name, "")
+	}
+	t.Setenv(configFileEnv, "")
+
+	cases := map[string]string{
+		"READ_TIMEOUT":        "30",
+		"MAX_BODY_BYTES":      "1MB",
+		"MIN_FREE_DISK_BYTES": "-1",
+		"BACKUP_KEEP_DAILY":   "seven",
+	}
+	for variable, value := range cases {
+		t.Run(variable, func(t *testing.T) {
+			t.Setenv(variable, value)
+
+			_, err := parseServeConfig(commandLine{stdout: io.Discard, stderr: io.Discard}, nil)
+			expected := variable + ": invalid value \"" + value + "\""
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Fatalf("expected %q error, got %v", expected, err)
+			}
+		})
+	}
+
+	t.Setenv("READ_TIMEOUT", "30")
+	if _, err := parseServeConfig(commandLine{stdout: io.Discard, stderr: io.Discard}, []string{"-read-timeout", "30s"}); err != nil {
+		t.Fatalf("expected a flag to take over a malformed environment value, got %v", err)
+	}
+}
+
+func TestServeUntilStoppedDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(writer, "done")
+	})}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	address := "http://" + listener.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- serveUntilStopped(ctx, server, listener, 5*time.Second)
+	}()
+
+	responses := make(chan string, 1)
+	go func() {
+		response, err := http.Get(address)
+		if err != nil {
+			responses <- "error: " + err.Error()
+			return
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		responses <- string(body)
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-stopped:
+		t.Fatalf("expected shutdown to wait for the in-flight request, returned %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	if body := <-responses; body != "done" {
+		t.Fatalf("expected in-flight request to complete, got %q", body)
+	}
+	if err := <-stopped; err != nil {
+		t.Fatalf("expected clean shutdown, got %v", err)
+	}
+	if _, err := http.Get(address); err == nil {
+		t.Fatal("expected the listener to be closed after shutdown")
+	}
+}
@@ -1,5 +1,5 @@
 const { test, expect } = require("@playwright/test");
-const { openApp, openSettingsSection } = require("./helpers");
+const { openApp, openSettingsSection, uniqueCardNumber, maskedCardNumber } = require("./helpers");
 
 function uniqueSuffix() {
   return `${Date.now()}_${Math.floor(Math.random() * 100000)}`;
@@ -19,7 +19,7 @@ test("credit card cycle CRUD flow works end-to-end", async ({ page }) => {
   const suffix = uniqueSuffix();
   const bankName = `Cycle Bank ${suffix}`;
   const personName = `Cycle Person ${suffix}`;
-  const cardNumber = `CYCLE-${suffix}`;
+  const cardNumber = uniqueCardNumber();
 
   await openApp(page);
 
@@ -59,14 +59,14 @@ test("credit card cycle CRUD flow works end-to-end", async ({ page }) => {
   await creditCardForm.getByRole("button", { name: "Create" }).click();
 
   await expect(page.locator("#credit-card-form-message")).toHaveText("Credit card created");
-  await expect(page.locator("#credit-cards-body")).toContainText(cardNumber);
+  await expect(page.locator("#credit-cards-body")).toContainText(maskedCardNumber(cardNumber));
 
   await page.locator('[data-credit-card-tab="cycles"]').click();
 
   await page.getByRole("button", { name: "Create credit card cycle" }).click();
 
   const cycleForm = page.locator("#credit-card-cycle-form");
-  await selectOptionContaining(cycleForm.getByLabel("Credit Card"), cardNumber);
+  await selectOptionContaining(cycleForm.getByLabel("Credit Card"), maskedCardNumber(cardNumber));
   await cycleForm.getByLabel("Closing Date").fill("2026-03-20");
   await cycleForm.getByLabel("Due Date").fill("2026-03-30");
   await cycleForm.getByRole("button", { name: "Create" }).click();
@@ -129,7 +129,7 @@ test("credit card cycle validates due date is on or after closing date", async (
   const suffix = uniqueSuffix();
   const bankName = `Cycle Validation Bank ${suffix}`;
   const personName = `Cycle Validation Person ${suffix}`;
-  const cardNumber = `CYCLE-V-${suffix}`;
+  const cardNumber = uniqueCardNumber();
 
   await openApp(page);
 
@@ -166,7 +166,7 @@ test("credit card cycle validates due date is on or after closing date", async (
   await page.getByRole("button", { name: "Create credit card cycle" }).click();
 
   const cycleForm = page.locator("#credit-card-cycle-form");
-  await selectOptionContaining(cycleForm.getByLabel("Credit Card"), cardNumber);
+  await selectOptionContaining(cycleForm.getByLabel("Credit Card"), maskedCardNumber(cardNumber));
   await cycleForm.getByLabel("Closing Date").fill("2026-08-20");
   await cycleForm.getByLabel("Due Date").fill("2026-08-10");
   await cycleForm.getByRole("button", { name: "Create" }).click();
@@ -1,5 +1,5 @@
 const { test, expect } = require("@playwright/test");
-const { openApp, openSettingsSection, uniqueCurrencyCode } = require("./helpers");
+const { openApp, openSettingsSection, uniqueCurrencyCode, uniqueCardNumber, maskedCardNumber } = require("./helpers");
 
 function uniqueSuffix() {
   return `${Date.now()}_${Math.floor(Math.random() * 100000)}`;
@@ -43,7 +43,7 @@ test("credit card installment CRUD flow works end-to-end", async ({ page }) => {
   const suffix = uniqueSuffix();
   const bankName = `Installment Bank ${suffix}`;
   const personName = `Installment Person ${suffix}`;
-  const cardNumber = `INST-${suffix}`;
+  const cardNumber = uniqueCardNumber();
   const concept = `Laptop ${suffix}`;
   const updatedConcept = `Laptop Updated ${suffix}`;
   const currencyName = `Installment Currency ${suffix}`;
@@ -92,7 +92,7 @@ test("credit card installment CRUD flow works end-to-end", async ({ page }) => {
   await page.getByRole("button", { name: "Create credit card installment" }).click();
 
   const installmentForm = page.locator("#credit-card-installment-form");
-  await selectOptionContaining(installmentForm.getByLabel("Credit Card"), cardNumber);
+  await selectOptionContaining(installmentForm.getByLabel("Credit Card"), maskedCardNumber(cardNumber));
   await installmentForm.getByLabel("Currency").selectOption({ label: `${currencyCode} (${currencyName})` });
   await installmentForm.getByLabel("Concept").fill(concept);
   await installmentForm.getByLabel("Amount").fill("350.75");
@@ -131,7 +131,7 @@ test("duplicate credit card installment concept per card is blocked", async ({ p
   const suffix = uniqueSuffix();
   const bankName = `Installment Dup Bank ${suffix}`;
   const personName = `Installment Dup Person ${suffix}`;
-  const cardNumber = `INST-DUP-${suffix}`;
+  const cardNumber = uniqueCardNumber();
   const concept = `Phone ${suffix}`;
   const currencyName = `Installment Dup Currency ${suffix}`;
   const currencyCode = uniqueCurrencyCode("D");
@@ -178,7 +178,7 @@ test("duplicate credit card installment concept per card is blocked", async ({ p
   await page.getByRole("button", { name: "Create credit card installment" }).click();
 
   const installmentForm = page.locator("#credit-card-installment-form");
-  await selectOptionContaining(installmentForm.getByLabel("Credit Card"), cardNumber);
+  await selectOptionContaining(installmentForm.getByLabel("Credit Card"), maskedCardNumber(cardNumber));
   await installmentForm.getByLabel("Currency").selectOption({ label: `${currencyCode} (${currencyName})` });
   await installmentForm.getByLabel("Concept").fill(concept);
   await installmentForm.getByLabel("Amount").fill("99.99");
@@ -189,7 +189,7 @@ test("duplicate credit card installment concept per card is blocked", async ({ p
 
   await page.getByRole("button", { name: "Create credit card installment" }).click();
 
-  await selectOptionContaining(installmentForm.getByLabel("Credit Card"), cardNumber);
+  await selectOptionContaining(installmentForm.getByLabel("Credit Card"), maskedCardNumber(cardNumber));
   await installmentForm.getByLabel("Currency").selectOption({ label: `${currencyCode} (${currencyName})` });
   await installmentForm.getByLabel("Concept").fill(concept);
   await installmentForm.getByLabel("Amount").fill("200");
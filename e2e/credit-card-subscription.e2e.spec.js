@@ -1,5 +1,5 @@
 const { test, expect } = require("@playwright/test");
-const { openApp, openSettingsSection, uniqueCurrencyCode } = require("./helpers");
+const { openApp, openSettingsSection, uniqueCurrencyCode, uniqueCardNumber, maskedCardNumber } = require("./helpers");
 
 function uniqueSuffix() {
   return `${Date.now()}_${Math.floor(Math.random() * 100000)}`;
@@ -19,7 +19,7 @@ test("credit card subscription CRUD flow works end-to-end", async ({ page }) =>
   const suffix = uniqueSuffix();
   const bankName = `Subscription Bank ${suffix}`;
   const personName = `Subscription Person ${suffix}`;
-  const cardNumber = `SUB-${suffix}`;
+  const cardNumber = uniqueCardNumber();
   const currencyName = `Subscription Currency ${suffix}`;
   const currencyCode = uniqueCurrencyCode("S");
 
@@ -65,7 +65,7 @@ test("credit card subscription CRUD flow works end-to-end", async ({ page }) =>
   await page.getByRole("button", { name: "Create credit card subscription" }).click();
 
   const subscriptionForm = page.locator("#credit-card-subscription-form");
-  await selectOptionContaining(subscriptionForm.getByLabel("Credit Card"), cardNumber);
+  await selectOptionContaining(subscriptionForm.getByLabel("Credit Card"), maskedCardNumber(cardNumber));
   await subscriptionForm.getByLabel("Currency").selectOption({ label: `${currencyCode} (${currencyName})` });
   await subscriptionForm.getByLabel("Concept").fill("Streaming Service");
   await subscriptionForm.getByLabel("Amount").fill("19.99");
@@ -1,5 +1,5 @@
 const { test, expect } = require("@playwright/test");
-const { openApp, openSettingsSection } = require("./helpers");
+const { openApp, openSettingsSection, uniqueCardNumber, maskedCardNumber } = require("./helpers");
 
 function uniqueSuffix() {
   return `${Date.now()}_${Math.floor(Math.random() * 100000)}`;
@@ -30,7 +30,7 @@ async function updateCreditCardWithRetry(page, creditCardForm, initialNumber, up
         await expect(message).toHaveText("Credit card updated");
       }
 
-      const row = page.locator("#credit-cards-body tr", { hasText: initialNumber });
+      const row = page.locator("#credit-cards-body tr", { hasText: maskedCardNumber(initialNumber) });
       await row.locator('button[data-action="edit"]').click();
       await expect(page.locator("#credit-card-submit-button")).toHaveText("Update");
       await creditCardForm.getByLabel("Number").fill(updatedNumber);
@@ -60,8 +60,8 @@ test("credit card CRUD flow works end-to-end", async ({ page }) => {
   const suffix = uniqueSuffix();
   const bankName = `Card Bank ${suffix}`;
   const personName = `Card Person ${suffix}`;
-  const initialNumber = `CARD-${suffix}`;
-  const updatedNumber = `CARD-U-${suffix}`;
+  const initialNumber = uniqueCardNumber();
+  const updatedNumber = uniqueCardNumber();
 
   await openApp(page);
 
@@ -98,30 +98,30 @@ test("credit card CRUD flow works end-to-end", async ({ page }) => {
     page.locator("#credit-card-form-message"),
     "Credit card created"
   );
-  await expect(page.locator("#credit-cards-body")).toContainText(initialNumber);
+  await expect(page.locator("#credit-cards-body")).toContainText(maskedCardNumber(initialNumber));
 
-  const initialRow = page.locator("#credit-cards-body tr", { hasText: initialNumber });
+  const initialRow = page.locator("#credit-cards-body tr", { hasText: maskedCardNumber(initialNumber) });
   await initialRow.locator('button[data-action="edit"]').click();
   await expect(page.locator("#credit-card-submit-button")).toHaveText("Update");
 
   await creditCardForm.getByLabel("Number").fill(updatedNumber);
   await creditCardForm.getByLabel("Name").fill("Updated Card");
   await updateCreditCardWithRetry(page, creditCardForm, initialNumber, updatedNumber);
-  await expect(page.locator("#credit-cards-body")).toContainText(updatedNumber);
+  await expect(page.locator("#credit-cards-body")).toContainText(maskedCardNumber(updatedNumber));
   await expect(page.locator("#credit-cards-body")).toContainText("Updated Card");
 
-  const updatedRow = page.locator("#credit-cards-body tr", { hasText: updatedNumber });
+  const updatedRow = page.locator("#credit-cards-body tr", { hasText: maskedCardNumber(updatedNumber) });
   await updatedRow.locator('button[data-action="delete"]').click();
 
   await expect(page.locator("#credit-card-form-message")).toHaveText("Credit card deleted");
-  await expect(page.locator("#credit-cards-body")).not.toContainText(updatedNumber);
+  await expect(page.locator("#credit-cards-body")).not.toContainText(maskedCardNumber(updatedNumber));
 });
 
 test("duplicate credit card number shows backend conflict message", async ({ page }) => {
   const suffix = uniqueSuffix();
   const bankName = `Dup Card Bank ${suffix}`;
   const personName = `Dup Card Person ${suffix}`;
-  const duplicatedNumber = `DUP-${suffix}`;
+  const duplicatedNumber = uniqueCardNumber();
 
   await openApp(page);
 
@@ -157,7 +157,7 @@ test("duplicate credit card number shows backend conflict message", async ({ pag
     page.locator("#credit-card-form-message"),
     "Credit card created"
   );
-  await expect(page.locator("#credit-cards-body")).toContainText(duplicatedNumber);
+  await expect(page.locator("#credit-cards-body")).toContainText(maskedCardNumber(duplicatedNumber));
   await page.getByRole("button", { name: "Create credit card" }).click();
   await creditCardForm.getByLabel("Bank").selectOption({ label: `${bankName} (US)` });
   await selectOptionContaining(creditCardForm.getByLabel("Person"), personName);
@@ -41,10 +41,38 @@ function uniqueCurrencyCode(prefix) {
   return `${prefix}${Math.random().toString(36).slice(2, 8).toUpperCase()}`;
 }
 
+function uniqueCardNumber() {
+  const digits = [4];
+  while (digits.length < 15) {
+    digits.push(Math.floor(Math.random() * 10));
+  }
+
+  let sum = 0;
+  for (let index = 0; index < digits.length; index += 1) {
+    let digit = digits[digits.length - 1 - index];
+    if (index % 2 === 0) {
+      digit *= 2;
+      if (digit > 9) {
+        digit -= 9;
+      }
+    }
+    sum += digit;
+  }
+  digits.push((10 - (sum % 10)) % 10);
+
+  return digits.join("");
+}
+
+function maskedCardNumber(number) {
+  return `**** ${number.slice(-4)}`;
+}
+
 module.exports = {
   waitForAppReady,
   openApp,
   openSettingsSection,
   openTransactionsSection,
   uniqueCurrencyCode,
+  uniqueCardNumber,
+  maskedCardNumber,
 };
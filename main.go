@@ -1,39 +1,162 @@
 package main
 
 import (
-	"log"
-	"net/http"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"personal-finances/backend"
+	"personal-finances/migrations"
+	"personal-finances/web"
 )
 
-const defaultServerPort = "8080"
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(cli commandLine, args []string) error
+}
+
+// commandLine carries the output streams so commands can be exercised in tests.
+type commandLine struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+var errUsage = errors.New("invalid usage")
+
+func commands() []command {
+	return []command{
+		{name: "serve", usage: "serve [-config file] [-db path] [-listen address | -port port] [-backup-interval duration] [-*-timeout duration] [-max-body-bytes n]", summary: "run the HTTP server (default when no command is given)", run: runServe},
+		{name: "migrate", usage: "migrate up [-dry-run] | down -to version | status", summary: "manage the database schema", run: runMigrate},
+		{name: "backup", usage: "backup [-db path] [-output file | -backup-dir dir -keep-daily n -keep-weekly n -keep-monthly n]", summary: "write a consistent snapshot of the database", run: runBackup},
+		{name: "restore", usage: "restore <file> | -at time [-db path] [-backup-dir dir]", summary: "replace the database with a verified snapshot", run: runRestore},
+		{name: "encryption", usage: "encryption enable | rotate | disable | status [-db path]", summary: "manage field encryption of account and card numbers", run: runEncryption},
+		{name: "import", usage: "import <file> -account id -person id -category id [-db path]", summary: "load a CSV bank statement as transactions", run: runImport},
+		{name: "export", usage: "export [-format json|csv] [-entity name] [-output file] [-db path]", summary: "dump active data", run: runExport},
+		{name: "report", usage: "report monthly|categories|people [-year yyyy] [-db path]", summary: "print report tables", run: runReport},
+		{name: "integrity", usage: "integrity [-check names] [-format text|json] [-db path]", summary: "check the data for inconsistencies and suggest repairs", run: runIntegrity},
+	}
+}
 
 func main() {
-	databasePath := os.Getenv("DATABASE_PATH")
-	if strings.TrimSpace(databasePath) == "" {
-		databasePath = filepath.Join("data", "personal_finances.db")
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	cli := commandLine{stdout: stdout, stderr: stderr}
+
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name = args[0]
+		args = args[1:]
+	}
+	if name == "help" {
+		printUsage(stdout)
+		return 0
+	}
+
+	for _, candidate := range commands() {
+		if candidate.name != name {
+			continue
+		}
+
+		err := candidate.run(cli, args)
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(stderr, "usage: personal-finances %s\n", candidate.usage)
+			return 2
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", name, err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+	printUsage(stderr)
+	return 2
+}
+
+func printUsage(output io.Writer) {
+	fmt.Fprintln(output, "usage: personal-finances <command> [arguments]")
+	fmt.Fprintln(output)
+	fmt.Fprintln(output, "commands:")
+	for _, item := range commands() {
+		fmt.Fprintf(output, "  %-10s %s\n", item.name, item.summary)
+	}
+}
+
+// newFlagSet returns a flag set with the -db and -config flags every command
+// shares. Their defaults come from DATABASE_PATH and CONFIG_FILE.
+func newFlagSet(cli commandLine, name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(cli.stderr)
+	databasePath := flags.String("db", defaultDatabasePath(), "SQLite database file or postgres:// DSN")
+	flags.String("config", strings.TrimSpace(os.Getenv(configFileEnv)), "settings file with key = value lines (see README)")
+	return flags, databasePath
+}
+
+// parseArgs parses flags that may appear before, between or after positional
+// arguments, so "import statement.csv -account 3" works as expected. Settings
+// from the environment and the -config file are applied afterwards.
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
 	}
 
-	serverPort := strings.TrimSpace(os.Getenv("PORT"))
-	if serverPort == "" {
-		serverPort = defaultServerPort
+	if err := applyEnvironment(flags); err != nil {
+		return nil, err
+	}
+	if err := applyConfigFile(flags); err != nil {
+		return nil, err
 	}
-	serverAddress := ":" + strings.TrimPrefix(serverPort, ":")
 
-	db, err := backend.SetupDatabase(databasePath)
-	if err != nil {
-		log.Fatalf("database setup failed: %v", err)
+	return positional, nil
+}
+
+func defaultDatabasePath() string {
+	databasePath := strings.TrimSpace(os.Getenv("DATABASE_PATH"))
+	if databasePath == "" {
+		return filepath.Join("data", "personal_finances.db")
 	}
-	defer db.Close()
 
-	mux := backend.NewMux(db)
+	return databasePath
+}
+
+func migrationFiles() fs.FS {
+	return assetFiles(migrations.Files, "MIGRATIONS_DIR")
+}
+
+func webFiles() fs.FS {
+	return assetFiles(web.Files, "WEB_DIR")
+}
 
-	log.Printf("Server is running on http://localhost%s", serverAddress)
-	if err = http.ListenAndServe(serverAddress, mux); err != nil {
-		log.Fatalf("server failed: %v", err)
+// assetFiles returns the embedded files unless the environment variable
+// envName points to a directory to load them from instead, which lets
+// development edits take effect without rebuilding the binary.
+func assetFiles(embedded fs.FS, envName string) fs.FS {
+	dir := strings.TrimSpace(os.Getenv(envName))
+	if dir == "" {
+		return embedded
 	}
+
+	slog.Info("loading assets from directory", slog.String("assets", strings.ToLower(strings.TrimSuffix(envName, "_DIR"))), slog.String("dir", dir))
+	return os.DirFS(dir)
 }
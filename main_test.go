@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommandLineWorkflow(t *testing.T) {
+	databasePath := filepath.Join(t.TempDir(), "cli.db")
+
+	runCommand := func(args ...string) (string, string, int) {
+		var stdout, stderr bytes.Buffer
+		code := run(args, &stdout, &stderr)
+		return stdout.String(), stderr.String(), code
+	}
+
+	output, errors, code := runCommand("migrate", "up", "-db", databasePath, "-dry-run")
+	if code != 0 || !strings.Contains(output, "pending 001_") {
+		t.Fatalf("expected dry run to list pending migrations, got %d %q %q", code, output, errors)
+	}
+
+	if _, errors, code = runCommand("migrate", "up", "-db", databasePath); code != 0 {
+		t.Fatalf("expected migrate up to succeed, got %d %q", code, errors)
+	}
+
+	output, _, code = runCommand("migrate", "status", "-db", databasePath)
+	if code != 0 || strings.Contains(output, "pending") {
+		t.Fatalf("expected every migration to be applied, got %d %q", code, output)
+	}
+
+	output, errors, code = runCommand("export", "-db", databasePath, "-format", "csv", "-entity", "currencies")
+	if code != 0 || !strings.HasPrefix(output, "id,") {
+		t.Fatalf("expected csv header, got %d %q %q", code, output, errors)
+	}
+
+	if _, _, code = runCommand("export", "-db", databasePath, "-format", "csv"); code != 1 {
+		t.Fatalf("expected csv export without entity to fail, got %d", code)
+	}
+
+	backupPath := filepath.Join(t.TempDir(), "snapshot.db")
+	if _, errors, code = runCommand("backup", "-db", databasePath, "-output", backupPath); code != 0 {
+		t.Fatalf("expected backup to succeed, got %d %q", code, errors)
+	}
+	if _, errors, code = runCommand("restore", backupPath, "-db", databasePath); code != 0 {
+		t.Fatalf("expected restore to succeed, got %d %q", code, errors)
+	}
+
+	output, errors, code = runCommand("integrity", "-db", databasePath)
+	if code != 0 || !strings.Contains(output, "duplicate_transactions") {
+		t.Fatalf("expected a clean integrity report, got %d %q %q", code, output, errors)
+	}
+	if _, _, code = runCommand("integrity", "-db", databasePath, "-check", "nope"); code != 1 {
+		t.Fatalf("expected an unknown integrity check to fail, got %d", code)
+	}
+
+	if _, _, code = runCommand("import", "statement.csv"); code != 2 {
+		t.Fatalf("expected import without ids to print usage, got %d", code)
+	}
+	if _, _, code = runCommand("unknown"); code != 2 {
+		t.Fatalf("expected unknown command to exit 2, got %d", code)
+	}
+}
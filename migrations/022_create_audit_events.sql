@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  entity TEXT NOT NULL,
+  entity_id INTEGER NOT NULL,
+  action TEXT NOT NULL CHECK(action IN ('create', 'update', 'delete')),
+  actor TEXT NOT NULL,
+  occurred_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  changes TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity_entity_id
+ON audit_events(entity, entity_id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_occurred_at
+ON audit_events(occurred_at);
+
+CREATE TRIGGER IF NOT EXISTS trg_audit_events_no_update
+BEFORE UPDATE ON audit_events
+BEGIN
+  SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_audit_events_no_delete
+BEFORE DELETE ON audit_events
+BEGIN
+  SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
@@ -0,0 +1,314 @@
+CREATE TABLE currencies_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL COLLATE NOCASE,
+  code TEXT NOT NULL COLLATE NOCASE,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME
+);
+
+INSERT INTO currencies_new (id, name, code, created_at, updated_at)
+SELECT id, name, code, created_at, updated_at
+FROM currencies;
+
+DROP TABLE currencies;
+ALTER TABLE currencies_new RENAME TO currencies;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_currencies_name_unique
+ON currencies(name)
+WHERE deleted_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_currencies_code_unique
+ON currencies(code)
+WHERE deleted_at IS NULL;
+
+CREATE TABLE banks_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL COLLATE NOCASE,
+  country TEXT NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME,
+  FOREIGN KEY (country) REFERENCES countries(code)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT
+);
+
+INSERT INTO banks_new (id, name, country, created_at, updated_at)
+SELECT id, name, country, created_at, updated_at
+FROM banks;
+
+DROP TABLE banks;
+ALTER TABLE banks_new RENAME TO banks;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_banks_name_country_unique
+ON banks(name, country)
+WHERE deleted_at IS NULL;
+
+CREATE TABLE bank_accounts_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  bank_id INTEGER NOT NULL,
+  currency_id INTEGER NOT NULL,
+  account_number TEXT NOT NULL,
+  balance REAL NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME,
+  FOREIGN KEY (bank_id) REFERENCES banks(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT,
+  FOREIGN KEY (currency_id) REFERENCES currencies(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT
+);
+
+INSERT INTO bank_accounts_new (id, bank_id, currency_id, account_number, balance, created_at, updated_at)
+SELECT id, bank_id, currency_id, account_number, balance, created_at, updated_at
+FROM bank_accounts;
+
+DROP TABLE bank_accounts;
+ALTER TABLE bank_accounts_new RENAME TO bank_accounts;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bank_accounts_bank_currency_number_unique
+ON bank_accounts(bank_id, currency_id, account_number)
+WHERE deleted_at IS NULL;
+
+ALTER TABLE people ADD COLUMN deleted_at DATETIME;
+
+ALTER TABLE transaction_categories ADD COLUMN deleted_at DATETIME;
+
+DROP INDEX IF EXISTS idx_transaction_categories_unique_root_name;
+DROP INDEX IF EXISTS idx_transaction_categories_unique_child_name;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaction_categories_unique_root_name
+ON transaction_categories(name)
+WHERE parent_id IS NULL AND deleted_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaction_categories_unique_child_name
+ON transaction_categories(parent_id, name)
+WHERE parent_id IS NOT NULL AND deleted_at IS NULL;
+
+ALTER TABLE transactions ADD COLUMN deleted_at DATETIME;
+
+CREATE TABLE credit_cards_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  bank_id INTEGER NOT NULL,
+  person_id INTEGER NOT NULL,
+  number TEXT NOT NULL,
+  name TEXT,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME,
+  FOREIGN KEY(bank_id) REFERENCES banks(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+  FOREIGN KEY(person_id) REFERENCES people(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+INSERT INTO credit_cards_new (id, bank_id, person_id, number, name, created_at, updated_at)
+SELECT id, bank_id, person_id, number, name, created_at, updated_at
+FROM credit_cards;
+
+DROP TABLE credit_cards;
+ALTER TABLE credit_cards_new RENAME TO credit_cards;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_cards_number_unique
+ON credit_cards(number)
+WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_credit_cards_bank_id
+ON credit_cards(bank_id);
+
+CREATE INDEX IF NOT EXISTS idx_credit_cards_person_id
+ON credit_cards(person_id);
+
+CREATE TABLE credit_card_cycles_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  credit_card_id INTEGER NOT NULL,
+  closing_date TEXT NOT NULL,
+  due_date TEXT NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME,
+  FOREIGN KEY(credit_card_id) REFERENCES credit_cards(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT
+);
+
+INSERT INTO credit_card_cycles_new (id, credit_card_id, closing_date, due_date, created_at, updated_at)
+SELECT id, credit_card_id, closing_date, due_date, created_at, updated_at
+FROM credit_card_cycles;
+
+DROP TABLE credit_card_cycles;
+ALTER TABLE credit_card_cycles_new RENAME TO credit_card_cycles;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_card_cycles_card_dates_unique
+ON credit_card_cycles(credit_card_id, closing_date, due_date)
+WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_credit_card_cycles_credit_card_id
+ON credit_card_cycles(credit_card_id);
+
+CREATE TABLE credit_card_cycle_balances_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  credit_card_cycle_id INTEGER NOT NULL,
+  currency_id INTEGER NOT NULL,
+  balance REAL NOT NULL DEFAULT 0,
+  paid INTEGER NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME,
+  FOREIGN KEY(credit_card_cycle_id) REFERENCES credit_card_cycles(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT,
+  FOREIGN KEY(currency_id) REFERENCES currencies(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT
+);
+
+INSERT INTO credit_card_cycle_balances_new (id, credit_card_cycle_id, currency_id, balance, paid, created_at, updated_at)
+SELECT id, credit_card_cycle_id, currency_id, balance, paid, created_at, updated_at
+FROM credit_card_cycle_balances;
+
+DROP TABLE credit_card_cycle_balances;
+ALTER TABLE credit_card_cycle_balances_new RENAME TO credit_card_cycle_balances;
+
+CREATE INDEX IF NOT EXISTS idx_credit_card_cycle_balances_cycle_id
+ON credit_card_cycle_balances(credit_card_cycle_id);
+
+CREATE INDEX IF NOT EXISTS idx_credit_card_cycle_balances_currency_id
+ON credit_card_cycle_balances(currency_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_card_cycle_balances_cycle_currency_unique
+ON credit_card_cycle_balances(credit_card_cycle_id, currency_id)
+WHERE deleted_at IS NULL;
+
+CREATE TABLE credit_card_installments_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  credit_card_id INTEGER NOT NULL,
+  currency_id INTEGER NOT NULL,
+  concept TEXT NOT NULL,
+  amount REAL NOT NULL,
+  start_date TEXT NOT NULL,
+  count INTEGER NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME,
+  FOREIGN KEY(credit_card_id) REFERENCES credit_cards(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT,
+  FOREIGN KEY(currency_id) REFERENCES currencies(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT,
+  CONSTRAINT chk_credit_card_installments_concept_not_empty CHECK(length(trim(concept)) > 0),
+  CONSTRAINT chk_credit_card_installments_amount_positive CHECK(amount > 0),
+  CONSTRAINT chk_credit_card_installments_count_positive CHECK(count > 0)
+);
+
+INSERT INTO credit_card_installments_new (id, credit_card_id, currency_id, concept, amount, start_date, count, created_at, updated_at)
+SELECT id, credit_card_id, currency_id, concept, amount, start_date, count, created_at, updated_at
+FROM credit_card_installments;
+
+DROP TABLE credit_card_installments;
+ALTER TABLE credit_card_installments_new RENAME TO credit_card_installments;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_card_installments_card_currency_concept_unique
+ON credit_card_installments(credit_card_id, currency_id, concept)
+WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_credit_card_installments_credit_card_id
+ON credit_card_installments(credit_card_id);
+
+CREATE INDEX IF NOT EXISTS idx_credit_card_installments_currency_id
+ON credit_card_installments(currency_id);
+
+CREATE TABLE credit_card_subscriptions_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  credit_card_id INTEGER NOT NULL,
+  currency_id INTEGER NOT NULL,
+  concept TEXT NOT NULL,
+  amount REAL NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME,
+  FOREIGN KEY(credit_card_id) REFERENCES credit_cards(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT,
+  FOREIGN KEY(currency_id) REFERENCES currencies(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT,
+  CONSTRAINT chk_credit_card_subscriptions_concept_not_empty CHECK(length(trim(concept)) > 0),
+  CONSTRAINT chk_credit_card_subscriptions_amount_positive CHECK(amount > 0)
+);
+
+INSERT INTO credit_card_subscriptions_new (id, credit_card_id, currency_id, concept, amount, created_at, updated_at)
+SELECT id, credit_card_id, currency_id, concept, amount, created_at, updated_at
+FROM credit_card_subscriptions;
+
+DROP TABLE credit_card_subscriptions;
+ALTER TABLE credit_card_subscriptions_new RENAME TO credit_card_subscriptions;
+
+CREATE INDEX IF NOT EXISTS idx_credit_card_subscriptions_credit_card_id
+ON credit_card_subscriptions(credit_card_id);
+
+CREATE INDEX IF NOT EXISTS idx_credit_card_subscriptions_currency_id
+ON credit_card_subscriptions(currency_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_card_subscriptions_card_currency_concept_unique
+ON credit_card_subscriptions(credit_card_id, currency_id, concept)
+WHERE deleted_at IS NULL;
+
+CREATE TABLE expenses_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL COLLATE NOCASE,
+  frequency TEXT NOT NULL CHECK (frequency IN ('daily', 'weekly', 'monthly', 'annually')),
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME
+);
+
+INSERT INTO expenses_new (id, name, frequency, created_at, updated_at)
+SELECT id, name, frequency, created_at, updated_at
+FROM expenses;
+
+DROP TABLE expenses;
+ALTER TABLE expenses_new RENAME TO expenses;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_expenses_name_unique
+ON expenses(name)
+WHERE deleted_at IS NULL;
+
+ALTER TABLE expense_payments ADD COLUMN deleted_at DATETIME;
+
+CREATE TABLE audit_events_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  entity TEXT NOT NULL,
+  entity_id INTEGER NOT NULL,
+  action TEXT NOT NULL CHECK(action IN ('create', 'update', 'delete', 'restore', 'purge')),
+  actor TEXT NOT NULL,
+  occurred_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  changes TEXT NOT NULL
+);
+
+INSERT INTO audit_events_new (id, entity, entity_id, action, actor, occurred_at, changes)
+SELECT id, entity, entity_id, action, actor, occurred_at, changes
+FROM audit_events;
+
+DROP TABLE audit_events;
+ALTER TABLE audit_events_new RENAME TO audit_events;
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity_entity_id
+ON audit_events(entity, entity_id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_occurred_at
+ON audit_events(occurred_at);
+
+CREATE TRIGGER IF NOT EXISTS trg_audit_events_no_update
+BEFORE UPDATE ON audit_events
+BEGIN
+  SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_audit_events_no_delete
+BEFORE DELETE ON audit_events
+BEGIN
+  SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
@@ -0,0 +1,35 @@
+CREATE TABLE credit_cards_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  bank_id INTEGER NOT NULL,
+  person_id INTEGER NOT NULL,
+  number TEXT,
+  number_lookup TEXT,
+  last4 TEXT NOT NULL,
+  network TEXT NOT NULL DEFAULT 'unknown',
+  name TEXT,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME,
+  FOREIGN KEY(bank_id) REFERENCES banks(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+  FOREIGN KEY(person_id) REFERENCES people(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- Plaintext numbers are reduced to last4 and a fingerprint by the data step
+-- that runs with this migration. Encrypted numbers keep their ciphertext and
+-- get last4 when they are next read or updated.
+INSERT INTO credit_cards_new (id, bank_id, person_id, number, number_lookup, last4, name, created_at, updated_at, deleted_at)
+SELECT id, bank_id, person_id, number, number_lookup, '', name, created_at, updated_at, deleted_at
+FROM credit_cards;
+
+DROP TABLE credit_cards;
+ALTER TABLE credit_cards_new RENAME TO credit_cards;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_cards_number_unique
+ON credit_cards(number_lookup)
+WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_credit_cards_bank_id
+ON credit_cards(bank_id);
+
+CREATE INDEX IF NOT EXISTS idx_credit_cards_person_id
+ON credit_cards(person_id);
@@ -0,0 +1,34 @@
+CREATE TABLE audit_events_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  entity TEXT NOT NULL,
+  entity_id INTEGER NOT NULL,
+  action TEXT NOT NULL CHECK(action IN ('create', 'update', 'delete', 'restore', 'purge', 'merge')),
+  actor TEXT NOT NULL,
+  occurred_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  changes TEXT NOT NULL
+);
+
+INSERT INTO audit_events_new (id, entity, entity_id, action, actor, occurred_at, changes)
+SELECT id, entity, entity_id, action, actor, occurred_at, changes
+FROM audit_events;
+
+DROP TABLE audit_events;
+ALTER TABLE audit_events_new RENAME TO audit_events;
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity_entity_id
+ON audit_events(entity, entity_id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_occurred_at
+ON audit_events(occurred_at);
+
+CREATE TRIGGER IF NOT EXISTS trg_audit_events_no_update
+BEFORE UPDATE ON audit_events
+BEGIN
+  SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_audit_events_no_delete
+BEFORE DELETE ON audit_events
+BEGIN
+  SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
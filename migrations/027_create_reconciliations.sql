@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS reconciliations (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  bank_account_id INTEGER NOT NULL,
+  statement_date TEXT NOT NULL,
+  opening_balance REAL NOT NULL DEFAULT 0,
+  statement_balance REAL NOT NULL,
+  status TEXT NOT NULL DEFAULT 'open' CHECK(status IN ('open', 'locked')),
+  locked_at DATETIME,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME,
+  FOREIGN KEY(bank_account_id) REFERENCES bank_accounts(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliations_bank_account_id
+ON reconciliations(bank_account_id);
+
+-- An account has at most one session in progress.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reconciliations_open_unique
+ON reconciliations(bank_account_id)
+WHERE status = 'open' AND deleted_at IS NULL;
+
+ALTER TABLE transactions ADD COLUMN reconciliation_status TEXT NOT NULL DEFAULT 'uncleared'
+  CHECK(reconciliation_status IN ('uncleared', 'cleared', 'reconciled'));
+
+ALTER TABLE transactions ADD COLUMN reconciliation_id INTEGER
+  REFERENCES reconciliations(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_reconciliation_id
+ON transactions(reconciliation_id);
@@ -0,0 +1,25 @@
+-- Split lines of a transaction. They belong to their transaction: they are
+-- replaced as a whole when it is written, follow its soft delete and go away
+-- with it when it is purged.
+CREATE TABLE IF NOT EXISTS transaction_splits (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  transaction_id INTEGER NOT NULL,
+  position INTEGER NOT NULL,
+  category_id INTEGER NOT NULL,
+  person_id INTEGER NOT NULL,
+  amount REAL NOT NULL CHECK(amount > 0),
+  notes TEXT,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY(transaction_id) REFERENCES transactions(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  FOREIGN KEY(category_id) REFERENCES transaction_categories(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+  FOREIGN KEY(person_id) REFERENCES people(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaction_splits_position
+ON transaction_splits(transaction_id, position);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_category_id
+ON transaction_splits(category_id);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_person_id
+ON transaction_splits(person_id);
@@ -0,0 +1,65 @@
+-- A shared expense records that the person paying a transaction did so on
+-- behalf of others, and how the cost is shared between them.
+CREATE TABLE IF NOT EXISTS shared_expenses (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  transaction_id INTEGER NOT NULL,
+  payer_id INTEGER NOT NULL,
+  split_method TEXT NOT NULL CHECK(split_method IN ('equal', 'percentage', 'exact')),
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME,
+  FOREIGN KEY(transaction_id) REFERENCES transactions(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+  FOREIGN KEY(payer_id) REFERENCES people(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- A transaction is shared at most once.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shared_expenses_transaction_unique
+ON shared_expenses(transaction_id)
+WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_shared_expenses_payer_id
+ON shared_expenses(payer_id);
+
+-- The people sharing an expense. percentage is set for percentage splits and
+-- amount for exact splits; equal splits set neither.
+CREATE TABLE IF NOT EXISTS shared_expense_shares (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  shared_expense_id INTEGER NOT NULL,
+  position INTEGER NOT NULL,
+  person_id INTEGER NOT NULL,
+  percentage REAL CHECK(percentage > 0 AND percentage <= 100),
+  amount REAL CHECK(amount > 0),
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY(shared_expense_id) REFERENCES shared_expenses(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  FOREIGN KEY(person_id) REFERENCES people(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shared_expense_shares_person
+ON shared_expense_shares(shared_expense_id, person_id);
+
+CREATE INDEX IF NOT EXISTS idx_shared_expense_shares_person_id
+ON shared_expense_shares(person_id);
+
+-- A settlement is a payment between two people that pays off shared costs.
+CREATE TABLE IF NOT EXISTS settlements (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  from_person_id INTEGER NOT NULL,
+  to_person_id INTEGER NOT NULL,
+  amount REAL NOT NULL CHECK(amount > 0),
+  currency_id INTEGER NOT NULL,
+  settlement_date TEXT NOT NULL,
+  notes TEXT,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME,
+  CHECK(from_person_id <> to_person_id),
+  FOREIGN KEY(from_person_id) REFERENCES people(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+  FOREIGN KEY(to_person_id) REFERENCES people(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+  FOREIGN KEY(currency_id) REFERENCES currencies(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_settlements_from_person_id
+ON settlements(from_person_id);
+
+CREATE INDEX IF NOT EXISTS idx_settlements_to_person_id
+ON settlements(to_person_id);
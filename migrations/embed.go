@@ -0,0 +1,12 @@
+// Package migrations embeds the SQL migration files so the binary can apply
+// them regardless of the working directory it is started from.
+package migrations
+
+import "embed"
+
+// Files holds every migration, named NNN_description.sql. The SQLite
+// migrations are at the top level and the PostgreSQL ones, with the same
+// versions and names, under postgres/.
+//
+//go:embed *.sql postgres/*.sql
+var Files embed.FS
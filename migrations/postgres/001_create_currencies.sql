@@ -0,0 +1,14 @@
+-- Case-insensitive comparisons for the columns declared COLLATE NOCASE in
+-- the SQLite schema. Unique indexes on these columns then treat names that
+-- differ only in case as duplicates.
+CREATE COLLATION IF NOT EXISTS nocase (provider = icu, locale = 'und-u-ks-level2', deterministic = false);
+
+CREATE TABLE IF NOT EXISTS currencies (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL COLLATE nocase,
+    code TEXT NOT NULL COLLATE nocase,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_currencies_name UNIQUE (name),
+    CONSTRAINT uq_currencies_code UNIQUE (code)
+);
@@ -0,0 +1,6 @@
+ALTER TABLE banks DROP CONSTRAINT IF EXISTS chk_banks_country;
+
+ALTER TABLE banks
+  ADD CONSTRAINT fk_banks_country FOREIGN KEY (country) REFERENCES countries(code)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT;
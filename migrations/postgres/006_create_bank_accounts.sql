@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS bank_accounts (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  bank_id BIGINT NOT NULL,
+  currency_id BIGINT NOT NULL,
+  account_number TEXT NOT NULL,
+  balance DOUBLE PRECISION NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT uq_bank_accounts_bank_currency_number UNIQUE (bank_id, currency_id, account_number),
+  FOREIGN KEY (bank_id) REFERENCES banks(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT,
+  FOREIGN KEY (currency_id) REFERENCES currencies(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT
+);
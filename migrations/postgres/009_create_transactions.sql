@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS transactions (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  transaction_date TEXT NOT NULL,
+  type TEXT NOT NULL CHECK(type IN ('income', 'expense')),
+  amount DOUBLE PRECISION NOT NULL CHECK(amount > 0),
+  notes TEXT,
+  person_id BIGINT NOT NULL,
+  bank_account_id BIGINT NOT NULL,
+  category_id BIGINT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY(person_id) REFERENCES people(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+  FOREIGN KEY(bank_account_id) REFERENCES bank_accounts(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+  FOREIGN KEY(category_id) REFERENCES transaction_categories(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_transaction_date
+ON transactions(transaction_date);
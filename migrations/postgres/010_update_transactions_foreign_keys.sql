@@ -0,0 +1,3 @@
+-- The SQLite migration of this version rebuilds transactions to repair its
+-- foreign keys. The PostgreSQL table was created with them, so there is
+-- nothing to change.
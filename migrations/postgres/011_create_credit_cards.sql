@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS credit_cards (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  bank_id BIGINT NOT NULL,
+  person_id BIGINT NOT NULL,
+  number TEXT NOT NULL,
+  name TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT uq_credit_cards_number UNIQUE (number),
+  FOREIGN KEY(bank_id) REFERENCES banks(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+  FOREIGN KEY(person_id) REFERENCES people(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_cards_bank_id
+ON credit_cards(bank_id);
+
+CREATE INDEX IF NOT EXISTS idx_credit_cards_person_id
+ON credit_cards(person_id);
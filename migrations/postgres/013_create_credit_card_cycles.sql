@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS credit_card_cycles (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  credit_card_id BIGINT NOT NULL,
+  closing_date TEXT NOT NULL,
+  due_date TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT uq_credit_card_cycles_card_dates UNIQUE (credit_card_id, closing_date, due_date),
+  CONSTRAINT fk_credit_card_cycles_credit_card FOREIGN KEY(credit_card_id) REFERENCES credit_cards(id)
+    ON UPDATE CASCADE
+    ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_card_cycles_credit_card_id
+ON credit_card_cycles(credit_card_id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS credit_card_currencies;
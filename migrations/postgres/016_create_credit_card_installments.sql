@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS credit_card_installments (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  credit_card_id BIGINT NOT NULL,
+  currency_id BIGINT NOT NULL,
+  concept TEXT NOT NULL,
+  amount DOUBLE PRECISION NOT NULL,
+  start_date TEXT NOT NULL,
+  count INTEGER NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT fk_credit_card_installments_credit_card FOREIGN KEY(credit_card_id) REFERENCES credit_cards(id)
+    ON UPDATE CASCADE
+    ON DELETE CASCADE,
+  FOREIGN KEY(currency_id) REFERENCES currencies(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT,
+  CONSTRAINT chk_credit_card_installments_concept_not_empty CHECK(length(trim(concept)) > 0),
+  CONSTRAINT chk_credit_card_installments_amount_positive CHECK(amount > 0),
+  CONSTRAINT chk_credit_card_installments_count_positive CHECK(count > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_card_installments_card_concept_unique
+ON credit_card_installments(credit_card_id, concept);
+
+CREATE INDEX IF NOT EXISTS idx_credit_card_installments_credit_card_id
+ON credit_card_installments(credit_card_id);
+
+CREATE INDEX IF NOT EXISTS idx_credit_card_installments_currency_id
+ON credit_card_installments(currency_id);
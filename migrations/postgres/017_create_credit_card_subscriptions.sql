@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS credit_card_subscriptions (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  credit_card_id BIGINT NOT NULL,
+  currency_id BIGINT NOT NULL,
+  concept TEXT NOT NULL,
+  amount DOUBLE PRECISION NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT fk_credit_card_subscriptions_credit_card FOREIGN KEY(credit_card_id) REFERENCES credit_cards(id)
+    ON UPDATE CASCADE
+    ON DELETE CASCADE,
+  FOREIGN KEY(currency_id) REFERENCES currencies(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT,
+  CONSTRAINT chk_credit_card_subscriptions_concept_not_empty CHECK(length(trim(concept)) > 0),
+  CONSTRAINT chk_credit_card_subscriptions_amount_positive CHECK(amount > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_card_subscriptions_credit_card_id
+ON credit_card_subscriptions(credit_card_id);
+
+CREATE INDEX IF NOT EXISTS idx_credit_card_subscriptions_currency_id
+ON credit_card_subscriptions(currency_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_card_subscriptions_card_currency_concept_unique
+ON credit_card_subscriptions(credit_card_id, currency_id, concept);
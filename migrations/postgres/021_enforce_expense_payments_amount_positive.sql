@@ -0,0 +1,2 @@
+ALTER TABLE expense_payments
+  ADD CONSTRAINT chk_expense_payments_amount_positive CHECK(amount > 0);
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  entity TEXT NOT NULL,
+  entity_id BIGINT NOT NULL,
+  action TEXT NOT NULL CONSTRAINT chk_audit_events_action CHECK(action IN ('create', 'update', 'delete')),
+  actor TEXT NOT NULL,
+  occurred_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  changes TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity_entity_id
+ON audit_events(entity, entity_id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_occurred_at
+ON audit_events(occurred_at);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_audit_events_no_update
+BEFORE UPDATE ON audit_events
+FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER trg_audit_events_no_delete
+BEFORE DELETE ON audit_events
+FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,115 @@
+ALTER TABLE currencies ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE currencies DROP CONSTRAINT uq_currencies_name;
+ALTER TABLE currencies DROP CONSTRAINT uq_currencies_code;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_currencies_name_unique
+ON currencies(name)
+WHERE deleted_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_currencies_code_unique
+ON currencies(code)
+WHERE deleted_at IS NULL;
+
+ALTER TABLE banks ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE banks DROP CONSTRAINT uq_banks_name_country;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_banks_name_country_unique
+ON banks(name, country)
+WHERE deleted_at IS NULL;
+
+ALTER TABLE bank_accounts ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE bank_accounts DROP CONSTRAINT uq_bank_accounts_bank_currency_number;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bank_accounts_bank_currency_number_unique
+ON bank_accounts(bank_id, currency_id, account_number)
+WHERE deleted_at IS NULL;
+
+ALTER TABLE people ADD COLUMN deleted_at TIMESTAMPTZ;
+
+ALTER TABLE transaction_categories ADD COLUMN deleted_at TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS idx_transaction_categories_unique_root_name;
+DROP INDEX IF EXISTS idx_transaction_categories_unique_child_name;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaction_categories_unique_root_name
+ON transaction_categories(name)
+WHERE parent_id IS NULL AND deleted_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaction_categories_unique_child_name
+ON transaction_categories(parent_id, name)
+WHERE parent_id IS NOT NULL AND deleted_at IS NULL;
+
+ALTER TABLE transactions ADD COLUMN deleted_at TIMESTAMPTZ;
+
+ALTER TABLE credit_cards ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE credit_cards DROP CONSTRAINT uq_credit_cards_number;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_cards_number_unique
+ON credit_cards(number)
+WHERE deleted_at IS NULL;
+
+-- Children of soft deleted rows stay in place, so deleting a card or a cycle
+-- for good must not cascade to them any more.
+ALTER TABLE credit_card_cycles ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE credit_card_cycles DROP CONSTRAINT uq_credit_card_cycles_card_dates;
+ALTER TABLE credit_card_cycles DROP CONSTRAINT fk_credit_card_cycles_credit_card;
+ALTER TABLE credit_card_cycles
+  ADD CONSTRAINT fk_credit_card_cycles_credit_card FOREIGN KEY(credit_card_id) REFERENCES credit_cards(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_card_cycles_card_dates_unique
+ON credit_card_cycles(credit_card_id, closing_date, due_date)
+WHERE deleted_at IS NULL;
+
+ALTER TABLE credit_card_cycle_balances ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE credit_card_cycle_balances DROP CONSTRAINT fk_credit_card_cycle_balances_cycle;
+ALTER TABLE credit_card_cycle_balances
+  ADD CONSTRAINT fk_credit_card_cycle_balances_cycle FOREIGN KEY(credit_card_cycle_id) REFERENCES credit_card_cycles(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT;
+
+DROP INDEX IF EXISTS idx_credit_card_cycle_balances_cycle_currency_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_card_cycle_balances_cycle_currency_unique
+ON credit_card_cycle_balances(credit_card_cycle_id, currency_id)
+WHERE deleted_at IS NULL;
+
+ALTER TABLE credit_card_installments ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE credit_card_installments DROP CONSTRAINT fk_credit_card_installments_credit_card;
+ALTER TABLE credit_card_installments
+  ADD CONSTRAINT fk_credit_card_installments_credit_card FOREIGN KEY(credit_card_id) REFERENCES credit_cards(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT;
+
+DROP INDEX IF EXISTS idx_credit_card_installments_card_currency_concept_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_card_installments_card_currency_concept_unique
+ON credit_card_installments(credit_card_id, currency_id, concept)
+WHERE deleted_at IS NULL;
+
+ALTER TABLE credit_card_subscriptions ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE credit_card_subscriptions DROP CONSTRAINT fk_credit_card_subscriptions_credit_card;
+ALTER TABLE credit_card_subscriptions
+  ADD CONSTRAINT fk_credit_card_subscriptions_credit_card FOREIGN KEY(credit_card_id) REFERENCES credit_cards(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT;
+
+DROP INDEX IF EXISTS idx_credit_card_subscriptions_card_currency_concept_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_card_subscriptions_card_currency_concept_unique
+ON credit_card_subscriptions(credit_card_id, currency_id, concept)
+WHERE deleted_at IS NULL;
+
+ALTER TABLE expenses ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE expenses DROP CONSTRAINT uq_expenses_name;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_expenses_name_unique
+ON expenses(name)
+WHERE deleted_at IS NULL;
+
+ALTER TABLE expense_payments ADD COLUMN deleted_at TIMESTAMPTZ;
+
+ALTER TABLE audit_events DROP CONSTRAINT chk_audit_events_action;
+ALTER TABLE audit_events
+  ADD CONSTRAINT chk_audit_events_action CHECK(action IN ('create', 'update', 'delete', 'restore', 'purge'));
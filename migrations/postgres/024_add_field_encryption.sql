@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS encryption_keys (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  salt TEXT NOT NULL,
+  iterations INTEGER NOT NULL CHECK (iterations > 0),
+  memory_kib INTEGER NOT NULL CHECK (memory_kib > 0),
+  parallelism INTEGER NOT NULL CHECK (parallelism BETWEEN 1 AND 255),
+  verifier TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  retired_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_encryption_keys_single_active
+ON encryption_keys((1))
+WHERE retired_at IS NULL;
+
+ALTER TABLE bank_accounts ADD COLUMN account_number_lookup TEXT;
+
+DROP INDEX IF EXISTS idx_bank_accounts_bank_currency_number_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bank_accounts_bank_currency_number_unique
+ON bank_accounts(bank_id, currency_id, COALESCE(account_number_lookup, account_number))
+WHERE deleted_at IS NULL;
+
+ALTER TABLE credit_cards ADD COLUMN number_lookup TEXT;
+
+DROP INDEX IF EXISTS idx_credit_cards_number_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_cards_number_unique
+ON credit_cards(COALESCE(number_lookup, number))
+WHERE deleted_at IS NULL;
@@ -0,0 +1,13 @@
+-- Plaintext numbers are reduced to last4 and a fingerprint by the data step
+-- that runs with this migration. Encrypted numbers keep their ciphertext and
+-- get last4 when they are next read or updated.
+ALTER TABLE credit_cards ALTER COLUMN number DROP NOT NULL;
+ALTER TABLE credit_cards ADD COLUMN last4 TEXT NOT NULL DEFAULT '';
+ALTER TABLE credit_cards ALTER COLUMN last4 DROP DEFAULT;
+ALTER TABLE credit_cards ADD COLUMN network TEXT NOT NULL DEFAULT 'unknown';
+
+DROP INDEX IF EXISTS idx_credit_cards_number_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_cards_number_unique
+ON credit_cards(number_lookup)
+WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE audit_events DROP CONSTRAINT chk_audit_events_action;
+ALTER TABLE audit_events
+  ADD CONSTRAINT chk_audit_events_action CHECK(action IN ('create', 'update', 'delete', 'restore', 'purge', 'merge'));
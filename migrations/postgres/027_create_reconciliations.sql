@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS reconciliations (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    bank_account_id BIGINT NOT NULL,
+    statement_date TEXT NOT NULL,
+    opening_balance DOUBLE PRECISION NOT NULL DEFAULT 0,
+    statement_balance DOUBLE PRECISION NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open' CONSTRAINT chk_reconciliations_status CHECK(status IN ('open', 'locked')),
+    locked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMPTZ,
+    FOREIGN KEY(bank_account_id) REFERENCES bank_accounts(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliations_bank_account_id
+ON reconciliations(bank_account_id);
+
+-- An account has at most one session in progress.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reconciliations_open_unique
+ON reconciliations(bank_account_id)
+WHERE status = 'open' AND deleted_at IS NULL;
+
+ALTER TABLE transactions ADD COLUMN reconciliation_status TEXT NOT NULL DEFAULT 'uncleared'
+  CONSTRAINT chk_transactions_reconciliation_status CHECK(reconciliation_status IN ('uncleared', 'cleared', 'reconciled'));
+
+ALTER TABLE transactions ADD COLUMN reconciliation_id BIGINT
+  CONSTRAINT fk_transactions_reconciliation_id REFERENCES reconciliations(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_reconciliation_id
+ON transactions(reconciliation_id);
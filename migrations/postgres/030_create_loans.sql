@@ -0,0 +1,50 @@
+-- A loan given to or taken from a person or a bank, repaid on a monthly
+-- schedule. annual_rate is a nominal yearly percentage.
+CREATE TABLE IF NOT EXISTS loans (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL,
+    direction TEXT NOT NULL CONSTRAINT chk_loans_direction CHECK(direction IN ('lent', 'borrowed')),
+    person_id BIGINT,
+    bank_id BIGINT,
+    principal DOUBLE PRECISION NOT NULL CONSTRAINT chk_loans_principal CHECK(principal > 0),
+    currency_id BIGINT NOT NULL,
+    annual_rate DOUBLE PRECISION NOT NULL DEFAULT 0 CONSTRAINT chk_loans_annual_rate CHECK(annual_rate >= 0),
+    term_months INTEGER NOT NULL CONSTRAINT chk_loans_term_months CHECK(term_months > 0),
+    start_date TEXT NOT NULL,
+    amortization TEXT NOT NULL CONSTRAINT chk_loans_amortization CHECK(amortization IN ('french', 'german', 'interest_only')),
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMPTZ,
+    CONSTRAINT chk_loans_counterparty CHECK((person_id IS NULL) <> (bank_id IS NULL)),
+    FOREIGN KEY(person_id) REFERENCES people(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    FOREIGN KEY(bank_id) REFERENCES banks(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    FOREIGN KEY(currency_id) REFERENCES currencies(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_loans_person_id
+ON loans(person_id);
+
+CREATE INDEX IF NOT EXISTS idx_loans_bank_id
+ON loans(bank_id);
+
+-- Repayments actually made, through a bank account in the loan currency.
+CREATE TABLE IF NOT EXISTS loan_repayments (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    loan_id BIGINT NOT NULL,
+    bank_account_id BIGINT NOT NULL,
+    payment_date TEXT NOT NULL,
+    amount DOUBLE PRECISION NOT NULL CONSTRAINT chk_loan_repayments_amount CHECK(amount > 0),
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMPTZ,
+    FOREIGN KEY(loan_id) REFERENCES loans(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    FOREIGN KEY(bank_account_id) REFERENCES bank_accounts(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_loan_repayments_loan_id
+ON loan_repayments(loan_id);
+
+CREATE INDEX IF NOT EXISTS idx_loan_repayments_bank_account_id
+ON loan_repayments(bank_account_id);
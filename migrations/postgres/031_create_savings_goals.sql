@@ -0,0 +1,38 @@
+-- A target amount to save in one currency by a deadline.
+CREATE TABLE IF NOT EXISTS savings_goals (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL,
+    target_amount DOUBLE PRECISION NOT NULL CONSTRAINT chk_savings_goals_target_amount CHECK(target_amount > 0),
+    currency_id BIGINT NOT NULL,
+    deadline TEXT NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMPTZ,
+    FOREIGN KEY(currency_id) REFERENCES currencies(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- Bank accounts whose balances make up a goal. They belong to their goal:
+-- they are replaced as a whole when it is written, follow its soft delete
+-- and go away with it when it is purged.
+CREATE TABLE IF NOT EXISTS savings_goal_accounts (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    savings_goal_id BIGINT NOT NULL,
+    bank_account_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(savings_goal_id) REFERENCES savings_goals(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY(bank_account_id) REFERENCES bank_accounts(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_savings_goal_accounts_unique
+ON savings_goal_accounts(savings_goal_id, bank_account_id);
+
+CREATE INDEX IF NOT EXISTS idx_savings_goal_accounts_bank_account_id
+ON savings_goal_accounts(bank_account_id);
+
+-- Transactions tagged to a goal count towards it when it has no accounts.
+ALTER TABLE transactions ADD COLUMN savings_goal_id BIGINT
+    CONSTRAINT fk_transactions_savings_goal_id REFERENCES savings_goals(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_savings_goal_id
+ON transactions(savings_goal_id);
@@ -0,0 +1,9 @@
+// Package web embeds the frontend assets served by the backend.
+package web
+
+import "embed"
+
+// Files holds the static files of the frontend.
+//
+//go:embed index.html styles.css router.js utils.js app modules
+var Files embed.FS
@@ -81,7 +81,7 @@
       if (creditCards.length === 0) {
         const row = document.createElement("tr");
         const cell = document.createElement("td");
-        cell.colSpan = 6;
+        cell.colSpan = 7;
         cell.textContent = "No credit cards yet";
         row.appendChild(cell);
         elements.bodyElement.appendChild(row);
@@ -95,6 +95,7 @@
           <td>${escapeHtml(formatBankLabel(creditCard.bank_id))}</td>
           <td>${escapeHtml(formatPersonLabel(creditCard.person_id))}</td>
           <td>${escapeHtml(creditCard.number)}</td>
+          <td>${escapeHtml(creditCard.network || "—")}</td>
           <td>${escapeHtml(creditCard.name || "—")}</td>
           ${generateActionsCell(creditCard)}
         `;